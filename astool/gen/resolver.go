@@ -26,6 +26,9 @@ const (
 	errorCannotTypeAssert            = "errCannotTypeAssertType"
 	isUnFnName                       = "IsUnmatchedErr"
 	toAliasMapFnName                 = "toAliasMap"
+	// checkLimitsFnName is implemented by hand in the streams package, as
+	// it does not depend on the vocabularies.
+	checkLimitsFnName = "checkLimits"
 )
 
 // ResolverGenerator generates the code required for the TypeResolver and the
@@ -365,6 +368,12 @@ func (r *ResolverGenerator) jsonResolverMethods() (m []*codegen.Method) {
 			jen.Error(),
 		},
 		[]jen.Code{
+			jen.If(
+				jen.Err().Op(":=").Id(checkLimitsFnName).Call(jen.Id("ctx"), jen.Id("m")),
+				jen.Err().Op("!=").Nil(),
+			).Block(
+				jen.Return(jen.Err()),
+			),
 			jen.List(
 				jen.Id("typeValue"),
 				jen.Id("ok"),
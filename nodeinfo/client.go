@@ -0,0 +1,107 @@
+package nodeinfo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-fed/activity/pub"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+const (
+	// maxDocumentBytes bounds the size of the documents read by a Client.
+	maxDocumentBytes = 1 << 20
+)
+
+// ErrNoNodeInfo indicates the Discovery document of a server links to no
+// NodeInfo document of a supported schema.
+var ErrNoNodeInfo = errors.New("no supported nodeinfo document")
+
+// Client fetches the NodeInfo of other servers.
+//
+// It may be used concurrently if its HttpClient may.
+type Client struct {
+	client   pub.HttpClient
+	appAgent string
+}
+
+// NewClient returns a Client sending NodeInfo requests over HTTPS through the
+// HttpClient.
+//
+// The appAgent is sent in the User-Agent header, so peers may aid debugging the
+// requests incoming from this server.
+func NewClient(client pub.HttpClient, appAgent string) *Client {
+	return &Client{
+		client:   client,
+		appAgent: appAgent,
+	}
+}
+
+// Fetch obtains the NodeInfo of the server at the host, preferring the 2.1
+// schema over 2.0.
+//
+// Returns ErrNoNodeInfo if the server links to neither, and a
+// *pub.TransportError if a request failed.
+func (n *Client) Fetch(c context.Context, host string) (*NodeInfo, error) {
+	wellKnown := &url.URL{
+		Scheme: "https",
+		Host:   host,
+		Path:   WellKnownPath,
+	}
+	var d Discovery
+	if err := n.get(c, wellKnown, &d); err != nil {
+		return nil, err
+	}
+	var href string
+	for _, schema := range []string{Schema21, Schema20} {
+		for _, l := range d.Links {
+			if l.Rel == schema && l.Href != "" {
+				href = l.Href
+				break
+			}
+		}
+		if href != "" {
+			break
+		}
+	}
+	if href == "" {
+		return nil, ErrNoNodeInfo
+	}
+	u, err := wellKnown.Parse(href)
+	if err != nil {
+		return nil, err
+	}
+	var ni NodeInfo
+	if err = n.get(c, u, &ni); err != nil {
+		return nil, err
+	}
+	return &ni, nil
+}
+
+// get requests the JSON document at the URL and decodes it into v.
+func (n *Client) get(c context.Context, u *url.URL, v interface{}) error {
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return &pub.TransportError{Method: "GET", IRI: u, Err: err}
+	}
+	req = req.WithContext(c)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", n.appAgent)
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return &pub.TransportError{Method: "GET", IRI: u, Retryable: true, Err: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return &pub.TransportError{
+			Method:     "GET",
+			IRI:        u,
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Retryable:  resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500,
+		}
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxDocumentBytes)).Decode(v)
+}
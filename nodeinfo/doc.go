@@ -0,0 +1,7 @@
+// Package nodeinfo implements the NodeInfo 2.0 and 2.1 protocol, which
+// describes the software and usage of a federated server.
+//
+// A server serves its NodeInfo with the handler returned by NewHandler, which
+// takes the usage statistics from a UsageProvider. A Client fetches the
+// NodeInfo of peers, so that policies may depend on the software they run.
+package nodeinfo
@@ -0,0 +1,132 @@
+package nodeinfo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/go-fed/activity/pub"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// softwareName is the NodeInfo name of go-fed.
+	softwareName = "go-fed"
+	// softwareRepository is the source code repository of go-fed.
+	softwareRepository = "https://github.com/go-fed/activity"
+	// softwareHomepage is the homepage of go-fed.
+	softwareHomepage = "https://go-fed.org/"
+)
+
+// UsageProvider provides the usage statistics of this server.
+type UsageProvider interface {
+	// Usage returns the current usage statistics.
+	Usage(c context.Context) (Usage, error)
+	// OpenRegistrations returns whether anyone may currently create an
+	// account.
+	OpenRegistrations(c context.Context) (bool, error)
+}
+
+// HandlerFunc serves NodeInfo requests.
+//
+// If an error is returned, then the calling function is responsible for writing
+// to the ResponseWriter as part of error handling. Otherwise the HandlerFunc
+// has written the response.
+type HandlerFunc func(c context.Context, w http.ResponseWriter, r *http.Request) error
+
+// NewHandler creates a HandlerFunc serving the Discovery document at
+// WellKnownPath, and the NodeInfo 2.0 and 2.1 documents at Path20 and Path21.
+// It responds with 404 Not Found to requests for any other path.
+//
+// The base is the scheme and host of this server, to which the paths of the
+// links of the Discovery document are resolved. The software of the NodeInfo
+// is go-fed at the version of the pub package, supporting the ActivityPub
+// protocol, and its usage is obtained from the UsageProvider.
+func NewHandler(base *url.URL, p UsageProvider) HandlerFunc {
+	discovery := &Discovery{
+		Links: []Link{
+			{
+				Rel:  Schema20,
+				Href: base.ResolveReference(&url.URL{Path: Path20}).String(),
+			},
+			{
+				Rel:  Schema21,
+				Href: base.ResolveReference(&url.URL{Path: Path21}).String(),
+			},
+		},
+	}
+	return func(c context.Context, w http.ResponseWriter, r *http.Request) error {
+		switch r.URL.Path {
+		case WellKnownPath:
+			return writeJSON(w, "application/json", discovery)
+		case Path20:
+			ni, err := newNodeInfo(c, p, "2.0")
+			if err != nil {
+				return err
+			}
+			return writeJSON(w, contentType(Schema20), ni)
+		case Path21:
+			ni, err := newNodeInfo(c, p, "2.1")
+			if err != nil {
+				return err
+			}
+			ni.Software.Repository = softwareRepository
+			ni.Software.Homepage = softwareHomepage
+			return writeJSON(w, contentType(Schema21), ni)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return nil
+		}
+	}
+}
+
+// newNodeInfo returns the NodeInfo of this server at the schema version.
+func newNodeInfo(c context.Context, p UsageProvider, version string) (*NodeInfo, error) {
+	usage, err := p.Usage(c)
+	if err != nil {
+		return nil, err
+	}
+	open, err := p.OpenRegistrations(c)
+	if err != nil {
+		return nil, err
+	}
+	return &NodeInfo{
+		Version: version,
+		Software: Software{
+			Name:    softwareName,
+			Version: strings.TrimPrefix(pub.Version(), "v"),
+		},
+		Protocols: []string{activityPubProtocol},
+		Services: Services{
+			Inbound:  []string{},
+			Outbound: []string{},
+		},
+		OpenRegistrations: open,
+		Usage:             usage,
+		Metadata:          map[string]interface{}{},
+	}, nil
+}
+
+// contentType returns the media type of NodeInfo documents of the schema.
+func contentType(schema string) string {
+	return fmt.Sprintf("application/json; profile=\"%s#\"", schema)
+}
+
+// writeJSON writes the value as the JSON body of a successful response.
+func writeJSON(w http.ResponseWriter, contentType string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(http.StatusOK)
+	n, err := w.Write(raw)
+	if err != nil {
+		return err
+	} else if n != len(raw) {
+		return fmt.Errorf("only wrote %d of %d bytes", n, len(raw))
+	}
+	return nil
+}
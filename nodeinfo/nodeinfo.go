@@ -0,0 +1,88 @@
+package nodeinfo
+
+const (
+	// Schema20 is the schema of NodeInfo 2.0 documents.
+	Schema20 = "http://nodeinfo.diaspora.software/ns/schema/2.0"
+	// Schema21 is the schema of NodeInfo 2.1 documents.
+	Schema21 = "http://nodeinfo.diaspora.software/ns/schema/2.1"
+	// WellKnownPath is the path of the document linking to the NodeInfo
+	// documents of a server.
+	WellKnownPath = "/.well-known/nodeinfo"
+	// Path20 is the path at which NewHandler serves NodeInfo 2.0.
+	Path20 = "/nodeinfo/2.0"
+	// Path21 is the path at which NewHandler serves NodeInfo 2.1.
+	Path21 = "/nodeinfo/2.1"
+	// activityPubProtocol is the protocol supported by go-fed servers.
+	activityPubProtocol = "activitypub"
+)
+
+// Discovery is the document at WellKnownPath, linking to the NodeInfo
+// documents of a server.
+type Discovery struct {
+	Links []Link `json:"links"`
+}
+
+// Link relates a server to one of its NodeInfo documents. Its Rel is the
+// schema of the document.
+type Link struct {
+	Rel  string `json:"rel"`
+	Href string `json:"href"`
+}
+
+// NodeInfo describes the software and usage of a server.
+type NodeInfo struct {
+	// Version is the version of the NodeInfo schema, such as "2.1".
+	Version string `json:"version"`
+	// Software is the software the server runs.
+	Software Software `json:"software"`
+	// Protocols are the federation protocols the server supports.
+	Protocols []string `json:"protocols"`
+	// Services are the third party sites the server can interact with.
+	Services Services `json:"services"`
+	// OpenRegistrations is whether anyone may create an account.
+	OpenRegistrations bool `json:"openRegistrations"`
+	// Usage has the usage statistics of the server.
+	Usage Usage `json:"usage"`
+	// Metadata is free form information about the server.
+	Metadata map[string]interface{} `json:"metadata"`
+}
+
+// Software is the software a server runs.
+type Software struct {
+	// Name is the canonical name of the software, in lower case.
+	Name string `json:"name"`
+	// Version is the version of the software.
+	Version string `json:"version"`
+	// Repository is the URL of the source code, since NodeInfo 2.1.
+	Repository string `json:"repository,omitempty"`
+	// Homepage is the URL of the homepage, since NodeInfo 2.1.
+	Homepage string `json:"homepage,omitempty"`
+}
+
+// Services are the third party sites a server can retrieve messages from, or
+// publish messages to.
+type Services struct {
+	Inbound  []string `json:"inbound"`
+	Outbound []string `json:"outbound"`
+}
+
+// Usage has the usage statistics of a server.
+type Usage struct {
+	// Users counts the accounts of the server.
+	Users Users `json:"users"`
+	// LocalPosts is the number of posts made by the accounts.
+	LocalPosts int `json:"localPosts,omitempty"`
+	// LocalComments is the number of comments made by the accounts.
+	LocalComments int `json:"localComments,omitempty"`
+}
+
+// Users counts the accounts of a server.
+type Users struct {
+	// Total is the number of accounts.
+	Total int `json:"total,omitempty"`
+	// ActiveHalfyear is the number of accounts active in the last 180
+	// days.
+	ActiveHalfyear int `json:"activeHalfyear,omitempty"`
+	// ActiveMonth is the number of accounts active in the last 30 days.
+	ActiveMonth int `json:"activeMonth,omitempty"`
+}
@@ -0,0 +1,167 @@
+package nodeinfo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-fed/activity/pub"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// testProvider provides fixed usage statistics.
+type testProvider struct {
+	err error
+}
+
+func (p testProvider) Usage(c context.Context) (Usage, error) {
+	return Usage{Users: Users{Total: 3, ActiveMonth: 2}, LocalPosts: 10}, p.err
+}
+
+func (p testProvider) OpenRegistrations(c context.Context) (bool, error) {
+	return true, nil
+}
+
+func TestHandler(t *testing.T) {
+	ctx := context.Background()
+	base, _ := url.Parse("https://example.com")
+	h := NewHandler(base, testProvider{})
+	serveFn := func(t *testing.T, path string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", path, nil)
+		if err := h(ctx, resp, req); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		return resp
+	}
+	t.Run("ServesDiscovery", func(t *testing.T) {
+		resp := serveFn(t, WellKnownPath)
+		var d Discovery
+		if err := json.Unmarshal(resp.Body.Bytes(), &d); err != nil {
+			t.Fatal(err)
+		}
+		want := []Link{
+			{Rel: Schema20, Href: "https://example.com/nodeinfo/2.0"},
+			{Rel: Schema21, Href: "https://example.com/nodeinfo/2.1"},
+		}
+		if len(d.Links) != len(want) || d.Links[0] != want[0] || d.Links[1] != want[1] {
+			t.Fatalf("expected links %v, got %v", want, d.Links)
+		}
+	})
+	t.Run("ServesNodeInfo21", func(t *testing.T) {
+		resp := serveFn(t, Path21)
+		if ct := resp.Header().Get("Content-Type"); ct != contentType(Schema21) {
+			t.Fatalf("unexpected content type %s", ct)
+		}
+		var ni NodeInfo
+		if err := json.Unmarshal(resp.Body.Bytes(), &ni); err != nil {
+			t.Fatal(err)
+		}
+		if ni.Version != "2.1" {
+			t.Fatalf("unexpected version %s", ni.Version)
+		} else if ni.Software.Name != "go-fed" || "v"+ni.Software.Version != pub.Version() {
+			t.Fatalf("unexpected software %v", ni.Software)
+		} else if ni.Software.Repository == "" {
+			t.Fatalf("expected software repository")
+		} else if len(ni.Protocols) != 1 || ni.Protocols[0] != "activitypub" {
+			t.Fatalf("unexpected protocols %v", ni.Protocols)
+		} else if !ni.OpenRegistrations || ni.Usage.Users.Total != 3 || ni.Usage.LocalPosts != 10 {
+			t.Fatalf("unexpected usage %v", ni.Usage)
+		}
+	})
+	t.Run("ServesNodeInfo20WithoutRepository", func(t *testing.T) {
+		resp := serveFn(t, Path20)
+		var ni NodeInfo
+		if err := json.Unmarshal(resp.Body.Bytes(), &ni); err != nil {
+			t.Fatal(err)
+		}
+		if ni.Version != "2.0" {
+			t.Fatalf("unexpected version %s", ni.Version)
+		} else if ni.Software.Repository != "" || ni.Software.Homepage != "" {
+			t.Fatalf("unexpected software %v", ni.Software)
+		}
+	})
+	t.Run("NotFoundForOtherPaths", func(t *testing.T) {
+		resp := serveFn(t, "/nodeinfo/1.0")
+		if resp.Code != http.StatusNotFound {
+			t.Fatalf("expected 404, got %d", resp.Code)
+		}
+	})
+	t.Run("ReturnsProviderError", func(t *testing.T) {
+		testErr := errors.New("test error")
+		h := NewHandler(base, testProvider{err: testErr})
+		resp := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", Path21, nil)
+		if err := h(ctx, resp, req); err != testErr {
+			t.Fatalf("expected test error, got %v", err)
+		}
+	})
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	setupFn := func(h http.HandlerFunc) (*httptest.Server, *Client, string) {
+		s := httptest.NewTLSServer(h)
+		u, _ := url.Parse(s.URL)
+		return s, NewClient(s.Client(), "testApp"), u.Host
+	}
+	t.Run("FetchesNodeInfoFromHandler", func(t *testing.T) {
+		var h HandlerFunc
+		s, c, host := setupFn(func(w http.ResponseWriter, r *http.Request) {
+			h(r.Context(), w, r)
+		})
+		defer s.Close()
+		base, _ := url.Parse(s.URL)
+		h = NewHandler(base, testProvider{})
+		ni, err := c.Fetch(ctx, host)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		} else if ni.Version != "2.1" || ni.Software.Name != "go-fed" {
+			t.Fatalf("unexpected nodeinfo %v", ni)
+		}
+	})
+	t.Run("FallsBackToNodeInfo20", func(t *testing.T) {
+		s, c, host := setupFn(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case WellKnownPath:
+				w.Write([]byte(`{"links":[{"rel":"` + Schema20 + `","href":"/nodeinfo/2.0.json"}]}`))
+			case "/nodeinfo/2.0.json":
+				w.Write([]byte(`{"version":"2.0","software":{"name":"mastodon","version":"3.2.0"}}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		})
+		defer s.Close()
+		ni, err := c.Fetch(ctx, host)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		} else if ni.Version != "2.0" || ni.Software.Name != "mastodon" {
+			t.Fatalf("unexpected nodeinfo %v", ni)
+		}
+	})
+	t.Run("ErrorIfNoSupportedSchema", func(t *testing.T) {
+		s, c, host := setupFn(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"links":[{"rel":"http://nodeinfo.diaspora.software/ns/schema/1.0","href":"/nodeinfo/1.0"}]}`))
+		})
+		defer s.Close()
+		_, err := c.Fetch(ctx, host)
+		if err != ErrNoNodeInfo {
+			t.Fatalf("expected ErrNoNodeInfo, got %v", err)
+		}
+	})
+	t.Run("TransportErrorIfNotFound", func(t *testing.T) {
+		s, c, host := setupFn(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+		defer s.Close()
+		_, err := c.Fetch(ctx, host)
+		var te *pub.TransportError
+		if !errors.As(err, &te) {
+			t.Fatalf("expected *pub.TransportError, got %v", err)
+		} else if te.StatusCode != http.StatusNotFound {
+			t.Fatalf("expected 404, got %d", te.StatusCode)
+		}
+	})
+}
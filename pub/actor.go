@@ -112,3 +112,50 @@ type FederatingActor interface {
 	// care should be used to not call this method if only C2S is supported.
 	Send(c context.Context, outbox *url.URL, t vocab.Type) (Activity, error)
 }
+
+// SharedInboxActor is implemented by the FederatingActors returned by
+// NewFederatingActor, NewActor, and NewCustomActor, and allows handling POST
+// requests to the server's shared inbox.
+//
+// An Actor created with NewCustomActor only handles them if its DelegateActor
+// is a SharedInboxDelegateActor.
+type SharedInboxActor interface {
+	// PostSharedInbox returns true if the request was handled as an
+	// ActivityPub POST to the server's shared inbox. If false, the request
+	// was not an ActivityPub request and may still be handled by the
+	// caller in another way, such as serving a web page.
+	//
+	// The Activity is authenticated and authorized the same way as for
+	// PostInbox, and then delivered to the inboxes of each local actor it
+	// is addressed to, including the local followers of its actor when
+	// the Database is a LocalFollowerLister.
+	//
+	// If the error is nil, then the ResponseWriter's headers and response
+	// has already been written. If a non-nil error is returned, then no
+	// response has been written.
+	PostSharedInbox(c context.Context, w http.ResponseWriter, r *http.Request) (bool, error)
+}
+
+// FollowApprovalActor is implemented by the FederatingActors returned by
+// NewFederatingActor, NewActor, and NewCustomActor, and allows answering the
+// Follows received with OnFollowPendingApproval.
+//
+// An Actor created with NewCustomActor only answers them if its DelegateActor
+// is a FollowApprovalDelegateActor.
+type FollowApprovalActor interface {
+	// AcceptFollow approves the Follow with the given id, which was
+	// received with OnFollowPendingApproval by the actor of the outbox.
+	//
+	// An Accept of the Follow is sent from the outbox as with Send, and
+	// then the actors of the Follow are added to the 'followers'
+	// collection. If the Follow is not pending, ErrFollowNotPending is
+	// returned. The Follow remains pending if any other error is returned.
+	AcceptFollow(c context.Context, outbox, followId *url.URL) (Activity, error)
+	// RejectFollow refuses the Follow with the given id, which was
+	// received with OnFollowPendingApproval by the actor of the outbox.
+	//
+	// A Reject of the Follow is sent from the outbox as with Send. If the
+	// Follow is not pending, ErrFollowNotPending is returned. The Follow
+	// remains pending if any other error is returned.
+	RejectFollow(c context.Context, outbox, followId *url.URL) (Activity, error)
+}
@@ -0,0 +1,160 @@
+package pub
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"github.com/go-fed/activity/streams"
+	"github.com/go-fed/activity/streams/vocab"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ActorCache caches the actors and collections of peers that are dereferenced
+// when resolving the inboxes to deliver an activity to.
+//
+// Entries are invalidated when a federated Update or Delete of them is
+// received. Implementations are responsible for expiring entries that have
+// been cached for too long.
+type ActorCache interface {
+	// Get returns the cached actor or collection with the given id, and
+	// whether it exists. Expired entries must not be returned.
+	Get(c context.Context, id *url.URL) (t vocab.Type, exists bool, err error)
+	// Set caches the actor or collection with the given id, replacing any
+	// existing entry.
+	Set(c context.Context, id *url.URL, t vocab.Type) error
+	// Invalidate removes the entry with the given id, if any.
+	Invalidate(c context.Context, id *url.URL) error
+}
+
+// ActorCache must be implemented by MemoryActorCache.
+var _ ActorCache = &MemoryActorCache{}
+
+// DefaultActorCacheTTL is a reasonable default for how long a peer's actor or
+// collection is cached.
+const DefaultActorCacheTTL = time.Hour
+
+// MemoryActorCache is an ActorCache that keeps a bounded number of entries in
+// memory, evicting the least recently used entry when full.
+//
+// Entries are stored serialized, so the values returned by Get may be modified
+// by the caller. It is safe for concurrent use.
+type MemoryActorCache struct {
+	clock    Clock
+	ttl      time.Duration
+	capacity int
+
+	mu sync.Mutex
+	// entries maps ids to elements of lru, whose values are
+	// *memoryActorCacheEntry.
+	entries map[string]*list.Element
+	// lru orders the entries from most to least recently used.
+	lru *list.List
+}
+
+// memoryActorCacheEntry is a single serialized entry in the MemoryActorCache.
+type memoryActorCacheEntry struct {
+	id      string
+	b       []byte
+	expires time.Time
+}
+
+// NewMemoryActorCache returns an empty MemoryActorCache whose entries expire
+// after ttl, according to the clock, and which holds at most capacity entries.
+//
+// If capacity is not positive, the number of entries is unbounded.
+func NewMemoryActorCache(clock Clock, ttl time.Duration, capacity int) *MemoryActorCache {
+	return &MemoryActorCache{
+		clock:    clock,
+		ttl:      ttl,
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// Get returns a copy of the cached actor or collection, if it has not expired.
+func (m *MemoryActorCache) Get(c context.Context, id *url.URL) (t vocab.Type, exists bool, err error) {
+	m.mu.Lock()
+	e, ok := m.entries[id.String()]
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+	entry := e.Value.(*memoryActorCacheEntry)
+	if !m.clock.Now().Before(entry.expires) {
+		m.remove(e)
+		m.mu.Unlock()
+		return
+	}
+	m.lru.MoveToFront(e)
+	b := entry.b
+	m.mu.Unlock()
+	var raw map[string]interface{}
+	if err = json.Unmarshal(b, &raw); err != nil {
+		return
+	}
+	t, err = streams.ToType(c, raw)
+	if err != nil {
+		return
+	}
+	exists = true
+	return
+}
+
+// Set caches a serialized copy of the actor or collection, evicting the least
+// recently used entry if the cache is full.
+func (m *MemoryActorCache) Set(c context.Context, id *url.URL, t vocab.Type) error {
+	raw, err := streams.Serialize(t)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry := &memoryActorCacheEntry{
+		id:      id.String(),
+		b:       b,
+		expires: m.clock.Now().Add(m.ttl),
+	}
+	if e, ok := m.entries[entry.id]; ok {
+		e.Value = entry
+		m.lru.MoveToFront(e)
+		return nil
+	}
+	m.entries[entry.id] = m.lru.PushFront(entry)
+	if m.capacity > 0 && m.lru.Len() > m.capacity {
+		m.remove(m.lru.Back())
+	}
+	return nil
+}
+
+// Invalidate removes the entry with the given id.
+func (m *MemoryActorCache) Invalidate(c context.Context, id *url.URL) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e, ok := m.entries[id.String()]; ok {
+		m.remove(e)
+	}
+	return nil
+}
+
+// Len returns the number of entries in the cache, including expired entries
+// that have not yet been removed.
+func (m *MemoryActorCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lru.Len()
+}
+
+// remove deletes the entry.
+//
+// Must be called with the mutex held.
+func (m *MemoryActorCache) remove(e *list.Element) {
+	m.lru.Remove(e)
+	delete(m.entries, e.Value.(*memoryActorCacheEntry).id)
+}
@@ -0,0 +1,91 @@
+package pub
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"testing"
+	"time"
+)
+
+// TestMemoryActorCache tests caching, expiring, evicting, and invalidating
+// actors.
+func TestMemoryActorCache(t *testing.T) {
+	ctx := context.Background()
+	setupFn := func(ctl *gomock.Controller, capacity int) (m *MemoryActorCache, clock *MockClock) {
+		setupData()
+		clock = NewMockClock(ctl)
+		m = NewMemoryActorCache(clock, time.Hour, capacity)
+		return
+	}
+	t.Run("GetsSetActors", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		m, clock := setupFn(ctl, 0)
+		// Mock
+		clock.EXPECT().Now().Return(now()).Times(2)
+		// Run
+		err := m.Set(ctx, mustParse(testFederatedActorIRI), testFederatedPerson1)
+		assertEqual(t, err, nil)
+		got, exists, err := m.Get(ctx, mustParse(testFederatedActorIRI))
+		// Verify
+		assertEqual(t, err, nil)
+		assertEqual(t, exists, true)
+		assertByteEqual(t, mustSerializeToBytes(got), mustSerializeToBytes(testFederatedPerson1))
+		_, exists, err = m.Get(ctx, mustParse(testFederatedActorIRI2))
+		assertEqual(t, err, nil)
+		assertEqual(t, exists, false)
+	})
+	t.Run("ExpiresActors", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		m, clock := setupFn(ctl, 0)
+		// Mock
+		clock.EXPECT().Now().Return(now())
+		clock.EXPECT().Now().Return(now().Add(time.Hour))
+		// Run
+		m.Set(ctx, mustParse(testFederatedActorIRI), testFederatedPerson1)
+		_, exists, err := m.Get(ctx, mustParse(testFederatedActorIRI))
+		// Verify
+		assertEqual(t, err, nil)
+		assertEqual(t, exists, false)
+		assertEqual(t, m.Len(), 0)
+	})
+	t.Run("EvictsLeastRecentlyUsed", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		m, clock := setupFn(ctl, 2)
+		// Mock
+		clock.EXPECT().Now().Return(now()).AnyTimes()
+		// Run
+		m.Set(ctx, mustParse(testFederatedActorIRI), testFederatedPerson1)
+		m.Set(ctx, mustParse(testFederatedActorIRI2), testFederatedPerson2)
+		m.Get(ctx, mustParse(testFederatedActorIRI))
+		m.Set(ctx, mustParse(testFederatedActorIRI3), testFederatedPerson1)
+		// Verify
+		assertEqual(t, m.Len(), 2)
+		_, exists, _ := m.Get(ctx, mustParse(testFederatedActorIRI))
+		assertEqual(t, exists, true)
+		_, exists, _ = m.Get(ctx, mustParse(testFederatedActorIRI2))
+		assertEqual(t, exists, false)
+		_, exists, _ = m.Get(ctx, mustParse(testFederatedActorIRI3))
+		assertEqual(t, exists, true)
+	})
+	t.Run("InvalidatesActors", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		m, clock := setupFn(ctl, 0)
+		// Mock
+		clock.EXPECT().Now().Return(now())
+		// Run
+		m.Set(ctx, mustParse(testFederatedActorIRI), testFederatedPerson1)
+		err := m.Invalidate(ctx, mustParse(testFederatedActorIRI))
+		// Verify
+		assertEqual(t, err, nil)
+		_, exists, _ := m.Get(ctx, mustParse(testFederatedActorIRI))
+		assertEqual(t, exists, false)
+	})
+}
@@ -0,0 +1,268 @@
+package pub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/go-fed/activity/streams"
+	"github.com/go-fed/activity/streams/vocab"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	// activityJSONMediaType is the ActivityPub media type.
+	activityJSONMediaType = "application/activity+json"
+	// ldJSONMediaType is the JSON-LD media type, which is an ActivityPub
+	// media type with the ActivityStreams profile.
+	ldJSONMediaType = "application/ld+json"
+	// activityStreamsProfile is the profile of ActivityStreams JSON-LD.
+	activityStreamsProfile = "https://www.w3.org/ns/activitystreams"
+	// varyHeader is the Vary header.
+	varyHeader = "Vary"
+)
+
+// ActorProfile describes an actor on this server, from which the handler
+// returned by NewActorHandler builds the actor's document.
+type ActorProfile struct {
+	// Type is the ActivityStreams type of the actor: "Person", "Service",
+	// "Group", "Application", or "Organization". It is "Person" if empty.
+	Type string
+	// Id is the IRI of the actor.
+	Id *url.URL
+	// Inbox, Outbox, Followers, and Following are the IRIs of the actor's
+	// collections.
+	Inbox, Outbox, Followers, Following *url.URL
+	// Liked is the IRI of the actor's liked collection, if any.
+	Liked *url.URL
+	// SharedInbox is the IRI of the shared inbox of this server, if any.
+	SharedInbox *url.URL
+	// PreferredUsername, Name, and Summary are shown to people. They are
+	// omitted if empty.
+	PreferredUsername, Name, Summary string
+	// PublicKeyId is the id of the actor's public key, used to verify
+	// the HTTP Signatures of its requests.
+	PublicKeyId *url.URL
+	// PublicKeyPem is the PEM encoding of the actor's public key.
+	PublicKeyPem string
+}
+
+// ActorProfileProvider provides the profiles of the actors on this server.
+type ActorProfileProvider interface {
+	// ActorProfile returns the profile of the actor, and whether it
+	// exists.
+	ActorProfile(c context.Context, actorIRI *url.URL) (p ActorProfile, exists bool, err error)
+}
+
+// ActorHTMLFunc serves an HTML representation of an actor, such as a profile
+// webpage, to requests that do not prefer an ActivityPub media type.
+type ActorHTMLFunc func(c context.Context, w http.ResponseWriter, r *http.Request, p ActorProfile) error
+
+// actorDocument is an ActivityStreams actor type built by NewActorHandler.
+type actorDocument interface {
+	vocab.Type
+	SetActivityStreamsInbox(i vocab.ActivityStreamsInboxProperty)
+	SetActivityStreamsOutbox(i vocab.ActivityStreamsOutboxProperty)
+	SetActivityStreamsFollowers(i vocab.ActivityStreamsFollowersProperty)
+	SetActivityStreamsFollowing(i vocab.ActivityStreamsFollowingProperty)
+	SetActivityStreamsLiked(i vocab.ActivityStreamsLikedProperty)
+	SetActivityStreamsPreferredUsername(i vocab.ActivityStreamsPreferredUsernameProperty)
+	SetActivityStreamsName(i vocab.ActivityStreamsNameProperty)
+	SetActivityStreamsSummary(i vocab.ActivityStreamsSummaryProperty)
+	SetW3IDSecurityV1PublicKey(i vocab.W3IDSecurityV1PublicKeyProperty)
+}
+
+// NewActorHandler creates a HandlerFunc serving the documents of the actors on
+// this server, whose profiles are obtained from the ActorProfileProvider.
+//
+// The media type of the response is negotiated with the Accept header of the
+// GET request, between 'application/activity+json', 'application/ld+json' with
+// the ActivityStreams profile, and HTML if html is not nil. The Accept header
+// is honored along with its quality values, and the ActivityPub media types
+// are preferred for wildcards.
+//
+// If html is nil and the request does not accept an ActivityPub media type,
+// then 'isASRequest' is false and nothing is written, so the calling function
+// may continue processing the request. If html is not nil, it serves such
+// requests and 'isASRequest' is true, as the request has been served.
+//
+// Responds with 404 Not Found if there is no such actor. Callers are
+// responsible for authorized access to this resource.
+func NewActorHandler(p ActorProfileProvider, clock Clock, html ActorHTMLFunc) HandlerFunc {
+	return func(c context.Context, w http.ResponseWriter, r *http.Request) (isASRequest bool, err error) {
+		if r.Method != "GET" {
+			return
+		}
+		mediaType := negotiateActorMediaType(r.Header.Get(acceptHeader), html != nil)
+		if mediaType == "" {
+			return
+		}
+		isASRequest = true
+		w.Header().Add(varyHeader, acceptHeader)
+		id := requestId(r)
+		profile, exists, err := p.ActorProfile(c, id)
+		if err != nil {
+			return
+		} else if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if mediaType == "text/html" {
+			err = html(c, w, r, profile)
+			return
+		}
+		raw, err := serializeActorProfile(profile)
+		if err != nil {
+			return
+		}
+		addResponseHeaders(w.Header(), clock, raw)
+		if mediaType == activityJSONMediaType {
+			w.Header().Set(contentTypeHeader, activityJSONMediaType)
+		}
+		w.WriteHeader(http.StatusOK)
+		n, err := w.Write(raw)
+		if err != nil {
+			return
+		} else if n != len(raw) {
+			err = fmt.Errorf("only wrote %d of %d bytes", n, len(raw))
+			return
+		}
+		return
+	}
+}
+
+// negotiateActorMediaType returns the media type of the actor document that
+// is most acceptable to the Accept header: activityJSONMediaType,
+// ldJSONMediaType, or "text/html" if html is allowed. It is empty if none is
+// acceptable.
+func negotiateActorMediaType(accept string, allowHTML bool) string {
+	offers := []string{activityJSONMediaType, ldJSONMediaType}
+	if allowHTML {
+		offers = append(offers, "text/html")
+	}
+	var best string
+	bestQ := 0.0
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if qs, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(qs, 64); err != nil {
+				continue
+			}
+		}
+		if q <= bestQ {
+			continue
+		}
+		for _, offer := range offers {
+			if acceptsActorMediaType(mt, params, offer) {
+				best = offer
+				bestQ = q
+				break
+			}
+		}
+	}
+	if best == "" && allowHTML {
+		best = "text/html"
+	}
+	return best
+}
+
+// acceptsActorMediaType determines whether a media range of the Accept header
+// includes the offered media type.
+func acceptsActorMediaType(mt string, params map[string]string, offer string) bool {
+	switch offer {
+	case activityJSONMediaType:
+		return mt == activityJSONMediaType || mt == "application/*" || mt == "*/*"
+	case ldJSONMediaType:
+		return mt == ldJSONMediaType && params["profile"] == activityStreamsProfile
+	default:
+		return mt == offer || mt == "application/xhtml+xml" || mt == "text/*" || mt == "*/*"
+	}
+}
+
+// serializeActorProfile builds the ActivityStreams document of the actor.
+func serializeActorProfile(p ActorProfile) ([]byte, error) {
+	var actor actorDocument
+	switch p.Type {
+	case "", "Person":
+		actor = streams.NewActivityStreamsPerson()
+	case "Service":
+		actor = streams.NewActivityStreamsService()
+	case "Group":
+		actor = streams.NewActivityStreamsGroup()
+	case "Application":
+		actor = streams.NewActivityStreamsApplication()
+	case "Organization":
+		actor = streams.NewActivityStreamsOrganization()
+	default:
+		return nil, fmt.Errorf("unsupported actor type %q", p.Type)
+	}
+	id := streams.NewJSONLDIdProperty()
+	id.Set(p.Id)
+	actor.SetJSONLDId(id)
+	inbox := streams.NewActivityStreamsInboxProperty()
+	inbox.SetIRI(p.Inbox)
+	actor.SetActivityStreamsInbox(inbox)
+	outbox := streams.NewActivityStreamsOutboxProperty()
+	outbox.SetIRI(p.Outbox)
+	actor.SetActivityStreamsOutbox(outbox)
+	followers := streams.NewActivityStreamsFollowersProperty()
+	followers.SetIRI(p.Followers)
+	actor.SetActivityStreamsFollowers(followers)
+	following := streams.NewActivityStreamsFollowingProperty()
+	following.SetIRI(p.Following)
+	actor.SetActivityStreamsFollowing(following)
+	if p.Liked != nil {
+		liked := streams.NewActivityStreamsLikedProperty()
+		liked.SetIRI(p.Liked)
+		actor.SetActivityStreamsLiked(liked)
+	}
+	if len(p.PreferredUsername) > 0 {
+		username := streams.NewActivityStreamsPreferredUsernameProperty()
+		username.SetXMLSchemaString(p.PreferredUsername)
+		actor.SetActivityStreamsPreferredUsername(username)
+	}
+	if len(p.Name) > 0 {
+		name := streams.NewActivityStreamsNameProperty()
+		name.AppendXMLSchemaString(p.Name)
+		actor.SetActivityStreamsName(name)
+	}
+	if len(p.Summary) > 0 {
+		summary := streams.NewActivityStreamsSummaryProperty()
+		summary.AppendXMLSchemaString(p.Summary)
+		actor.SetActivityStreamsSummary(summary)
+	}
+	if p.PublicKeyId != nil {
+		key := streams.NewW3IDSecurityV1PublicKey()
+		keyId := streams.NewJSONLDIdProperty()
+		keyId.Set(p.PublicKeyId)
+		key.SetJSONLDId(keyId)
+		owner := streams.NewW3IDSecurityV1OwnerProperty()
+		owner.SetIRI(p.Id)
+		key.SetW3IDSecurityV1Owner(owner)
+		pem := streams.NewW3IDSecurityV1PublicKeyPemProperty()
+		pem.Set(p.PublicKeyPem)
+		key.SetW3IDSecurityV1PublicKeyPem(pem)
+		publicKey := streams.NewW3IDSecurityV1PublicKeyProperty()
+		publicKey.AppendW3IDSecurityV1PublicKey(key)
+		actor.SetW3IDSecurityV1PublicKey(publicKey)
+	}
+	m, err := streams.Serialize(actor)
+	if err != nil {
+		return nil, err
+	}
+	// The 'endpoints' property is not natively supported by the streams
+	// package, so it is added to the serialized document.
+	if p.SharedInbox != nil {
+		m[endpointsProperty] = map[string]interface{}{
+			sharedInboxProperty: p.SharedInbox.String(),
+		}
+	}
+	return json.Marshal(m)
+}
@@ -0,0 +1,188 @@
+package pub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+// TestActorHandler tests the handler for serving actor documents.
+func TestActorHandler(t *testing.T) {
+	ctx := context.Background()
+	const actorIRI = "https://example.com/addison"
+	profile := ActorProfile{
+		Type:              "Service",
+		Id:                mustParse(actorIRI),
+		Inbox:             mustParse(testMyInboxIRI),
+		Outbox:            mustParse(testMyOutboxIRI),
+		Followers:         mustParse(actorIRI + "/followers"),
+		Following:         mustParse(actorIRI + "/following"),
+		SharedInbox:       mustParse("https://example.com/inbox"),
+		PreferredUsername: "addison",
+		PublicKeyId:       mustParse(actorIRI + "#main-key"),
+		PublicKeyPem:      "-----BEGIN PUBLIC KEY-----",
+	}
+	setupFn := func(ctl *gomock.Controller, html ActorHTMLFunc) (p *MockActorProfileProvider, clock *MockClock, hf HandlerFunc) {
+		p = NewMockActorProfileProvider(ctl)
+		clock = NewMockClock(ctl)
+		hf = NewActorHandler(p, clock, html)
+		return
+	}
+	htmlFn := func(c context.Context, w http.ResponseWriter, r *http.Request, p ActorProfile) error {
+		w.Header().Set(contentTypeHeader, "text/html")
+		w.WriteHeader(http.StatusOK)
+		_, err := w.Write([]byte("<p>" + p.PreferredUsername + "</p>"))
+		return err
+	}
+	newRequestFn := func(accept string) *http.Request {
+		req := httptest.NewRequest("GET", actorIRI, nil)
+		req.Header.Set(acceptHeader, accept)
+		return req
+	}
+	t.Run("IgnoresIfNotActivityPubAndNoHTML", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		_, _, hf := setupFn(ctl, nil)
+		resp := httptest.NewRecorder()
+		req := newRequestFn("text/html,application/xhtml+xml;q=0.9")
+		// Run & Verify
+		isAPReq, err := hf(ctx, resp, req)
+		assertEqual(t, isAPReq, false)
+		assertEqual(t, err, nil)
+		assertEqual(t, len(resp.Result().Header), 0)
+	})
+	t.Run("NotFoundIfNoSuchActor", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		p, _, hf := setupFn(ctl, nil)
+		resp := httptest.NewRecorder()
+		req := newRequestFn(activityJSONMediaType)
+		// Mock
+		p.EXPECT().ActorProfile(ctx, mustParse(actorIRI)).Return(ActorProfile{}, false, nil)
+		// Run & Verify
+		isAPReq, err := hf(ctx, resp, req)
+		assertEqual(t, isAPReq, true)
+		assertEqual(t, err, nil)
+		assertEqual(t, resp.Code, http.StatusNotFound)
+	})
+	t.Run("ReturnsErrorWhenProviderReturnsError", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		p, _, hf := setupFn(ctl, nil)
+		resp := httptest.NewRecorder()
+		req := newRequestFn(activityJSONMediaType)
+		testErr := fmt.Errorf("test error")
+		// Mock
+		p.EXPECT().ActorProfile(ctx, mustParse(actorIRI)).Return(ActorProfile{}, false, testErr)
+		// Run & Verify
+		isAPReq, err := hf(ctx, resp, req)
+		assertEqual(t, isAPReq, true)
+		assertEqual(t, err, testErr)
+	})
+	t.Run("ServesActivityJSONDocument", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		p, mockClock, hf := setupFn(ctl, htmlFn)
+		resp := httptest.NewRecorder()
+		req := newRequestFn(activityJSONMediaType)
+		// Mock
+		p.EXPECT().ActorProfile(ctx, mustParse(actorIRI)).Return(profile, true, nil)
+		mockClock.EXPECT().Now().Return(now())
+		// Run & Verify
+		isAPReq, err := hf(ctx, resp, req)
+		assertEqual(t, isAPReq, true)
+		assertEqual(t, err, nil)
+		assertEqual(t, resp.Code, http.StatusOK)
+		respV := resp.Result()
+		assertEqual(t, respV.Header.Get(contentTypeHeader), activityJSONMediaType)
+		assertEqual(t, respV.Header.Get(varyHeader), acceptHeader)
+		b, err := ioutil.ReadAll(respV.Body)
+		assertEqual(t, err, nil)
+		var m map[string]interface{}
+		assertEqual(t, json.Unmarshal(b, &m), nil)
+		assertEqual(t, m["type"], "Service")
+		assertEqual(t, m["id"], actorIRI)
+		assertEqual(t, m["inbox"], testMyInboxIRI)
+		assertEqual(t, m["outbox"], testMyOutboxIRI)
+		assertEqual(t, m["followers"], actorIRI+"/followers")
+		assertEqual(t, m["following"], actorIRI+"/following")
+		assertEqual(t, m["preferredUsername"], "addison")
+		assertEqual(t, m[endpointsProperty].(map[string]interface{})[sharedInboxProperty], "https://example.com/inbox")
+		key := m["publicKey"].(map[string]interface{})
+		assertEqual(t, key["id"], actorIRI+"#main-key")
+		assertEqual(t, key["owner"], actorIRI)
+		assertEqual(t, key["publicKeyPem"], "-----BEGIN PUBLIC KEY-----")
+		_, hasLiked := m["liked"]
+		assertEqual(t, hasLiked, false)
+	})
+	t.Run("ServesLDJSONDocumentWithProfile", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		p, mockClock, hf := setupFn(ctl, htmlFn)
+		resp := httptest.NewRecorder()
+		req := newRequestFn("text/html;q=0.5, application/ld+json; profile=\"https://www.w3.org/ns/activitystreams\"")
+		// Mock
+		p.EXPECT().ActorProfile(ctx, mustParse(actorIRI)).Return(profile, true, nil)
+		mockClock.EXPECT().Now().Return(now())
+		// Run & Verify
+		isAPReq, err := hf(ctx, resp, req)
+		assertEqual(t, isAPReq, true)
+		assertEqual(t, err, nil)
+		assertEqual(t, resp.Result().Header.Get(contentTypeHeader), contentTypeHeaderValue)
+	})
+	t.Run("ServesHTMLIfPreferred", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		p, _, hf := setupFn(ctl, htmlFn)
+		resp := httptest.NewRecorder()
+		req := newRequestFn("text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+		// Mock
+		p.EXPECT().ActorProfile(ctx, mustParse(actorIRI)).Return(profile, true, nil)
+		// Run & Verify
+		isAPReq, err := hf(ctx, resp, req)
+		assertEqual(t, isAPReq, true)
+		assertEqual(t, err, nil)
+		respV := resp.Result()
+		assertEqual(t, respV.Header.Get(contentTypeHeader), "text/html")
+		b, err := ioutil.ReadAll(respV.Body)
+		assertEqual(t, err, nil)
+		assertByteEqual(t, b, []byte("<p>addison</p>"))
+	})
+}
+
+// TestNegotiateActorMediaType tests choosing the media type of actor
+// documents.
+func TestNegotiateActorMediaType(t *testing.T) {
+	tests := []struct {
+		name      string
+		accept    string
+		allowHTML bool
+		expect    string
+	}{
+		{"ActivityJSON", "application/activity+json", false, activityJSONMediaType},
+		{"LDJSONWithProfile", "application/ld+json; profile=\"https://www.w3.org/ns/activitystreams\"", false, ldJSONMediaType},
+		{"LDJSONWithoutProfile", "application/ld+json", false, ""},
+		{"WildcardPrefersActivityJSON", "*/*", true, activityJSONMediaType},
+		{"HigherQualityWins", "application/activity+json;q=0.4, text/html;q=0.9", true, "text/html"},
+		{"ZeroQualityExcluded", "application/activity+json;q=0", false, ""},
+		{"HTMLFallback", "image/png", true, "text/html"},
+		{"HTMLNotAllowed", "text/html", false, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assertEqual(t, negotiateActorMediaType(test.accept, test.allowHTML), test.expect)
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package pub
+
+// ActorOption configures optional behavior of the Actors returned by
+// NewSocialActor, NewFederatingActor, and NewActor.
+//
+// Omitting every option results in the default behavior documented on each
+// constructor.
+type ActorOption func(o *actorOptions)
+
+// actorOptions holds the values set by ActorOptions.
+type actorOptions struct {
+	// deliveryQueue, if set, receives outgoing deliveries instead of the
+	// Transport's BatchDeliver.
+	deliveryQueue DeliveryQueue
+	// publicKeyStore, if set, is kept up to date with federated Updates
+	// and Deletes of actors.
+	publicKeyStore PublicKeyStore
+	// actorCache, if set, is consulted before dereferencing peers' actors
+	// and collections when delivering.
+	actorCache ActorCache
+	// blockList, if set, records blocks made through the outbox and is
+	// enforced when receiving and delivering activities.
+	blockList BlockList
+	// pendingFollows, if set, holds the Follows received with
+	// OnFollowPendingApproval.
+	pendingFollows PendingFollowStore
+	// votes, if set, records the votes tallied on local Questions.
+	votes VoteStore
+	// repliesCollections, if set, maintains the 'replies' collections of
+	// local objects.
+	repliesCollections bool
+	// requestLimits bounds the POST requests to inboxes and outboxes.
+	requestLimits RequestLimits
+	// instanceActor signs the requests made in the background.
+	instanceActor *InstanceActor
+}
+
+// newActorOptions applies the ActorOptions in order.
+func newActorOptions(opts []ActorOption) actorOptions {
+	var o actorOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithDeliveryQueue makes the Actor hand every federated delivery to the
+// DeliveryQueue, so that failed deliveries are retried, instead of sending
+// them once with the Transport's BatchDeliver.
+func WithDeliveryQueue(q DeliveryQueue) ActorOption {
+	return func(o *actorOptions) {
+		o.deliveryQueue = q
+	}
+}
+
+// WithPublicKeyStore keeps the PublicKeyStore used by an HttpSigVerifier up to
+// date: a federated Update of an actor invalidates its cached public keys, and
+// a federated Delete of an actor revokes them.
+//
+// It only applies to Actors using the Federating Protocol.
+func WithPublicKeyStore(s PublicKeyStore) ActorOption {
+	return func(o *actorOptions) {
+		o.publicKeyStore = s
+	}
+}
+
+// WithActorCache makes the Actor consult the ActorCache before dereferencing
+// the actors and collections of peers when resolving the inboxes to deliver
+// to. A federated Update or Delete of a cached entry invalidates it.
+//
+// It only applies to Actors using the Federating Protocol.
+func WithActorCache(ac ActorCache) ActorOption {
+	return func(o *actorOptions) {
+		o.actorCache = ac
+	}
+}
+
+// WithBlockList makes the Actor record in the BlockList the Blocks its actors
+// post to their outbox, and lift them when the Blocks are undone.
+//
+// The BlockList is also enforced: requests from peers blocked by the whole
+// server are forbidden before FederatingProtocol.Blocked is consulted,
+// activities from peers blocked by the receiving actor are dropped, blocked
+// peers are not delivered to or forwarded to, and their activities are left
+// out of the inbox.
+func WithBlockList(b BlockList) ActorOption {
+	return func(o *actorOptions) {
+		o.blockList = b
+	}
+}
+
+// WithPendingFollowStore makes the Actor record the Follows of its actors
+// received with OnFollowPendingApproval in the PendingFollowStore, to be
+// answered with AcceptFollow or RejectFollow.
+//
+// It only applies to Actors using the Federating Protocol.
+func WithPendingFollowStore(p PendingFollowStore) ActorOption {
+	return func(o *actorOptions) {
+		o.pendingFollows = p
+	}
+}
+
+// WithVoteStore makes the Actor tally the votes of peers on the Questions
+// owned by its actors, recording them in the VoteStore so that each peer's
+// vote is only counted once. Without it, votes are handled like any other
+// federated Create.
+//
+// It only applies to Actors using the Federating Protocol.
+func WithVoteStore(v VoteStore) ActorOption {
+	return func(o *actorOptions) {
+		o.votes = v
+	}
+}
+
+// WithRepliesCollections makes the Actor maintain the 'replies' collection of
+// the objects owned by its server: the objects of a Create, whether received
+// from a peer or posted to an outbox, are added to the 'replies' of the local
+// objects they are 'inReplyTo'.
+//
+// A federated Delete of a reply removes it from the 'replies' collections. A
+// Delete posted to an outbox leaves the reply's id in place, where it is now
+// served as a Tombstone.
+func WithRepliesCollections() ActorOption {
+	return func(o *actorOptions) {
+		o.repliesCollections = true
+	}
+}
+
+// WithRequestLimits makes the Actor enforce the RequestLimits on the POST
+// requests to inboxes and outboxes. A body that is too large is refused with
+// 413 Request Entity Too Large, and one that is too deeply nested or has too
+// many array items with 400 Bad Request. The body is bounded before the
+// delegate authenticates the request, so that a verifier reading the body
+// cannot read past MaxBodyBytes either.
+//
+// The depth and item limits are also carried by the context given to the
+// delegate, so that streams.ToType enforces them on the values fetched while
+// handling the request.
+func WithRequestLimits(l RequestLimits) ActorOption {
+	return func(o *actorOptions) {
+		o.requestLimits = l
+	}
+}
+
+// WithInstanceActor makes the Actor dereference recipients when resolving
+// inboxes, and the objects of activities when checking their actors, with a
+// Transport of the InstanceActor instead of one created by CommonBehavior's
+// NewTransport for the inbox or outbox.
+func WithInstanceActor(i *InstanceActor) ActorOption {
+	return func(o *actorOptions) {
+		o.instanceActor = i
+	}
+}
@@ -0,0 +1,285 @@
+package pub
+
+import (
+	"context"
+	"github.com/go-fed/activity/streams/vocab"
+	"net/http"
+	"net/url"
+)
+
+const (
+	// wwwAuthenticateHeader is the WWW-Authenticate header.
+	wwwAuthenticateHeader = "WWW-Authenticate"
+	// signatureChallenge is the WWW-Authenticate challenge asking for an
+	// HTTP Signature.
+	signatureChallenge = "Signature realm=\"activitypub\",headers=\"(request-target) host date\""
+)
+
+// signerKey is the context key of the actor that signed a request.
+type signerKey struct{}
+
+// SignerFromContext returns the actor that signed the GET request, as
+// authenticated by AuthorizedFetch, if any.
+func SignerFromContext(c context.Context) (signer *url.URL, ok bool) {
+	signer, ok = c.Value(signerKey{}).(*url.URL)
+	return
+}
+
+// AuthorizedFetch implements authorized fetch, also known as secure mode, in
+// which every ActivityStreams GET request must carry a valid HTTP Signature
+// and objects are only served to the actors they are addressed to.
+//
+// It is opt-in: its Wrap method wraps the HandlerFuncs serving ActivityStreams
+// values, NewAuthorizedActivityStreamsHandler replaces
+// NewActivityStreamsHandler, and its Authenticate method may implement
+// CommonBehavior's AuthenticateGetInbox and AuthenticateGetOutbox.
+//
+// Following Mastodon, requests that are not signed, or whose signature fails
+// verification, are answered with 401 Unauthorized. Requests signed by an
+// actor blocked by the whole server are answered with 403 Forbidden. Objects
+// that the signing actor may not see are answered with 404 Not Found, so as
+// not to reveal that they exist.
+type AuthorizedFetch struct {
+	verifier     *HttpSigVerifier
+	db           Database
+	blocks       BlockList
+	newTransport func(c context.Context, actorBoxIRI *url.URL, gofedAgent string) (Transport, error)
+	boxIRI       *url.URL
+}
+
+// NewAuthorizedFetch returns a new AuthorizedFetch.
+//
+// The verifier verifies the HTTP Signatures, dereferencing the public keys
+// with a Transport returned by newTransport for the boxIRI, which is typically
+// the application's CommonBehavior.NewTransport and the outbox of an actor
+// representing the whole server. The blocks are optional.
+func NewAuthorizedFetch(verifier *HttpSigVerifier,
+	db Database,
+	blocks BlockList,
+	newTransport func(c context.Context, actorBoxIRI *url.URL, gofedAgent string) (Transport, error),
+	boxIRI *url.URL) *AuthorizedFetch {
+	return &AuthorizedFetch{
+		verifier:     verifier,
+		db:           db,
+		blocks:       blocks,
+		newTransport: newTransport,
+		boxIRI:       boxIRI,
+	}
+}
+
+// Authenticate verifies the HTTP Signature of the request. If it is authentic
+// and its signer is not blocked, the returned context carries the signer,
+// which may be obtained with SignerFromContext.
+//
+// Otherwise authenticated is false and the response has been written. Its
+// signature matches that of CommonBehavior's AuthenticateGetInbox and
+// AuthenticateGetOutbox.
+func (a *AuthorizedFetch) Authenticate(c context.Context, w http.ResponseWriter, r *http.Request) (out context.Context, authenticated bool, err error) {
+	out = c
+	t, err := a.newTransport(c, a.boxIRI, goFedUserAgent())
+	if err != nil {
+		return
+	}
+	_, signer, verr := a.verifier.Verify(c, r, t)
+	if verr != nil {
+		w.Header().Set(wwwAuthenticateHeader, signatureChallenge)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if a.blocks != nil {
+		var blocked bool
+		blocked, err = a.blocks.Blocked(c, nil, []*url.URL{signer})
+		if err != nil {
+			return
+		} else if blocked {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+	}
+	out = context.WithValue(c, signerKey{}, signer)
+	authenticated = true
+	return
+}
+
+// Wrap returns a HandlerFunc that authenticates ActivityStreams GET requests
+// before calling the HandlerFunc with the context carrying the signer. Other
+// requests are passed to the HandlerFunc unchanged.
+func (a *AuthorizedFetch) Wrap(h HandlerFunc) HandlerFunc {
+	return func(c context.Context, w http.ResponseWriter, r *http.Request) (isASRequest bool, err error) {
+		if !isActivityPubGet(r) {
+			return h(c, w, r)
+		}
+		c, authenticated, err := a.Authenticate(c, w, r)
+		if err != nil || !authenticated {
+			return true, err
+		}
+		return h(c, w, r)
+	}
+}
+
+// Visible determines whether the signer may see the ActivityStreams value.
+//
+// Values without any 'to', 'bto', 'cc', 'bcc', or 'audience', such as actors
+// and collections, are visible to everyone, as are values addressed to the
+// Public collection. Otherwise the signer must be addressed, be one of the
+// value's 'attributedTo' or 'actor', or follow one of them on this server
+// whose followers collection is addressed. Values attributed to an actor on
+// this server that blocks the signer are never visible.
+//
+// The value must not be locked by the caller.
+func (a *AuthorizedFetch) Visible(c context.Context, signer *url.URL, t vocab.Type) (visible bool, err error) {
+	owners, err := getOwnerIds(t)
+	if err != nil {
+		return
+	}
+	if a.blocks != nil {
+		for _, owner := range owners {
+			var blocked bool
+			blocked, err = a.blocks.Blocked(c, owner, []*url.URL{signer})
+			if err != nil || blocked {
+				return
+			}
+		}
+	}
+	recipients, err := getObjectRecipients(t)
+	if err != nil {
+		return
+	} else if len(recipients) == 0 {
+		return true, nil
+	}
+	addressed := make(map[string]bool, len(recipients))
+	for _, r := range recipients {
+		if IsPublic(r.String()) || r.String() == signer.String() {
+			return true, nil
+		}
+		addressed[r.String()] = true
+	}
+	for _, owner := range owners {
+		if owner.String() == signer.String() {
+			return true, nil
+		}
+	}
+	for _, owner := range owners {
+		visible, err = a.followsAddressed(c, signer, owner, addressed)
+		if err != nil || visible {
+			return
+		}
+	}
+	return
+}
+
+// followsAddressed determines whether the signer follows the owner, and the
+// owner's followers collection is addressed.
+func (a *AuthorizedFetch) followsAddressed(c context.Context, signer, owner *url.URL, addressed map[string]bool) (follows bool, err error) {
+	owns, err := a.db.Owns(c, owner)
+	if err != nil || !owns {
+		return
+	}
+	err = a.db.Lock(c, owner)
+	if err != nil {
+		return
+	}
+	defer a.db.Unlock(c, owner)
+	followers, err := a.db.Followers(c, owner)
+	if err != nil {
+		return
+	}
+	id, err := GetId(followers)
+	if err != nil || !addressed[id.String()] {
+		return
+	}
+	items := followers.GetActivityStreamsItems()
+	if items == nil {
+		return
+	}
+	for iter := items.Begin(); iter != items.End(); iter = iter.Next() {
+		var iri *url.URL
+		iri, err = ToId(iter)
+		if err != nil {
+			return
+		} else if iri.String() == signer.String() {
+			return true, nil
+		}
+	}
+	return
+}
+
+// getObjectRecipients returns the ids in the 'to', 'bto', 'cc', 'bcc', and
+// 'audience' properties of any ActivityStreams value.
+func getObjectRecipients(t vocab.Type) (r []*url.URL, err error) {
+	appendFn := func(iter IdProperty) error {
+		val, err := ToId(iter)
+		if err != nil {
+			return err
+		}
+		r = append(r, val)
+		return nil
+	}
+	if v, ok := t.(toer); ok && v.GetActivityStreamsTo() != nil {
+		to := v.GetActivityStreamsTo()
+		for iter := to.Begin(); iter != to.End(); iter = iter.Next() {
+			if err = appendFn(iter); err != nil {
+				return
+			}
+		}
+	}
+	if v, ok := t.(btoer); ok && v.GetActivityStreamsBto() != nil {
+		bto := v.GetActivityStreamsBto()
+		for iter := bto.Begin(); iter != bto.End(); iter = iter.Next() {
+			if err = appendFn(iter); err != nil {
+				return
+			}
+		}
+	}
+	if v, ok := t.(ccer); ok && v.GetActivityStreamsCc() != nil {
+		cc := v.GetActivityStreamsCc()
+		for iter := cc.Begin(); iter != cc.End(); iter = iter.Next() {
+			if err = appendFn(iter); err != nil {
+				return
+			}
+		}
+	}
+	if v, ok := t.(bccer); ok && v.GetActivityStreamsBcc() != nil {
+		bcc := v.GetActivityStreamsBcc()
+		for iter := bcc.Begin(); iter != bcc.End(); iter = iter.Next() {
+			if err = appendFn(iter); err != nil {
+				return
+			}
+		}
+	}
+	if v, ok := t.(audiencer); ok && v.GetActivityStreamsAudience() != nil {
+		audience := v.GetActivityStreamsAudience()
+		for iter := audience.Begin(); iter != audience.End(); iter = iter.Next() {
+			if err = appendFn(iter); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// getOwnerIds returns the ids in the 'attributedTo' and 'actor' properties of
+// any ActivityStreams value.
+func getOwnerIds(t vocab.Type) (o []*url.URL, err error) {
+	if v, ok := t.(attributedToer); ok && v.GetActivityStreamsAttributedTo() != nil {
+		attrTo := v.GetActivityStreamsAttributedTo()
+		for iter := attrTo.Begin(); iter != attrTo.End(); iter = iter.Next() {
+			var id *url.URL
+			if id, err = ToId(iter); err != nil {
+				return
+			}
+			o = append(o, id)
+		}
+	}
+	if v, ok := t.(actorer); ok && v.GetActivityStreamsActor() != nil {
+		actor := v.GetActivityStreamsActor()
+		for iter := actor.Begin(); iter != actor.End(); iter = iter.Next() {
+			var id *url.URL
+			if id, err = ToId(iter); err != nil {
+				return
+			}
+			o = append(o, id)
+		}
+	}
+	return
+}
@@ -0,0 +1,202 @@
+package pub
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-fed/activity/streams"
+	"github.com/go-fed/activity/streams/vocab"
+	"github.com/go-fed/httpsig"
+	"github.com/golang/mock/gomock"
+)
+
+// newTestSignedGetRequest creates an ActivityStreams GET request for the IRI,
+// signed over the request target, host, and date.
+func newTestSignedGetRequest(t *testing.T, privKey *rsa.PrivateKey, keyId string, iri string) *http.Request {
+	r := toAPRequest(httptest.NewRequest("GET", iri, nil))
+	r.Header.Set(hostHeader, r.Host)
+	s, _, err := httpsig.NewSigner([]httpsig.Algorithm{httpsig.RSA_SHA256}, httpsig.DigestSha256, []string{httpsig.RequestTarget, "host", "date"}, httpsig.Signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.SignRequest(privKey, keyId, r, nil); err != nil {
+		t.Fatal(err)
+	}
+	r.Header.Del(hostHeader)
+	return r
+}
+
+// TestAuthorizedActivityStreamsHandler tests serving ActivityStreams values
+// in authorized fetch mode.
+func TestAuthorizedActivityStreamsHandler(t *testing.T) {
+	ctx := context.Background()
+	setupData()
+	privKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	person := newTestKeyedPerson(newTestPublicKey(t, testFederatedKeyIRI, testFederatedActorIRI, &privKey.PublicKey))
+	const actorIRI = "https://example.com/addison"
+	const followersIRI = actorIRI + "/followers"
+	newNoteFn := func(to string) vocab.ActivityStreamsNote {
+		n := streams.NewActivityStreamsNote()
+		id := streams.NewJSONLDIdProperty()
+		id.Set(mustParse(testNoteId1))
+		n.SetJSONLDId(id)
+		attrTo := streams.NewActivityStreamsAttributedToProperty()
+		attrTo.AppendIRI(mustParse(actorIRI))
+		n.SetActivityStreamsAttributedTo(attrTo)
+		toProp := streams.NewActivityStreamsToProperty()
+		toProp.AppendIRI(mustParse(to))
+		n.SetActivityStreamsTo(toProp)
+		return n
+	}
+	setupFn := func(ctl *gomock.Controller, blocks BlockList) (db *MockDatabase, tp *MockTransport, hf HandlerFunc) {
+		db = NewMockDatabase(ctl)
+		tp = NewMockTransport(ctl)
+		clock := NewMockClock(ctl)
+		clock.EXPECT().Now().Return(now()).AnyTimes()
+		newTransportFn := func(c context.Context, actorBoxIRI *url.URL, gofedAgent string) (Transport, error) {
+			assertEqual(t, actorBoxIRI.String(), testMyOutboxIRI)
+			return tp, nil
+		}
+		a := NewAuthorizedFetch(NewHttpSigVerifier(clock, nil, time.Minute), db, blocks, newTransportFn, mustParse(testMyOutboxIRI))
+		hf = NewAuthorizedActivityStreamsHandler(db, clock, a)
+		return
+	}
+	getFn := func(db *MockDatabase, t vocab.Type) {
+		db.EXPECT().Lock(gomock.Any(), mustParse(testNoteId1))
+		db.EXPECT().Get(gomock.Any(), mustParse(testNoteId1)).Return(t, nil)
+		db.EXPECT().Unlock(gomock.Any(), mustParse(testNoteId1))
+	}
+	t.Run("IgnoresIfNotActivityPubGetRequest", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		_, _, hf := setupFn(ctl, nil)
+		resp := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", testNoteId1, nil)
+		// Run & Verify
+		isAPReq, err := hf(ctx, resp, req)
+		assertEqual(t, isAPReq, false)
+		assertEqual(t, err, nil)
+		assertEqual(t, len(resp.Result().Header), 0)
+	})
+	t.Run("UnauthorizedIfNotSigned", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		_, _, hf := setupFn(ctl, nil)
+		resp := httptest.NewRecorder()
+		req := toAPRequest(httptest.NewRequest("GET", testNoteId1, nil))
+		// Run & Verify
+		isAPReq, err := hf(ctx, resp, req)
+		assertEqual(t, isAPReq, true)
+		assertEqual(t, err, nil)
+		assertEqual(t, resp.Code, http.StatusUnauthorized)
+		assertEqual(t, resp.Result().Header.Get(wwwAuthenticateHeader), signatureChallenge)
+	})
+	t.Run("ForbiddenIfSignerBlockedByServer", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		blocks := NewMemoryBlockList()
+		assertEqual(t, blocks.BlockDomain(ctx, nil, "other.example.com"), nil)
+		_, tp, hf := setupFn(ctl, blocks)
+		resp := httptest.NewRecorder()
+		req := newTestSignedGetRequest(t, privKey, testFederatedKeyIRI, testNoteId1)
+		// Mock
+		tp.EXPECT().Dereference(ctx, mustParse(testFederatedKeyIRI)).Return(mustSerializeToBytes(person), nil)
+		// Run & Verify
+		isAPReq, err := hf(ctx, resp, req)
+		assertEqual(t, isAPReq, true)
+		assertEqual(t, err, nil)
+		assertEqual(t, resp.Code, http.StatusForbidden)
+	})
+	t.Run("ServesPublicValue", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		db, tp, hf := setupFn(ctl, nil)
+		resp := httptest.NewRecorder()
+		req := newTestSignedGetRequest(t, privKey, testFederatedKeyIRI, testNoteId1)
+		note := newNoteFn(PublicActivityPubIRI)
+		// Mock
+		tp.EXPECT().Dereference(ctx, mustParse(testFederatedKeyIRI)).Return(mustSerializeToBytes(person), nil)
+		getFn(db, note)
+		// Run & Verify
+		isAPReq, err := hf(ctx, resp, req)
+		assertEqual(t, isAPReq, true)
+		assertEqual(t, err, nil)
+		assertEqual(t, resp.Code, http.StatusOK)
+	})
+	t.Run("NotFoundIfNotAddressedToSigner", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		db, tp, hf := setupFn(ctl, nil)
+		resp := httptest.NewRecorder()
+		req := newTestSignedGetRequest(t, privKey, testFederatedKeyIRI, testNoteId1)
+		note := newNoteFn(testFederatedActorIRI2)
+		// Mock
+		tp.EXPECT().Dereference(ctx, mustParse(testFederatedKeyIRI)).Return(mustSerializeToBytes(person), nil)
+		getFn(db, note)
+		db.EXPECT().Owns(gomock.Any(), mustParse(actorIRI)).Return(true, nil)
+		db.EXPECT().Lock(gomock.Any(), mustParse(actorIRI))
+		db.EXPECT().Followers(gomock.Any(), mustParse(actorIRI)).Return(newCollection(mustParse(followersIRI)), nil)
+		db.EXPECT().Unlock(gomock.Any(), mustParse(actorIRI))
+		// Run & Verify
+		isAPReq, err := hf(ctx, resp, req)
+		assertEqual(t, isAPReq, true)
+		assertEqual(t, err, nil)
+		assertEqual(t, resp.Code, http.StatusNotFound)
+	})
+	t.Run("ServesToFollowersOfAuthor", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		db, tp, hf := setupFn(ctl, nil)
+		resp := httptest.NewRecorder()
+		req := newTestSignedGetRequest(t, privKey, testFederatedKeyIRI, testNoteId1)
+		note := newNoteFn(followersIRI)
+		followers := newCollection(mustParse(followersIRI))
+		followers.GetActivityStreamsItems().AppendIRI(mustParse(testFederatedActorIRI))
+		// Mock
+		tp.EXPECT().Dereference(ctx, mustParse(testFederatedKeyIRI)).Return(mustSerializeToBytes(person), nil)
+		getFn(db, note)
+		db.EXPECT().Owns(gomock.Any(), mustParse(actorIRI)).Return(true, nil)
+		db.EXPECT().Lock(gomock.Any(), mustParse(actorIRI))
+		db.EXPECT().Followers(gomock.Any(), mustParse(actorIRI)).Return(followers, nil)
+		db.EXPECT().Unlock(gomock.Any(), mustParse(actorIRI))
+		// Run & Verify
+		isAPReq, err := hf(ctx, resp, req)
+		assertEqual(t, isAPReq, true)
+		assertEqual(t, err, nil)
+		assertEqual(t, resp.Code, http.StatusOK)
+	})
+	t.Run("NotFoundIfAuthorBlocksSigner", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		blocks := NewMemoryBlockList()
+		assertEqual(t, blocks.Block(ctx, mustParse(actorIRI), mustParse(testFederatedActorIRI)), nil)
+		db, tp, hf := setupFn(ctl, blocks)
+		resp := httptest.NewRecorder()
+		req := newTestSignedGetRequest(t, privKey, testFederatedKeyIRI, testNoteId1)
+		note := newNoteFn(PublicActivityPubIRI)
+		// Mock
+		tp.EXPECT().Dereference(ctx, mustParse(testFederatedKeyIRI)).Return(mustSerializeToBytes(person), nil)
+		getFn(db, note)
+		// Run & Verify
+		isAPReq, err := hf(ctx, resp, req)
+		assertEqual(t, isAPReq, true)
+		assertEqual(t, err, nil)
+		assertEqual(t, resp.Code, http.StatusNotFound)
+	})
+}
@@ -35,11 +35,19 @@ type baseActor struct {
 	enableFederatedProtocol bool
 	// clock simply tracks the current time.
 	clock Clock
+	// limits bounds the POST requests to inboxes and outboxes.
+	limits RequestLimits
 }
 
 // baseActorFederating must satisfy the FederatingActor interface.
 var _ FederatingActor = &baseActorFederating{}
 
+// baseActorFederating must satisfy the SharedInboxActor interface.
+var _ SharedInboxActor = &baseActorFederating{}
+
+// baseActorFederating must satisfy the FollowApprovalActor interface.
+var _ FollowApprovalActor = &baseActorFederating{}
+
 // baseActorFederating is a baseActor that also satisfies the FederatingActor
 // interface.
 //
@@ -61,19 +69,28 @@ type baseActorFederating struct {
 //
 // Do not try to use NewSocialActor and NewFederatingActor together to cover
 // both the Social and Federating parts of the protocol. Instead, use NewActor.
+//
+// Optional behaviors may be enabled by passing ActorOptions.
 func NewSocialActor(c CommonBehavior,
 	c2s SocialProtocol,
 	db Database,
-	clock Clock) Actor {
+	clock Clock,
+	opts ...ActorOption) Actor {
+	o := newActorOptions(opts)
 	return &baseActor{
 		delegate: &sideEffectActor{
-			common: c,
-			c2s:    c2s,
-			db:     db,
-			clock:  clock,
+			common:   c,
+			c2s:      c2s,
+			db:       db,
+			clock:    clock,
+			queue:    o.deliveryQueue,
+			blocks:   o.blockList,
+			replies:  o.repliesCollections,
+			instance: o.instanceActor,
 		},
 		enableSocialProtocol: true,
 		clock:                clock,
+		limits:               o.requestLimits,
 	}
 }
 
@@ -89,20 +106,33 @@ func NewSocialActor(c CommonBehavior,
 //
 // Do not try to use NewSocialActor and NewFederatingActor together to cover
 // both the Social and Federating parts of the protocol. Instead, use NewActor.
+//
+// Optional behaviors may be enabled by passing ActorOptions.
 func NewFederatingActor(c CommonBehavior,
 	s2s FederatingProtocol,
 	db Database,
-	clock Clock) FederatingActor {
+	clock Clock,
+	opts ...ActorOption) FederatingActor {
+	o := newActorOptions(opts)
 	return &baseActorFederating{
 		baseActor{
 			delegate: &sideEffectActor{
-				common: c,
-				s2s:    s2s,
-				db:     db,
-				clock:  clock,
+				common:         c,
+				s2s:            s2s,
+				db:             db,
+				clock:          clock,
+				queue:          o.deliveryQueue,
+				keys:           o.publicKeyStore,
+				actorCache:     o.actorCache,
+				blocks:         o.blockList,
+				pendingFollows: o.pendingFollows,
+				votes:          o.votes,
+				replies:        o.repliesCollections,
+				instance:       o.instanceActor,
 			},
 			enableFederatedProtocol: true,
 			clock:                   clock,
+			limits:                  o.requestLimits,
 		},
 	}
 }
@@ -116,23 +146,36 @@ func NewFederatingActor(c CommonBehavior,
 // It leverages as much of go-fed as possible to ensure the implementation is
 // compliant with the ActivityPub specification, while providing enough freedom
 // to be productive without shooting one's self in the foot.
+//
+// Optional behaviors may be enabled by passing ActorOptions.
 func NewActor(c CommonBehavior,
 	c2s SocialProtocol,
 	s2s FederatingProtocol,
 	db Database,
-	clock Clock) FederatingActor {
+	clock Clock,
+	opts ...ActorOption) FederatingActor {
+	o := newActorOptions(opts)
 	return &baseActorFederating{
 		baseActor{
 			delegate: &sideEffectActor{
-				common: c,
-				c2s:    c2s,
-				s2s:    s2s,
-				db:     db,
-				clock:  clock,
+				common:         c,
+				c2s:            c2s,
+				s2s:            s2s,
+				db:             db,
+				clock:          clock,
+				queue:          o.deliveryQueue,
+				keys:           o.publicKeyStore,
+				actorCache:     o.actorCache,
+				blocks:         o.blockList,
+				pendingFollows: o.pendingFollows,
+				votes:          o.votes,
+				replies:        o.repliesCollections,
+				instance:       o.instanceActor,
 			},
 			enableSocialProtocol:    true,
 			enableFederatedProtocol: true,
 			clock:                   clock,
+			limits:                  o.requestLimits,
 		},
 	}
 }
@@ -163,6 +206,13 @@ func NewCustomActor(delegate DelegateActor,
 // actor's inbox independent on an application. It relies on a delegate to
 // implement application specific functionality.
 func (b *baseActor) PostInbox(c context.Context, w http.ResponseWriter, r *http.Request) (bool, error) {
+	return b.postInbox(c, w, r, nil)
+}
+
+// postInbox handles a POST request to either an actor's inbox or, if shared is
+// not nil, the server's shared inbox. Requests to the shared inbox are
+// delivered to the inboxes the shared delegate determines.
+func (b *baseActor) postInbox(c context.Context, w http.ResponseWriter, r *http.Request, shared SharedInboxDelegateActor) (bool, error) {
 	// Do nothing if it is not an ActivityPub POST request.
 	if !isActivityPubPost(r) {
 		return false, nil
@@ -173,9 +223,18 @@ func (b *baseActor) PostInbox(c context.Context, w http.ResponseWriter, r *http.
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return true, nil
 	}
+	// Bound the body before the delegate reads it to authenticate the
+	// request.
+	if err := b.limits.limitBody(r); err != nil {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return true, nil
+	}
 	// Check the peer request is authentic.
 	c, authenticated, err := b.delegate.AuthenticatePostInbox(c, w, r)
-	if err != nil {
+	if err == ErrRequestBodyTooLarge {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return true, nil
+	} else if err != nil {
 		return true, err
 	} else if !authenticated {
 		return true, nil
@@ -184,15 +243,23 @@ func (b *baseActor) PostInbox(c context.Context, w http.ResponseWriter, r *http.
 	// authorization (ex: blocks). Obtain the activity reject unknown
 	// activities.
 	raw, err := ioutil.ReadAll(r.Body)
-	if err != nil {
+	if err == ErrRequestBodyTooLarge {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return true, nil
+	} else if err != nil {
 		return true, err
 	}
 	var m map[string]interface{}
 	if err = json.Unmarshal(raw, &m); err != nil {
 		return true, err
 	}
+	c = b.limits.context(c)
 	asValue, err := streams.ToType(c, m)
-	if err != nil && !streams.IsUnmatchedErr(err) {
+	if isLimitsErr(err) {
+		// Respond with bad request -- the peer sent too much.
+		w.WriteHeader(http.StatusBadRequest)
+		return true, nil
+	} else if err != nil && !streams.IsUnmatchedErr(err) {
 		return true, err
 	} else if streams.IsUnmatchedErr(err) {
 		// Respond with bad request -- we do not understand the type.
@@ -219,11 +286,29 @@ func (b *baseActor) PostInbox(c context.Context, w http.ResponseWriter, r *http.
 	} else if !authorized {
 		return true, nil
 	}
-	// Post the activity to the actor's inbox and trigger side effects for
-	// that particular Activity type. It is up to the delegate to resolve
-	// the given map.
-	inboxId := requestId(r)
-	err = b.delegate.PostInbox(c, inboxId, activity)
+	// Post the activity to the actor's inbox, or to the inboxes of the
+	// actors it is for if received in the shared inbox, and trigger side
+	// effects for that particular Activity type. It is up to the delegate
+	// to resolve the given map.
+	var inboxId *url.URL
+	if shared != nil {
+		var inboxIds []*url.URL
+		inboxIds, err = shared.SharedInboxRecipients(c, activity)
+		if err != nil {
+			return true, err
+		} else if len(inboxIds) == 0 {
+			w.WriteHeader(http.StatusOK)
+			return true, nil
+		}
+		var posted []*url.URL
+		posted, err = shared.PostSharedInbox(c, inboxIds, activity)
+		if len(posted) > 0 {
+			inboxId = posted[0]
+		}
+	} else {
+		inboxId = requestId(r)
+		err = b.delegate.PostInbox(c, inboxId, activity)
+	}
 	if err != nil {
 		// Special case: We know it is a bad request if the object or
 		// target properties needed to be populated, but weren't.
@@ -236,9 +321,13 @@ func (b *baseActor) PostInbox(c context.Context, w http.ResponseWriter, r *http.
 		return true, err
 	}
 	// Our side effects are complete, now delegate determining whether to
-	// do inbox forwarding, as well as the action to do it.
-	if err := b.delegate.InboxForwarding(c, inboxId, activity); err != nil {
-		return true, err
+	// do inbox forwarding, as well as the action to do it. It is done once
+	// for the activity, even if it was posted to several inboxes, and not
+	// at all if every local recipient refused it.
+	if inboxId != nil {
+		if err := b.delegate.InboxForwarding(c, inboxId, activity); err != nil {
+			return true, err
+		}
 	}
 	// Request has been processed. Begin responding to the request.
 	//
@@ -263,14 +352,16 @@ func (b *baseActor) GetInbox(c context.Context, w http.ResponseWriter, r *http.R
 		return true, nil
 	}
 	// Everything is good to begin processing the request.
-	oc, err := b.delegate.GetInbox(c, r)
+	oc, err := b.getInbox(c, r)
 	if err != nil {
 		return true, err
 	}
-	// Deduplicate the 'orderedItems' property by ID.
-	err = dedupeOrderedItems(oc)
-	if err != nil {
-		return true, err
+	// Deduplicate the 'orderedItems' property by ID, if this is a page.
+	if oi, ok := oc.(orderedItemser); ok {
+		err = dedupeOrderedItems(oi)
+		if err != nil {
+			return true, err
+		}
 	}
 	// Request has been processed. Begin responding to the request.
 	//
@@ -308,16 +399,27 @@ func (b *baseActor) PostOutbox(c context.Context, w http.ResponseWriter, r *http
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return true, nil
 	}
+	// Bound the body before the delegate may read it.
+	if err := b.limits.limitBody(r); err != nil {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return true, nil
+	}
 	// Delegate authenticating and authorizing the request.
 	c, authenticated, err := b.delegate.AuthenticatePostOutbox(c, w, r)
-	if err != nil {
+	if err == ErrRequestBodyTooLarge {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return true, nil
+	} else if err != nil {
 		return true, err
 	} else if !authenticated {
 		return true, nil
 	}
 	// Everything is good to begin processing the request.
 	raw, err := ioutil.ReadAll(r.Body)
-	if err != nil {
+	if err == ErrRequestBodyTooLarge {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return true, nil
+	} else if err != nil {
 		return true, err
 	}
 	var m map[string]interface{}
@@ -328,8 +430,13 @@ func (b *baseActor) PostOutbox(c context.Context, w http.ResponseWriter, r *http
 	// not known to go-fed. This prevents accidentally wrapping an Activity
 	// type unknown to go-fed in a Create below. Instead,
 	// streams.ErrUnhandledType will be returned here.
+	c = b.limits.context(c)
 	asValue, err := streams.ToType(c, m)
-	if err != nil && !streams.IsUnmatchedErr(err) {
+	if isLimitsErr(err) {
+		// Respond with bad request -- the client sent too much.
+		w.WriteHeader(http.StatusBadRequest)
+		return true, nil
+	} else if err != nil && !streams.IsUnmatchedErr(err) {
 		return true, err
 	} else if streams.IsUnmatchedErr(err) {
 		// Respond with bad request -- we do not understand the type.
@@ -377,7 +484,7 @@ func (b *baseActor) GetOutbox(c context.Context, w http.ResponseWriter, r *http.
 		return true, nil
 	}
 	// Everything is good to begin processing the request.
-	oc, err := b.delegate.GetOutbox(c, r)
+	oc, err := b.getOutbox(c, r)
 	if err != nil {
 		return true, err
 	}
@@ -404,6 +511,31 @@ func (b *baseActor) GetOutbox(c context.Context, w http.ResponseWriter, r *http.
 	return true, nil
 }
 
+// getInbox obtains the inbox, or the requested page of it, from the delegate.
+func (b *baseActor) getInbox(c context.Context, r *http.Request) (vocab.Type, error) {
+	if paged, ok := b.delegate.(PagedDelegateActor); ok {
+		return paged.GetPagedInbox(c, r)
+	}
+	oc, err := b.delegate.GetInbox(c, r)
+	if err != nil {
+		return nil, err
+	}
+	return oc, nil
+}
+
+// getOutbox obtains the outbox, or the requested page of it, from the
+// delegate.
+func (b *baseActor) getOutbox(c context.Context, r *http.Request) (vocab.Type, error) {
+	if paged, ok := b.delegate.(PagedDelegateActor); ok {
+		return paged.GetPagedOutbox(c, r)
+	}
+	oc, err := b.delegate.GetOutbox(c, r)
+	if err != nil {
+		return nil, err
+	}
+	return oc, nil
+}
+
 // deliver delegates all outbox handling steps and optionally will federate the
 // activity if the federated protocol is enabled.
 //
@@ -464,7 +596,54 @@ func (b *baseActor) deliver(c context.Context, outbox *url.URL, asValue vocab.Ty
 	return
 }
 
+// PostSharedInbox implements the generic algorithm for handling a POST request
+// to the server's shared inbox independent on an application. It relies on a
+// delegate to determine the local inboxes the activity is for.
+func (b *baseActorFederating) PostSharedInbox(c context.Context, w http.ResponseWriter, r *http.Request) (bool, error) {
+	// Do nothing if it is not an ActivityPub POST request.
+	if !isActivityPubPost(r) {
+		return false, nil
+	}
+	shared, ok := b.delegate.(SharedInboxDelegateActor)
+	if !ok {
+		return true, fmt.Errorf("shared inbox requires the DelegateActor to be a SharedInboxDelegateActor")
+	}
+	return b.postInbox(c, w, r, shared)
+}
+
 // Send is programmatically accessible if the federated protocol is enabled.
 func (b *baseActorFederating) Send(c context.Context, outbox *url.URL, t vocab.Type) (Activity, error) {
 	return b.deliver(c, outbox, t, nil)
 }
+
+// AcceptFollow sends an Accept of the pending Follow from the outbox.
+func (b *baseActorFederating) AcceptFollow(c context.Context, outbox, followId *url.URL) (Activity, error) {
+	return b.respondToFollow(c, outbox, followId, true)
+}
+
+// RejectFollow sends a Reject of the pending Follow from the outbox.
+func (b *baseActorFederating) RejectFollow(c context.Context, outbox, followId *url.URL) (Activity, error) {
+	return b.respondToFollow(c, outbox, followId, false)
+}
+
+// respondToFollow has the delegate build the response to the pending Follow,
+// sends it like any other activity, and only then has the delegate resolve the
+// Follow. The Follow stays pending if any of these steps fail.
+func (b *baseActorFederating) respondToFollow(c context.Context, outbox, followId *url.URL, accept bool) (Activity, error) {
+	approval, ok := b.delegate.(FollowApprovalDelegateActor)
+	if !ok {
+		return nil, fmt.Errorf("answering a pending Follow requires the DelegateActor to be a FollowApprovalDelegateActor")
+	}
+	response, err := approval.RespondToPendingFollow(c, outbox, followId, accept)
+	if err != nil {
+		return nil, err
+	}
+	activity, err := b.deliver(c, outbox, response, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err = approval.ResolvePendingFollow(c, outbox, followId, accept); err != nil {
+		return nil, err
+	}
+	return activity, nil
+}
@@ -2,6 +2,8 @@ package pub
 
 import (
 	"context"
+	"encoding/json"
+	"github.com/go-fed/activity/streams"
 	"github.com/go-fed/activity/streams/vocab"
 	"github.com/golang/mock/gomock"
 	"io/ioutil"
@@ -361,6 +363,26 @@ func TestBaseActorFederatingProtocol(t *testing.T) {
 			clock)
 		return
 	}
+	setupSharedFn := func(ctl *gomock.Controller) (delegate *MockDelegateActor, shared *MockSharedInboxDelegateActor, a Actor) {
+		delegate = NewMockDelegateActor(ctl)
+		shared = NewMockSharedInboxDelegateActor(ctl)
+		a = NewCustomActor(
+			sharedInboxDelegateActor{delegate, shared},
+			/*enableSocialProtocol=*/ false,
+			/*enableFederatedProtocol=*/ true,
+			NewMockClock(ctl))
+		return
+	}
+	setupApprovalFn := func(ctl *gomock.Controller) (delegate *MockDelegateActor, approval *MockFollowApprovalDelegateActor, a Actor) {
+		delegate = NewMockDelegateActor(ctl)
+		approval = NewMockFollowApprovalDelegateActor(ctl)
+		a = NewCustomActor(
+			followApprovalDelegateActor{delegate, approval},
+			/*enableSocialProtocol=*/ false,
+			/*enableFederatedProtocol=*/ true,
+			NewMockClock(ctl))
+		return
+	}
 	// Run tests
 	t.Run("PostInboxIgnoresNonActivityPubRequest", func(t *testing.T) {
 		// Setup
@@ -499,6 +521,140 @@ func TestBaseActorFederatingProtocol(t *testing.T) {
 		assertEqual(t, handled, true)
 		assertEqual(t, resp.Code, http.StatusBadRequest)
 	})
+	t.Run("PostSharedInboxPostsToRecipientInboxesOnce", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		delegate, shared, a := setupSharedFn(ctl)
+		resp := httptest.NewRecorder()
+		req := toAPRequest(toPostInboxRequest(testCreate))
+		inboxes := []*url.URL{mustParse(testMyInboxIRI), mustParse(testFederatedInboxIRI)}
+		delegate.EXPECT().AuthenticatePostInbox(ctx, resp, req).Return(ctx, true, nil)
+		delegate.EXPECT().PostInboxRequestBodyHook(ctx, req, toDeserializedForm(testCreate)).Return(ctx, nil)
+		delegate.EXPECT().AuthorizePostInbox(ctx, resp, toDeserializedForm(testCreate)).Return(true, nil)
+		shared.EXPECT().SharedInboxRecipients(ctx, toDeserializedForm(testCreate)).Return(inboxes, nil)
+		shared.EXPECT().PostSharedInbox(ctx, inboxes, toDeserializedForm(testCreate)).Return(inboxes, nil)
+		delegate.EXPECT().InboxForwarding(ctx, mustParse(testMyInboxIRI), toDeserializedForm(testCreate)).Return(nil)
+		// Run the test
+		handled, err := a.(SharedInboxActor).PostSharedInbox(ctx, resp, req)
+		// Verify results
+		assertEqual(t, err, nil)
+		assertEqual(t, handled, true)
+		assertEqual(t, resp.Code, http.StatusOK)
+	})
+	t.Run("PostSharedInboxForwardsFromPostedInbox", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		delegate, shared, a := setupSharedFn(ctl)
+		resp := httptest.NewRecorder()
+		req := toAPRequest(toPostInboxRequest(testCreate))
+		inboxes := []*url.URL{mustParse(testMyInboxIRI), mustParse(testFederatedInboxIRI)}
+		delegate.EXPECT().AuthenticatePostInbox(ctx, resp, req).Return(ctx, true, nil)
+		delegate.EXPECT().PostInboxRequestBodyHook(ctx, req, toDeserializedForm(testCreate)).Return(ctx, nil)
+		delegate.EXPECT().AuthorizePostInbox(ctx, resp, toDeserializedForm(testCreate)).Return(true, nil)
+		shared.EXPECT().SharedInboxRecipients(ctx, toDeserializedForm(testCreate)).Return(inboxes, nil)
+		shared.EXPECT().PostSharedInbox(ctx, inboxes, toDeserializedForm(testCreate)).Return(inboxes[1:], nil)
+		delegate.EXPECT().InboxForwarding(ctx, mustParse(testFederatedInboxIRI), toDeserializedForm(testCreate)).Return(nil)
+		// Run the test
+		handled, err := a.(SharedInboxActor).PostSharedInbox(ctx, resp, req)
+		// Verify results
+		assertEqual(t, err, nil)
+		assertEqual(t, handled, true)
+		assertEqual(t, resp.Code, http.StatusOK)
+	})
+	t.Run("PostSharedInboxDoesNotForwardIfNotPosted", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		delegate, shared, a := setupSharedFn(ctl)
+		resp := httptest.NewRecorder()
+		req := toAPRequest(toPostInboxRequest(testCreate))
+		inboxes := []*url.URL{mustParse(testMyInboxIRI)}
+		delegate.EXPECT().AuthenticatePostInbox(ctx, resp, req).Return(ctx, true, nil)
+		delegate.EXPECT().PostInboxRequestBodyHook(ctx, req, toDeserializedForm(testCreate)).Return(ctx, nil)
+		delegate.EXPECT().AuthorizePostInbox(ctx, resp, toDeserializedForm(testCreate)).Return(true, nil)
+		shared.EXPECT().SharedInboxRecipients(ctx, toDeserializedForm(testCreate)).Return(inboxes, nil)
+		shared.EXPECT().PostSharedInbox(ctx, inboxes, toDeserializedForm(testCreate)).Return(nil, nil)
+		// Run the test
+		handled, err := a.(SharedInboxActor).PostSharedInbox(ctx, resp, req)
+		// Verify results
+		assertEqual(t, err, nil)
+		assertEqual(t, handled, true)
+		assertEqual(t, resp.Code, http.StatusOK)
+	})
+	t.Run("PostSharedInboxBadRequestForErrObjectRequired", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		delegate, shared, a := setupSharedFn(ctl)
+		resp := httptest.NewRecorder()
+		req := toAPRequest(toPostInboxRequest(testCreate))
+		inboxes := []*url.URL{mustParse(testMyInboxIRI), mustParse(testFederatedInboxIRI)}
+		delegate.EXPECT().AuthenticatePostInbox(ctx, resp, req).Return(ctx, true, nil)
+		delegate.EXPECT().PostInboxRequestBodyHook(ctx, req, toDeserializedForm(testCreate)).Return(ctx, nil)
+		delegate.EXPECT().AuthorizePostInbox(ctx, resp, toDeserializedForm(testCreate)).Return(true, nil)
+		shared.EXPECT().SharedInboxRecipients(ctx, toDeserializedForm(testCreate)).Return(inboxes, nil)
+		shared.EXPECT().PostSharedInbox(ctx, inboxes, toDeserializedForm(testCreate)).Return(nil, ErrObjectRequired)
+		// Run the test
+		handled, err := a.(SharedInboxActor).PostSharedInbox(ctx, resp, req)
+		// Verify results
+		assertEqual(t, err, nil)
+		assertEqual(t, handled, true)
+		assertEqual(t, resp.Code, http.StatusBadRequest)
+	})
+	t.Run("PostSharedInboxReturnsErrorWithoutForwarding", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		delegate, shared, a := setupSharedFn(ctl)
+		resp := httptest.NewRecorder()
+		req := toAPRequest(toPostInboxRequest(testCreate))
+		inboxes := []*url.URL{mustParse(testMyInboxIRI), mustParse(testFederatedInboxIRI)}
+		delegate.EXPECT().AuthenticatePostInbox(ctx, resp, req).Return(ctx, true, nil)
+		delegate.EXPECT().PostInboxRequestBodyHook(ctx, req, toDeserializedForm(testCreate)).Return(ctx, nil)
+		delegate.EXPECT().AuthorizePostInbox(ctx, resp, toDeserializedForm(testCreate)).Return(true, nil)
+		shared.EXPECT().SharedInboxRecipients(ctx, toDeserializedForm(testCreate)).Return(inboxes, nil)
+		shared.EXPECT().PostSharedInbox(ctx, inboxes, toDeserializedForm(testCreate)).Return(nil, testErr)
+		// Run the test
+		handled, err := a.(SharedInboxActor).PostSharedInbox(ctx, resp, req)
+		// Verify results
+		assertEqual(t, err, testErr)
+		assertEqual(t, handled, true)
+	})
+	t.Run("PostSharedInboxRespondsWithStatusIfNoRecipients", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		delegate, shared, a := setupSharedFn(ctl)
+		resp := httptest.NewRecorder()
+		req := toAPRequest(toPostInboxRequest(testCreate))
+		delegate.EXPECT().AuthenticatePostInbox(ctx, resp, req).Return(ctx, true, nil)
+		delegate.EXPECT().PostInboxRequestBodyHook(ctx, req, toDeserializedForm(testCreate)).Return(ctx, nil)
+		delegate.EXPECT().AuthorizePostInbox(ctx, resp, toDeserializedForm(testCreate)).Return(true, nil)
+		shared.EXPECT().SharedInboxRecipients(ctx, toDeserializedForm(testCreate)).Return(nil, nil)
+		// Run the test
+		handled, err := a.(SharedInboxActor).PostSharedInbox(ctx, resp, req)
+		// Verify results
+		assertEqual(t, err, nil)
+		assertEqual(t, handled, true)
+		assertEqual(t, resp.Code, http.StatusOK)
+	})
+	t.Run("PostSharedInboxErrorsIfDelegateLacksSharedInbox", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		_, _, a := setupFn(ctl)
+		resp := httptest.NewRecorder()
+		req := toAPRequest(toPostInboxRequest(testCreate))
+		// Run the test
+		handled, err := a.(SharedInboxActor).PostSharedInbox(ctx, resp, req)
+		// Verify results
+		if err == nil {
+			t.Fatalf("expected error, got none")
+		}
+		assertEqual(t, handled, true)
+	})
 	t.Run("GetInboxIgnoresNonActivityPubRequest", func(t *testing.T) {
 		// Setup
 		ctl := gomock.NewController(t)
@@ -574,6 +730,37 @@ func TestBaseActorFederatingProtocol(t *testing.T) {
 		assertEqual(t, err, nil)
 		assertByteEqual(t, b, []byte(testOrderedCollectionDedupedElemsString))
 	})
+	t.Run("GetInboxServesPagedInbox", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		delegate, clock, _ := setupFn(ctl)
+		paged := NewMockPagedDelegateActor(ctl)
+		a := NewCustomActor(
+			pagedDelegateActor{delegate, paged},
+			/*enableSocialProtocol=*/ false,
+			/*enableFederatedProtocol=*/ true,
+			clock)
+		resp := httptest.NewRecorder()
+		req := toAPRequest(toGetInboxRequest())
+		delegate.EXPECT().AuthenticateGetInbox(ctx, resp, req).Return(ctx, true, nil)
+		paged.EXPECT().GetPagedInbox(ctx, req).Return(NewBoxCollection(mustParse(testMyInboxIRI), 3), nil)
+		clock.EXPECT().Now().Return(now())
+		// Run the test
+		handled, err := a.GetInbox(ctx, resp, req)
+		// Verify results
+		assertEqual(t, err, nil)
+		assertEqual(t, handled, true)
+		assertEqual(t, resp.Code, http.StatusOK)
+		b, err := ioutil.ReadAll(resp.Result().Body)
+		assertEqual(t, err, nil)
+		var m map[string]interface{}
+		err = json.Unmarshal(b, &m)
+		assertEqual(t, err, nil)
+		assertEqual(t, m["type"], "OrderedCollection")
+		assertEqual(t, m["totalItems"], float64(3))
+		assertEqual(t, m["first"], testMyInboxIRI+"?page=true")
+	})
 	t.Run("PostOutboxIgnoresNonActivityPubRequest", func(t *testing.T) {
 		// Setup
 		ctl := gomock.NewController(t)
@@ -658,6 +845,58 @@ func TestBaseActorFederatingProtocol(t *testing.T) {
 		assertEqual(t, err, nil)
 		assertByteEqual(t, b, []byte(testOrderedCollectionUniqueElemsString))
 	})
+	t.Run("AcceptFollowResolvesAfterDelivery", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		delegate, approval, a := setupApprovalFn(ctl)
+		outboxIRI := mustParse(testMyOutboxIRI)
+		followId := mustParse(testFederatedActivityIRI)
+		accept := streams.NewActivityStreamsAccept()
+		gomock.InOrder(
+			approval.EXPECT().RespondToPendingFollow(ctx, outboxIRI, followId, true).Return(accept, nil),
+			delegate.EXPECT().AddNewIDs(ctx, accept),
+			delegate.EXPECT().PostOutbox(ctx, accept, outboxIRI, gomock.Any()).Return(true, nil),
+			delegate.EXPECT().Deliver(ctx, outboxIRI, accept),
+			approval.EXPECT().ResolvePendingFollow(ctx, outboxIRI, followId, true),
+		)
+		// Run the test
+		activity, err := a.(FollowApprovalActor).AcceptFollow(ctx, outboxIRI, followId)
+		// Verify results
+		assertEqual(t, err, nil)
+		assertEqual(t, activity, Activity(accept))
+	})
+	t.Run("AcceptFollowKeepsPendingIfDeliveryFails", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		delegate, approval, a := setupApprovalFn(ctl)
+		outboxIRI := mustParse(testMyOutboxIRI)
+		followId := mustParse(testFederatedActivityIRI)
+		accept := streams.NewActivityStreamsAccept()
+		gomock.InOrder(
+			approval.EXPECT().RespondToPendingFollow(ctx, outboxIRI, followId, true).Return(accept, nil),
+			delegate.EXPECT().AddNewIDs(ctx, accept),
+			delegate.EXPECT().PostOutbox(ctx, accept, outboxIRI, gomock.Any()).Return(true, nil),
+			delegate.EXPECT().Deliver(ctx, outboxIRI, accept).Return(testErr),
+		)
+		// Run the test
+		_, err := a.(FollowApprovalActor).AcceptFollow(ctx, outboxIRI, followId)
+		// Verify results
+		assertEqual(t, err, testErr)
+	})
+	t.Run("AcceptFollowErrorsIfDelegateLacksFollowApproval", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		_, _, a := setupFn(ctl)
+		// Run the test
+		_, err := a.(FollowApprovalActor).AcceptFollow(ctx, mustParse(testMyOutboxIRI), mustParse(testFederatedActivityIRI))
+		// Verify results
+		if err == nil {
+			t.Fatalf("expected error, got none")
+		}
+	})
 }
 
 // TestBaseActor tests the Actor returned with NewCustomActor and having both
@@ -754,3 +993,126 @@ func TestBaseActor(t *testing.T) {
 		assertEqual(t, respV.Header.Get(locationHeader), testNewActivityIRI)
 	})
 }
+
+// TestBaseActorRequestLimits tests the RequestLimits enforced on the POST
+// requests to inboxes and outboxes.
+func TestBaseActorRequestLimits(t *testing.T) {
+	// Set up test case
+	setupData()
+	ctx := context.Background()
+	setupFn := func(ctl *gomock.Controller, l RequestLimits) (delegate *MockDelegateActor, a Actor) {
+		delegate = NewMockDelegateActor(ctl)
+		a = &baseActor{
+			delegate:                delegate,
+			enableSocialProtocol:    true,
+			enableFederatedProtocol: true,
+			clock:                   NewMockClock(ctl),
+			limits:                  l,
+		}
+		return
+	}
+	// Run tests
+	t.Run("PostInboxEntityTooLargeBeforeAuthenticatingIfContentLengthExceedsMaxBodyBytes", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		_, a := setupFn(ctl, RequestLimits{MaxBodyBytes: 16})
+		resp := httptest.NewRecorder()
+		req := toAPRequest(toPostInboxRequest(testCreate))
+		// Run the test
+		handled, err := a.PostInbox(ctx, resp, req)
+		// Verify results
+		assertEqual(t, err, nil)
+		assertEqual(t, handled, true)
+		assertEqual(t, resp.Code, http.StatusRequestEntityTooLarge)
+	})
+	t.Run("PostInboxEntityTooLargeIfAuthenticatingReadsPastMaxBodyBytes", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		delegate, a := setupFn(ctl, RequestLimits{MaxBodyBytes: 16})
+		resp := httptest.NewRecorder()
+		req := toAPRequest(toPostInboxRequest(testCreate))
+		req.ContentLength = -1
+		var read int
+		delegate.EXPECT().AuthenticatePostInbox(ctx, resp, req).DoAndReturn(func(ctx context.Context, resp http.ResponseWriter, req *http.Request) (context.Context, bool, error) {
+			b, err := ioutil.ReadAll(req.Body)
+			read = len(b)
+			return ctx, false, err
+		})
+		// Run the test
+		handled, err := a.PostInbox(ctx, resp, req)
+		// Verify results
+		assertEqual(t, err, nil)
+		assertEqual(t, handled, true)
+		assertEqual(t, read, 16)
+		assertEqual(t, resp.Code, http.StatusRequestEntityTooLarge)
+	})
+	t.Run("PostInboxEntityTooLargeIfBodyExceedsMaxBodyBytes", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		delegate, a := setupFn(ctl, RequestLimits{MaxBodyBytes: 16})
+		resp := httptest.NewRecorder()
+		req := toAPRequest(toPostInboxRequest(testCreate))
+		req.ContentLength = -1
+		delegate.EXPECT().AuthenticatePostInbox(ctx, resp, req).Return(ctx, true, nil)
+		// Run the test
+		handled, err := a.PostInbox(ctx, resp, req)
+		// Verify results
+		assertEqual(t, err, nil)
+		assertEqual(t, handled, true)
+		assertEqual(t, resp.Code, http.StatusRequestEntityTooLarge)
+	})
+	t.Run("PostInboxBadRequestIfBodyExceedsMaxDepth", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		delegate, a := setupFn(ctl, RequestLimits{MaxDepth: 1})
+		resp := httptest.NewRecorder()
+		req := toAPRequest(toPostInboxRequest(testCreate))
+		delegate.EXPECT().AuthenticatePostInbox(ctx, resp, req).Return(ctx, true, nil)
+		// Run the test
+		handled, err := a.PostInbox(ctx, resp, req)
+		// Verify results
+		assertEqual(t, err, nil)
+		assertEqual(t, handled, true)
+		assertEqual(t, resp.Code, http.StatusBadRequest)
+	})
+	t.Run("PostOutboxEntityTooLargeIfBodyExceedsMaxBodyBytes", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		delegate, a := setupFn(ctl, RequestLimits{MaxBodyBytes: 16})
+		resp := httptest.NewRecorder()
+		req := toAPRequest(toPostOutboxRequest(testCreate))
+		req.ContentLength = -1
+		delegate.EXPECT().AuthenticatePostOutbox(ctx, resp, req).Return(ctx, true, nil)
+		// Run the test
+		handled, err := a.PostOutbox(ctx, resp, req)
+		// Verify results
+		assertEqual(t, err, nil)
+		assertEqual(t, handled, true)
+		assertEqual(t, resp.Code, http.StatusRequestEntityTooLarge)
+	})
+	t.Run("PostOutboxBadRequestIfBodyExceedsMaxItems", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		delegate, a := setupFn(ctl, RequestLimits{MaxItems: 1})
+		resp := httptest.NewRecorder()
+		create := streams.NewActivityStreamsCreate()
+		to := streams.NewActivityStreamsToProperty()
+		to.AppendIRI(mustParse(testFederatedActorIRI))
+		to.AppendIRI(mustParse(testFederatedActorIRI2))
+		create.SetActivityStreamsTo(to)
+		req := toAPRequest(toPostOutboxRequest(create))
+		delegate.EXPECT().AuthenticatePostOutbox(ctx, resp, req).Return(ctx, true, nil)
+		// Run the test
+		handled, err := a.PostOutbox(ctx, resp, req)
+		// Verify results
+		assertEqual(t, err, nil)
+		assertEqual(t, handled, true)
+		assertEqual(t, resp.Code, http.StatusBadRequest)
+	})
+}
@@ -0,0 +1,200 @@
+package pub
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// BlockList records which peers the actors on this server have blocked.
+//
+// Blocks are scoped to one of this server's actors. A nil actor IRI scopes a
+// block to the whole server, so that it applies to every actor on it.
+//
+// An actor-level block blocks a single peer actor. A domain-level block blocks
+// every actor whose id is on the domain or one of its subdomains.
+type BlockList interface {
+	// Block records that the actor blocks the peer actor.
+	Block(c context.Context, actorIRI, blockedIRI *url.URL) error
+	// Unblock lifts the actor's block of the peer actor, if any.
+	Unblock(c context.Context, actorIRI, blockedIRI *url.URL) error
+	// BlockDomain records that the actor blocks every peer actor on the
+	// domain.
+	BlockDomain(c context.Context, actorIRI *url.URL, domain string) error
+	// UnblockDomain lifts the actor's block of the domain, if any.
+	UnblockDomain(c context.Context, actorIRI *url.URL, domain string) error
+	// Blocks lists the peer actors and domains blocked by the actor. It
+	// does not include the blocks scoped to the whole server.
+	Blocks(c context.Context, actorIRI *url.URL) (actors []*url.URL, domains []string, err error)
+	// Blocked determines whether any of the peer actors are blocked by the
+	// actor, or by the whole server.
+	Blocked(c context.Context, actorIRI *url.URL, iris []*url.URL) (blocked bool, err error)
+}
+
+// BlockList must be implemented by MemoryBlockList.
+var _ BlockList = &MemoryBlockList{}
+
+// MemoryBlockList is a BlockList that keeps blocks in memory.
+//
+// Blocks do not survive restarts of the application. It is safe for
+// concurrent use.
+type MemoryBlockList struct {
+	mu     sync.RWMutex
+	scopes map[string]*blockScope
+}
+
+// blockScope holds the blocks of one actor, or of the whole server.
+type blockScope struct {
+	actors  map[string]*url.URL
+	domains map[string]bool
+}
+
+// NewMemoryBlockList returns an empty MemoryBlockList.
+func NewMemoryBlockList() *MemoryBlockList {
+	return &MemoryBlockList{
+		scopes: make(map[string]*blockScope),
+	}
+}
+
+// Block records that the actor blocks the peer actor.
+func (m *MemoryBlockList) Block(c context.Context, actorIRI, blockedIRI *url.URL) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.scope(actorIRI).actors[blockedIRI.String()] = blockedIRI
+	return nil
+}
+
+// Unblock lifts the actor's block of the peer actor.
+func (m *MemoryBlockList) Unblock(c context.Context, actorIRI, blockedIRI *url.URL) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s, ok := m.scopes[scopeKey(actorIRI)]; ok {
+		delete(s.actors, blockedIRI.String())
+	}
+	return nil
+}
+
+// BlockDomain records that the actor blocks every peer actor on the domain.
+func (m *MemoryBlockList) BlockDomain(c context.Context, actorIRI *url.URL, domain string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.scope(actorIRI).domains[strings.ToLower(domain)] = true
+	return nil
+}
+
+// UnblockDomain lifts the actor's block of the domain.
+func (m *MemoryBlockList) UnblockDomain(c context.Context, actorIRI *url.URL, domain string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s, ok := m.scopes[scopeKey(actorIRI)]; ok {
+		delete(s.domains, strings.ToLower(domain))
+	}
+	return nil
+}
+
+// Blocks lists the peer actors and domains blocked by the actor, sorted.
+func (m *MemoryBlockList) Blocks(c context.Context, actorIRI *url.URL) (actors []*url.URL, domains []string, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	s, ok := m.scopes[scopeKey(actorIRI)]
+	if !ok {
+		return
+	}
+	for _, iri := range s.actors {
+		actors = append(actors, iri)
+	}
+	sort.Slice(actors, func(i, j int) bool {
+		return actors[i].String() < actors[j].String()
+	})
+	for domain := range s.domains {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	return
+}
+
+// Blocked determines whether any of the peer actors are blocked by the actor,
+// or by the whole server.
+func (m *MemoryBlockList) Blocked(c context.Context, actorIRI *url.URL, iris []*url.URL) (blocked bool, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	scopes := []*blockScope{m.scopes[scopeKey(nil)]}
+	if actorIRI != nil {
+		scopes = append(scopes, m.scopes[scopeKey(actorIRI)])
+	}
+	for _, s := range scopes {
+		if s == nil {
+			continue
+		}
+		for _, iri := range iris {
+			if _, ok := s.actors[iri.String()]; ok {
+				return true, nil
+			}
+			if s.blocksHost(iri.Hostname()) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// scope returns the blocks of the actor, creating them if necessary. The
+// write lock must be held.
+func (m *MemoryBlockList) scope(actorIRI *url.URL) *blockScope {
+	k := scopeKey(actorIRI)
+	s, ok := m.scopes[k]
+	if !ok {
+		s = &blockScope{
+			actors:  make(map[string]*url.URL),
+			domains: make(map[string]bool),
+		}
+		m.scopes[k] = s
+	}
+	return s
+}
+
+// blocksHost determines whether the host, or a domain it is a subdomain of,
+// is blocked.
+func (s *blockScope) blocksHost(host string) bool {
+	host = strings.ToLower(host)
+	for host != "" {
+		if s.domains[host] {
+			return true
+		}
+		i := strings.Index(host, ".")
+		if i < 0 {
+			break
+		}
+		host = host[i+1:]
+	}
+	return false
+}
+
+// scopeKey returns the key of the actor's blocks. The whole server's blocks
+// use the empty key.
+func scopeKey(actorIRI *url.URL) string {
+	if actorIRI == nil {
+		return ""
+	}
+	return actorIRI.String()
+}
+
+// filterBlocked removes the peers blocked by the actor, or by the whole
+// server, from the IRIs.
+func filterBlocked(c context.Context, blocks BlockList, actorIRI *url.URL, iris []*url.URL) ([]*url.URL, error) {
+	if blocks == nil {
+		return iris, nil
+	}
+	filtered := make([]*url.URL, 0, len(iris))
+	for _, iri := range iris {
+		blocked, err := blocks.Blocked(c, actorIRI, []*url.URL{iri})
+		if err != nil {
+			return nil, err
+		} else if !blocked {
+			filtered = append(filtered, iri)
+		}
+	}
+	return filtered, nil
+}
@@ -0,0 +1,64 @@
+package pub
+
+import (
+	"context"
+	"net/url"
+	"testing"
+)
+
+// TestMemoryBlockList tests blocking actors and domains per actor and for the
+// whole server.
+func TestMemoryBlockList(t *testing.T) {
+	ctx := context.Background()
+	myIRI := mustParse(testPersonIRI)
+	t.Run("BlocksActorsPerActor", func(t *testing.T) {
+		b := NewMemoryBlockList()
+		err := b.Block(ctx, myIRI, mustParse(testFederatedActorIRI))
+		assertEqual(t, err, nil)
+		blocked, err := b.Blocked(ctx, myIRI, []*url.URL{mustParse(testFederatedActorIRI2), mustParse(testFederatedActorIRI)})
+		assertEqual(t, err, nil)
+		assertEqual(t, blocked, true)
+		blocked, err = b.Blocked(ctx, myIRI, []*url.URL{mustParse(testFederatedActorIRI2)})
+		assertEqual(t, err, nil)
+		assertEqual(t, blocked, false)
+		blocked, err = b.Blocked(ctx, mustParse(testServiceIRI), []*url.URL{mustParse(testFederatedActorIRI)})
+		assertEqual(t, err, nil)
+		assertEqual(t, blocked, false)
+	})
+	t.Run("BlocksDomainsAndSubdomains", func(t *testing.T) {
+		b := NewMemoryBlockList()
+		err := b.BlockDomain(ctx, myIRI, "Example.com")
+		assertEqual(t, err, nil)
+		blocked, _ := b.Blocked(ctx, myIRI, []*url.URL{mustParse(testFederatedActorIRI)})
+		assertEqual(t, blocked, true)
+		blocked, _ = b.Blocked(ctx, myIRI, []*url.URL{mustParse("https://notexample.com/dakota")})
+		assertEqual(t, blocked, false)
+	})
+	t.Run("ServerBlocksApplyToEveryActor", func(t *testing.T) {
+		b := NewMemoryBlockList()
+		err := b.BlockDomain(ctx, nil, "other.example.com")
+		assertEqual(t, err, nil)
+		blocked, _ := b.Blocked(ctx, myIRI, []*url.URL{mustParse(testFederatedActorIRI)})
+		assertEqual(t, blocked, true)
+		blocked, _ = b.Blocked(ctx, nil, []*url.URL{mustParse(testFederatedActorIRI)})
+		assertEqual(t, blocked, true)
+	})
+	t.Run("UnblocksAndListsBlocks", func(t *testing.T) {
+		b := NewMemoryBlockList()
+		b.Block(ctx, myIRI, mustParse(testFederatedActorIRI2))
+		b.Block(ctx, myIRI, mustParse(testFederatedActorIRI))
+		b.BlockDomain(ctx, myIRI, "example.net")
+		err := b.Unblock(ctx, myIRI, mustParse(testFederatedActorIRI2))
+		assertEqual(t, err, nil)
+		actors, domains, err := b.Blocks(ctx, myIRI)
+		assertEqual(t, err, nil)
+		assertEqual(t, len(actors), 1)
+		assertEqual(t, actors[0].String(), testFederatedActorIRI)
+		assertEqual(t, len(domains), 1)
+		assertEqual(t, domains[0], "example.net")
+		err = b.UnblockDomain(ctx, myIRI, "example.net")
+		assertEqual(t, err, nil)
+		_, domains, _ = b.Blocks(ctx, myIRI)
+		assertEqual(t, len(domains), 0)
+	})
+}
@@ -0,0 +1,269 @@
+package pub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/go-fed/activity/streams"
+	"github.com/go-fed/activity/streams/vocab"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const (
+	// DefaultCollectionPageSize is the number of items in each page served
+	// by NewCollectionHandler if no page size is given.
+	DefaultCollectionPageSize = 20
+)
+
+// CollectionKind identifies which collection is served by the handler
+// returned by NewCollectionHandler.
+type CollectionKind int
+
+const (
+	// FollowersCollection is the 'followers' collection of an actor.
+	FollowersCollection CollectionKind = iota
+	// FollowingCollection is the 'following' collection of an actor.
+	FollowingCollection
+	// LikedCollection is the 'liked' collection of an actor.
+	LikedCollection
+	// LikesCollection is the 'likes' collection of an object.
+	LikesCollection
+	// SharesCollection is the 'shares' collection of an object.
+	SharesCollection
+)
+
+// CollectionVisibility is how much of a collection a request may see.
+type CollectionVisibility int
+
+const (
+	// CollectionVisible exposes the items of the collection and their
+	// number.
+	CollectionVisible CollectionVisibility = iota
+	// CollectionCountOnly exposes only the number of items, as
+	// 'totalItems', hiding who is a member. Requests for its pages are
+	// forbidden.
+	CollectionCountOnly
+	// CollectionHidden forbids requests for the collection.
+	CollectionHidden
+)
+
+// CollectionOwnerFunc returns the owner of the collection at the IRI: the
+// actor whose followers, following, or liked collection it is, or the object
+// whose likes or shares collection it is.
+type CollectionOwnerFunc func(c context.Context, collectionIRI *url.URL) (owner *url.URL, err error)
+
+// CollectionAuthorizeFunc determines how much of the owner's collection the
+// request may see. It may authenticate the request, such as by verifying its
+// HTTP Signature, to allow the owner to see its own collections.
+type CollectionAuthorizeFunc func(c context.Context, r *http.Request, kind CollectionKind, owner *url.URL) (v CollectionVisibility, err error)
+
+// NewCollectionHandler creates a HandlerFunc serving a followers, following,
+// liked, likes, or shares collection as an OrderedCollection with
+// 'totalItems', whose items are served in OrderedCollectionPages of pageSize
+// items. The pages are requested with the query parameters of
+// CollectionCursor, and their cursors are offsets into the collection.
+//
+// The followers, following, and liked collections are obtained from the
+// Database's Followers, Following, and Liked of the owner. The likes and
+// shares collections are the ones embedded in the owner object by the
+// Database's Get.
+//
+// If authorize is nil, every collection is visible. Otherwise it is called for
+// every request, which is answered with 403 Forbidden if it may not see what
+// it requested.
+//
+// Like NewActivityStreamsHandler, it does nothing if the request is not an
+// ActivityStreams GET request.
+func NewCollectionHandler(db Database, clock Clock, kind CollectionKind, owner CollectionOwnerFunc, authorize CollectionAuthorizeFunc, pageSize int) HandlerFunc {
+	if pageSize <= 0 {
+		pageSize = DefaultCollectionPageSize
+	}
+	return func(c context.Context, w http.ResponseWriter, r *http.Request) (isASRequest bool, err error) {
+		// Do nothing if it is not an ActivityPub GET request
+		if !isActivityPubGet(r) {
+			return
+		}
+		isASRequest = true
+		cursor := ParseCollectionCursor(r.URL)
+		collectionIRI := CollectionCursor{}.URL(requestId(r))
+		ownerIRI, err := owner(c, collectionIRI)
+		if err != nil {
+			return
+		}
+		visibility := CollectionVisible
+		if authorize != nil {
+			visibility, err = authorize(c, r, kind, ownerIRI)
+			if err != nil {
+				return
+			}
+		}
+		if visibility == CollectionHidden ||
+			(visibility == CollectionCountOnly && cursor.Page) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		// Lock and obtain a copy of the collection's items.
+		err = db.Lock(c, ownerIRI)
+		if err != nil {
+			return
+		}
+		// WARNING: Unlock not deferred
+		items, err := getCollectionItems(c, db, kind, ownerIRI)
+		db.Unlock(c, ownerIRI)
+		// Unlock must have been called by this point and in every
+		// branch above
+		if err != nil {
+			return
+		}
+		var t vocab.Type
+		if !cursor.Page && visibility == CollectionCountOnly {
+			t = newCountOnlyCollection(collectionIRI, len(items))
+		} else if !cursor.Page {
+			t = NewBoxCollection(collectionIRI, len(items))
+		} else {
+			t = NewBoxPage(collectionIRI, cursor, pageOfItems(items, cursor, pageSize))
+		}
+		// Serialize the collection.
+		m, err := streams.Serialize(t)
+		if err != nil {
+			return
+		}
+		raw, err := json.Marshal(m)
+		if err != nil {
+			return
+		}
+		// Construct the response.
+		addResponseHeaders(w.Header(), clock, raw)
+		// Write the response.
+		w.WriteHeader(http.StatusOK)
+		n, err := w.Write(raw)
+		if err != nil {
+			return
+		} else if n != len(raw) {
+			err = fmt.Errorf("only wrote %d of %d bytes", n, len(raw))
+			return
+		}
+		return
+	}
+}
+
+// getCollectionItems obtains the ids of the items in the owner's collection.
+//
+// The owner must be locked by the caller.
+func getCollectionItems(c context.Context, db Database, kind CollectionKind, ownerIRI *url.URL) ([]*url.URL, error) {
+	var col vocab.Type
+	switch kind {
+	case FollowersCollection:
+		followers, err := db.Followers(c, ownerIRI)
+		if err != nil {
+			return nil, err
+		}
+		col = followers
+	case FollowingCollection:
+		following, err := db.Following(c, ownerIRI)
+		if err != nil {
+			return nil, err
+		}
+		col = following
+	case LikedCollection:
+		liked, err := db.Liked(c, ownerIRI)
+		if err != nil {
+			return nil, err
+		}
+		col = liked
+	case LikesCollection, SharesCollection:
+		t, err := db.Get(c, ownerIRI)
+		if err != nil {
+			return nil, err
+		}
+		if l, ok := t.(likeser); ok && kind == LikesCollection {
+			if likes := l.GetActivityStreamsLikes(); likes != nil {
+				col = likes.GetType()
+			}
+		} else if s, ok := t.(shareser); ok && kind == SharesCollection {
+			if shares := s.GetActivityStreamsShares(); shares != nil {
+				col = shares.GetType()
+			}
+		}
+		if col == nil {
+			// The object has not been liked or shared yet.
+			return nil, nil
+		}
+	default:
+		return nil, fmt.Errorf("unknown collection kind: %d", kind)
+	}
+	var ids []*url.URL
+	if i, ok := col.(itemser); ok {
+		if items := i.GetActivityStreamsItems(); items != nil {
+			for iter := items.Begin(); iter != items.End(); iter = iter.Next() {
+				id, err := ToId(iter)
+				if err != nil {
+					return nil, err
+				}
+				ids = append(ids, id)
+			}
+		}
+	} else if oi, ok := col.(orderedItemser); ok {
+		if oItems := oi.GetActivityStreamsOrderedItems(); oItems != nil {
+			for iter := oItems.Begin(); iter != oItems.End(); iter = iter.Next() {
+				id, err := ToId(iter)
+				if err != nil {
+					return nil, err
+				}
+				ids = append(ids, id)
+			}
+		}
+	} else {
+		return nil, fmt.Errorf("collection is neither a Collection nor an OrderedCollection: %T", col)
+	}
+	return ids, nil
+}
+
+// pageOfItems returns the page of the items identified by the cursor, whose
+// MaxID and MinID are offsets into the items: MaxID is the offset of the first
+// item of the page, and MinID is the offset of the first item after it.
+func pageOfItems(items []*url.URL, cursor CollectionCursor, pageSize int) BoxPage {
+	start := 0
+	if cursor.Last {
+		start = ((len(items) - 1) / pageSize) * pageSize
+	} else if maxId, err := strconv.Atoi(cursor.MaxID); err == nil && maxId > 0 {
+		start = maxId
+	} else if minId, err := strconv.Atoi(cursor.MinID); err == nil && minId > 0 {
+		start = minId - pageSize
+	}
+	if start < 0 {
+		start = 0
+	} else if start > len(items) {
+		start = len(items)
+	}
+	end := start + pageSize
+	if end > len(items) {
+		end = len(items)
+	}
+	page := BoxPage{
+		Items:      items[start:end],
+		TotalItems: len(items),
+	}
+	if end < len(items) {
+		page.Next = strconv.Itoa(end)
+	}
+	if start > 0 {
+		page.Prev = strconv.Itoa(start)
+	}
+	return page
+}
+
+// newCountOnlyCollection returns an OrderedCollection exposing only the
+// number of items in the collection.
+func newCountOnlyCollection(collectionIRI *url.URL, totalItems int) vocab.ActivityStreamsOrderedCollection {
+	oc := streams.NewActivityStreamsOrderedCollection()
+	id := streams.NewJSONLDIdProperty()
+	id.Set(collectionIRI)
+	oc.SetJSONLDId(id)
+	total := streams.NewActivityStreamsTotalItemsProperty()
+	total.Set(totalItems)
+	oc.SetActivityStreamsTotalItems(total)
+	return oc
+}
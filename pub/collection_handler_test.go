@@ -0,0 +1,168 @@
+package pub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/go-fed/activity/streams"
+	"github.com/go-fed/activity/streams/vocab"
+	"github.com/golang/mock/gomock"
+)
+
+// TestCollectionHandler tests the handler for serving paged collections.
+func TestCollectionHandler(t *testing.T) {
+	ctx := context.Background()
+	setupData()
+	const actorIRI = "https://example.com/addison"
+	const followersIRI = actorIRI + "/followers"
+	ownerFn := func(c context.Context, collectionIRI *url.URL) (*url.URL, error) {
+		return mustParse(actorIRI), nil
+	}
+	newFollowersFn := func(n int) *url.URL {
+		return mustParse(fmt.Sprintf("https://other.example.com/%d", n))
+	}
+	followersFn := func(n int) vocab.ActivityStreamsCollection {
+		col := streams.NewActivityStreamsCollection()
+		items := streams.NewActivityStreamsItemsProperty()
+		for i := 0; i < n; i++ {
+			items.AppendIRI(newFollowersFn(i))
+		}
+		col.SetActivityStreamsItems(items)
+		return col
+	}
+	setupFn := func(ctl *gomock.Controller, kind CollectionKind, authorize CollectionAuthorizeFunc) (db *MockDatabase, clock *MockClock, hf HandlerFunc) {
+		db = NewMockDatabase(ctl)
+		clock = NewMockClock(ctl)
+		hf = NewCollectionHandler(db, clock, kind, ownerFn, authorize, 2)
+		return
+	}
+	serveFn := func(t *testing.T, hf HandlerFunc, iri string) (map[string]interface{}, *httptest.ResponseRecorder) {
+		resp := httptest.NewRecorder()
+		req := toAPRequest(httptest.NewRequest("GET", iri, nil))
+		isAPReq, err := hf(ctx, resp, req)
+		assertEqual(t, isAPReq, true)
+		assertEqual(t, err, nil)
+		if resp.Code != http.StatusOK {
+			return nil, resp
+		}
+		b, err := ioutil.ReadAll(resp.Result().Body)
+		assertEqual(t, err, nil)
+		var m map[string]interface{}
+		assertEqual(t, json.Unmarshal(b, &m), nil)
+		return m, resp
+	}
+	t.Run("IgnoresIfNotActivityPubGetRequest", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		_, _, hf := setupFn(ctl, FollowersCollection, nil)
+		resp := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", followersIRI, nil)
+		// Run & Verify
+		isAPReq, err := hf(ctx, resp, req)
+		assertEqual(t, isAPReq, false)
+		assertEqual(t, err, nil)
+		assertEqual(t, len(resp.Result().Header), 0)
+	})
+	t.Run("ServesCollectionWithTotalItems", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		db, clock, hf := setupFn(ctl, FollowersCollection, nil)
+		// Mock
+		db.EXPECT().Lock(ctx, mustParse(actorIRI))
+		db.EXPECT().Followers(ctx, mustParse(actorIRI)).Return(followersFn(3), nil)
+		db.EXPECT().Unlock(ctx, mustParse(actorIRI))
+		clock.EXPECT().Now().Return(now())
+		// Run & Verify
+		m, _ := serveFn(t, hf, followersIRI)
+		assertEqual(t, m["type"], "OrderedCollection")
+		assertEqual(t, m["id"], followersIRI)
+		assertEqual(t, m["totalItems"], float64(3))
+		assertEqual(t, m["first"], followersIRI+"?page=true")
+		assertEqual(t, m["last"], followersIRI+"?page=last")
+	})
+	t.Run("ServesPagesWithOffsetCursors", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		db, clock, hf := setupFn(ctl, FollowersCollection, nil)
+		// Mock
+		db.EXPECT().Lock(ctx, mustParse(actorIRI)).Times(2)
+		db.EXPECT().Followers(ctx, mustParse(actorIRI)).Return(followersFn(3), nil).Times(2)
+		db.EXPECT().Unlock(ctx, mustParse(actorIRI)).Times(2)
+		clock.EXPECT().Now().Return(now()).Times(2)
+		// Run & Verify
+		m, _ := serveFn(t, hf, followersIRI+"?page=true")
+		assertEqual(t, m["type"], "OrderedCollectionPage")
+		assertEqual(t, m["totalItems"], float64(3))
+		assertEqual(t, len(m["orderedItems"].([]interface{})), 2)
+		assertEqual(t, m["next"], followersIRI+"?max_id=2&page=true")
+		_, hasPrev := m["prev"]
+		assertEqual(t, hasPrev, false)
+		m, _ = serveFn(t, hf, followersIRI+"?max_id=2&page=true")
+		assertEqual(t, m["orderedItems"], newFollowersFn(2).String())
+		assertEqual(t, m["prev"], followersIRI+"?min_id=2&page=true")
+		_, hasNext := m["next"]
+		assertEqual(t, hasNext, false)
+	})
+	t.Run("ServesOnlyCountIfMembershipHidden", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		authorizeFn := func(c context.Context, r *http.Request, kind CollectionKind, owner *url.URL) (CollectionVisibility, error) {
+			assertEqual(t, kind, FollowersCollection)
+			assertEqual(t, owner.String(), actorIRI)
+			return CollectionCountOnly, nil
+		}
+		db, clock, hf := setupFn(ctl, FollowersCollection, authorizeFn)
+		// Mock
+		db.EXPECT().Lock(ctx, mustParse(actorIRI))
+		db.EXPECT().Followers(ctx, mustParse(actorIRI)).Return(followersFn(3), nil)
+		db.EXPECT().Unlock(ctx, mustParse(actorIRI))
+		clock.EXPECT().Now().Return(now())
+		// Run & Verify
+		m, _ := serveFn(t, hf, followersIRI)
+		assertEqual(t, m["totalItems"], float64(3))
+		_, hasFirst := m["first"]
+		assertEqual(t, hasFirst, false)
+		_, resp := serveFn(t, hf, followersIRI+"?page=true")
+		assertEqual(t, resp.Code, http.StatusForbidden)
+	})
+	t.Run("ForbiddenIfHidden", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		authorizeFn := func(c context.Context, r *http.Request, kind CollectionKind, owner *url.URL) (CollectionVisibility, error) {
+			return CollectionHidden, nil
+		}
+		_, _, hf := setupFn(ctl, FollowingCollection, authorizeFn)
+		// Run & Verify
+		_, resp := serveFn(t, hf, actorIRI+"/following")
+		assertEqual(t, resp.Code, http.StatusForbidden)
+	})
+	t.Run("ServesLikesOfObject", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		db, clock, hf := setupFn(ctl, LikesCollection, nil)
+		note := streams.NewActivityStreamsNote()
+		likes := streams.NewActivityStreamsLikesProperty()
+		likes.SetActivityStreamsCollection(followersFn(1))
+		note.SetActivityStreamsLikes(likes)
+		// Mock
+		db.EXPECT().Lock(ctx, mustParse(actorIRI))
+		db.EXPECT().Get(ctx, mustParse(actorIRI)).Return(note, nil)
+		db.EXPECT().Unlock(ctx, mustParse(actorIRI))
+		clock.EXPECT().Now().Return(now())
+		// Run & Verify
+		m, _ := serveFn(t, hf, testNoteId1+"/likes?page=true")
+		assertEqual(t, m["orderedItems"], newFollowersFn(0).String())
+	})
+}
@@ -0,0 +1,176 @@
+package pub
+
+import (
+	"context"
+	"github.com/go-fed/activity/streams"
+	"github.com/go-fed/activity/streams/vocab"
+	"net/http"
+	"net/url"
+)
+
+const (
+	// pageQueryParameter requests a page of a collection instead of the
+	// collection itself.
+	pageQueryParameter = "page"
+	// maxIdQueryParameter requests the items older than a cursor.
+	maxIdQueryParameter = "max_id"
+	// minIdQueryParameter requests the items newer than a cursor.
+	minIdQueryParameter = "min_id"
+	// pageTrueValue is the value of the page query parameter for all pages
+	// but the last.
+	pageTrueValue = "true"
+	// pageLastValue is the value of the page query parameter requesting the
+	// page of the oldest items.
+	pageLastValue = "last"
+)
+
+// CollectionCursor identifies either an inbox or outbox OrderedCollection, or
+// one of its pages, as requested by the 'page', 'max_id', and 'min_id' query
+// parameters.
+//
+// The MaxID and MinID cursors are opaque to the library: they are created by
+// the Database in a BoxPage and handed back to it unmodified.
+type CollectionCursor struct {
+	// Page is whether a page was requested instead of the OrderedCollection
+	// itself. Without a cursor, it is the page of the newest items.
+	Page bool
+	// Last is whether the page of the oldest items was requested.
+	Last bool
+	// MaxID, if set, requests the newest items that are older than the
+	// cursor.
+	MaxID string
+	// MinID, if set, requests the oldest items that are newer than the
+	// cursor.
+	MinID string
+}
+
+// ParseCollectionCursor obtains the CollectionCursor from the query parameters
+// of the IRI.
+func ParseCollectionCursor(u *url.URL) CollectionCursor {
+	q := u.Query()
+	cur := CollectionCursor{
+		MaxID: q.Get(maxIdQueryParameter),
+		MinID: q.Get(minIdQueryParameter),
+	}
+	cur.Last = q.Get(pageQueryParameter) == pageLastValue
+	cur.Page = cur.Last ||
+		len(q.Get(pageQueryParameter)) > 0 ||
+		len(cur.MaxID) > 0 ||
+		len(cur.MinID) > 0
+	return cur
+}
+
+// URL returns the IRI of the page of the box that the cursor identifies, or
+// the box IRI itself if it does not identify a page.
+func (c CollectionCursor) URL(boxIRI *url.URL) *url.URL {
+	u := *boxIRI
+	if !c.Page {
+		u.RawQuery = ""
+		return &u
+	}
+	q := url.Values{}
+	if c.Last {
+		q.Set(pageQueryParameter, pageLastValue)
+	} else {
+		q.Set(pageQueryParameter, pageTrueValue)
+	}
+	if len(c.MaxID) > 0 {
+		q.Set(maxIdQueryParameter, c.MaxID)
+	}
+	if len(c.MinID) > 0 {
+		q.Set(minIdQueryParameter, c.MinID)
+	}
+	u.RawQuery = q.Encode()
+	return &u
+}
+
+// BoxPage is a page of an inbox or outbox, as returned by the Database.
+type BoxPage struct {
+	// Items are the ids of the activities in the page, newest first.
+	Items []*url.URL
+	// TotalItems is the number of activities in the whole box.
+	TotalItems int
+	// Next is the cursor of the page of older items, used as MaxID, or
+	// empty if there are no older items.
+	Next string
+	// Prev is the cursor of the page of newer items, used as MinID, or
+	// empty if there are no newer items.
+	Prev string
+}
+
+// BoxPageFunc obtains a page of the box, such as PagedDatabase's GetInboxPage
+// and GetOutboxPage.
+type BoxPageFunc func(c context.Context, boxIRI *url.URL, cursor CollectionCursor) (page BoxPage, err error)
+
+// BoxForRequest obtains the inbox or outbox OrderedCollection, or one of its
+// OrderedCollectionPages, requested by a GET request. It is meant to help
+// implement PagedFederatingProtocol's GetPagedInbox and PagedCommonBehavior's
+// GetPagedOutbox.
+//
+// The getPage function is called with the box IRI, without the query
+// parameters of the request. When the OrderedCollection itself is requested,
+// it is called for the first page to obtain the total number of items.
+//
+// The caller is responsible for taking any locks needed by getPage.
+func BoxForRequest(c context.Context, r *http.Request, getPage BoxPageFunc) (vocab.Type, error) {
+	cursor := ParseCollectionCursor(r.URL)
+	boxIRI := CollectionCursor{}.URL(requestId(r))
+	page, err := getPage(c, boxIRI, cursor)
+	if err != nil {
+		return nil, err
+	}
+	if !cursor.Page {
+		return NewBoxCollection(boxIRI, page.TotalItems), nil
+	}
+	return NewBoxPage(boxIRI, cursor, page), nil
+}
+
+// NewBoxCollection returns the OrderedCollection for an inbox or outbox, which
+// links to its first and last pages.
+func NewBoxCollection(boxIRI *url.URL, totalItems int) vocab.ActivityStreamsOrderedCollection {
+	oc := streams.NewActivityStreamsOrderedCollection()
+	id := streams.NewJSONLDIdProperty()
+	id.Set(boxIRI)
+	oc.SetJSONLDId(id)
+	total := streams.NewActivityStreamsTotalItemsProperty()
+	total.Set(totalItems)
+	oc.SetActivityStreamsTotalItems(total)
+	first := streams.NewActivityStreamsFirstProperty()
+	first.SetIRI(CollectionCursor{Page: true}.URL(boxIRI))
+	oc.SetActivityStreamsFirst(first)
+	last := streams.NewActivityStreamsLastProperty()
+	last.SetIRI(CollectionCursor{Page: true, Last: true}.URL(boxIRI))
+	oc.SetActivityStreamsLast(last)
+	return oc
+}
+
+// NewBoxPage returns the OrderedCollectionPage of an inbox or outbox that the
+// cursor identifies, linked to its neighboring pages.
+func NewBoxPage(boxIRI *url.URL, cursor CollectionCursor, page BoxPage) vocab.ActivityStreamsOrderedCollectionPage {
+	ocp := streams.NewActivityStreamsOrderedCollectionPage()
+	id := streams.NewJSONLDIdProperty()
+	id.Set(cursor.URL(boxIRI))
+	ocp.SetJSONLDId(id)
+	partOf := streams.NewActivityStreamsPartOfProperty()
+	partOf.SetIRI(boxIRI)
+	ocp.SetActivityStreamsPartOf(partOf)
+	total := streams.NewActivityStreamsTotalItemsProperty()
+	total.Set(page.TotalItems)
+	ocp.SetActivityStreamsTotalItems(total)
+	oi := streams.NewActivityStreamsOrderedItemsProperty()
+	for _, item := range page.Items {
+		oi.AppendIRI(item)
+	}
+	ocp.SetActivityStreamsOrderedItems(oi)
+	if len(page.Next) > 0 {
+		next := streams.NewActivityStreamsNextProperty()
+		next.SetIRI(CollectionCursor{Page: true, MaxID: page.Next}.URL(boxIRI))
+		ocp.SetActivityStreamsNext(next)
+	}
+	if len(page.Prev) > 0 {
+		prev := streams.NewActivityStreamsPrevProperty()
+		prev.SetIRI(CollectionCursor{Page: true, MinID: page.Prev}.URL(boxIRI))
+		ocp.SetActivityStreamsPrev(prev)
+	}
+	return ocp
+}
@@ -0,0 +1,87 @@
+package pub
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/go-fed/activity/streams/vocab"
+)
+
+// TestParseCollectionCursor ensures cursors are parsed from, and formatted
+// back into, query parameters.
+func TestParseCollectionCursor(t *testing.T) {
+	t.Run("ParsesCollection", func(t *testing.T) {
+		cur := ParseCollectionCursor(mustParse(testMyOutboxIRI))
+		assertEqual(t, cur, CollectionCursor{})
+		assertEqual(t, cur.URL(mustParse(testMyOutboxIRI)).String(), testMyOutboxIRI)
+	})
+	t.Run("ParsesFirstPage", func(t *testing.T) {
+		cur := ParseCollectionCursor(mustParse(testMyOutboxIRI + "?page=true"))
+		assertEqual(t, cur, CollectionCursor{Page: true})
+	})
+	t.Run("ParsesLastPage", func(t *testing.T) {
+		cur := ParseCollectionCursor(mustParse(testMyOutboxIRI + "?page=last"))
+		assertEqual(t, cur, CollectionCursor{Page: true, Last: true})
+	})
+	t.Run("ParsesCursors", func(t *testing.T) {
+		cur := ParseCollectionCursor(mustParse(testMyOutboxIRI + "?max_id=abc"))
+		assertEqual(t, cur, CollectionCursor{Page: true, MaxID: "abc"})
+		assertEqual(t, cur.URL(mustParse(testMyOutboxIRI)).String(), testMyOutboxIRI+"?max_id=abc&page=true")
+		cur = ParseCollectionCursor(mustParse(testMyOutboxIRI + "?page=true&min_id=d%2Fe"))
+		assertEqual(t, cur, CollectionCursor{Page: true, MinID: "d/e"})
+		assertEqual(t, ParseCollectionCursor(cur.URL(mustParse(testMyOutboxIRI))), cur)
+	})
+}
+
+// TestBoxForRequest ensures the OrderedCollection and its pages are built from
+// the requested cursor.
+func TestBoxForRequest(t *testing.T) {
+	ctx := context.Background()
+	page := BoxPage{
+		Items:      []*url.URL{mustParse(testNoteId2), mustParse(testNoteId1)},
+		TotalItems: 10,
+		Next:       "1",
+		Prev:       "2",
+	}
+	var gotIRI *url.URL
+	var gotCursor CollectionCursor
+	getPage := func(c context.Context, boxIRI *url.URL, cursor CollectionCursor) (BoxPage, error) {
+		gotIRI = boxIRI
+		gotCursor = cursor
+		return page, nil
+	}
+	t.Run("ReturnsCollection", func(t *testing.T) {
+		// Setup
+		req := toAPRequest(httptest.NewRequest("GET", testMyOutboxIRI, nil))
+		// Run
+		v, err := BoxForRequest(ctx, req, getPage)
+		// Verify
+		assertEqual(t, err, nil)
+		assertEqual(t, gotIRI.String(), testMyOutboxIRI)
+		oc, ok := v.(vocab.ActivityStreamsOrderedCollection)
+		assertEqual(t, ok, true)
+		assertEqual(t, oc.GetActivityStreamsTotalItems().Get(), 10)
+		assertEqual(t, oc.GetActivityStreamsFirst().GetIRI().String(), testMyOutboxIRI+"?page=true")
+		assertEqual(t, oc.GetActivityStreamsLast().GetIRI().String(), testMyOutboxIRI+"?page=last")
+	})
+	t.Run("ReturnsPage", func(t *testing.T) {
+		// Setup
+		req := toAPRequest(httptest.NewRequest("GET", testMyOutboxIRI+"?page=true&max_id=3", nil))
+		// Run
+		v, err := BoxForRequest(ctx, req, getPage)
+		// Verify
+		assertEqual(t, err, nil)
+		assertEqual(t, gotIRI.String(), testMyOutboxIRI)
+		assertEqual(t, gotCursor, CollectionCursor{Page: true, MaxID: "3"})
+		ocp, ok := v.(vocab.ActivityStreamsOrderedCollectionPage)
+		assertEqual(t, ok, true)
+		assertEqual(t, ocp.GetJSONLDId().Get().String(), testMyOutboxIRI+"?max_id=3&page=true")
+		assertEqual(t, ocp.GetActivityStreamsPartOf().GetIRI().String(), testMyOutboxIRI)
+		assertEqual(t, ocp.GetActivityStreamsOrderedItems().Len(), 2)
+		assertEqual(t, ocp.GetActivityStreamsOrderedItems().At(0).GetIRI().String(), testNoteId2)
+		assertEqual(t, ocp.GetActivityStreamsNext().GetIRI().String(), testMyOutboxIRI+"?max_id=1&page=true")
+		assertEqual(t, ocp.GetActivityStreamsPrev().GetIRI().String(), testMyOutboxIRI+"?min_id=2&page=true")
+	})
+}
@@ -87,3 +87,19 @@ type CommonBehavior interface {
 	// garbage collected.
 	NewTransport(c context.Context, actorBoxIRI *url.URL, gofedAgent string) (t Transport, err error)
 }
+
+// PagedCommonBehavior may optionally be implemented by a CommonBehavior to
+// serve the outbox as an OrderedCollection, whose OrderedCollectionPages are
+// requested with the 'page', 'max_id', and 'min_id' query parameters.
+type PagedCommonBehavior interface {
+	// GetPagedOutbox returns the OrderedCollection outbox of the actor for
+	// this context, or the OrderedCollectionPage of it requested by the
+	// query parameters. It is up to the implementation to provide the
+	// correct collection for the kind of authorization given in the
+	// request. BoxForRequest may be used to build either from the
+	// PagedDatabase's GetOutboxPage.
+	//
+	// AuthenticateGetOutbox will be called prior to this, and GetOutbox
+	// will not be called.
+	GetPagedOutbox(c context.Context, r *http.Request) (vocab.Type, error)
+}
@@ -137,3 +137,61 @@ type Database interface {
 	// The library makes this call only after acquiring a lock first.
 	Liked(c context.Context, actorIRI *url.URL) (followers vocab.ActivityStreamsCollection, err error)
 }
+
+// PagedDatabase may optionally be implemented by a Database to store inboxes
+// and outboxes as lists of ids that are read a page at a time, instead of as a
+// single OrderedCollectionPage that is loaded and saved whole for every new
+// item.
+//
+// When implemented, the library calls AppendInbox and AppendOutbox instead of
+// GetInbox and SetInbox, and GetOutbox and SetOutbox.
+type PagedDatabase interface {
+	// AppendInbox adds the id as the newest item of the inbox at the
+	// specified IRI, without needing to load the rest of the inbox. Note
+	// that the item must not be added as an independent database entry. A
+	// separate call to Create will do that.
+	//
+	// The library makes this call only after acquiring a lock first.
+	AppendInbox(c context.Context, inboxIRI, id *url.URL) error
+	// GetInboxPage returns the page of the inbox at the specified IRI that
+	// the cursor identifies, along with the cursors of its neighboring
+	// pages and the total number of items in the inbox. If the cursor
+	// does not identify a page, the page of the newest items is returned.
+	//
+	// The library does not call this itself. It is meant to be used by
+	// PagedFederatingProtocol's GetPagedInbox, such as with
+	// BoxForRequest.
+	GetInboxPage(c context.Context, inboxIRI *url.URL, cursor CollectionCursor) (page BoxPage, err error)
+	// AppendOutbox adds the id as the newest item of the outbox at the
+	// specified IRI, without needing to load the rest of the outbox. Note
+	// that the item must not be added as an independent database entry. A
+	// separate call to Create will do that.
+	//
+	// The library makes this call only after acquiring a lock first.
+	AppendOutbox(c context.Context, outboxIRI, id *url.URL) error
+	// GetOutboxPage returns the page of the outbox at the specified IRI
+	// that the cursor identifies, along with the cursors of its
+	// neighboring pages and the total number of items in the outbox. If
+	// the cursor does not identify a page, the page of the newest items is
+	// returned.
+	//
+	// The library does not call this itself. It is meant to be used by
+	// PagedCommonBehavior's GetPagedOutbox, such as with BoxForRequest.
+	GetOutboxPage(c context.Context, outboxIRI *url.URL, cursor CollectionCursor) (page BoxPage, err error)
+}
+
+// OutboxFollowFinder may optionally be implemented by a Database to find the
+// Follow an actor sent, so that the Undo of it sent on the actor's behalf, such
+// as when the followed actor moves, refers to it by its id.
+//
+// Without it, the undone Follow has no id, and peers match it by its 'actor'
+// and 'object' instead.
+type OutboxFollowFinder interface {
+	// FindOutboxFollow returns the newest Follow of the object in the
+	// outbox at the specified IRI, or nil if there is none. It is expected
+	// to be looked up directly, such as with an index, rather than by
+	// reading the whole outbox.
+	//
+	// The library makes this call only after acquiring a lock first.
+	FindOutboxFollow(c context.Context, outboxIRI, object *url.URL) (follow vocab.ActivityStreamsFollow, err error)
+}
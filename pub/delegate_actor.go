@@ -246,3 +246,79 @@ type DelegateActor interface {
 	// API is enabled.
 	GetInbox(c context.Context, r *http.Request) (vocab.ActivityStreamsOrderedCollectionPage, error)
 }
+
+// PagedDelegateActor may optionally be implemented by a DelegateActor to serve
+// the inbox and outbox as OrderedCollections, whose OrderedCollectionPages are
+// requested with the 'page', 'max_id', and 'min_id' query parameters.
+type PagedDelegateActor interface {
+	// GetPagedOutbox returns the OrderedCollection outbox of the actor for
+	// this context, or the requested OrderedCollectionPage of it. It is
+	// called instead of GetOutbox.
+	//
+	// AuthenticateGetOutbox will be called prior to this.
+	GetPagedOutbox(c context.Context, r *http.Request) (vocab.Type, error)
+	// GetPagedInbox returns the OrderedCollection inbox of the actor for
+	// this context, or the requested OrderedCollectionPage of it. It is
+	// called instead of GetInbox.
+	//
+	// AuthenticateGetInbox will be called prior to this.
+	GetPagedInbox(c context.Context, r *http.Request) (vocab.Type, error)
+}
+
+// SharedInboxDelegateActor may optionally be implemented by a DelegateActor to
+// handle POST requests to the server's shared inbox, as done by the
+// SharedInboxActor returned by NewCustomActor.
+type SharedInboxDelegateActor interface {
+	// SharedInboxRecipients determines the inboxes of the actors on this
+	// server that an Activity received in the shared inbox is for.
+	//
+	// Only called if the Federated Protocol is enabled.
+	//
+	// The Activity has already been authenticated and authorized. The
+	// returned inboxes are then passed to PostSharedInbox.
+	SharedInboxRecipients(c context.Context, activity Activity) (inboxes []*url.URL, err error)
+	// PostSharedInbox delegates the side effects of adding a new Activity
+	// received in the shared inbox to the inboxes of the actors it is
+	// for, as determined by SharedInboxRecipients.
+	//
+	// Only called if the Federated Protocol is enabled.
+	//
+	// It is the shared inbox counterpart of PostInbox. The Activity is set
+	// in each of the inboxes, but the side effects that are not specific
+	// to the actor owning an inbox, such as adding a Like to the 'likes'
+	// of an object, must only happen once for the Activity.
+	//
+	// The inboxes the Activity was posted to are returned, leaving out
+	// those whose actors refused it, such as by blocking its sender. The
+	// first of them is passed to InboxForwarding, which is only called
+	// once for the Activity, and not at all if none are returned.
+	//
+	// If the error is ErrObjectRequired or ErrTargetRequired, then a Bad
+	// Request status is sent in the response.
+	PostSharedInbox(c context.Context, inboxIRIs []*url.URL, activity Activity) (posted []*url.URL, err error)
+}
+
+// FollowApprovalDelegateActor may optionally be implemented by a DelegateActor
+// to answer the Follows awaiting approval, as done by the FollowApprovalActor
+// returned by NewCustomActor.
+type FollowApprovalDelegateActor interface {
+	// RespondToPendingFollow returns the Accept, or Reject, of the pending
+	// Follow with the given id among those awaiting approval by the actor
+	// of the outbox. It must not change the pending Follow.
+	//
+	// Only called if the Federated Protocol is enabled.
+	//
+	// The returned Activity is then posted to the outbox and delivered,
+	// as if given to Send, before ResolvePendingFollow is called. If the
+	// Follow is not pending, then ErrFollowNotPending must be returned.
+	RespondToPendingFollow(c context.Context, outboxIRI, followId *url.URL, accept bool) (response Activity, err error)
+	// ResolvePendingFollow adds the actors of the pending Follow with the
+	// given id to the 'followers' collection of the actor of the outbox if
+	// accepted, and then removes the Follow from those awaiting approval.
+	//
+	// Only called if the Federated Protocol is enabled, once the response
+	// from RespondToPendingFollow has been delivered or queued. If it
+	// returns an error, the Follow must remain pending so that responding
+	// to it may be tried again.
+	ResolvePendingFollow(c context.Context, outboxIRI, followId *url.URL, accept bool) error
+}
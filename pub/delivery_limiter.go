@@ -0,0 +1,237 @@
+package pub
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// retryAfterHeader is the HTTP header a peer uses to ask that requests
+	// be retried later.
+	retryAfterHeader = "Retry-After"
+)
+
+// DefaultRetryAfter is how long a host is avoided after it responds with 429
+// Too Many Requests without a usable Retry-After header.
+const DefaultRetryAfter = time.Minute
+
+// DeliveryLimits are the limits a DeliveryLimiter applies to deliveries. A zero
+// value for any limit disables it.
+type DeliveryLimits struct {
+	// MaxConcurrent is the maximum number of deliveries in flight at once,
+	// across all hosts.
+	MaxConcurrent int
+	// MaxConcurrentPerHost is the maximum number of deliveries in flight to
+	// a single host at once.
+	MaxConcurrentPerHost int
+	// HostRate is the maximum sustained number of deliveries per second to
+	// a single host.
+	HostRate float64
+	// HostBurst is the number of deliveries that may be sent to a single
+	// host at once before HostRate applies. It is at least one.
+	HostBurst int
+	// RetryAfter is how long a host is avoided after it responds with 429
+	// Too Many Requests without a usable Retry-After header. Defaults to
+	// DefaultRetryAfter.
+	RetryAfter time.Duration
+}
+
+// DeliveryLimiter limits the concurrency and rate of deliveries made by one or
+// more HttpSigTransports.
+//
+// A Transport is created for every request, so the same DeliveryLimiter is
+// meant to be shared by all of them. It is safe for concurrent use.
+type DeliveryLimiter struct {
+	clock  Clock
+	limits DeliveryLimits
+	// global is a semaphore for MaxConcurrent, or nil if unlimited.
+	global chan struct{}
+
+	mu    sync.Mutex
+	hosts map[string]*hostLimit
+}
+
+// hostLimit tracks the deliveries to a single host.
+type hostLimit struct {
+	// sem is a semaphore for MaxConcurrentPerHost, or nil if unlimited.
+	sem chan struct{}
+	// tokens is the number of deliveries that may be sent immediately,
+	// as of last.
+	tokens float64
+	last   time.Time
+	// retryAfter is the time before which the host asked not to receive
+	// more requests.
+	retryAfter time.Time
+}
+
+// NewDeliveryLimiter returns a DeliveryLimiter applying the limits, using the
+// clock to measure rates and Retry-After times.
+func NewDeliveryLimiter(clock Clock, limits DeliveryLimits) *DeliveryLimiter {
+	if limits.HostBurst < 1 {
+		limits.HostBurst = 1
+	}
+	if limits.RetryAfter <= 0 {
+		limits.RetryAfter = DefaultRetryAfter
+	}
+	d := &DeliveryLimiter{
+		clock:  clock,
+		limits: limits,
+		hosts:  make(map[string]*hostLimit),
+	}
+	if limits.MaxConcurrent > 0 {
+		d.global = make(chan struct{}, limits.MaxConcurrent)
+	}
+	return d
+}
+
+// acquireGlobal blocks until a delivery may be sent without exceeding
+// MaxConcurrent. The returned function must be called once the delivery is
+// done.
+func (d *DeliveryLimiter) acquireGlobal(c context.Context) (release func(), err error) {
+	return acquire(c, d.global)
+}
+
+// acquireHost determines whether a delivery may be sent to the host now. If
+// the host asked to be retried later, or HostRate is exceeded, the time at
+// which the delivery may be retried is returned instead.
+//
+// Otherwise, it blocks until a delivery may be sent without exceeding
+// MaxConcurrentPerHost. The returned function must be called once the
+// delivery is done.
+func (d *DeliveryLimiter) acquireHost(c context.Context, host string) (release func(), retryAfter time.Time, err error) {
+	now := d.clock.Now()
+	d.mu.Lock()
+	h := d.host(host, now)
+	if now.Before(h.retryAfter) {
+		retryAfter = h.retryAfter
+		d.mu.Unlock()
+		return
+	}
+	if d.limits.HostRate > 0 {
+		h.tokens += now.Sub(h.last).Seconds() * d.limits.HostRate
+		if max := float64(d.limits.HostBurst); h.tokens > max {
+			h.tokens = max
+		}
+		h.last = now
+		if h.tokens < 1 {
+			wait := (1 - h.tokens) / d.limits.HostRate
+			retryAfter = now.Add(time.Duration(wait * float64(time.Second)))
+			d.mu.Unlock()
+			return
+		}
+		h.tokens--
+	}
+	sem := h.sem
+	d.mu.Unlock()
+	release, err = acquire(c, sem)
+	return
+}
+
+// deferHost records that the host asked not to receive requests until the
+// given time.
+func (d *DeliveryLimiter) deferHost(host string, until time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	h := d.host(host, d.clock.Now())
+	if until.After(h.retryAfter) {
+		h.retryAfter = until
+	}
+}
+
+// host returns the hostLimit for the host, creating it if needed.
+//
+// Must be called with the mutex held.
+func (d *DeliveryLimiter) host(host string, now time.Time) *hostLimit {
+	h, ok := d.hosts[host]
+	if !ok {
+		h = &hostLimit{
+			tokens: float64(d.limits.HostBurst),
+			last:   now,
+		}
+		if d.limits.MaxConcurrentPerHost > 0 {
+			h.sem = make(chan struct{}, d.limits.MaxConcurrentPerHost)
+		}
+		d.hosts[host] = h
+	}
+	return h
+}
+
+// acquire blocks until the semaphore has room or the context is done. A nil
+// semaphore never blocks.
+func acquire(c context.Context, sem chan struct{}) (release func(), err error) {
+	if sem == nil {
+		return func() {}, nil
+	}
+	select {
+	case sem <- struct{}{}:
+		return func() { <-sem }, nil
+	case <-c.Done():
+		return nil, c.Err()
+	}
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is either a
+// number of seconds or an HTTP date.
+func parseRetryAfter(v string, now time.Time) (t time.Time, ok bool) {
+	v = strings.TrimSpace(v)
+	if len(v) == 0 {
+		return
+	}
+	if s, err := strconv.Atoi(v); err == nil {
+		if s < 0 {
+			return
+		}
+		return now.Add(time.Duration(s) * time.Second), true
+	}
+	t, err := http.ParseTime(v)
+	if err != nil {
+		return
+	}
+	return t, true
+}
+
+// DeferredDelivery is a recipient that was not delivered to because of a rate
+// limit, along with when delivery may be tried again.
+type DeferredDelivery struct {
+	// Recipient is the inbox that was not delivered to.
+	Recipient *url.URL
+	// RetryAfter is the earliest time delivery should be tried again.
+	RetryAfter time.Time
+}
+
+// DeliveryDeferredError is returned by the HttpSigTransport when one or more
+// recipients were not delivered to because of its DeliveryLimiter, or because
+// the peer responded with 429 Too Many Requests or a Retry-After header.
+//
+// Deferred recipients have not failed, and should be delivered to again later.
+type DeliveryDeferredError struct {
+	// Deferred are the recipients that were not delivered to.
+	Deferred []DeferredDelivery
+	// Err is the *BatchDeliveryError of the other recipients that failed,
+	// if any.
+	Err error
+}
+
+// Error describes the deferred recipients and any other failure.
+func (e *DeliveryDeferredError) Error() string {
+	r := make([]string, 0, len(e.Deferred))
+	for _, d := range e.Deferred {
+		r = append(r, fmt.Sprintf("%s until %s", d.Recipient, d.RetryAfter.UTC().Format(time.RFC3339)))
+	}
+	msg := fmt.Sprintf("delivery deferred to: %s", strings.Join(r, ", "))
+	if e.Err != nil {
+		msg = fmt.Sprintf("%s; %s", msg, e.Err)
+	}
+	return msg
+}
+
+// Unwrap returns the error of the other recipients that failed, if any.
+func (e *DeliveryDeferredError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,115 @@
+package pub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+)
+
+// TestDeliveryLimiter tests the concurrency and rate limits on deliveries.
+func TestDeliveryLimiter(t *testing.T) {
+	ctx := context.Background()
+	host := "other.example.com"
+	t.Run("LimitsHostRate", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		c := NewMockClock(ctl)
+		d := NewDeliveryLimiter(c, DeliveryLimits{HostRate: 2, HostBurst: 2})
+		// Mock
+		c.EXPECT().Now().Return(now()).Times(3)
+		c.EXPECT().Now().Return(now().Add(500 * time.Millisecond))
+		// Run & Verify
+		for i := 0; i < 2; i++ {
+			release, retryAfter, err := d.acquireHost(ctx, host)
+			assertEqual(t, err, nil)
+			assertEqual(t, retryAfter.IsZero(), true)
+			release()
+		}
+		_, retryAfter, err := d.acquireHost(ctx, host)
+		assertEqual(t, err, nil)
+		assertEqual(t, retryAfter.Equal(now().Add(500*time.Millisecond)), true)
+		_, retryAfter, err = d.acquireHost(ctx, host)
+		assertEqual(t, err, nil)
+		assertEqual(t, retryAfter.IsZero(), true)
+	})
+	t.Run("DefersHostUntilRetryAfter", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		c := NewMockClock(ctl)
+		d := NewDeliveryLimiter(c, DeliveryLimits{})
+		until := now().Add(time.Minute)
+		// Mock
+		c.EXPECT().Now().Return(now()).Times(3)
+		c.EXPECT().Now().Return(until)
+		// Run & Verify
+		d.deferHost(host, until)
+		_, retryAfter, err := d.acquireHost(ctx, host)
+		assertEqual(t, err, nil)
+		assertEqual(t, retryAfter.Equal(until), true)
+		_, retryAfter, err = d.acquireHost(ctx, "example.com")
+		assertEqual(t, err, nil)
+		assertEqual(t, retryAfter.IsZero(), true)
+		_, retryAfter, err = d.acquireHost(ctx, host)
+		assertEqual(t, err, nil)
+		assertEqual(t, retryAfter.IsZero(), true)
+	})
+	t.Run("LimitsHostConcurrency", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		c := NewMockClock(ctl)
+		d := NewDeliveryLimiter(c, DeliveryLimits{MaxConcurrentPerHost: 1})
+		cctx, cancel := context.WithCancel(ctx)
+		// Mock
+		c.EXPECT().Now().Return(now()).Times(2)
+		// Run & Verify
+		release, _, err := d.acquireHost(ctx, host)
+		assertEqual(t, err, nil)
+		cancel()
+		_, _, err = d.acquireHost(cctx, host)
+		assertEqual(t, err, context.Canceled)
+		release()
+	})
+	t.Run("LimitsGlobalConcurrency", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		c := NewMockClock(ctl)
+		d := NewDeliveryLimiter(c, DeliveryLimits{MaxConcurrent: 1})
+		cctx, cancel := context.WithCancel(ctx)
+		// Run & Verify
+		release, err := d.acquireGlobal(ctx)
+		assertEqual(t, err, nil)
+		cancel()
+		_, err = d.acquireGlobal(cctx)
+		assertEqual(t, err, context.Canceled)
+		release()
+		release, err = d.acquireGlobal(ctx)
+		assertEqual(t, err, nil)
+		release()
+	})
+}
+
+// TestParseRetryAfter tests both forms of the Retry-After header.
+func TestParseRetryAfter(t *testing.T) {
+	t.Run("ParsesSeconds", func(t *testing.T) {
+		r, ok := parseRetryAfter("120", now())
+		assertEqual(t, ok, true)
+		assertEqual(t, r.Equal(now().Add(2*time.Minute)), true)
+	})
+	t.Run("ParsesHTTPDate", func(t *testing.T) {
+		r, ok := parseRetryAfter(nowDateHeader(), now())
+		assertEqual(t, ok, true)
+		assertEqual(t, r.Equal(now().Truncate(time.Second)), true)
+	})
+	t.Run("RejectsMalformed", func(t *testing.T) {
+		_, ok := parseRetryAfter("soon", now())
+		assertEqual(t, ok, false)
+		_, ok = parseRetryAfter("-1", now())
+		assertEqual(t, ok, false)
+	})
+}
@@ -0,0 +1,407 @@
+package pub
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DeliveryStatus enumerates the states a single Delivery can be in.
+type DeliveryStatus int
+
+const (
+	// DeliveryPending indicates the Delivery has not yet succeeded and will
+	// be attempted again once its NextAttempt time has passed.
+	DeliveryPending DeliveryStatus = iota
+	// DeliverySucceeded indicates the recipient accepted the Delivery.
+	DeliverySucceeded
+	// DeliveryDead indicates the Delivery failed too many times and will
+	// no longer be attempted.
+	DeliveryDead
+)
+
+// String returns a human readable form of the DeliveryStatus.
+func (d DeliveryStatus) String() string {
+	switch d {
+	case DeliveryPending:
+		return "pending"
+	case DeliverySucceeded:
+		return "succeeded"
+	case DeliveryDead:
+		return "dead"
+	default:
+		return fmt.Sprintf("DeliveryStatus(%d)", int(d))
+	}
+}
+
+// Delivery is a single serialized activity destined for a single recipient
+// inbox.
+//
+// A Delivery is uniquely identified by its ActivityIRI and Recipient.
+type Delivery struct {
+	// ActivityIRI is the 'id' of the activity being delivered.
+	ActivityIRI *url.URL
+	// BoxIRI is the inbox or outbox of the actor on whose behalf the
+	// delivery is made. It is passed to NewTransport for every attempt.
+	BoxIRI *url.URL
+	// Recipient is the inbox IRI the activity is delivered to.
+	Recipient *url.URL
+	// Payload is the serialized activity.
+	Payload []byte
+	// Status is the current state of the Delivery.
+	Status DeliveryStatus
+	// Attempts is the number of delivery attempts that have failed.
+	Attempts int
+	// NextAttempt is the earliest time the Delivery may be retried. Only
+	// meaningful when Status is DeliveryPending.
+	NextAttempt time.Time
+	// LastError is the message of the most recent failed attempt, if any.
+	LastError string
+}
+
+// DeliveryStore persists Deliveries so that they survive transient failures,
+// and optionally process restarts.
+//
+// Applications may back a DeliveryStore with their own database. An in-memory
+// implementation is returned by NewMemoryDeliveryStore.
+//
+// Implementations must be safe for concurrent use.
+type DeliveryStore interface {
+	// Put inserts the Delivery, or replaces the existing one with the same
+	// ActivityIRI and Recipient.
+	Put(c context.Context, d Delivery) error
+	// Get returns the Delivery for the activity and recipient. Returns an
+	// error if no such Delivery exists.
+	Get(c context.Context, activityIRI, recipient *url.URL) (Delivery, error)
+	// List returns all Deliveries for the activity, in any order.
+	List(c context.Context, activityIRI *url.URL) ([]Delivery, error)
+	// Due returns up to max pending Deliveries whose NextAttempt is not
+	// after now, earliest first. A max of zero or less means no limit.
+	Due(c context.Context, now time.Time, max int) ([]Delivery, error)
+}
+
+// DeliveryQueue accepts serialized activities for delivery to recipients.
+//
+// When an Actor is configured with a DeliveryQueue via WithDeliveryQueue, it
+// enqueues outgoing activities instead of calling the Transport's
+// BatchDeliver.
+type DeliveryQueue interface {
+	// Enqueue records the activity for delivery to each of the recipients
+	// on behalf of the actor owning boxIRI.
+	//
+	// An error is returned only if the deliveries could not be recorded.
+	// Failed delivery attempts are retried by the queue and are not
+	// reported here.
+	Enqueue(c context.Context, boxIRI, activityIRI *url.URL, b []byte, recipients []*url.URL) error
+}
+
+// RetryPolicy determines how often and how long failed Deliveries are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the number of failed attempts after which a Delivery
+	// is marked as dead. Zero or a negative number retries forever.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry. Each following retry
+	// doubles the previous delay.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between retries. Zero means no cap.
+	MaxDelay time.Duration
+	// Jitter is the fraction, between 0 and 1, by which a delay is
+	// randomly shortened so that retries to the same peer spread out.
+	Jitter float64
+}
+
+// DefaultRetryPolicy retries a Delivery for roughly a day and a half before
+// declaring it dead.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 12,
+	BaseDelay:   time.Minute,
+	MaxDelay:    12 * time.Hour,
+	Jitter:      0.2,
+}
+
+// delay returns the backoff before the next attempt, given the number of
+// attempts that have failed so far.
+func (r RetryPolicy) delay(attempts int) time.Duration {
+	d := r.BaseDelay
+	for i := 1; i < attempts && (r.MaxDelay <= 0 || d < r.MaxDelay); i++ {
+		d *= 2
+	}
+	if r.MaxDelay > 0 && d > r.MaxDelay {
+		d = r.MaxDelay
+	}
+	if r.Jitter > 0 {
+		d -= time.Duration(float64(d) * r.Jitter * rand.Float64())
+	}
+	return d
+}
+
+// deliveryLease is how long a Delivery being attempted by Enqueue or ProcessDue
+// is kept from being attempted again by ProcessDue. The attempt reschedules it
+// once done, so the lease only runs out if the process stopped during the
+// attempt.
+const deliveryLease = 5 * time.Minute
+
+// DefaultDeliveryWorkers is the default number of delivery attempts a
+// RetryingDeliveryQueue makes concurrently.
+const DefaultDeliveryWorkers = 16
+
+// RetryingDeliveryQueue must satisfy the DeliveryQueue interface.
+var _ DeliveryQueue = &RetryingDeliveryQueue{}
+
+// RetryingDeliveryQueue is a DeliveryQueue that persists every Delivery in a
+// DeliveryStore, attempts it immediately, and retries failures with
+// exponential backoff and jitter according to its RetryPolicy. Deliveries
+// failing with a TransportError that is not Retryable are marked as dead
+// without being retried.
+//
+// Retries only happen when ProcessDue is called, which Run does periodically.
+type RetryingDeliveryQueue struct {
+	store        DeliveryStore
+	newTransport func(c context.Context, actorBoxIRI *url.URL, gofedAgent string) (Transport, error)
+	clock        Clock
+	policy       RetryPolicy
+	// workers holds a token for every delivery attempt in progress, across
+	// all calls to Enqueue and ProcessDue.
+	workers chan struct{}
+}
+
+// NewRetryingDeliveryQueue returns a new RetryingDeliveryQueue.
+//
+// The newTransport function is called for every delivery attempt, and is
+// typically the application's CommonBehavior.NewTransport. At most workers
+// attempts are made concurrently. A workers of zero or less uses
+// DefaultDeliveryWorkers.
+func NewRetryingDeliveryQueue(store DeliveryStore,
+	newTransport func(c context.Context, actorBoxIRI *url.URL, gofedAgent string) (Transport, error),
+	clock Clock,
+	policy RetryPolicy,
+	workers int) *RetryingDeliveryQueue {
+	if workers <= 0 {
+		workers = DefaultDeliveryWorkers
+	}
+	return &RetryingDeliveryQueue{
+		store:        store,
+		newTransport: newTransport,
+		clock:        clock,
+		policy:       policy,
+		workers:      make(chan struct{}, workers),
+	}
+}
+
+// Enqueue persists a pending Delivery for every recipient and then attempts
+// them, several at a time.
+//
+// The Deliveries are persisted as not due until a lease has passed, so that a
+// concurrent ProcessDue does not attempt them a second time.
+func (q *RetryingDeliveryQueue) Enqueue(c context.Context, boxIRI, activityIRI *url.URL, b []byte, recipients []*url.URL) error {
+	lease := q.clock.Now().Add(deliveryLease)
+	ds := make([]Delivery, 0, len(recipients))
+	for _, r := range recipients {
+		d := Delivery{
+			ActivityIRI: activityIRI,
+			BoxIRI:      boxIRI,
+			Recipient:   r,
+			Payload:     b,
+			Status:      DeliveryPending,
+			NextAttempt: lease,
+		}
+		if err := q.store.Put(c, d); err != nil {
+			return err
+		}
+		ds = append(ds, d)
+	}
+	return q.attempt(c, ds)
+}
+
+// ProcessDue attempts every pending Delivery whose retry time has come.
+//
+// Like in Enqueue, the Deliveries are persisted as not due until a lease has
+// passed before they are attempted, so that a concurrent ProcessDue does not
+// attempt them a second time.
+func (q *RetryingDeliveryQueue) ProcessDue(c context.Context) error {
+	now := q.clock.Now()
+	ds, err := q.store.Due(c, now, 0)
+	if err != nil {
+		return err
+	}
+	for i := range ds {
+		ds[i].NextAttempt = now.Add(deliveryLease)
+		if err := q.store.Put(c, ds[i]); err != nil {
+			return err
+		}
+	}
+	return q.attempt(c, ds)
+}
+
+// Run calls ProcessDue every interval until the context is done. Errors from
+// ProcessDue are passed to onErr, which may be nil.
+func (q *RetryingDeliveryQueue) Run(c context.Context, interval time.Duration, onErr func(error)) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-c.Done():
+			return
+		case <-t.C:
+			if err := q.ProcessDue(c); err != nil && onErr != nil {
+				onErr(err)
+			}
+		}
+	}
+}
+
+// Status returns the Delivery of the activity to the recipient.
+func (q *RetryingDeliveryQueue) Status(c context.Context, activityIRI, recipient *url.URL) (Delivery, error) {
+	return q.store.Get(c, activityIRI, recipient)
+}
+
+// Statuses returns the Deliveries of the activity to all of its recipients.
+func (q *RetryingDeliveryQueue) Statuses(c context.Context, activityIRI *url.URL) ([]Delivery, error) {
+	return q.store.List(c, activityIRI)
+}
+
+// attempt concurrently tries each Delivery once and records the outcome. It
+// waits for a worker to be free before starting each attempt.
+//
+// Returns the first error encountered while recording outcomes. Failures to
+// deliver are not errors.
+func (q *RetryingDeliveryQueue) attempt(c context.Context, ds []Delivery) error {
+	var wg sync.WaitGroup
+	errCh := make(chan error, len(ds))
+	for _, d := range ds {
+		q.workers <- struct{}{}
+		wg.Add(1)
+		go func(d Delivery) {
+			defer wg.Done()
+			defer func() { <-q.workers }()
+			if err := q.store.Put(c, q.try(c, d)); err != nil {
+				errCh <- err
+			}
+		}(d)
+	}
+	wg.Wait()
+	select {
+	case err := <-errCh:
+		return err
+	default:
+		return nil
+	}
+}
+
+// try makes a single delivery attempt, returning the updated Delivery.
+//
+// A new Transport is used for every attempt since a Transport is not safe for
+// concurrent use.
+func (q *RetryingDeliveryQueue) try(c context.Context, d Delivery) Delivery {
+	tp, err := q.newTransport(c, d.BoxIRI, goFedUserAgent())
+	if err == nil {
+		err = tp.Deliver(c, d.Payload, d.Recipient)
+	}
+	if err == nil {
+		d.Status = DeliverySucceeded
+		d.LastError = ""
+		return d
+	}
+	// A deferred delivery was not attempted, so it is rescheduled without
+	// counting against the RetryPolicy.
+	if de, ok := err.(*DeliveryDeferredError); ok && de.Err == nil && len(de.Deferred) > 0 {
+		d.LastError = err.Error()
+		d.NextAttempt = de.Deferred[0].RetryAfter
+		return d
+	}
+	d.Attempts++
+	d.LastError = err.Error()
+	// A peer that rejects the delivery outright, such as with 410 Gone,
+	// will not accept it when retried.
+	te, isTransportErr := err.(*TransportError)
+	if isTransportErr && !te.Retryable {
+		d.Status = DeliveryDead
+	} else if q.policy.MaxAttempts > 0 && d.Attempts >= q.policy.MaxAttempts {
+		d.Status = DeliveryDead
+	} else {
+		d.NextAttempt = q.clock.Now().Add(q.policy.delay(d.Attempts))
+		if isTransportErr && te.RetryAfter.After(d.NextAttempt) {
+			d.NextAttempt = te.RetryAfter
+		}
+	}
+	return d
+}
+
+// MemoryDeliveryStore must satisfy the DeliveryStore interface.
+var _ DeliveryStore = &MemoryDeliveryStore{}
+
+// MemoryDeliveryStore is a DeliveryStore that keeps Deliveries in memory.
+//
+// Deliveries are lost when the process exits, so it is best suited for tests
+// and single-process deployments that tolerate this.
+type MemoryDeliveryStore struct {
+	mu         sync.Mutex
+	deliveries map[string]Delivery
+}
+
+// NewMemoryDeliveryStore returns an empty MemoryDeliveryStore.
+func NewMemoryDeliveryStore() *MemoryDeliveryStore {
+	return &MemoryDeliveryStore{
+		deliveries: make(map[string]Delivery),
+	}
+}
+
+// deliveryKey uniquely identifies a Delivery.
+func deliveryKey(activityIRI, recipient *url.URL) string {
+	return activityIRI.String() + " " + recipient.String()
+}
+
+// Put inserts or replaces the Delivery.
+func (m *MemoryDeliveryStore) Put(c context.Context, d Delivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deliveries[deliveryKey(d.ActivityIRI, d.Recipient)] = d
+	return nil
+}
+
+// Get returns the Delivery for the activity and recipient.
+func (m *MemoryDeliveryStore) Get(c context.Context, activityIRI, recipient *url.URL) (Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d, ok := m.deliveries[deliveryKey(activityIRI, recipient)]
+	if !ok {
+		return Delivery{}, fmt.Errorf("no delivery of %s to %s", activityIRI, recipient)
+	}
+	return d, nil
+}
+
+// List returns all Deliveries for the activity.
+func (m *MemoryDeliveryStore) List(c context.Context, activityIRI *url.URL) ([]Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var ds []Delivery
+	for _, d := range m.deliveries {
+		if d.ActivityIRI.String() == activityIRI.String() {
+			ds = append(ds, d)
+		}
+	}
+	return ds, nil
+}
+
+// Due returns pending Deliveries ready to be retried, earliest first.
+func (m *MemoryDeliveryStore) Due(c context.Context, now time.Time, max int) ([]Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var ds []Delivery
+	for _, d := range m.deliveries {
+		if d.Status == DeliveryPending && !d.NextAttempt.After(now) {
+			ds = append(ds, d)
+		}
+	}
+	sort.Slice(ds, func(i, j int) bool {
+		return ds[i].NextAttempt.Before(ds[j].NextAttempt)
+	})
+	if max > 0 && len(ds) > max {
+		ds = ds[:max]
+	}
+	return ds, nil
+}
@@ -0,0 +1,304 @@
+package pub
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+)
+
+// TestRetryPolicyDelay ensures the backoff doubles and is capped.
+func TestRetryPolicyDelay(t *testing.T) {
+	p := RetryPolicy{
+		BaseDelay: time.Second,
+		MaxDelay:  10 * time.Second,
+	}
+	tests := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{50, 10 * time.Second},
+	}
+	for _, test := range tests {
+		assertEqual(t, p.delay(test.attempts), test.expected)
+	}
+	p.Jitter = 0.5
+	for i := 0; i < 10; i++ {
+		d := p.delay(3)
+		if d > 4*time.Second || d < 2*time.Second {
+			t.Errorf("jittered delay out of range: %v", d)
+		}
+	}
+}
+
+// TestRetryingDeliveryQueue tests persisting and retrying deliveries.
+func TestRetryingDeliveryQueue(t *testing.T) {
+	ctx := context.Background()
+	policy := RetryPolicy{
+		MaxAttempts: 2,
+		BaseDelay:   time.Minute,
+	}
+	setupFn := func(ctl *gomock.Controller) (q *RetryingDeliveryQueue, s *MemoryDeliveryStore, c *MockClock, tp *MockTransport) {
+		s = NewMemoryDeliveryStore()
+		c = NewMockClock(ctl)
+		tp = NewMockTransport(ctl)
+		q = NewRetryingDeliveryQueue(s, func(c context.Context, actorBoxIRI *url.URL, gofedAgent string) (Transport, error) {
+			return tp, nil
+		}, c, policy, 0)
+		return
+	}
+	t.Run("MarksSuccessfulDeliveries", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		q, _, c, tp := setupFn(ctl)
+		// Mock
+		c.EXPECT().Now().Return(now())
+		tp.EXPECT().Deliver(ctx, testRespBody, mustParse(testFederatedInboxIRI))
+		// Run
+		err := q.Enqueue(ctx, mustParse(testMyOutboxIRI), mustParse(testNewActivityIRI), testRespBody, []*url.URL{mustParse(testFederatedInboxIRI)})
+		// Verify
+		assertEqual(t, err, nil)
+		d, err := q.Status(ctx, mustParse(testNewActivityIRI), mustParse(testFederatedInboxIRI))
+		assertEqual(t, err, nil)
+		assertEqual(t, d.Status, DeliverySucceeded)
+		assertEqual(t, d.Attempts, 0)
+	})
+	t.Run("SchedulesRetryOnFailure", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		q, _, c, tp := setupFn(ctl)
+		// Mock
+		c.EXPECT().Now().Return(now()).Times(2)
+		tp.EXPECT().Deliver(ctx, testRespBody, mustParse(testFederatedInboxIRI)).Return(testErr)
+		// Run
+		err := q.Enqueue(ctx, mustParse(testMyOutboxIRI), mustParse(testNewActivityIRI), testRespBody, []*url.URL{mustParse(testFederatedInboxIRI)})
+		// Verify
+		assertEqual(t, err, nil)
+		d, err := q.Status(ctx, mustParse(testNewActivityIRI), mustParse(testFederatedInboxIRI))
+		assertEqual(t, err, nil)
+		assertEqual(t, d.Status, DeliveryPending)
+		assertEqual(t, d.Attempts, 1)
+		assertEqual(t, d.LastError, testErr.Error())
+		assertEqual(t, d.NextAttempt.Equal(now().Add(time.Minute)), true)
+	})
+	t.Run("MarksDeadIfNotRetryable", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		q, _, c, tp := setupFn(ctl)
+		goneErr := &TransportError{
+			Method:     "POST",
+			IRI:        mustParse(testFederatedInboxIRI),
+			StatusCode: http.StatusGone,
+		}
+		// Mock
+		c.EXPECT().Now().Return(now())
+		tp.EXPECT().Deliver(ctx, testRespBody, mustParse(testFederatedInboxIRI)).Return(goneErr)
+		// Run
+		err := q.Enqueue(ctx, mustParse(testMyOutboxIRI), mustParse(testNewActivityIRI), testRespBody, []*url.URL{mustParse(testFederatedInboxIRI)})
+		// Verify
+		assertEqual(t, err, nil)
+		d, err := q.Status(ctx, mustParse(testNewActivityIRI), mustParse(testFederatedInboxIRI))
+		assertEqual(t, err, nil)
+		assertEqual(t, d.Status, DeliveryDead)
+		assertEqual(t, d.Attempts, 1)
+	})
+	t.Run("ReschedulesDeferredDeliveries", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		q, _, c, tp := setupFn(ctl)
+		retryAfter := now().Add(time.Hour)
+		deferErr := &DeliveryDeferredError{
+			Deferred: []DeferredDelivery{{Recipient: mustParse(testFederatedInboxIRI), RetryAfter: retryAfter}},
+		}
+		// Mock
+		c.EXPECT().Now().Return(now())
+		tp.EXPECT().Deliver(ctx, testRespBody, mustParse(testFederatedInboxIRI)).Return(deferErr)
+		// Run
+		err := q.Enqueue(ctx, mustParse(testMyOutboxIRI), mustParse(testNewActivityIRI), testRespBody, []*url.URL{mustParse(testFederatedInboxIRI)})
+		// Verify
+		assertEqual(t, err, nil)
+		d, err := q.Status(ctx, mustParse(testNewActivityIRI), mustParse(testFederatedInboxIRI))
+		assertEqual(t, err, nil)
+		assertEqual(t, d.Status, DeliveryPending)
+		assertEqual(t, d.Attempts, 0)
+		assertEqual(t, d.NextAttempt.Equal(retryAfter), true)
+	})
+	t.Run("OnlyRetriesDueDeliveries", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		q, s, c, tp := setupFn(ctl)
+		s.Put(ctx, Delivery{
+			ActivityIRI: mustParse(testNewActivityIRI),
+			BoxIRI:      mustParse(testMyOutboxIRI),
+			Recipient:   mustParse(testFederatedInboxIRI),
+			Payload:     testRespBody,
+			NextAttempt: now(),
+		})
+		s.Put(ctx, Delivery{
+			ActivityIRI: mustParse(testNewActivityIRI),
+			BoxIRI:      mustParse(testMyOutboxIRI),
+			Recipient:   mustParse(testFederatedInboxIRI2),
+			Payload:     testRespBody,
+			NextAttempt: now().Add(time.Hour),
+		})
+		// Mock
+		c.EXPECT().Now().Return(now())
+		tp.EXPECT().Deliver(ctx, testRespBody, mustParse(testFederatedInboxIRI))
+		// Run
+		err := q.ProcessDue(ctx)
+		// Verify
+		assertEqual(t, err, nil)
+		ds, err := q.Statuses(ctx, mustParse(testNewActivityIRI))
+		assertEqual(t, err, nil)
+		assertEqual(t, len(ds), 2)
+		for _, d := range ds {
+			if d.Recipient.String() == testFederatedInboxIRI {
+				assertEqual(t, d.Status, DeliverySucceeded)
+			} else {
+				assertEqual(t, d.Status, DeliveryPending)
+			}
+		}
+	})
+	t.Run("MarksDeadAfterMaxAttempts", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		q, s, c, tp := setupFn(ctl)
+		s.Put(ctx, Delivery{
+			ActivityIRI: mustParse(testNewActivityIRI),
+			BoxIRI:      mustParse(testMyOutboxIRI),
+			Recipient:   mustParse(testFederatedInboxIRI),
+			Payload:     testRespBody,
+			Attempts:    1,
+			NextAttempt: now(),
+		})
+		// Mock
+		c.EXPECT().Now().Return(now())
+		tp.EXPECT().Deliver(ctx, testRespBody, mustParse(testFederatedInboxIRI)).Return(testErr)
+		// Run
+		err := q.ProcessDue(ctx)
+		// Verify
+		assertEqual(t, err, nil)
+		d, err := q.Status(ctx, mustParse(testNewActivityIRI), mustParse(testFederatedInboxIRI))
+		assertEqual(t, err, nil)
+		assertEqual(t, d.Status, DeliveryDead)
+		assertEqual(t, d.Attempts, 2)
+		due, err := s.Due(ctx, now().Add(time.Hour), 0)
+		assertEqual(t, err, nil)
+		assertEqual(t, len(due), 0)
+	})
+	t.Run("DoesNotProcessDeliveriesBeingEnqueued", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		q, _, c, tp := setupFn(ctl)
+		started := make(chan struct{})
+		release := make(chan struct{})
+		done := make(chan error)
+		// Mock
+		c.EXPECT().Now().Return(now()).Times(2)
+		tp.EXPECT().Deliver(ctx, testRespBody, mustParse(testFederatedInboxIRI)).DoAndReturn(func(c context.Context, b []byte, to *url.URL) error {
+			close(started)
+			<-release
+			return nil
+		})
+		// Run
+		go func() {
+			done <- q.Enqueue(ctx, mustParse(testMyOutboxIRI), mustParse(testNewActivityIRI), testRespBody, []*url.URL{mustParse(testFederatedInboxIRI)})
+		}()
+		<-started
+		err := q.ProcessDue(ctx)
+		close(release)
+		// Verify
+		assertEqual(t, err, nil)
+		assertEqual(t, <-done, nil)
+		d, err := q.Status(ctx, mustParse(testNewActivityIRI), mustParse(testFederatedInboxIRI))
+		assertEqual(t, err, nil)
+		assertEqual(t, d.Status, DeliverySucceeded)
+	})
+	t.Run("DoesNotProcessDeliveriesBeingProcessed", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		q, s, c, tp := setupFn(ctl)
+		s.Put(ctx, Delivery{
+			ActivityIRI: mustParse(testNewActivityIRI),
+			BoxIRI:      mustParse(testMyOutboxIRI),
+			Recipient:   mustParse(testFederatedInboxIRI),
+			Payload:     testRespBody,
+			NextAttempt: now(),
+		})
+		started := make(chan struct{})
+		release := make(chan struct{})
+		done := make(chan error)
+		// Mock
+		c.EXPECT().Now().Return(now()).Times(2)
+		tp.EXPECT().Deliver(ctx, testRespBody, mustParse(testFederatedInboxIRI)).DoAndReturn(func(c context.Context, b []byte, to *url.URL) error {
+			close(started)
+			<-release
+			return nil
+		})
+		// Run
+		go func() {
+			done <- q.ProcessDue(ctx)
+		}()
+		<-started
+		err := q.ProcessDue(ctx)
+		close(release)
+		// Verify
+		assertEqual(t, err, nil)
+		assertEqual(t, <-done, nil)
+		d, err := q.Status(ctx, mustParse(testNewActivityIRI), mustParse(testFederatedInboxIRI))
+		assertEqual(t, err, nil)
+		assertEqual(t, d.Status, DeliverySucceeded)
+	})
+	t.Run("LimitsConcurrentAttemptsToWorkers", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		s := NewMemoryDeliveryStore()
+		c := NewMockClock(ctl)
+		tp := NewMockTransport(ctl)
+		q := NewRetryingDeliveryQueue(s, func(c context.Context, actorBoxIRI *url.URL, gofedAgent string) (Transport, error) {
+			return tp, nil
+		}, c, policy, 1)
+		var mu sync.Mutex
+		inFlight, maxInFlight := 0, 0
+		deliverFn := func(c context.Context, b []byte, to *url.URL) error {
+			mu.Lock()
+			inFlight++
+			if inFlight > maxInFlight {
+				maxInFlight = inFlight
+			}
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+			return nil
+		}
+		// Mock
+		c.EXPECT().Now().Return(now())
+		tp.EXPECT().Deliver(ctx, testRespBody, mustParse(testFederatedInboxIRI)).DoAndReturn(deliverFn)
+		tp.EXPECT().Deliver(ctx, testRespBody, mustParse(testFederatedInboxIRI2)).DoAndReturn(deliverFn)
+		// Run
+		err := q.Enqueue(ctx, mustParse(testMyOutboxIRI), mustParse(testNewActivityIRI), testRespBody, []*url.URL{mustParse(testFederatedInboxIRI), mustParse(testFederatedInboxIRI2)})
+		// Verify
+		assertEqual(t, err, nil)
+		assertEqual(t, maxInFlight, 1)
+	})
+}
@@ -0,0 +1,134 @@
+package pub
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+const (
+	// The Content-Digest header, from RFC 9530.
+	contentDigestHeader = "Content-Digest"
+	// The delimiter around byte sequences in the Content-Digest header.
+	contentDigestByteDelimiter = ":"
+	// The delimiter between digests in the Digest and Content-Digest
+	// headers.
+	digestListDelimiter = ","
+	// SHA-512 string for the Digest header.
+	sha512Digest = "SHA-512"
+)
+
+// addDigestHeaders sets both the legacy Digest header of RFC 3230 and the
+// Content-Digest header of RFC 9530 to the SHA-256 digest of the content.
+func addDigestHeaders(h http.Header, content []byte) {
+	hashed := sha256.Sum256(content)
+	enc := base64.StdEncoding.EncodeToString(hashed[:])
+	// RFC 3230 and RFC 5843
+	var b bytes.Buffer
+	b.WriteString(sha256Digest)
+	b.WriteString(digestDelimiter)
+	b.WriteString(enc)
+	h.Set(digestHeader, b.String())
+	// RFC 9530
+	b.Reset()
+	b.WriteString(strings.ToLower(sha256Digest))
+	b.WriteString(digestDelimiter)
+	b.WriteString(contentDigestByteDelimiter)
+	b.WriteString(enc)
+	b.WriteString(contentDigestByteDelimiter)
+	h.Set(contentDigestHeader, b.String())
+}
+
+// VerifyRequestDigest checks that the Digest or Content-Digest headers of the
+// request match its body. It is suitable for use in PostInboxRequestBodyHook
+// or AuthenticatePostInbox.
+//
+// A request with a non-empty body must have at least one of the headers, and
+// every digest using a supported algorithm must match. SHA-256 and SHA-512 are
+// supported. The body is replaced after being read, so it may be read again by
+// the caller.
+//
+// This does not check that the headers are covered by an HTTP Signature; the
+// HttpSigVerifier does so in addition to calling this.
+func VerifyRequestDigest(r *http.Request) error {
+	if r.Body == nil {
+		return verifyDigestHeaders(r.Header, nil)
+	}
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewReader(b))
+	return verifyDigestHeaders(r.Header, b)
+}
+
+// verifyDigestHeaders checks that the Digest and Content-Digest headers match
+// the body, and that at least one is present if the body is not empty.
+func verifyDigestHeaders(h http.Header, body []byte) error {
+	digest := h.Get(digestHeader)
+	contentDigest := h.Get(contentDigestHeader)
+	if len(digest) == 0 && len(contentDigest) == 0 {
+		if len(body) == 0 {
+			return nil
+		}
+		return fmt.Errorf("request with a body is missing both the %s and %s headers", digestHeader, contentDigestHeader)
+	}
+	if len(digest) > 0 {
+		if err := verifyDigestList(digestHeader, digest, body, func(v string) string {
+			return v
+		}); err != nil {
+			return err
+		}
+	}
+	if len(contentDigest) > 0 {
+		if err := verifyDigestList(contentDigestHeader, contentDigest, body, func(v string) string {
+			return strings.Trim(v, contentDigestByteDelimiter)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyDigestList checks each digest in a header value of the form
+// "algorithm=value, ...". The unwrap function obtains the base64 encoded
+// digest from each value. At least one algorithm must be supported.
+func verifyDigestList(name, header string, body []byte, unwrap func(string) string) error {
+	supported := false
+	for _, d := range strings.Split(header, digestListDelimiter) {
+		kv := strings.SplitN(strings.TrimSpace(d), digestDelimiter, 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("malformed %s header: %s", name, header)
+		}
+		var hasher hash.Hash
+		switch strings.ToUpper(kv[0]) {
+		case sha256Digest:
+			hasher = sha256.New()
+		case sha512Digest:
+			hasher = sha512.New()
+		default:
+			continue
+		}
+		supported = true
+		expected, err := base64.StdEncoding.DecodeString(unwrap(kv[1]))
+		if err != nil {
+			return fmt.Errorf("malformed %s header: %s", name, err)
+		}
+		hasher.Write(body)
+		if subtle.ConstantTimeCompare(hasher.Sum(nil), expected) != 1 {
+			return fmt.Errorf("%s header does not match the body", name)
+		}
+	}
+	if !supported {
+		return fmt.Errorf("%s header has no supported algorithm: %s", name, header)
+	}
+	return nil
+}
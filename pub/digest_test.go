@@ -0,0 +1,87 @@
+package pub
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestVerifyRequestDigest tests checking the Digest and Content-Digest headers
+// against a request body.
+func TestVerifyRequestDigest(t *testing.T) {
+	newRequestFn := func(body []byte) *http.Request {
+		return httptest.NewRequest("POST", testMyInboxIRI, bytes.NewReader(body))
+	}
+	hashed := sha512.Sum512(testRespBody)
+	sha512Value := base64.StdEncoding.EncodeToString(hashed[:])
+	t.Run("AcceptsGeneratedHeaders", func(t *testing.T) {
+		r := newRequestFn(testRespBody)
+		addDigestHeaders(r.Header, testRespBody)
+		err := VerifyRequestDigest(r)
+		assertEqual(t, err, nil)
+		b, err := ioutil.ReadAll(r.Body)
+		assertEqual(t, err, nil)
+		assertByteEqual(t, b, testRespBody)
+	})
+	t.Run("AcceptsOnlyDigest", func(t *testing.T) {
+		r := newRequestFn(testRespBody)
+		addDigestHeaders(r.Header, testRespBody)
+		r.Header.Del(contentDigestHeader)
+		err := VerifyRequestDigest(r)
+		assertEqual(t, err, nil)
+	})
+	t.Run("AcceptsOnlyContentDigest", func(t *testing.T) {
+		r := newRequestFn(testRespBody)
+		addDigestHeaders(r.Header, testRespBody)
+		r.Header.Del(digestHeader)
+		err := VerifyRequestDigest(r)
+		assertEqual(t, err, nil)
+	})
+	t.Run("AcceptsSHA512", func(t *testing.T) {
+		r := newRequestFn(testRespBody)
+		r.Header.Set(digestHeader, "SHA-512="+sha512Value)
+		r.Header.Set(contentDigestHeader, "sha-512=:"+sha512Value+":")
+		err := VerifyRequestDigest(r)
+		assertEqual(t, err, nil)
+	})
+	t.Run("IgnoresUnsupportedAlgorithms", func(t *testing.T) {
+		r := newRequestFn(testRespBody)
+		r.Header.Set(contentDigestHeader, "md5=:bm90IGNoZWNrZWQ=:, sha-512=:"+sha512Value+":")
+		err := VerifyRequestDigest(r)
+		assertEqual(t, err, nil)
+	})
+	t.Run("AcceptsEmptyBodyWithoutHeaders", func(t *testing.T) {
+		r := newRequestFn(nil)
+		err := VerifyRequestDigest(r)
+		assertEqual(t, err, nil)
+	})
+	t.Run("RejectsMissingHeaders", func(t *testing.T) {
+		r := newRequestFn(testRespBody)
+		err := VerifyRequestDigest(r)
+		assertNotEqual(t, err, nil)
+	})
+	t.Run("RejectsOnlyUnsupportedAlgorithms", func(t *testing.T) {
+		r := newRequestFn(testRespBody)
+		r.Header.Set(digestHeader, "MD5=bm90IGNoZWNrZWQ=")
+		err := VerifyRequestDigest(r)
+		assertNotEqual(t, err, nil)
+	})
+	t.Run("RejectsDigestMismatch", func(t *testing.T) {
+		r := newRequestFn(testRespBody)
+		addDigestHeaders(r.Header, []byte("tampered"))
+		r.Header.Del(contentDigestHeader)
+		err := VerifyRequestDigest(r)
+		assertNotEqual(t, err, nil)
+	})
+	t.Run("RejectsContentDigestMismatch", func(t *testing.T) {
+		r := newRequestFn(testRespBody)
+		addDigestHeaders(r.Header, testRespBody)
+		r.Header.Set(contentDigestHeader, "sha-512=:"+base64.StdEncoding.EncodeToString([]byte("tampered"))+":")
+		err := VerifyRequestDigest(r)
+		assertNotEqual(t, err, nil)
+	})
+}
@@ -64,6 +64,11 @@ type FederatingProtocol interface {
 	// Finally, if the authentication and authorization succeeds, then
 	// blocked must be false and error nil. The request will continue
 	// to be processed.
+	//
+	// If the Actor was created with WithBlockList, the blocks scoped to
+	// the whole server are checked before calling Blocked, and the blocks
+	// of the receiving actor are enforced afterwards. Applications relying
+	// only on the BlockList may simply return false.
 	Blocked(c context.Context, actorIRIs []*url.URL) (blocked bool, err error)
 	// FederatingCallbacks returns the application logic that handles
 	// ActivityStreams received from federating peers.
@@ -122,3 +127,20 @@ type FederatingProtocol interface {
 	// API is enabled.
 	GetInbox(c context.Context, r *http.Request) (vocab.ActivityStreamsOrderedCollectionPage, error)
 }
+
+// PagedFederatingProtocol may optionally be implemented by a
+// FederatingProtocol to serve the inbox as an OrderedCollection, whose
+// OrderedCollectionPages are requested with the 'page', 'max_id', and 'min_id'
+// query parameters.
+type PagedFederatingProtocol interface {
+	// GetPagedInbox returns the OrderedCollection inbox of the actor for
+	// this context, or the OrderedCollectionPage of it requested by the
+	// query parameters. It is up to the implementation to provide the
+	// correct collection for the kind of authorization given in the
+	// request. BoxForRequest may be used to build either from the
+	// PagedDatabase's GetInboxPage.
+	//
+	// AuthenticateGetInbox will be called prior to this, and GetInbox will
+	// not be called.
+	GetPagedInbox(c context.Context, r *http.Request) (vocab.Type, error)
+}
@@ -23,6 +23,11 @@ const (
 	// OnFollowAutomaticallyAccept triggers the side effect of sending a
 	// Reject of this Follow request in response.
 	OnFollowAutomaticallyReject
+	// OnFollowPendingApproval records this Follow request in the
+	// PendingFollowStore, to be answered later with AcceptFollow or
+	// RejectFollow. It requires the Actor to be created with
+	// WithPendingFollowStore.
+	OnFollowPendingApproval
 )
 
 // FederatingWrappedCallbacks lists the callback functions that already have
@@ -37,6 +42,19 @@ type FederatingWrappedCallbacks struct {
 	// 'object' property is created in the database.
 	//
 	// Create calls Create for each object in the federated Activity.
+	//
+	// If the Actor was created with WithRepliesCollections, the objects
+	// are also added to the 'replies' collection of the local objects they
+	// are 'inReplyTo'.
+	//
+	// If the Actor was created with WithVoteStore, objects that are votes
+	// on an open Question owned by the actor of this inbox are tallied on
+	// the Question: a vote is a Note whose 'name' is an option of the
+	// Question and which is 'inReplyTo' it. Each option's 'replies'
+	// 'totalItems' and the Question's 'votersCount' are incremented, then
+	// an Update of the Question is sent to the actor's followers. A peer
+	// may only vote once for a 'oneOf' Question, and once per option for
+	// an 'anyOf' Question; other votes are not tallied.
 	Create func(context.Context, vocab.ActivityStreamsCreate) error
 	// Update handles additional side effects for the Update ActivityStreams
 	// type, specific to the application using go-fed.
@@ -45,18 +63,29 @@ type FederatingWrappedCallbacks struct {
 	// 'object' property is updated in the database.
 	//
 	// Update calls Update on the federated entry from the database, with a
-	// new value.
+	// new value. If a PublicKeyStore is in use, the cached public keys
+	// owned by the updated objects are invalidated. If an ActorCache is in
+	// use, the updated objects are removed from it.
 	Update func(context.Context, vocab.ActivityStreamsUpdate) error
 	// Delete handles additional side effects for the Delete ActivityStreams
 	// type, specific to the application using go-fed.
 	//
-	// Delete removes the federated entry from the database.
+	// Delete removes the federated entry from the database. If a
+	// PublicKeyStore is in use, the public keys owned by the deleted
+	// objects are revoked. If an ActorCache is in use, the deleted objects
+	// are removed from it. If the Actor was created with
+	// WithRepliesCollections, the deleted objects are removed from the
+	// 'replies' collection of the local objects they were 'inReplyTo'.
 	Delete func(context.Context, vocab.ActivityStreamsDelete) error
 	// Follow handles additional side effects for the Follow ActivityStreams
 	// type, specific to the application using go-fed.
 	//
 	// The wrapping function can have one of several default behaviors,
 	// depending on the value of the OnFollow setting.
+	//
+	// With OnFollowPendingApproval, the Follow is added to the
+	// PendingFollowStore and no response is sent until the application
+	// calls AcceptFollow or RejectFollow.
 	Follow func(context.Context, vocab.ActivityStreamsFollow) error
 	// OnFollow determines what action to take for this particular callback
 	// if a Follow Activity is handled.
@@ -73,11 +102,30 @@ type FederatingWrappedCallbacks struct {
 	// Reject handles additional side effects for the Reject ActivityStreams
 	// type, specific to the application using go-fed.
 	//
-	// The wrapping function has no default side effects. However, if this
-	// 'Reject' is in response to a 'Follow' then the client MUST NOT go
-	// forward with adding the 'actor' to the original 'actor's 'following'
-	// collection by the client application.
+	// The wrapping function determines if this 'Reject' is in response to
+	// a 'Follow' sent by this actor, verifying it the same way as for an
+	// 'Accept'. If so, then the 'actor' is removed from the original
+	// 'actor's 'following' collection, if present.
+	//
+	// Otherwise, no side effects are done by go-fed.
 	Reject func(context.Context, vocab.ActivityStreamsReject) error
+	// TentativeAccept handles additional side effects for the
+	// TentativeAccept ActivityStreams type, specific to the application
+	// using go-fed.
+	//
+	// The wrapping function verifies a 'TentativeAccept' of a 'Follow'
+	// sent by this actor the same way as for an 'Accept', but has no
+	// other default side effects. The 'actor' is not yet added to the
+	// 'following' collection.
+	TentativeAccept func(context.Context, vocab.ActivityStreamsTentativeAccept) error
+	// TentativeReject handles additional side effects for the
+	// TentativeReject ActivityStreams type, specific to the application
+	// using go-fed.
+	//
+	// The wrapping function verifies a 'TentativeReject' of a 'Follow'
+	// sent by this actor the same way as for an 'Accept', but has no
+	// other default side effects.
+	TentativeReject func(context.Context, vocab.ActivityStreamsTentativeReject) error
 	// Add handles additional side effects for the Add ActivityStreams
 	// type, specific to the application using go-fed.
 	//
@@ -112,9 +160,19 @@ type FederatingWrappedCallbacks struct {
 	// It enforces that the actors on the Undo must correspond to all of the
 	// 'object' actors in some manner.
 	//
-	// It is expected that the application will implement the proper
-	// reversal of activities that are being undone.
+	// The wrapping function then reverses the default side effects of the
+	// activities being undone: an undone 'Follow' of this actor removes
+	// its actors from the 'followers' collection, or from the pending
+	// Follows if it was not yet approved, and an undone 'Like' or
+	// 'Announce' is removed from the 'likes' or 'shares' collection of
+	// the objects owned by this server. Any other reversal is left to the
+	// application.
 	Undo func(context.Context, vocab.ActivityStreamsUndo) error
+	// SkipUndo optionally determines whether the default side effects of
+	// an activity being undone should be left in place. If it returns
+	// true, the wrapping Undo function does not reverse them, but the
+	// Undo callback is still called.
+	SkipUndo func(c context.Context, undone vocab.Type) bool
 	// Block handles additional side effects for the Block ActivityStreams
 	// type, specific to the application using go-fed.
 	//
@@ -123,6 +181,24 @@ type FederatingWrappedCallbacks struct {
 	// received from a federated peer, as delivering Blocks explicitly
 	// deviates from the original ActivityPub specification.
 	Block func(context.Context, vocab.ActivityStreamsBlock) error
+	// Move handles additional side effects for the Move ActivityStreams
+	// type, specific to the application using go-fed.
+	//
+	// The wrapping function ensures the Move was sent by the actor being
+	// moved, and that the 'target' actor lists the moved actor in its
+	// 'alsoKnownAs' property, as fetched from the target's server. Then,
+	// if the actor owning this inbox follows the moved actor, the moved
+	// actor is removed from its 'following' collection and sent an Undo
+	// of its Follow, as found if the Database is an OutboxFollowFinder,
+	// and a Follow of the target is sent. The target is added to the
+	// 'following' collection once it accepts the Follow.
+	Move func(context.Context, vocab.ActivityStreamsMove) error
+	// SkipMove optionally lets the application veto moving the following
+	// of the actor owning this inbox from the origin to the target of a
+	// verified Move. If it returns true, the 'following' collection is left
+	// unchanged and no Undo nor Follow is sent, but the Move callback is
+	// still called.
+	SkipMove func(c context.Context, actorIRI, origin, target *url.URL) bool
 
 	// Sidechannel data -- this is set at request handling time. These must
 	// be set before the callbacks are used.
@@ -131,6 +207,11 @@ type FederatingWrappedCallbacks struct {
 	db Database
 	// inboxIRI is the inboxIRI that is handling this callback.
 	inboxIRI *url.URL
+	// inboxIRIs are all the inboxes handling this callback when the
+	// activity was received in the shared inbox, the first of which is
+	// inboxIRI. The side effects specific to the actor owning the inbox
+	// are applied for each of them.
+	inboxIRIs []*url.URL
 	// addNewIds creates new 'id' entries on an activity and its objects if
 	// it is a Create activity.
 	addNewIds func(c context.Context, activity Activity) error
@@ -138,6 +219,25 @@ type FederatingWrappedCallbacks struct {
 	deliver func(c context.Context, outboxIRI *url.URL, activity Activity) error
 	// newTransport creates a new Transport.
 	newTransport func(c context.Context, actorBoxIRI *url.URL, gofedAgent string) (t Transport, err error)
+	// newDereferenceTransport creates a new Transport for dereferencing
+	// values in the background, such as with the InstanceActor.
+	newDereferenceTransport func(c context.Context, actorBoxIRI *url.URL, gofedAgent string) (t Transport, err error)
+	// keys is the optional PublicKeyStore of peers' public keys.
+	keys PublicKeyStore
+	// actorCache is the optional ActorCache of peers' actors and
+	// collections.
+	actorCache ActorCache
+	// pendingFollows is the optional PendingFollowStore of Follows
+	// awaiting approval.
+	pendingFollows PendingFollowStore
+	// votes is the optional VoteStore of the votes tallied on local
+	// Questions.
+	votes VoteStore
+	// clock is the server's clock.
+	clock Clock
+	// replies determines whether the 'replies' collections of local
+	// objects are maintained.
+	replies bool
 }
 
 // callbacks returns the WrappedCallbacks members into a single interface slice
@@ -152,12 +252,15 @@ func (w FederatingWrappedCallbacks) callbacks(fns []interface{}) []interface{} {
 	enableFollow := true
 	enableAccept := true
 	enableReject := true
+	enableTentativeAccept := true
+	enableTentativeReject := true
 	enableAdd := true
 	enableRemove := true
 	enableLike := true
 	enableAnnounce := true
 	enableUndo := true
 	enableBlock := true
+	enableMove := true
 	for _, fn := range fns {
 		switch fn.(type) {
 		default:
@@ -174,6 +277,10 @@ func (w FederatingWrappedCallbacks) callbacks(fns []interface{}) []interface{} {
 			enableAccept = false
 		case func(context.Context, vocab.ActivityStreamsReject) error:
 			enableReject = false
+		case func(context.Context, vocab.ActivityStreamsTentativeAccept) error:
+			enableTentativeAccept = false
+		case func(context.Context, vocab.ActivityStreamsTentativeReject) error:
+			enableTentativeReject = false
 		case func(context.Context, vocab.ActivityStreamsAdd) error:
 			enableAdd = false
 		case func(context.Context, vocab.ActivityStreamsRemove) error:
@@ -186,6 +293,8 @@ func (w FederatingWrappedCallbacks) callbacks(fns []interface{}) []interface{} {
 			enableUndo = false
 		case func(context.Context, vocab.ActivityStreamsBlock) error:
 			enableBlock = false
+		case func(context.Context, vocab.ActivityStreamsMove) error:
+			enableMove = false
 		}
 	}
 	if enableCreate {
@@ -198,13 +307,29 @@ func (w FederatingWrappedCallbacks) callbacks(fns []interface{}) []interface{} {
 		fns = append(fns, w.deleteFn)
 	}
 	if enableFollow {
-		fns = append(fns, w.follow)
+		fns = append(fns, func(c context.Context, a vocab.ActivityStreamsFollow) error {
+			return w.forEachInbox(func(w FederatingWrappedCallbacks) error { return w.follow(c, a) })
+		})
 	}
 	if enableAccept {
-		fns = append(fns, w.accept)
+		fns = append(fns, func(c context.Context, a vocab.ActivityStreamsAccept) error {
+			return w.forEachInbox(func(w FederatingWrappedCallbacks) error { return w.accept(c, a) })
+		})
 	}
 	if enableReject {
-		fns = append(fns, w.reject)
+		fns = append(fns, func(c context.Context, a vocab.ActivityStreamsReject) error {
+			return w.forEachInbox(func(w FederatingWrappedCallbacks) error { return w.reject(c, a) })
+		})
+	}
+	if enableTentativeAccept {
+		fns = append(fns, func(c context.Context, a vocab.ActivityStreamsTentativeAccept) error {
+			return w.forEachInbox(func(w FederatingWrappedCallbacks) error { return w.tentativeAccept(c, a) })
+		})
+	}
+	if enableTentativeReject {
+		fns = append(fns, func(c context.Context, a vocab.ActivityStreamsTentativeReject) error {
+			return w.forEachInbox(func(w FederatingWrappedCallbacks) error { return w.tentativeReject(c, a) })
+		})
 	}
 	if enableAdd {
 		fns = append(fns, w.add)
@@ -219,20 +344,44 @@ func (w FederatingWrappedCallbacks) callbacks(fns []interface{}) []interface{} {
 		fns = append(fns, w.announce)
 	}
 	if enableUndo {
-		fns = append(fns, w.undo)
+		fns = append(fns, func(c context.Context, a vocab.ActivityStreamsUndo) error {
+			return w.forEachInbox(func(w FederatingWrappedCallbacks) error { return w.undo(c, a) })
+		})
 	}
 	if enableBlock {
 		fns = append(fns, w.block)
 	}
+	if enableMove {
+		fns = append(fns, func(c context.Context, a vocab.ActivityStreamsMove) error {
+			return w.forEachInbox(func(w FederatingWrappedCallbacks) error { return w.move(c, a) })
+		})
+	}
 	return fns
 }
 
+// forEachInbox calls fn with the callbacks of each inbox handling the
+// activity, for the side effects specific to the actor owning the inbox, such
+// as its 'followers' and 'following' collections.
+func (w FederatingWrappedCallbacks) forEachInbox(fn func(w FederatingWrappedCallbacks) error) error {
+	if len(w.inboxIRIs) == 0 {
+		return fn(w)
+	}
+	for _, inboxIRI := range w.inboxIRIs {
+		w.inboxIRI = inboxIRI
+		if err := fn(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // create implements the federating Create activity side effects.
 func (w FederatingWrappedCallbacks) create(c context.Context, a vocab.ActivityStreamsCreate) error {
 	op := a.GetActivityStreamsObject()
 	if op == nil || op.Len() == 0 {
 		return ErrObjectRequired
 	}
+	var objects []vocab.Type
 	// Create anonymous loop function to be able to properly scope the defer
 	// for the database lock at each iteration.
 	loopFn := func(iter vocab.ActivityStreamsObjectPropertyIterator) error {
@@ -270,6 +419,7 @@ func (w FederatingWrappedCallbacks) create(c context.Context, a vocab.ActivitySt
 		if err := w.db.Create(c, t); err != nil {
 			return err
 		}
+		objects = append(objects, t)
 		return nil
 	}
 	for iter := op.Begin(); iter != op.End(); iter = iter.Next() {
@@ -277,12 +427,180 @@ func (w FederatingWrappedCallbacks) create(c context.Context, a vocab.ActivitySt
 			return err
 		}
 	}
+	if w.replies {
+		for _, t := range objects {
+			if err := addToReplies(c, t, w.db); err != nil {
+				return err
+			}
+		}
+	}
+	if w.votes != nil {
+		// The inbox of the Question's author tallies the votes, and may
+		// be any of the inboxes handling the Create.
+		err := w.forEachInbox(func(w FederatingWrappedCallbacks) error {
+			return w.tallyVotes(c, a, objects)
+		})
+		if err != nil {
+			return err
+		}
+	}
 	if w.Create != nil {
 		return w.Create(c, a)
 	}
 	return nil
 }
 
+// tallyVotes tallies the votes among the created objects on the open Questions
+// owned by the actor of this inbox, then sends an Update of each Question whose
+// tally changed to the actor's followers.
+func (w FederatingWrappedCallbacks) tallyVotes(c context.Context, a vocab.ActivityStreamsCreate, objects []vocab.Type) error {
+	voters, err := getActorIds(a)
+	if err != nil {
+		return err
+	}
+	// Get this actor's id and outbox.
+	if err = w.db.Lock(c, w.inboxIRI); err != nil {
+		return err
+	}
+	// WARNING: Unlock not deferred.
+	actorIRI, err := w.db.ActorForInbox(c, w.inboxIRI)
+	if err != nil {
+		w.db.Unlock(c, w.inboxIRI)
+		return err
+	}
+	outboxIRI, err := w.db.OutboxForInbox(c, w.inboxIRI)
+	if err != nil {
+		w.db.Unlock(c, w.inboxIRI)
+		return err
+	}
+	w.db.Unlock(c, w.inboxIRI)
+	// Unlock must be called by now and every branch above.
+	var tallied []vocab.ActivityStreamsQuestion
+	// Create anonymous loop function to be able to properly scope the defer
+	// for the database lock at each iteration.
+	loopFn := func(t vocab.Type) error {
+		questionIRI, name, ok := getVote(t)
+		if !ok {
+			return nil
+		}
+		if err := w.db.Lock(c, questionIRI); err != nil {
+			return err
+		}
+		defer w.db.Unlock(c, questionIRI)
+		if owns, err := w.db.Owns(c, questionIRI); err != nil {
+			return err
+		} else if !owns {
+			return nil
+		}
+		qt, err := w.db.Get(c, questionIRI)
+		if err != nil {
+			return err
+		}
+		q, ok := qt.(vocab.ActivityStreamsQuestion)
+		if !ok || isQuestionClosed(q, w.clock.Now()) {
+			return nil
+		}
+		// Only the Question's author tallies the votes, as they may be
+		// delivered to other local actors too.
+		attrTo := q.GetActivityStreamsAttributedTo()
+		if attrTo == nil {
+			return nil
+		}
+		isMine := false
+		for iter := attrTo.Begin(); iter != attrTo.End(); iter = iter.Next() {
+			id, err := ToId(iter)
+			if err != nil {
+				return err
+			}
+			if id.String() == actorIRI.String() {
+				isMine = true
+				break
+			}
+		}
+		if !isMine {
+			return nil
+		}
+		option, oneOf := getQuestionOption(q, name)
+		if option == nil {
+			return nil
+		}
+		changed := false
+		for _, voter := range voters {
+			names, err := w.votes.Votes(c, questionIRI, voter)
+			if err != nil {
+				return err
+			}
+			alreadyVoted := oneOf && len(names) > 0
+			for _, n := range names {
+				if n == name {
+					alreadyVoted = true
+					break
+				}
+			}
+			if alreadyVoted {
+				continue
+			}
+			if err = w.votes.AddVote(c, questionIRI, voter, name); err != nil {
+				return err
+			}
+			if err = addQuestionVote(q, option, len(names) == 0); err != nil {
+				return err
+			}
+			changed = true
+		}
+		if !changed {
+			return nil
+		}
+		if err = w.db.Update(c, q); err != nil {
+			return err
+		}
+		tallied = append(tallied, q)
+		return nil
+	}
+	for _, t := range objects {
+		if err := loopFn(t); err != nil {
+			return err
+		}
+	}
+	if len(tallied) == 0 {
+		return nil
+	}
+	// Send the new tallies to the followers.
+	if err = w.db.Lock(c, actorIRI); err != nil {
+		return err
+	}
+	// WARNING: Unlock not deferred.
+	followers, err := w.db.Followers(c, actorIRI)
+	if err != nil {
+		w.db.Unlock(c, actorIRI)
+		return err
+	}
+	w.db.Unlock(c, actorIRI)
+	// Unlock must be called by now and every branch above.
+	followersId, err := GetId(followers)
+	if err != nil {
+		return err
+	}
+	for _, q := range tallied {
+		update := streams.NewActivityStreamsUpdate()
+		me := streams.NewActivityStreamsActorProperty()
+		me.AppendIRI(actorIRI)
+		update.SetActivityStreamsActor(me)
+		op := streams.NewActivityStreamsObjectProperty()
+		op.AppendActivityStreamsQuestion(q)
+		update.SetActivityStreamsObject(op)
+		to := streams.NewActivityStreamsToProperty()
+		to.AppendIRI(followersId)
+		update.SetActivityStreamsTo(to)
+		if err = w.addNewIds(c, update); err != nil {
+			return err
+		} else if err = w.deliver(c, outboxIRI, update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // update implements the federating Update activity side effects.
 func (w FederatingWrappedCallbacks) update(c context.Context, a vocab.ActivityStreamsUpdate) error {
 	op := a.GetActivityStreamsObject()
@@ -311,6 +629,14 @@ func (w FederatingWrappedCallbacks) update(c context.Context, a vocab.ActivitySt
 		if err := w.db.Update(c, t); err != nil {
 			return err
 		}
+		if w.keys != nil {
+			if err := w.keys.InvalidateOwner(c, id); err != nil {
+				return err
+			}
+		}
+		if w.actorCache != nil {
+			return w.actorCache.Invalidate(c, id)
+		}
 		return nil
 	}
 	for iter := op.Begin(); iter != op.End(); iter = iter.Next() {
@@ -333,6 +659,7 @@ func (w FederatingWrappedCallbacks) deleteFn(c context.Context, a vocab.Activity
 	if err := mustHaveActivityOriginMatchObjects(a); err != nil {
 		return err
 	}
+	var deleted []vocab.Type
 	// Create anonymous loop function to be able to properly scope the defer
 	// for the database lock at each iteration.
 	loopFn := func(iter vocab.ActivityStreamsObjectPropertyIterator) error {
@@ -345,9 +672,29 @@ func (w FederatingWrappedCallbacks) deleteFn(c context.Context, a vocab.Activity
 			return err
 		}
 		defer w.db.Unlock(c, id)
+		if w.replies {
+			if exists, err := w.db.Exists(c, id); err != nil {
+				return err
+			} else if exists {
+				t, err := w.db.Get(c, id)
+				if err != nil {
+					return err
+				}
+				deleted = append(deleted, t)
+			}
+		}
 		if err := w.db.Delete(c, id); err != nil {
 			return err
 		}
+		// Only ids owning cached keys are revoked by the store.
+		if w.keys != nil {
+			if err := w.keys.RevokeOwner(c, id); err != nil {
+				return err
+			}
+		}
+		if w.actorCache != nil {
+			return w.actorCache.Invalidate(c, id)
+		}
 		return nil
 	}
 	for iter := op.Begin(); iter != op.End(); iter = iter.Next() {
@@ -355,6 +702,11 @@ func (w FederatingWrappedCallbacks) deleteFn(c context.Context, a vocab.Activity
 			return err
 		}
 	}
+	for _, t := range deleted {
+		if err := removeFromReplies(c, t, w.db); err != nil {
+			return err
+		}
+	}
 	if w.Delete != nil {
 		return w.Delete(c, a)
 	}
@@ -396,66 +748,34 @@ func (w FederatingWrappedCallbacks) follow(c context.Context, a vocab.ActivitySt
 			}
 		}
 	}
-	if isMe {
+	if isMe && w.OnFollow == OnFollowPendingApproval {
+		if w.pendingFollows == nil {
+			return fmt.Errorf("OnFollowPendingApproval requires a PendingFollowStore")
+		}
+		if err := w.pendingFollows.Add(c, actorIRI, a); err != nil {
+			return err
+		}
+	} else if isMe {
 		// Prepare the response.
-		var response Activity
+		var accept bool
 		if w.OnFollow == OnFollowAutomaticallyAccept {
-			response = streams.NewActivityStreamsAccept()
-		} else if w.OnFollow == OnFollowAutomaticallyReject {
-			response = streams.NewActivityStreamsReject()
-		} else {
+			accept = true
+		} else if w.OnFollow != OnFollowAutomaticallyReject {
 			return fmt.Errorf("unknown OnFollowBehavior: %d", w.OnFollow)
 		}
-		// Set us as the 'actor'.
-		me := streams.NewActivityStreamsActorProperty()
-		response.SetActivityStreamsActor(me)
-		me.AppendIRI(actorIRI)
-		// Set the Follow as the 'object' property.
-		op := streams.NewActivityStreamsObjectProperty()
-		response.SetActivityStreamsObject(op)
-		op.AppendActivityStreamsFollow(a)
-		// Add all actors on the original Follow to the 'to' property.
-		recipients := make([]*url.URL, 0)
-		to := streams.NewActivityStreamsToProperty()
-		response.SetActivityStreamsTo(to)
-		followActors := a.GetActivityStreamsActor()
-		for iter := followActors.Begin(); iter != followActors.End(); iter = iter.Next() {
-			id, err := ToId(iter)
-			if err != nil {
-				return err
-			}
-			to.AppendIRI(id)
-			recipients = append(recipients, id)
+		response, recipients, err := newFollowResponse(actorIRI, a, accept)
+		if err != nil {
+			return err
 		}
-		if w.OnFollow == OnFollowAutomaticallyAccept {
+		if accept {
 			// If automatically accepting, then also update our
 			// followers collection with the new actors.
 			//
 			// If automatically rejecting, do not update the
 			// followers collection.
-			if err := w.db.Lock(c, actorIRI); err != nil {
-				return err
-			}
-			// WARNING: Unlock not deferred.
-			followers, err := w.db.Followers(c, actorIRI)
-			if err != nil {
-				w.db.Unlock(c, actorIRI)
+			if err := addFollowers(c, w.db, actorIRI, recipients); err != nil {
 				return err
 			}
-			items := followers.GetActivityStreamsItems()
-			if items == nil {
-				items = streams.NewActivityStreamsItemsProperty()
-				followers.SetActivityStreamsItems(items)
-			}
-			for _, elem := range recipients {
-				items.PrependIRI(elem)
-			}
-			if err = w.db.Update(c, followers); err != nil {
-				w.db.Unlock(c, actorIRI)
-				return err
-			}
-			w.db.Unlock(c, actorIRI)
-			// Unlock must be called by now and every branch above.
 		}
 		// Lock without defer!
 		w.db.Lock(c, w.inboxIRI)
@@ -480,184 +800,43 @@ func (w FederatingWrappedCallbacks) follow(c context.Context, a vocab.ActivitySt
 
 // accept implements the federating Accept activity side effects.
 func (w FederatingWrappedCallbacks) accept(c context.Context, a vocab.ActivityStreamsAccept) error {
-	op := a.GetActivityStreamsObject()
-	if op != nil && op.Len() > 0 {
-		// Get this actor's id.
-		if err := w.db.Lock(c, w.inboxIRI); err != nil {
+	actorIRI, follow, err := w.myFollowInResponse(c, "Accept", a.GetActivityStreamsActor(), a.GetActivityStreamsObject())
+	if err != nil {
+		return err
+	}
+	// If we received an Accept whose 'object' is a Follow with an
+	// Accept that we sent, add to the following collection.
+	if follow != nil {
+		activityActors := a.GetActivityStreamsActor()
+		// Add the peer to our following collection.
+		if err := w.db.Lock(c, actorIRI); err != nil {
 			return err
 		}
 		// WARNING: Unlock not deferred.
-		actorIRI, err := w.db.ActorForInbox(c, w.inboxIRI)
+		following, err := w.db.Following(c, actorIRI)
 		if err != nil {
-			w.db.Unlock(c, w.inboxIRI)
+			w.db.Unlock(c, actorIRI)
 			return err
 		}
-		w.db.Unlock(c, w.inboxIRI)
-		// Unlock must be called by now and every branch above.
-		//
-		// Determine if we are in a follow on the 'object' property.
-		//
-		// TODO: Handle Accept multiple Follow.
-		var maybeMyFollowIRI *url.URL
-		for iter := op.Begin(); iter != op.End(); iter = iter.Next() {
-			t := iter.GetType()
-			if t == nil && iter.IsIRI() {
-				// Attempt to dereference the IRI instead
-				tport, err := w.newTransport(c, w.inboxIRI, goFedUserAgent())
-				if err != nil {
-					return err
-				}
-				b, err := tport.Dereference(c, iter.GetIRI())
-				if err != nil {
-					return err
-				}
-				var m map[string]interface{}
-				if err = json.Unmarshal(b, &m); err != nil {
-					return err
-				}
-				t, err = streams.ToType(c, m)
-				if err != nil {
-					return err
-				}
-			} else if t == nil {
-				return fmt.Errorf("cannot handle federated create: object is neither a value nor IRI")
-			}
-			// Ensure it is a Follow.
-			if !streams.IsOrExtendsActivityStreamsFollow(t) {
-				continue
-			}
-			follow, ok := t.(Activity)
-			if !ok {
-				return fmt.Errorf("a Follow in an Accept does not satisfy the Activity interface")
-			}
-			followId, err := GetId(follow)
-			if err != nil {
-				return err
-			}
-			// Ensure that we are one of the actors on the Follow.
-			actors := follow.GetActivityStreamsActor()
-			for iter := actors.Begin(); iter != actors.End(); iter = iter.Next() {
-				id, err := ToId(iter)
-				if err != nil {
-					return err
-				}
-				if id.String() == actorIRI.String() {
-					maybeMyFollowIRI = followId
-					break
-				}
-			}
-			// Continue breaking if we found ourselves
-			if maybeMyFollowIRI != nil {
-				break
-			}
+		items := following.GetActivityStreamsItems()
+		if items == nil {
+			items = streams.NewActivityStreamsItemsProperty()
+			following.SetActivityStreamsItems(items)
 		}
-		// If we received an Accept whose 'object' is a Follow with an
-		// Accept that we sent, add to the following collection.
-		if maybeMyFollowIRI != nil {
-			// Verify our Follow request exists and the peer didn't
-			// fabricate it.
-			activityActors := a.GetActivityStreamsActor()
-			if activityActors == nil || activityActors.Len() == 0 {
-				return fmt.Errorf("an Accept with a Follow has no actors")
-			}
-			// This may be a duplicate check if we dereferenced the
-			// Follow above. TODO: Separate this logic to avoid
-			// redundancy.
-			//
-			// Use an anonymous function to properly scope the
-			// database lock, immediately call it.
-			err = func() error {
-				if err := w.db.Lock(c, maybeMyFollowIRI); err != nil {
-					return err
-				}
-				defer w.db.Unlock(c, maybeMyFollowIRI)
-				t, err := w.db.Get(c, maybeMyFollowIRI)
-				if err != nil {
-					return err
-				}
-				if !streams.IsOrExtendsActivityStreamsFollow(t) {
-					return fmt.Errorf("peer gave an Accept wrapping a Follow but provided a non-Follow id")
-				}
-				follow, ok := t.(Activity)
-				if !ok {
-					return fmt.Errorf("a Follow in an Accept does not satisfy the Activity interface")
-				}
-				// Ensure that we are one of the actors on the Follow.
-				ok = false
-				actors := follow.GetActivityStreamsActor()
-				for iter := actors.Begin(); iter != actors.End(); iter = iter.Next() {
-					id, err := ToId(iter)
-					if err != nil {
-						return err
-					}
-					if id.String() == actorIRI.String() {
-						ok = true
-						break
-					}
-				}
-				if !ok {
-					return fmt.Errorf("peer gave an Accept wrapping a Follow but we are not the actor on that Follow")
-				}
-				// Build map of original Accept actors
-				acceptActors := make(map[string]bool)
-				for iter := activityActors.Begin(); iter != activityActors.End(); iter = iter.Next() {
-					id, err := ToId(iter)
-					if err != nil {
-						return err
-					}
-					acceptActors[id.String()] = false
-				}
-				// Verify all actor(s) were on the original Follow.
-				followObj := follow.GetActivityStreamsObject()
-				for iter := followObj.Begin(); iter != followObj.End(); iter = iter.Next() {
-					id, err := ToId(iter)
-					if err != nil {
-						return err
-					}
-					if _, ok := acceptActors[id.String()]; ok {
-						acceptActors[id.String()] = true
-					}
-				}
-				for _, found := range acceptActors {
-					if !found {
-						return fmt.Errorf("peer gave an Accept wrapping a Follow but was not an object in the original Follow")
-					}
-				}
-				return nil
-			}()
-			if err != nil {
-				return err
-			}
-			// Add the peer to our following collection.
-			if err := w.db.Lock(c, actorIRI); err != nil {
-				return err
-			}
-			// WARNING: Unlock not deferred.
-			following, err := w.db.Following(c, actorIRI)
+		for iter := activityActors.Begin(); iter != activityActors.End(); iter = iter.Next() {
+			id, err := ToId(iter)
 			if err != nil {
 				w.db.Unlock(c, actorIRI)
 				return err
 			}
-			items := following.GetActivityStreamsItems()
-			if items == nil {
-				items = streams.NewActivityStreamsItemsProperty()
-				following.SetActivityStreamsItems(items)
-			}
-			for iter := activityActors.Begin(); iter != activityActors.End(); iter = iter.Next() {
-				id, err := ToId(iter)
-				if err != nil {
-					w.db.Unlock(c, actorIRI)
-					return err
-				}
-				items.PrependIRI(id)
-			}
-			if err = w.db.Update(c, following); err != nil {
-				w.db.Unlock(c, actorIRI)
-				return err
-			}
+			items.PrependIRI(id)
+		}
+		if err = w.db.Update(c, following); err != nil {
 			w.db.Unlock(c, actorIRI)
-			// Unlock must be called by now and every branch above.
+			return err
 		}
+		w.db.Unlock(c, actorIRI)
+		// Unlock must be called by now and every branch above.
 	}
 	if w.Accept != nil {
 		return w.Accept(c, a)
@@ -667,12 +846,217 @@ func (w FederatingWrappedCallbacks) accept(c context.Context, a vocab.ActivitySt
 
 // reject implements the federating Reject activity side effects.
 func (w FederatingWrappedCallbacks) reject(c context.Context, a vocab.ActivityStreamsReject) error {
+	actorIRI, follow, err := w.myFollowInResponse(c, "Reject", a.GetActivityStreamsActor(), a.GetActivityStreamsObject())
+	if err != nil {
+		return err
+	}
+	// If we received a Reject whose 'object' is a Follow that we sent,
+	// ensure the peer is not in the following collection.
+	if follow != nil {
+		var ids []*url.URL
+		activityActors := a.GetActivityStreamsActor()
+		for iter := activityActors.Begin(); iter != activityActors.End(); iter = iter.Next() {
+			id, err := ToId(iter)
+			if err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+		if err := removeFromActorCollection(c, actorIRI, ids, w.db.Following, w.db); err != nil {
+			return err
+		}
+	}
 	if w.Reject != nil {
 		return w.Reject(c, a)
 	}
 	return nil
 }
 
+// tentativeAccept implements the federating TentativeAccept activity side
+// effects.
+func (w FederatingWrappedCallbacks) tentativeAccept(c context.Context, a vocab.ActivityStreamsTentativeAccept) error {
+	if _, _, err := w.myFollowInResponse(c, "TentativeAccept", a.GetActivityStreamsActor(), a.GetActivityStreamsObject()); err != nil {
+		return err
+	}
+	if w.TentativeAccept != nil {
+		return w.TentativeAccept(c, a)
+	}
+	return nil
+}
+
+// tentativeReject implements the federating TentativeReject activity side
+// effects.
+func (w FederatingWrappedCallbacks) tentativeReject(c context.Context, a vocab.ActivityStreamsTentativeReject) error {
+	if _, _, err := w.myFollowInResponse(c, "TentativeReject", a.GetActivityStreamsActor(), a.GetActivityStreamsObject()); err != nil {
+		return err
+	}
+	if w.TentativeReject != nil {
+		return w.TentativeReject(c, a)
+	}
+	return nil
+}
+
+// myFollowInResponse finds a Follow sent by the actor of this inbox in the
+// 'object' of a response to it, such as an Accept or Reject. The Follow is
+// verified against the one in the database, so that a peer cannot fabricate
+// it, and all of the response's actors must be an 'object' of the Follow.
+//
+// The returned Follow is nil if the response is not to a Follow sent by the
+// actor of this inbox. The responseType names the response in errors.
+func (w FederatingWrappedCallbacks) myFollowInResponse(c context.Context,
+	responseType string,
+	activityActors vocab.ActivityStreamsActorProperty,
+	op vocab.ActivityStreamsObjectProperty) (actorIRI *url.URL, follow Activity, err error) {
+	if op == nil || op.Len() == 0 {
+		return
+	}
+	// Get this actor's id.
+	if err = w.db.Lock(c, w.inboxIRI); err != nil {
+		return
+	}
+	// WARNING: Unlock not deferred.
+	actorIRI, err = w.db.ActorForInbox(c, w.inboxIRI)
+	if err != nil {
+		w.db.Unlock(c, w.inboxIRI)
+		return
+	}
+	w.db.Unlock(c, w.inboxIRI)
+	// Unlock must be called by now and every branch above.
+	//
+	// Determine if we are in a follow on the 'object' property.
+	//
+	// TODO: Handle responses to multiple Follows.
+	var maybeMyFollowIRI *url.URL
+	for iter := op.Begin(); iter != op.End(); iter = iter.Next() {
+		t := iter.GetType()
+		if t == nil && iter.IsIRI() {
+			// Attempt to dereference the IRI instead
+			var tport Transport
+			tport, err = w.newTransport(c, w.inboxIRI, goFedUserAgent())
+			if err != nil {
+				return
+			}
+			var b []byte
+			b, err = tport.Dereference(c, iter.GetIRI())
+			if err != nil {
+				return
+			}
+			var m map[string]interface{}
+			if err = json.Unmarshal(b, &m); err != nil {
+				return
+			}
+			t, err = streams.ToType(c, m)
+			if err != nil {
+				return
+			}
+		} else if t == nil {
+			err = fmt.Errorf("cannot handle federated %s: object is neither a value nor IRI", responseType)
+			return
+		}
+		// Ensure it is a Follow.
+		if !streams.IsOrExtendsActivityStreamsFollow(t) {
+			continue
+		}
+		f, ok := t.(Activity)
+		if !ok {
+			err = fmt.Errorf("a Follow in an %s does not satisfy the Activity interface", responseType)
+			return
+		}
+		var followId *url.URL
+		followId, err = GetId(f)
+		if err != nil {
+			return
+		}
+		// Ensure that we are one of the actors on the Follow.
+		actors := f.GetActivityStreamsActor()
+		for iter := actors.Begin(); iter != actors.End(); iter = iter.Next() {
+			var id *url.URL
+			id, err = ToId(iter)
+			if err != nil {
+				return
+			}
+			if id.String() == actorIRI.String() {
+				maybeMyFollowIRI = followId
+				break
+			}
+		}
+		// Continue breaking if we found ourselves
+		if maybeMyFollowIRI != nil {
+			break
+		}
+	}
+	if maybeMyFollowIRI == nil {
+		return
+	}
+	// Verify our Follow request exists and the peer didn't fabricate it.
+	if activityActors == nil || activityActors.Len() == 0 {
+		err = fmt.Errorf("an %s with a Follow has no actors", responseType)
+		return
+	}
+	// Use an anonymous function to properly scope the database lock,
+	// immediately call it.
+	follow, err = func() (Activity, error) {
+		if err := w.db.Lock(c, maybeMyFollowIRI); err != nil {
+			return nil, err
+		}
+		defer w.db.Unlock(c, maybeMyFollowIRI)
+		t, err := w.db.Get(c, maybeMyFollowIRI)
+		if err != nil {
+			return nil, err
+		}
+		if !streams.IsOrExtendsActivityStreamsFollow(t) {
+			return nil, fmt.Errorf("peer gave an %s wrapping a Follow but provided a non-Follow id", responseType)
+		}
+		follow, ok := t.(Activity)
+		if !ok {
+			return nil, fmt.Errorf("a Follow in an %s does not satisfy the Activity interface", responseType)
+		}
+		// Ensure that we are one of the actors on the Follow.
+		ok = false
+		actors := follow.GetActivityStreamsActor()
+		for iter := actors.Begin(); iter != actors.End(); iter = iter.Next() {
+			id, err := ToId(iter)
+			if err != nil {
+				return nil, err
+			}
+			if id.String() == actorIRI.String() {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("peer gave an %s wrapping a Follow but we are not the actor on that Follow", responseType)
+		}
+		// Build map of original response actors
+		responseActors := make(map[string]bool)
+		for iter := activityActors.Begin(); iter != activityActors.End(); iter = iter.Next() {
+			id, err := ToId(iter)
+			if err != nil {
+				return nil, err
+			}
+			responseActors[id.String()] = false
+		}
+		// Verify all actor(s) were on the original Follow.
+		followObj := follow.GetActivityStreamsObject()
+		for iter := followObj.Begin(); iter != followObj.End(); iter = iter.Next() {
+			id, err := ToId(iter)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := responseActors[id.String()]; ok {
+				responseActors[id.String()] = true
+			}
+		}
+		for _, found := range responseActors {
+			if !found {
+				return nil, fmt.Errorf("peer gave an %s wrapping a Follow but was not an object in the original Follow", responseType)
+			}
+		}
+		return follow, nil
+	}()
+	return
+}
+
 // add implements the federating Add activity side effects.
 func (w FederatingWrappedCallbacks) add(c context.Context, a vocab.ActivityStreamsAdd) error {
 	op := a.GetActivityStreamsObject()
@@ -885,15 +1269,100 @@ func (w FederatingWrappedCallbacks) undo(c context.Context, a vocab.ActivityStre
 		return ErrObjectRequired
 	}
 	actors := a.GetActivityStreamsActor()
-	if err := mustHaveActivityActorsMatchObjectActors(c, actors, op, w.newTransport, w.inboxIRI); err != nil {
+	undone, err := mustHaveActivityActorsMatchObjectActors(c, actors, op, w.newDereferenceTransport, w.inboxIRI)
+	if err != nil {
 		return err
 	}
+	for _, t := range undone {
+		if w.SkipUndo != nil && w.SkipUndo(c, t) {
+			continue
+		}
+		if err := w.undoSideEffects(c, t); err != nil {
+			return err
+		}
+	}
 	if w.Undo != nil {
 		return w.Undo(c, a)
 	}
 	return nil
 }
 
+// undoSideEffects reverses the federating side effects of an undone Follow,
+// Like, or Announce. Other types have no side effects to reverse.
+func (w FederatingWrappedCallbacks) undoSideEffects(c context.Context, t vocab.Type) error {
+	activity, ok := t.(Activity)
+	if !ok {
+		return nil
+	}
+	if streams.IsOrExtendsActivityStreamsFollow(t) {
+		// Get this actor's IRI.
+		if err := w.db.Lock(c, w.inboxIRI); err != nil {
+			return err
+		}
+		// WARNING: Unlock not deferred.
+		actorIRI, err := w.db.ActorForInbox(c, w.inboxIRI)
+		if err != nil {
+			w.db.Unlock(c, w.inboxIRI)
+			return err
+		}
+		w.db.Unlock(c, w.inboxIRI)
+		// Unlock must be called by now and every branch above.
+		//
+		// Only a Follow of this actor added to its 'followers'.
+		isMe := false
+		if op := activity.GetActivityStreamsObject(); op != nil {
+			for iter := op.Begin(); iter != op.End(); iter = iter.Next() {
+				id, err := ToId(iter)
+				if err != nil {
+					return err
+				}
+				if id.String() == actorIRI.String() {
+					isMe = true
+					break
+				}
+			}
+		}
+		if !isMe {
+			return nil
+		}
+		if w.pendingFollows != nil {
+			id, err := GetId(t)
+			if err != nil {
+				return err
+			}
+			if _, pending, err := w.pendingFollows.Remove(c, actorIRI, id); err != nil {
+				return err
+			} else if pending {
+				return nil
+			}
+		}
+		var followers []*url.URL
+		if actors := activity.GetActivityStreamsActor(); actors != nil {
+			for iter := actors.Begin(); iter != actors.End(); iter = iter.Next() {
+				id, err := ToId(iter)
+				if err != nil {
+					return err
+				}
+				followers = append(followers, id)
+			}
+		}
+		return removeFromActorCollection(c, actorIRI, followers, w.db.Followers, w.db)
+	} else if streams.IsOrExtendsActivityStreamsLike(t) {
+		id, err := GetId(t)
+		if err != nil {
+			return err
+		}
+		return removeFromOwnedObjects(c, activity.GetActivityStreamsObject(), id, likesCollection, w.db)
+	} else if streams.IsOrExtendsActivityStreamsAnnounce(t) {
+		id, err := GetId(t)
+		if err != nil {
+			return err
+		}
+		return removeFromOwnedObjects(c, activity.GetActivityStreamsObject(), id, sharesCollection, w.db)
+	}
+	return nil
+}
+
 // block implements the federating Block activity side effects.
 func (w FederatingWrappedCallbacks) block(c context.Context, a vocab.ActivityStreamsBlock) error {
 	op := a.GetActivityStreamsObject()
@@ -905,3 +1374,155 @@ func (w FederatingWrappedCallbacks) block(c context.Context, a vocab.ActivityStr
 	}
 	return nil
 }
+
+// move implements the federating Move activity side effects.
+func (w FederatingWrappedCallbacks) move(c context.Context, a vocab.ActivityStreamsMove) error {
+	op := a.GetActivityStreamsObject()
+	if op == nil || op.Len() == 0 {
+		return ErrObjectRequired
+	}
+	tp := a.GetActivityStreamsTarget()
+	if tp == nil || tp.Len() == 0 {
+		return ErrTargetRequired
+	}
+	if op.Len() != 1 || tp.Len() != 1 {
+		return fmt.Errorf("cannot handle federated Move: must have exactly one object and target")
+	}
+	origin, err := ToId(op.At(0))
+	if err != nil {
+		return err
+	}
+	target, err := ToId(tp.At(0))
+	if err != nil {
+		return err
+	}
+	// Ensure the moved actor sent the Move, so that peers cannot move
+	// others' accounts.
+	actors, err := getActorIds(a)
+	if err != nil {
+		return err
+	}
+	isOrigin := false
+	for _, actor := range actors {
+		if actor.String() == origin.String() {
+			isOrigin = true
+			break
+		}
+	}
+	if !isOrigin {
+		return fmt.Errorf("cannot handle federated Move: %s was not moved by one of its actors", origin)
+	}
+	// Fetch the target from its server, as it may not be trusted to be
+	// embedded, and ensure it claims to also be the moved actor.
+	tport, err := w.newDereferenceTransport(c, w.inboxIRI, goFedUserAgent())
+	if err != nil {
+		return err
+	}
+	t, err := FetchAndVerify(c, tport, target)
+	if err != nil {
+		return err
+	}
+	isAlias := false
+	for _, aka := range getAlsoKnownAs(t) {
+		if aka.String() == origin.String() {
+			isAlias = true
+			break
+		}
+	}
+	if !isAlias {
+		return fmt.Errorf("cannot handle federated Move: target %s does not list %s in %s", target, origin, alsoKnownAsProperty)
+	}
+	// Get this actor's id and outbox.
+	if err = w.db.Lock(c, w.inboxIRI); err != nil {
+		return err
+	}
+	// WARNING: Unlock not deferred.
+	actorIRI, err := w.db.ActorForInbox(c, w.inboxIRI)
+	if err != nil {
+		w.db.Unlock(c, w.inboxIRI)
+		return err
+	}
+	outboxIRI, err := w.db.OutboxForInbox(c, w.inboxIRI)
+	if err != nil {
+		w.db.Unlock(c, w.inboxIRI)
+		return err
+	}
+	w.db.Unlock(c, w.inboxIRI)
+	// Unlock must be called by now and every branch above.
+	//
+	// Determine if this actor follows the moved actor.
+	if err = w.db.Lock(c, actorIRI); err != nil {
+		return err
+	}
+	// WARNING: Unlock not deferred.
+	following, err := w.db.Following(c, actorIRI)
+	if err != nil {
+		w.db.Unlock(c, actorIRI)
+		return err
+	}
+	isFollowing := false
+	if items := following.GetActivityStreamsItems(); items != nil {
+		for iter := items.Begin(); iter != items.End(); iter = iter.Next() {
+			id, err := ToId(iter)
+			if err != nil {
+				w.db.Unlock(c, actorIRI)
+				return err
+			}
+			if id.String() == origin.String() {
+				isFollowing = true
+				break
+			}
+		}
+	}
+	w.db.Unlock(c, actorIRI)
+	// Unlock must be called by now and every branch above.
+	if isFollowing && (w.SkipMove == nil || !w.SkipMove(c, actorIRI, origin, target)) {
+		if err = removeFromActorCollection(c, actorIRI, []*url.URL{origin}, w.db.Following, w.db); err != nil {
+			return err
+		}
+		// Undo the Follow of the origin, so that it stops delivering to
+		// this actor.
+		originFollow, err := findOutboxFollow(c, w.db, outboxIRI, actorIRI, origin)
+		if err != nil {
+			return err
+		}
+		undo := newUndo(actorIRI, originFollow, origin)
+		if err = w.createAndDeliver(c, outboxIRI, undo); err != nil {
+			return err
+		}
+		// Save the Follow, so that the target's Accept of it can be
+		// verified.
+		follow := newFollow(actorIRI, target)
+		if err = w.createAndDeliver(c, outboxIRI, follow); err != nil {
+			return err
+		}
+	}
+	if w.Move != nil {
+		return w.Move(c, a)
+	}
+	return nil
+}
+
+// createAndDeliver gives the activity sent on behalf of this actor new ids,
+// saves it in the database, and delivers it from the outbox.
+func (w FederatingWrappedCallbacks) createAndDeliver(c context.Context, outboxIRI *url.URL, activity Activity) error {
+	err := w.addNewIds(c, activity)
+	if err != nil {
+		return err
+	}
+	id, err := GetId(activity)
+	if err != nil {
+		return err
+	}
+	if err = w.db.Lock(c, id); err != nil {
+		return err
+	}
+	// WARNING: Unlock not deferred.
+	if err = w.db.Create(c, activity); err != nil {
+		w.db.Unlock(c, id)
+		return err
+	}
+	w.db.Unlock(c, id)
+	// Unlock must be called by now and every branch above.
+	return w.deliver(c, outboxIRI, activity)
+}
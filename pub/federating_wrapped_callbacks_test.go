@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/go-fed/activity/streams"
 	"github.com/go-fed/activity/streams/vocab"
@@ -125,6 +126,54 @@ func TestFederatedCallbacks(t *testing.T) {
 			t.Fatalf("could not find overridden function")
 		}
 	})
+	t.Run("OverridesTentativeAccept", func(t *testing.T) {
+		ok := false
+		o := func(context.Context, vocab.ActivityStreamsTentativeAccept) error {
+			ok = true
+			return nil
+		}
+		var w FederatingWrappedCallbacks
+		for _, f := range w.callbacks([]interface{}{o}) {
+			if fn, ok := f.(func(context.Context, vocab.ActivityStreamsTentativeAccept) error); ok {
+				fn(nil, nil)
+			}
+		}
+		if !ok {
+			t.Fatalf("could not find overridden function")
+		}
+	})
+	t.Run("OverridesTentativeReject", func(t *testing.T) {
+		ok := false
+		o := func(context.Context, vocab.ActivityStreamsTentativeReject) error {
+			ok = true
+			return nil
+		}
+		var w FederatingWrappedCallbacks
+		for _, f := range w.callbacks([]interface{}{o}) {
+			if fn, ok := f.(func(context.Context, vocab.ActivityStreamsTentativeReject) error); ok {
+				fn(nil, nil)
+			}
+		}
+		if !ok {
+			t.Fatalf("could not find overridden function")
+		}
+	})
+	t.Run("OverridesMove", func(t *testing.T) {
+		ok := false
+		o := func(context.Context, vocab.ActivityStreamsMove) error {
+			ok = true
+			return nil
+		}
+		var w FederatingWrappedCallbacks
+		for _, f := range w.callbacks([]interface{}{o}) {
+			if fn, ok := f.(func(context.Context, vocab.ActivityStreamsMove) error); ok {
+				fn(nil, nil)
+			}
+		}
+		if !ok {
+			t.Fatalf("could not find overridden function")
+		}
+	})
 	t.Run("OverridesAdd", func(t *testing.T) {
 		ok := false
 		o := func(context.Context, vocab.ActivityStreamsAdd) error {
@@ -335,6 +384,260 @@ func TestFederatedCreate(t *testing.T) {
 		assertEqual(t, ctx, gotc)
 		assertEqual(t, c, got)
 	})
+	t.Run("AddsToRepliesOfOwnedObject", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		w, mockDB, _ := setupFn(ctl)
+		w.replies = true
+		reply := streams.NewActivityStreamsNote()
+		id := streams.NewJSONLDIdProperty()
+		id.Set(mustParse(testNoteId1))
+		reply.SetJSONLDId(id)
+		irt := streams.NewActivityStreamsInReplyToProperty()
+		irt.AppendIRI(mustParse(testNoteId2))
+		irt.AppendIRI(mustParse(inReplyToIRI))
+		reply.SetActivityStreamsInReplyTo(irt)
+		parent := streams.NewActivityStreamsNote()
+		parentId := streams.NewJSONLDIdProperty()
+		parentId.Set(mustParse(testNoteId2))
+		parent.SetJSONLDId(parentId)
+		mockDB.EXPECT().Lock(ctx, mustParse(testNoteId1))
+		mockDB.EXPECT().Create(ctx, reply)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testNoteId1))
+		mockDB.EXPECT().Lock(ctx, mustParse(testNoteId2))
+		mockDB.EXPECT().Owns(ctx, mustParse(testNoteId2)).Return(true, nil)
+		mockDB.EXPECT().Get(ctx, mustParse(testNoteId2)).Return(parent, nil)
+		mockDB.EXPECT().Update(ctx, parent)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testNoteId2))
+		mockDB.EXPECT().Lock(ctx, mustParse(inReplyToIRI))
+		mockDB.EXPECT().Owns(ctx, mustParse(inReplyToIRI)).Return(false, nil)
+		mockDB.EXPECT().Unlock(ctx, mustParse(inReplyToIRI))
+		c := newCreateFn()
+		op := streams.NewActivityStreamsObjectProperty()
+		op.AppendActivityStreamsNote(reply)
+		c.SetActivityStreamsObject(op)
+		err := w.create(ctx, c)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		items := parent.GetActivityStreamsReplies().GetActivityStreamsCollection().GetActivityStreamsItems()
+		assertEqual(t, items.Len(), 1)
+		assertEqual(t, items.At(0).GetIRI().String(), testNoteId1)
+	})
+}
+
+func TestFederatedCreateVote(t *testing.T) {
+	const followersIRI = "https://example.com/addison/followers"
+	newQuestionFn := func(endTime time.Time) vocab.ActivityStreamsQuestion {
+		q := streams.NewActivityStreamsQuestion()
+		id := streams.NewJSONLDIdProperty()
+		id.Set(mustParse(testNoteId2))
+		q.SetJSONLDId(id)
+		attrTo := streams.NewActivityStreamsAttributedToProperty()
+		attrTo.AppendIRI(mustParse(testFederatedActorIRI2))
+		q.SetActivityStreamsAttributedTo(attrTo)
+		oneOf := streams.NewActivityStreamsOneOfProperty()
+		for _, n := range []string{"yes", "no"} {
+			option := streams.NewActivityStreamsNote()
+			name := streams.NewActivityStreamsNameProperty()
+			name.AppendXMLSchemaString(n)
+			option.SetActivityStreamsName(name)
+			oneOf.AppendActivityStreamsNote(option)
+		}
+		q.SetActivityStreamsOneOf(oneOf)
+		et := streams.NewActivityStreamsEndTimeProperty()
+		et.Set(endTime)
+		q.SetActivityStreamsEndTime(et)
+		return q
+	}
+	newVoteFn := func() vocab.ActivityStreamsCreate {
+		vote := streams.NewActivityStreamsNote()
+		id := streams.NewJSONLDIdProperty()
+		id.Set(mustParse(testFederatedActivityIRI2))
+		vote.SetJSONLDId(id)
+		name := streams.NewActivityStreamsNameProperty()
+		name.AppendXMLSchemaString("yes")
+		vote.SetActivityStreamsName(name)
+		irt := streams.NewActivityStreamsInReplyToProperty()
+		irt.AppendIRI(mustParse(testNoteId2))
+		vote.SetActivityStreamsInReplyTo(irt)
+		c := streams.NewActivityStreamsCreate()
+		cid := streams.NewJSONLDIdProperty()
+		cid.Set(mustParse(testFederatedActivityIRI))
+		c.SetJSONLDId(cid)
+		actor := streams.NewActivityStreamsActorProperty()
+		actor.AppendIRI(mustParse(testFederatedActorIRI))
+		c.SetActivityStreamsActor(actor)
+		op := streams.NewActivityStreamsObjectProperty()
+		op.AppendActivityStreamsNote(vote)
+		c.SetActivityStreamsObject(op)
+		return c
+	}
+	ctx := context.Background()
+	setupFn := func(ctl *gomock.Controller) (w FederatingWrappedCallbacks, mockDB *MockDatabase, votes *MemoryVoteStore) {
+		mockDB = NewMockDatabase(ctl)
+		clock := NewMockClock(ctl)
+		clock.EXPECT().Now().Return(now()).AnyTimes()
+		votes = NewMemoryVoteStore()
+		w.inboxIRI = mustParse(testMyInboxIRI)
+		w.db = mockDB
+		w.clock = clock
+		w.votes = votes
+		w.addNewIds = func(c context.Context, activity Activity) error {
+			id := streams.NewJSONLDIdProperty()
+			id.Set(mustParse(testNewActivityIRI))
+			activity.SetJSONLDId(id)
+			return nil
+		}
+		w.deliver = func(c context.Context, outboxIRI *url.URL, activity Activity) error {
+			t.Fatalf("unexpected delivery")
+			return nil
+		}
+		return
+	}
+	expectVoteFn := func(mockDB *MockDatabase, q vocab.ActivityStreamsQuestion) {
+		mockDB.EXPECT().Lock(ctx, mustParse(testFederatedActivityIRI2))
+		mockDB.EXPECT().Create(ctx, gomock.Any())
+		mockDB.EXPECT().Unlock(ctx, mustParse(testFederatedActivityIRI2))
+		mockDB.EXPECT().Lock(ctx, mustParse(testMyInboxIRI))
+		mockDB.EXPECT().ActorForInbox(ctx, mustParse(testMyInboxIRI)).Return(
+			mustParse(testFederatedActorIRI2), nil)
+		mockDB.EXPECT().OutboxForInbox(ctx, mustParse(testMyInboxIRI)).Return(
+			mustParse(testMyOutboxIRI), nil)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testMyInboxIRI))
+		mockDB.EXPECT().Lock(ctx, mustParse(testNoteId2))
+		mockDB.EXPECT().Owns(ctx, mustParse(testNoteId2)).Return(true, nil)
+		mockDB.EXPECT().Get(ctx, mustParse(testNoteId2)).Return(q, nil)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testNoteId2))
+	}
+	t.Run("TalliesVoteAndSendsUpdate", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		w, mockDB, votes := setupFn(ctl)
+		q := newQuestionFn(now().Add(time.Hour))
+		expectVoteFn(mockDB, q)
+		mockDB.EXPECT().Update(ctx, q)
+		followers := streams.NewActivityStreamsCollection()
+		id := streams.NewJSONLDIdProperty()
+		id.Set(mustParse(followersIRI))
+		followers.SetJSONLDId(id)
+		mockDB.EXPECT().Lock(ctx, mustParse(testFederatedActorIRI2))
+		mockDB.EXPECT().Followers(ctx, mustParse(testFederatedActorIRI2)).Return(
+			followers, nil)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testFederatedActorIRI2))
+		var gotOutbox *url.URL
+		var gotActivity Activity
+		w.deliver = func(c context.Context, outboxIRI *url.URL, activity Activity) error {
+			gotOutbox = outboxIRI
+			gotActivity = activity
+			return nil
+		}
+		err := w.create(ctx, newVoteFn())
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		option := q.GetActivityStreamsOneOf().At(0).GetActivityStreamsNote()
+		totalItems := option.GetActivityStreamsReplies().GetActivityStreamsCollection().GetActivityStreamsTotalItems()
+		assertEqual(t, totalItems.Get(), 1)
+		assertEqual(t, q.GetActivityStreamsOneOf().At(1).GetActivityStreamsNote().GetActivityStreamsReplies(), nil)
+		assertEqual(t, q.GetTootVotersCount().Get(), 1)
+		names, _ := votes.Votes(ctx, mustParse(testNoteId2), mustParse(testFederatedActorIRI))
+		assertEqual(t, len(names), 1)
+		assertEqual(t, gotOutbox.String(), testMyOutboxIRI)
+		if !streams.IsOrExtendsActivityStreamsUpdate(gotActivity) {
+			t.Fatalf("expected Update, got %T", gotActivity)
+		}
+		assertEqual(t, gotActivity.GetActivityStreamsObject().At(0).GetActivityStreamsQuestion(), q)
+		assertEqual(t, gotActivity.GetActivityStreamsTo().At(0).GetIRI().String(), followersIRI)
+	})
+	t.Run("TalliesVoteInInboxOfAuthor", func(t *testing.T) {
+		const otherInboxIRI = "https://example.com/blake/inbox"
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		w, mockDB, votes := setupFn(ctl)
+		w.inboxIRI = mustParse(otherInboxIRI)
+		w.inboxIRIs = []*url.URL{mustParse(otherInboxIRI), mustParse(testMyInboxIRI)}
+		q := newQuestionFn(now().Add(time.Hour))
+		mockDB.EXPECT().Lock(ctx, mustParse(otherInboxIRI))
+		mockDB.EXPECT().ActorForInbox(ctx, mustParse(otherInboxIRI)).Return(
+			mustParse("https://example.com/blake"), nil)
+		mockDB.EXPECT().OutboxForInbox(ctx, mustParse(otherInboxIRI)).Return(
+			mustParse("https://example.com/blake/outbox"), nil)
+		mockDB.EXPECT().Unlock(ctx, mustParse(otherInboxIRI))
+		mockDB.EXPECT().Lock(ctx, mustParse(testNoteId2))
+		mockDB.EXPECT().Owns(ctx, mustParse(testNoteId2)).Return(true, nil)
+		mockDB.EXPECT().Get(ctx, mustParse(testNoteId2)).Return(q, nil)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testNoteId2))
+		expectVoteFn(mockDB, q)
+		mockDB.EXPECT().Update(ctx, q)
+		followers := streams.NewActivityStreamsCollection()
+		id := streams.NewJSONLDIdProperty()
+		id.Set(mustParse(followersIRI))
+		followers.SetJSONLDId(id)
+		mockDB.EXPECT().Lock(ctx, mustParse(testFederatedActorIRI2))
+		mockDB.EXPECT().Followers(ctx, mustParse(testFederatedActorIRI2)).Return(
+			followers, nil)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testFederatedActorIRI2))
+		var gotOutbox *url.URL
+		w.deliver = func(c context.Context, outboxIRI *url.URL, activity Activity) error {
+			gotOutbox = outboxIRI
+			return nil
+		}
+		err := w.create(ctx, newVoteFn())
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		assertEqual(t, q.GetTootVotersCount().Get(), 1)
+		names, _ := votes.Votes(ctx, mustParse(testNoteId2), mustParse(testFederatedActorIRI))
+		assertEqual(t, len(names), 1)
+		assertEqual(t, gotOutbox.String(), testMyOutboxIRI)
+	})
+	t.Run("IgnoresSecondVoteOnOneOf", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		w, mockDB, votes := setupFn(ctl)
+		votes.AddVote(ctx, mustParse(testNoteId2), mustParse(testFederatedActorIRI), "no")
+		q := newQuestionFn(now().Add(time.Hour))
+		expectVoteFn(mockDB, q)
+		err := w.create(ctx, newVoteFn())
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		assertEqual(t, q.GetTootVotersCount(), nil)
+	})
+	t.Run("IgnoresVoteAfterEndTime", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		w, mockDB, votes := setupFn(ctl)
+		q := newQuestionFn(now().Add(-time.Hour))
+		expectVoteFn(mockDB, q)
+		err := w.create(ctx, newVoteFn())
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		names, _ := votes.Votes(ctx, mustParse(testNoteId2), mustParse(testFederatedActorIRI))
+		assertEqual(t, len(names), 0)
+	})
+	t.Run("DoesNotAddVoteToReplies", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		w, mockDB, _ := setupFn(ctl)
+		w.replies = true
+		w.votes = nil
+		q := newQuestionFn(now().Add(time.Hour))
+		mockDB.EXPECT().Lock(ctx, mustParse(testFederatedActivityIRI2))
+		mockDB.EXPECT().Create(ctx, gomock.Any())
+		mockDB.EXPECT().Unlock(ctx, mustParse(testFederatedActivityIRI2))
+		mockDB.EXPECT().Lock(ctx, mustParse(testNoteId2))
+		mockDB.EXPECT().Owns(ctx, mustParse(testNoteId2)).Return(true, nil)
+		mockDB.EXPECT().Get(ctx, mustParse(testNoteId2)).Return(q, nil)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testNoteId2))
+		err := w.create(ctx, newVoteFn())
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		assertEqual(t, q.GetActivityStreamsReplies(), nil)
+	})
 }
 
 func TestFederatedUpdate(t *testing.T) {
@@ -416,6 +719,49 @@ func TestFederatedUpdate(t *testing.T) {
 			t.Fatalf("got error %s", err)
 		}
 	})
+	t.Run("InvalidatesPublicKeysOfUpdatedObject", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		w, mockDB := setupFn(ctl)
+		keys := NewMemoryPublicKeyStore()
+		keys.Set(ctx, CachedPublicKey{KeyId: mustParse(testFederatedKeyIRI), Owner: mustParse(testNoteId1)})
+		w.keys = keys
+		mockDB.EXPECT().Lock(ctx, mustParse(testNoteId1))
+		mockDB.EXPECT().Update(ctx, testFederatedNote)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testNoteId1))
+		u := newUpdateFn()
+		err := w.update(ctx, u)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		if _, exists, _ := keys.Get(ctx, mustParse(testFederatedKeyIRI)); exists {
+			t.Fatalf("expected key to be invalidated")
+		}
+		if revoked, _ := keys.IsRevoked(ctx, mustParse(testNoteId1)); revoked {
+			t.Fatalf("expected owner to not be revoked")
+		}
+	})
+	t.Run("InvalidatesCachedObject", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		w, mockDB := setupFn(ctl)
+		clock := NewMockClock(ctl)
+		ac := NewMemoryActorCache(clock, time.Hour, 0)
+		w.actorCache = ac
+		clock.EXPECT().Now().Return(now())
+		ac.Set(ctx, mustParse(testNoteId1), testFederatedNote)
+		mockDB.EXPECT().Lock(ctx, mustParse(testNoteId1))
+		mockDB.EXPECT().Update(ctx, testFederatedNote)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testNoteId1))
+		u := newUpdateFn()
+		err := w.update(ctx, u)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		if ac.Len() != 0 {
+			t.Fatalf("expected cached object to be invalidated")
+		}
+	})
 	t.Run("ErrorIfObjectIsIRI", func(t *testing.T) {
 		u := newUpdateFn()
 		op := streams.NewActivityStreamsObjectProperty()
@@ -530,6 +876,67 @@ func TestFederatedDelete(t *testing.T) {
 			t.Fatalf("got error %s", err)
 		}
 	})
+	t.Run("RevokesPublicKeysOfDeletedObject", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		w, mockDB := setupFn(ctl)
+		keys := NewMemoryPublicKeyStore()
+		keys.Set(ctx, CachedPublicKey{KeyId: mustParse(testFederatedKeyIRI), Owner: mustParse(testNoteId1)})
+		w.keys = keys
+		mockDB.EXPECT().Lock(ctx, mustParse(testNoteId1))
+		mockDB.EXPECT().Delete(ctx, mustParse(testNoteId1))
+		mockDB.EXPECT().Unlock(ctx, mustParse(testNoteId1))
+		d := newDeleteFn()
+		err := w.deleteFn(ctx, d)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		if _, exists, _ := keys.Get(ctx, mustParse(testFederatedKeyIRI)); exists {
+			t.Fatalf("expected key to be removed")
+		}
+		if revoked, _ := keys.IsRevoked(ctx, mustParse(testNoteId1)); !revoked {
+			t.Fatalf("expected owner to be revoked")
+		}
+	})
+	t.Run("DoesNotRevokeDeletedObjectWithoutPublicKeys", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		w, mockDB := setupFn(ctl)
+		keys := NewMemoryPublicKeyStore()
+		w.keys = keys
+		mockDB.EXPECT().Lock(ctx, mustParse(testNoteId1))
+		mockDB.EXPECT().Delete(ctx, mustParse(testNoteId1))
+		mockDB.EXPECT().Unlock(ctx, mustParse(testNoteId1))
+		d := newDeleteFn()
+		err := w.deleteFn(ctx, d)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		if revoked, _ := keys.IsRevoked(ctx, mustParse(testNoteId1)); revoked {
+			t.Fatalf("expected object without keys not to be revoked")
+		}
+	})
+	t.Run("InvalidatesCachedObject", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		w, mockDB := setupFn(ctl)
+		clock := NewMockClock(ctl)
+		ac := NewMemoryActorCache(clock, time.Hour, 0)
+		w.actorCache = ac
+		clock.EXPECT().Now().Return(now())
+		ac.Set(ctx, mustParse(testNoteId1), testFederatedNote)
+		mockDB.EXPECT().Lock(ctx, mustParse(testNoteId1))
+		mockDB.EXPECT().Delete(ctx, mustParse(testNoteId1))
+		mockDB.EXPECT().Unlock(ctx, mustParse(testNoteId1))
+		d := newDeleteFn()
+		err := w.deleteFn(ctx, d)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		if ac.Len() != 0 {
+			t.Fatalf("expected cached object to be invalidated")
+		}
+	})
 	t.Run("CallsCustomCallback", func(t *testing.T) {
 		ctl := gomock.NewController(t)
 		defer ctl.Finish()
@@ -552,6 +959,48 @@ func TestFederatedDelete(t *testing.T) {
 		assertEqual(t, ctx, gotc)
 		assertEqual(t, d, got)
 	})
+	t.Run("RemovesFromRepliesOfOwnedObject", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		w, mockDB := setupFn(ctl)
+		w.replies = true
+		reply := streams.NewActivityStreamsNote()
+		id := streams.NewJSONLDIdProperty()
+		id.Set(mustParse(testNoteId1))
+		reply.SetJSONLDId(id)
+		irt := streams.NewActivityStreamsInReplyToProperty()
+		irt.AppendIRI(mustParse(testNoteId2))
+		reply.SetActivityStreamsInReplyTo(irt)
+		parent := streams.NewActivityStreamsNote()
+		parentId := streams.NewJSONLDIdProperty()
+		parentId.Set(mustParse(testNoteId2))
+		parent.SetJSONLDId(parentId)
+		replies := streams.NewActivityStreamsRepliesProperty()
+		col := streams.NewActivityStreamsCollection()
+		items := streams.NewActivityStreamsItemsProperty()
+		items.AppendIRI(mustParse(testNoteId1))
+		items.AppendIRI(mustParse(testNewActivityIRI2))
+		col.SetActivityStreamsItems(items)
+		replies.SetActivityStreamsCollection(col)
+		parent.SetActivityStreamsReplies(replies)
+		mockDB.EXPECT().Lock(ctx, mustParse(testNoteId1))
+		mockDB.EXPECT().Exists(ctx, mustParse(testNoteId1)).Return(true, nil)
+		mockDB.EXPECT().Get(ctx, mustParse(testNoteId1)).Return(reply, nil)
+		mockDB.EXPECT().Delete(ctx, mustParse(testNoteId1))
+		mockDB.EXPECT().Unlock(ctx, mustParse(testNoteId1))
+		mockDB.EXPECT().Lock(ctx, mustParse(testNoteId2))
+		mockDB.EXPECT().Owns(ctx, mustParse(testNoteId2)).Return(true, nil)
+		mockDB.EXPECT().Get(ctx, mustParse(testNoteId2)).Return(parent, nil)
+		mockDB.EXPECT().Update(ctx, parent)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testNoteId2))
+		d := newDeleteFn()
+		err := w.deleteFn(ctx, d)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		assertEqual(t, items.Len(), 1)
+		assertEqual(t, items.At(0).GetIRI().String(), testNewActivityIRI2)
+	})
 }
 
 func TestFederatedFollow(t *testing.T) {
@@ -705,6 +1154,42 @@ func TestFederatedFollow(t *testing.T) {
 			t.Fatalf("got error %s", err)
 		}
 	})
+	t.Run("OnFollowPendingApprovalRecordsFollow", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		w, mockDB := setupFn(ctl)
+		w.OnFollow = OnFollowPendingApproval
+		pending := NewMemoryPendingFollowStore()
+		w.pendingFollows = pending
+		mockDB.EXPECT().Lock(ctx, mustParse(testMyInboxIRI))
+		mockDB.EXPECT().ActorForInbox(ctx, mustParse(testMyInboxIRI)).Return(
+			mustParse(testFederatedActorIRI2), nil)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testMyInboxIRI))
+		f := newFollowFn()
+		err := w.follow(ctx, f)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		follows, err := pending.List(ctx, mustParse(testFederatedActorIRI2))
+		assertEqual(t, err, nil)
+		assertEqual(t, len(follows), 1)
+		assertEqual(t, follows[0].GetJSONLDId().Get().String(), testNewActivityIRI)
+	})
+	t.Run("OnFollowPendingApprovalErrorsWithoutStore", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		w, mockDB := setupFn(ctl)
+		w.OnFollow = OnFollowPendingApproval
+		mockDB.EXPECT().Lock(ctx, mustParse(testMyInboxIRI))
+		mockDB.EXPECT().ActorForInbox(ctx, mustParse(testMyInboxIRI)).Return(
+			mustParse(testFederatedActorIRI2), nil)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testMyInboxIRI))
+		f := newFollowFn()
+		err := w.follow(ctx, f)
+		if err == nil {
+			t.Fatalf("expected error, got none")
+		}
+	})
 	t.Run("CallsCustomCallback", func(t *testing.T) {
 		ctl := gomock.NewController(t)
 		defer ctl.Finish()
@@ -896,9 +1381,123 @@ func TestFederatedAccept(t *testing.T) {
 }
 
 func TestFederatedReject(t *testing.T) {
-	ctx := context.Background()
-	t.Run("CallsCustomCallback", func(t *testing.T) {
+	newRejectFn := func() vocab.ActivityStreamsReject {
 		r := streams.NewActivityStreamsReject()
+		id := streams.NewJSONLDIdProperty()
+		id.Set(mustParse(testFederatedActivityIRI2))
+		r.SetJSONLDId(id)
+		actor := streams.NewActivityStreamsActorProperty()
+		actor.AppendIRI(mustParse(testFederatedActorIRI))
+		r.SetActivityStreamsActor(actor)
+		op := streams.NewActivityStreamsObjectProperty()
+		op.AppendActivityStreamsFollow(testFollow)
+		r.SetActivityStreamsObject(op)
+		return r
+	}
+	ctx := context.Background()
+	setupFn := func(ctl *gomock.Controller) (w FederatingWrappedCallbacks, mockDB *MockDatabase) {
+		mockDB = NewMockDatabase(ctl)
+		w.inboxIRI = mustParse(testMyInboxIRI)
+		w.db = mockDB
+		return
+	}
+	t.Run("DoesNothingIfNoObjects", func(t *testing.T) {
+		r := newRejectFn()
+		r.SetActivityStreamsObject(nil)
+		var w FederatingWrappedCallbacks
+		err := w.reject(ctx, r)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+	})
+	t.Run("IgnoresFollowObjectsNotContainingMe", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		w, mockDB := setupFn(ctl)
+		mockDB.EXPECT().Lock(ctx, mustParse(testMyInboxIRI))
+		mockDB.EXPECT().ActorForInbox(ctx, mustParse(testMyInboxIRI)).Return(
+			mustParse(testFederatedActorIRI3), nil)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testMyInboxIRI))
+		r := newRejectFn()
+		err := w.reject(ctx, r)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+	})
+	t.Run("ErrorIfPeerLiedAboutOurFollowId", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		w, mockDB := setupFn(ctl)
+		mockDB.EXPECT().Lock(ctx, mustParse(testMyInboxIRI))
+		mockDB.EXPECT().ActorForInbox(ctx, mustParse(testMyInboxIRI)).Return(
+			mustParse(testFederatedActorIRI2), nil)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testMyInboxIRI))
+		mockDB.EXPECT().Lock(ctx, mustParse(testFederatedActivityIRI))
+		mockDB.EXPECT().Get(ctx, mustParse(testFederatedActivityIRI)).Return(
+			testListen, nil)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testFederatedActivityIRI))
+		r := newRejectFn()
+		err := w.reject(ctx, r)
+		if err == nil {
+			t.Fatalf("expected error, got none")
+		}
+	})
+	t.Run("ErrorIfRejectingActorWasNotFollowed", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		w, mockDB := setupFn(ctl)
+		mockDB.EXPECT().Lock(ctx, mustParse(testMyInboxIRI))
+		mockDB.EXPECT().ActorForInbox(ctx, mustParse(testMyInboxIRI)).Return(
+			mustParse(testFederatedActorIRI2), nil)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testMyInboxIRI))
+		mockDB.EXPECT().Lock(ctx, mustParse(testFederatedActivityIRI))
+		mockDB.EXPECT().Get(ctx, mustParse(testFederatedActivityIRI)).Return(
+			testFollow, nil)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testFederatedActivityIRI))
+		r := newRejectFn()
+		actor := streams.NewActivityStreamsActorProperty()
+		actor.AppendIRI(mustParse(testFederatedActorIRI3))
+		r.SetActivityStreamsActor(actor)
+		err := w.reject(ctx, r)
+		if err == nil {
+			t.Fatalf("expected error, got none")
+		}
+	})
+	t.Run("RemovesFromFollowingCollection", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		w, mockDB := setupFn(ctl)
+		following := streams.NewActivityStreamsCollection()
+		items := streams.NewActivityStreamsItemsProperty()
+		items.AppendIRI(mustParse(testFederatedActorIRI))
+		items.AppendIRI(mustParse(testFederatedActorIRI3))
+		following.SetActivityStreamsItems(items)
+		expectFollowing := streams.NewActivityStreamsCollection()
+		expectItems := streams.NewActivityStreamsItemsProperty()
+		expectItems.AppendIRI(mustParse(testFederatedActorIRI3))
+		expectFollowing.SetActivityStreamsItems(expectItems)
+		mockDB.EXPECT().Lock(ctx, mustParse(testMyInboxIRI))
+		mockDB.EXPECT().ActorForInbox(ctx, mustParse(testMyInboxIRI)).Return(
+			mustParse(testFederatedActorIRI2), nil)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testMyInboxIRI))
+		mockDB.EXPECT().Lock(ctx, mustParse(testFederatedActivityIRI))
+		mockDB.EXPECT().Get(ctx, mustParse(testFederatedActivityIRI)).Return(
+			testFollow, nil)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testFederatedActivityIRI))
+		mockDB.EXPECT().Lock(ctx, mustParse(testFederatedActorIRI2))
+		mockDB.EXPECT().Following(ctx, mustParse(testFederatedActorIRI2)).Return(
+			following, nil)
+		mockDB.EXPECT().Update(ctx, expectFollowing)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testFederatedActorIRI2))
+		r := newRejectFn()
+		err := w.reject(ctx, r)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+	})
+	t.Run("CallsCustomCallback", func(t *testing.T) {
+		r := newRejectFn()
+		r.SetActivityStreamsObject(nil)
 		var w FederatingWrappedCallbacks
 		var gotc context.Context
 		var got vocab.ActivityStreamsReject
@@ -916,6 +1515,145 @@ func TestFederatedReject(t *testing.T) {
 	})
 }
 
+func TestFederatedTentativeAccept(t *testing.T) {
+	newTentativeAcceptFn := func() vocab.ActivityStreamsTentativeAccept {
+		a := streams.NewActivityStreamsTentativeAccept()
+		id := streams.NewJSONLDIdProperty()
+		id.Set(mustParse(testFederatedActivityIRI2))
+		a.SetJSONLDId(id)
+		actor := streams.NewActivityStreamsActorProperty()
+		actor.AppendIRI(mustParse(testFederatedActorIRI))
+		a.SetActivityStreamsActor(actor)
+		op := streams.NewActivityStreamsObjectProperty()
+		op.AppendActivityStreamsFollow(testFollow)
+		a.SetActivityStreamsObject(op)
+		return a
+	}
+	ctx := context.Background()
+	setupFn := func(ctl *gomock.Controller) (w FederatingWrappedCallbacks, mockDB *MockDatabase) {
+		mockDB = NewMockDatabase(ctl)
+		w.inboxIRI = mustParse(testMyInboxIRI)
+		w.db = mockDB
+		return
+	}
+	t.Run("ErrorIfPeerLiedAboutOurFollowId", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		w, mockDB := setupFn(ctl)
+		mockDB.EXPECT().Lock(ctx, mustParse(testMyInboxIRI))
+		mockDB.EXPECT().ActorForInbox(ctx, mustParse(testMyInboxIRI)).Return(
+			mustParse(testFederatedActorIRI2), nil)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testMyInboxIRI))
+		mockDB.EXPECT().Lock(ctx, mustParse(testFederatedActivityIRI))
+		mockDB.EXPECT().Get(ctx, mustParse(testFederatedActivityIRI)).Return(
+			testListen, nil)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testFederatedActivityIRI))
+		a := newTentativeAcceptFn()
+		err := w.tentativeAccept(ctx, a)
+		if err == nil {
+			t.Fatalf("expected error, got none")
+		}
+	})
+	t.Run("DoesNotUpdateFollowingCollection", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		w, mockDB := setupFn(ctl)
+		mockDB.EXPECT().Lock(ctx, mustParse(testMyInboxIRI))
+		mockDB.EXPECT().ActorForInbox(ctx, mustParse(testMyInboxIRI)).Return(
+			mustParse(testFederatedActorIRI2), nil)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testMyInboxIRI))
+		mockDB.EXPECT().Lock(ctx, mustParse(testFederatedActivityIRI))
+		mockDB.EXPECT().Get(ctx, mustParse(testFederatedActivityIRI)).Return(
+			testFollow, nil)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testFederatedActivityIRI))
+		a := newTentativeAcceptFn()
+		err := w.tentativeAccept(ctx, a)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+	})
+	t.Run("CallsCustomCallback", func(t *testing.T) {
+		a := newTentativeAcceptFn()
+		a.SetActivityStreamsObject(nil)
+		var w FederatingWrappedCallbacks
+		var gotc context.Context
+		var got vocab.ActivityStreamsTentativeAccept
+		w.TentativeAccept = func(ctx context.Context, v vocab.ActivityStreamsTentativeAccept) error {
+			gotc = ctx
+			got = v
+			return nil
+		}
+		err := w.tentativeAccept(ctx, a)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		assertEqual(t, ctx, gotc)
+		assertEqual(t, a, got)
+	})
+}
+
+func TestFederatedTentativeReject(t *testing.T) {
+	newTentativeRejectFn := func() vocab.ActivityStreamsTentativeReject {
+		r := streams.NewActivityStreamsTentativeReject()
+		id := streams.NewJSONLDIdProperty()
+		id.Set(mustParse(testFederatedActivityIRI2))
+		r.SetJSONLDId(id)
+		actor := streams.NewActivityStreamsActorProperty()
+		actor.AppendIRI(mustParse(testFederatedActorIRI))
+		r.SetActivityStreamsActor(actor)
+		op := streams.NewActivityStreamsObjectProperty()
+		op.AppendActivityStreamsFollow(testFollow)
+		r.SetActivityStreamsObject(op)
+		return r
+	}
+	ctx := context.Background()
+	setupFn := func(ctl *gomock.Controller) (w FederatingWrappedCallbacks, mockDB *MockDatabase) {
+		mockDB = NewMockDatabase(ctl)
+		w.inboxIRI = mustParse(testMyInboxIRI)
+		w.db = mockDB
+		return
+	}
+	t.Run("ErrorIfRejectingActorWasNotFollowed", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		w, mockDB := setupFn(ctl)
+		mockDB.EXPECT().Lock(ctx, mustParse(testMyInboxIRI))
+		mockDB.EXPECT().ActorForInbox(ctx, mustParse(testMyInboxIRI)).Return(
+			mustParse(testFederatedActorIRI2), nil)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testMyInboxIRI))
+		mockDB.EXPECT().Lock(ctx, mustParse(testFederatedActivityIRI))
+		mockDB.EXPECT().Get(ctx, mustParse(testFederatedActivityIRI)).Return(
+			testFollow, nil)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testFederatedActivityIRI))
+		r := newTentativeRejectFn()
+		actor := streams.NewActivityStreamsActorProperty()
+		actor.AppendIRI(mustParse(testFederatedActorIRI3))
+		r.SetActivityStreamsActor(actor)
+		err := w.tentativeReject(ctx, r)
+		if err == nil {
+			t.Fatalf("expected error, got none")
+		}
+	})
+	t.Run("CallsCustomCallback", func(t *testing.T) {
+		r := newTentativeRejectFn()
+		r.SetActivityStreamsObject(nil)
+		var w FederatingWrappedCallbacks
+		var gotc context.Context
+		var got vocab.ActivityStreamsTentativeReject
+		w.TentativeReject = func(ctx context.Context, v vocab.ActivityStreamsTentativeReject) error {
+			gotc = ctx
+			got = v
+			return nil
+		}
+		err := w.tentativeReject(ctx, r)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		assertEqual(t, ctx, gotc)
+		assertEqual(t, r, got)
+	})
+}
+
 func TestFederatedAdd(t *testing.T) {
 	newAddFn := func() vocab.ActivityStreamsAdd {
 		a := streams.NewActivityStreamsAdd()
@@ -1675,10 +2413,45 @@ func TestFederatedUndo(t *testing.T) {
 		return u
 	}
 	ctx := context.Background()
-	setupFn := func(ctl *gomock.Controller) (w FederatingWrappedCallbacks, mockTp *MockTransport) {
+	newUndoneFn := func(undone vocab.Type) vocab.ActivityStreamsUndo {
+		u := newUndoFn()
+		op := streams.NewActivityStreamsObjectProperty()
+		op.AppendType(undone)
+		u.SetActivityStreamsObject(op)
+		return u
+	}
+	newLikeFn := func() vocab.ActivityStreamsLike {
+		l := streams.NewActivityStreamsLike()
+		id := streams.NewJSONLDIdProperty()
+		id.Set(mustParse(testFederatedActivityIRI))
+		l.SetJSONLDId(id)
+		actor := streams.NewActivityStreamsActorProperty()
+		actor.AppendIRI(mustParse(testFederatedActorIRI))
+		l.SetActivityStreamsActor(actor)
+		op := streams.NewActivityStreamsObjectProperty()
+		op.AppendIRI(mustParse(testNoteId1))
+		l.SetActivityStreamsObject(op)
+		return l
+	}
+	newAnnounceFn := func() vocab.ActivityStreamsAnnounce {
+		a := streams.NewActivityStreamsAnnounce()
+		id := streams.NewJSONLDIdProperty()
+		id.Set(mustParse(testFederatedActivityIRI))
+		a.SetJSONLDId(id)
+		actor := streams.NewActivityStreamsActorProperty()
+		actor.AppendIRI(mustParse(testFederatedActorIRI))
+		a.SetActivityStreamsActor(actor)
+		op := streams.NewActivityStreamsObjectProperty()
+		op.AppendIRI(mustParse(testNoteId1))
+		a.SetActivityStreamsObject(op)
+		return a
+	}
+	setupFn := func(ctl *gomock.Controller) (w FederatingWrappedCallbacks, mockDB *MockDatabase, mockTp *MockTransport) {
+		mockDB = NewMockDatabase(ctl)
 		mockTp = NewMockTransport(ctl)
+		w.db = mockDB
 		w.inboxIRI = mustParse(testMyInboxIRI)
-		w.newTransport = func(c context.Context, a *url.URL, s string) (Transport, error) {
+		w.newDereferenceTransport = func(c context.Context, a *url.URL, s string) (Transport, error) {
 			return mockTp, nil
 		}
 		return
@@ -1704,7 +2477,7 @@ func TestFederatedUndo(t *testing.T) {
 	t.Run("ErrorIfActorMismatch", func(t *testing.T) {
 		ctl := gomock.NewController(t)
 		defer ctl.Finish()
-		w, mockTp := setupFn(ctl)
+		w, _, mockTp := setupFn(ctl)
 		mockTp.EXPECT().Dereference(ctx, mustParse(testFederatedActivityIRI)).Return(
 			mustSerializeToBytes(testListen), nil)
 		u := newUndoFn()
@@ -1719,7 +2492,7 @@ func TestFederatedUndo(t *testing.T) {
 	t.Run("ErrorIfActorMismatchWhenDereferencingIRI", func(t *testing.T) {
 		ctl := gomock.NewController(t)
 		defer ctl.Finish()
-		w, mockTp := setupFn(ctl)
+		w, _, mockTp := setupFn(ctl)
 		mockTp.EXPECT().Dereference(ctx, mustParse(testFederatedActivityIRI)).Return(
 			mustSerializeToBytes(testFollow), nil)
 		u := newUndoFn()
@@ -1734,7 +2507,7 @@ func TestFederatedUndo(t *testing.T) {
 	t.Run("DereferencesWhenUndoValue", func(t *testing.T) {
 		ctl := gomock.NewController(t)
 		defer ctl.Finish()
-		w, mockTp := setupFn(ctl)
+		w, _, mockTp := setupFn(ctl)
 		mockTp.EXPECT().Dereference(ctx, mustParse(testFederatedActivityIRI)).Return(
 			mustSerializeToBytes(testListen), nil)
 		u := newUndoFn()
@@ -1746,7 +2519,7 @@ func TestFederatedUndo(t *testing.T) {
 	t.Run("DereferencesWhenUndoIRI", func(t *testing.T) {
 		ctl := gomock.NewController(t)
 		defer ctl.Finish()
-		w, mockTp := setupFn(ctl)
+		w, _, mockTp := setupFn(ctl)
 		mockTp.EXPECT().Dereference(ctx, mustParse(testFederatedActivityIRI)).Return(
 			mustSerializeToBytes(testListen), nil)
 		u := newUndoFn()
@@ -1758,10 +2531,195 @@ func TestFederatedUndo(t *testing.T) {
 			t.Fatalf("got error %s", err)
 		}
 	})
+	t.Run("UndoFollowRemovesFromFollowers", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		w, mockDB, mockTp := setupFn(ctl)
+		followers := streams.NewActivityStreamsCollection()
+		items := streams.NewActivityStreamsItemsProperty()
+		items.AppendIRI(mustParse(testFederatedActorIRI3))
+		items.AppendIRI(mustParse(testFederatedActorIRI2))
+		followers.SetActivityStreamsItems(items)
+		expectFollowers := streams.NewActivityStreamsCollection()
+		expectItems := streams.NewActivityStreamsItemsProperty()
+		expectItems.AppendIRI(mustParse(testFederatedActorIRI3))
+		expectFollowers.SetActivityStreamsItems(expectItems)
+		mockTp.EXPECT().Dereference(ctx, mustParse(testFederatedActivityIRI)).Return(
+			mustSerializeToBytes(testFollow), nil)
+		mockDB.EXPECT().Lock(ctx, mustParse(testMyInboxIRI))
+		mockDB.EXPECT().ActorForInbox(ctx, mustParse(testMyInboxIRI)).Return(
+			mustParse(testFederatedActorIRI), nil)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testMyInboxIRI))
+		mockDB.EXPECT().Lock(ctx, mustParse(testFederatedActorIRI))
+		mockDB.EXPECT().Followers(ctx, mustParse(testFederatedActorIRI)).Return(
+			followers, nil)
+		mockDB.EXPECT().Update(ctx, expectFollowers)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testFederatedActorIRI))
+		u := newUndoneFn(testFollow)
+		actor := streams.NewActivityStreamsActorProperty()
+		actor.AppendIRI(mustParse(testFederatedActorIRI2))
+		u.SetActivityStreamsActor(actor)
+		err := w.undo(ctx, u)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+	})
+	t.Run("UndoFollowRemovesPendingFollow", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		w, mockDB, mockTp := setupFn(ctl)
+		pending := NewMemoryPendingFollowStore()
+		pending.Add(ctx, mustParse(testFederatedActorIRI), testFollow)
+		w.pendingFollows = pending
+		mockTp.EXPECT().Dereference(ctx, mustParse(testFederatedActivityIRI)).Return(
+			mustSerializeToBytes(testFollow), nil)
+		mockDB.EXPECT().Lock(ctx, mustParse(testMyInboxIRI))
+		mockDB.EXPECT().ActorForInbox(ctx, mustParse(testMyInboxIRI)).Return(
+			mustParse(testFederatedActorIRI), nil)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testMyInboxIRI))
+		u := newUndoneFn(testFollow)
+		actor := streams.NewActivityStreamsActorProperty()
+		actor.AppendIRI(mustParse(testFederatedActorIRI2))
+		u.SetActivityStreamsActor(actor)
+		err := w.undo(ctx, u)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		follows, _ := pending.List(ctx, mustParse(testFederatedActorIRI))
+		assertEqual(t, len(follows), 0)
+	})
+	t.Run("UndoFollowOfOtherActorDoesNothing", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		w, mockDB, mockTp := setupFn(ctl)
+		mockTp.EXPECT().Dereference(ctx, mustParse(testFederatedActivityIRI)).Return(
+			mustSerializeToBytes(testFollow), nil)
+		mockDB.EXPECT().Lock(ctx, mustParse(testMyInboxIRI))
+		mockDB.EXPECT().ActorForInbox(ctx, mustParse(testMyInboxIRI)).Return(
+			mustParse(testFederatedActorIRI3), nil)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testMyInboxIRI))
+		u := newUndoneFn(testFollow)
+		actor := streams.NewActivityStreamsActorProperty()
+		actor.AppendIRI(mustParse(testFederatedActorIRI2))
+		u.SetActivityStreamsActor(actor)
+		err := w.undo(ctx, u)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+	})
+	t.Run("UndoLikeRemovesFromLikes", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		w, mockDB, mockTp := setupFn(ctl)
+		note := streams.NewActivityStreamsNote()
+		likes := streams.NewActivityStreamsLikesProperty()
+		col := streams.NewActivityStreamsCollection()
+		items := streams.NewActivityStreamsItemsProperty()
+		items.AppendIRI(mustParse(testFederatedActivityIRI))
+		items.AppendIRI(mustParse(testFederatedActivityIRI2))
+		col.SetActivityStreamsItems(items)
+		likes.SetActivityStreamsCollection(col)
+		note.SetActivityStreamsLikes(likes)
+		expectNote := streams.NewActivityStreamsNote()
+		expectLikes := streams.NewActivityStreamsLikesProperty()
+		expectCol := streams.NewActivityStreamsCollection()
+		expectItems := streams.NewActivityStreamsItemsProperty()
+		expectItems.AppendIRI(mustParse(testFederatedActivityIRI2))
+		expectCol.SetActivityStreamsItems(expectItems)
+		expectLikes.SetActivityStreamsCollection(expectCol)
+		expectNote.SetActivityStreamsLikes(expectLikes)
+		like := newLikeFn()
+		mockTp.EXPECT().Dereference(ctx, mustParse(testFederatedActivityIRI)).Return(
+			mustSerializeToBytes(like), nil)
+		mockDB.EXPECT().Lock(ctx, mustParse(testNoteId1))
+		mockDB.EXPECT().Owns(ctx, mustParse(testNoteId1)).Return(true, nil)
+		mockDB.EXPECT().Get(ctx, mustParse(testNoteId1)).Return(
+			note, nil)
+		mockDB.EXPECT().Update(ctx, expectNote).Return(nil)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testNoteId1))
+		u := newUndoneFn(like)
+		err := w.undo(ctx, u)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+	})
+	t.Run("UndoLikeSkipsUnownedObjects", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		w, mockDB, mockTp := setupFn(ctl)
+		like := newLikeFn()
+		mockTp.EXPECT().Dereference(ctx, mustParse(testFederatedActivityIRI)).Return(
+			mustSerializeToBytes(like), nil)
+		mockDB.EXPECT().Lock(ctx, mustParse(testNoteId1))
+		mockDB.EXPECT().Owns(ctx, mustParse(testNoteId1)).Return(false, nil)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testNoteId1))
+		u := newUndoneFn(like)
+		err := w.undo(ctx, u)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+	})
+	t.Run("UndoAnnounceRemovesFromShares", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		w, mockDB, mockTp := setupFn(ctl)
+		note := streams.NewActivityStreamsNote()
+		shares := streams.NewActivityStreamsSharesProperty()
+		col := streams.NewActivityStreamsOrderedCollection()
+		items := streams.NewActivityStreamsOrderedItemsProperty()
+		items.AppendIRI(mustParse(testFederatedActivityIRI2))
+		items.AppendIRI(mustParse(testFederatedActivityIRI))
+		col.SetActivityStreamsOrderedItems(items)
+		shares.SetActivityStreamsOrderedCollection(col)
+		note.SetActivityStreamsShares(shares)
+		expectNote := streams.NewActivityStreamsNote()
+		expectShares := streams.NewActivityStreamsSharesProperty()
+		expectCol := streams.NewActivityStreamsOrderedCollection()
+		expectItems := streams.NewActivityStreamsOrderedItemsProperty()
+		expectItems.AppendIRI(mustParse(testFederatedActivityIRI2))
+		expectCol.SetActivityStreamsOrderedItems(expectItems)
+		expectShares.SetActivityStreamsOrderedCollection(expectCol)
+		expectNote.SetActivityStreamsShares(expectShares)
+		announce := newAnnounceFn()
+		mockTp.EXPECT().Dereference(ctx, mustParse(testFederatedActivityIRI)).Return(
+			mustSerializeToBytes(announce), nil)
+		mockDB.EXPECT().Lock(ctx, mustParse(testNoteId1))
+		mockDB.EXPECT().Owns(ctx, mustParse(testNoteId1)).Return(true, nil)
+		mockDB.EXPECT().Get(ctx, mustParse(testNoteId1)).Return(
+			note, nil)
+		mockDB.EXPECT().Update(ctx, expectNote).Return(nil)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testNoteId1))
+		u := newUndoneFn(announce)
+		err := w.undo(ctx, u)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+	})
+	t.Run("SkipUndoLeavesSideEffects", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		w, _, mockTp := setupFn(ctl)
+		like := newLikeFn()
+		mockTp.EXPECT().Dereference(ctx, mustParse(testFederatedActivityIRI)).Return(
+			mustSerializeToBytes(like), nil)
+		var skipped vocab.Type
+		w.SkipUndo = func(c context.Context, undone vocab.Type) bool {
+			skipped = undone
+			return streams.IsOrExtendsActivityStreamsLike(undone)
+		}
+		u := newUndoneFn(like)
+		err := w.undo(ctx, u)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		if skipped == nil {
+			t.Fatalf("expected SkipUndo to be called")
+		}
+	})
 	t.Run("CallsCustomCallback", func(t *testing.T) {
 		ctl := gomock.NewController(t)
 		defer ctl.Finish()
-		w, mockTp := setupFn(ctl)
+		w, _, mockTp := setupFn(ctl)
 		mockTp.EXPECT().Dereference(ctx, mustParse(testFederatedActivityIRI)).Return(
 			mustSerializeToBytes(testListen), nil)
 		var gotc context.Context
@@ -1832,3 +2790,256 @@ func TestFederatedBlock(t *testing.T) {
 		assertEqual(t, b, got)
 	})
 }
+
+func TestFederatedMove(t *testing.T) {
+	newMoveFn := func() vocab.ActivityStreamsMove {
+		m := streams.NewActivityStreamsMove()
+		id := streams.NewJSONLDIdProperty()
+		id.Set(mustParse(testFederatedActivityIRI))
+		m.SetJSONLDId(id)
+		actor := streams.NewActivityStreamsActorProperty()
+		actor.AppendIRI(mustParse(testFederatedActorIRI))
+		m.SetActivityStreamsActor(actor)
+		op := streams.NewActivityStreamsObjectProperty()
+		op.AppendIRI(mustParse(testFederatedActorIRI))
+		m.SetActivityStreamsObject(op)
+		target := streams.NewActivityStreamsTargetProperty()
+		target.AppendIRI(mustParse(testFederatedActorIRI3))
+		m.SetActivityStreamsTarget(target)
+		return m
+	}
+	newTargetFn := func(alsoKnownAs ...string) vocab.ActivityStreamsPerson {
+		p := streams.NewActivityStreamsPerson()
+		id := streams.NewJSONLDIdProperty()
+		id.Set(mustParse(testFederatedActorIRI3))
+		p.SetJSONLDId(id)
+		aka := make([]interface{}, 0, len(alsoKnownAs))
+		for _, iri := range alsoKnownAs {
+			aka = append(aka, iri)
+		}
+		p.GetUnknownProperties()[alsoKnownAsProperty] = aka
+		return p
+	}
+	newFollowingFn := func(iris ...string) vocab.ActivityStreamsCollection {
+		col := streams.NewActivityStreamsCollection()
+		items := streams.NewActivityStreamsItemsProperty()
+		for _, iri := range iris {
+			items.AppendIRI(mustParse(iri))
+		}
+		col.SetActivityStreamsItems(items)
+		return col
+	}
+	ctx := context.Background()
+	setupFn := func(ctl *gomock.Controller) (w FederatingWrappedCallbacks, mockDB *MockDatabase, mockTp *MockTransport) {
+		mockDB = NewMockDatabase(ctl)
+		mockTp = NewMockTransport(ctl)
+		w.inboxIRI = mustParse(testMyInboxIRI)
+		w.db = mockDB
+		w.newDereferenceTransport = func(c context.Context, a *url.URL, s string) (Transport, error) {
+			return mockTp, nil
+		}
+		w.addNewIds = func(c context.Context, activity Activity) error {
+			id := streams.NewJSONLDIdProperty()
+			id.Set(mustParse(testNewActivityIRI))
+			activity.SetJSONLDId(id)
+			return nil
+		}
+		return
+	}
+	expectActorFn := func(mockDB *MockDatabase, following vocab.ActivityStreamsCollection) {
+		mockDB.EXPECT().Lock(ctx, mustParse(testMyInboxIRI))
+		mockDB.EXPECT().ActorForInbox(ctx, mustParse(testMyInboxIRI)).Return(
+			mustParse(testFederatedActorIRI2), nil)
+		mockDB.EXPECT().OutboxForInbox(ctx, mustParse(testMyInboxIRI)).Return(
+			mustParse(testMyOutboxIRI), nil)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testMyInboxIRI))
+		mockDB.EXPECT().Lock(ctx, mustParse(testFederatedActorIRI2))
+		mockDB.EXPECT().Following(ctx, mustParse(testFederatedActorIRI2)).Return(
+			following, nil)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testFederatedActorIRI2))
+	}
+	t.Run("ErrorIfNoTarget", func(t *testing.T) {
+		m := newMoveFn()
+		m.SetActivityStreamsTarget(nil)
+		var w FederatingWrappedCallbacks
+		err := w.move(ctx, m)
+		if err != ErrTargetRequired {
+			t.Fatalf("expected %s, got %v", ErrTargetRequired, err)
+		}
+	})
+	t.Run("ErrorIfNotMovedByOrigin", func(t *testing.T) {
+		m := newMoveFn()
+		actor := streams.NewActivityStreamsActorProperty()
+		actor.AppendIRI(mustParse(testFederatedActorIRI4))
+		m.SetActivityStreamsActor(actor)
+		var w FederatingWrappedCallbacks
+		err := w.move(ctx, m)
+		if err == nil {
+			t.Fatalf("expected error, got none")
+		}
+	})
+	t.Run("ErrorIfTargetIsNotAlsoKnownAsOrigin", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		w, _, mockTp := setupFn(ctl)
+		mockTp.EXPECT().Dereference(ctx, mustParse(testFederatedActorIRI3)).Return(
+			mustSerializeToBytes(newTargetFn(testFederatedActorIRI4)), nil)
+		m := newMoveFn()
+		err := w.move(ctx, m)
+		if err == nil {
+			t.Fatalf("expected error, got none")
+		}
+	})
+	t.Run("DoesNothingIfNotFollowingOrigin", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		w, mockDB, mockTp := setupFn(ctl)
+		mockTp.EXPECT().Dereference(ctx, mustParse(testFederatedActorIRI3)).Return(
+			mustSerializeToBytes(newTargetFn(testFederatedActorIRI)), nil)
+		expectActorFn(mockDB, newFollowingFn(testFederatedActorIRI4))
+		w.deliver = func(c context.Context, outboxIRI *url.URL, activity Activity) error {
+			t.Fatalf("unexpected delivery")
+			return nil
+		}
+		m := newMoveFn()
+		err := w.move(ctx, m)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+	})
+	t.Run("UnfollowsOriginAndFollowsTarget", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		w, mockDB, mockTp := setupFn(ctl)
+		mockTp.EXPECT().Dereference(ctx, mustParse(testFederatedActorIRI3)).Return(
+			mustSerializeToBytes(newTargetFn(testFederatedActorIRI)), nil)
+		expectActorFn(mockDB, newFollowingFn(testFederatedActorIRI, testFederatedActorIRI4))
+		mockDB.EXPECT().Lock(ctx, mustParse(testFederatedActorIRI2))
+		mockDB.EXPECT().Following(ctx, mustParse(testFederatedActorIRI2)).Return(
+			newFollowingFn(testFederatedActorIRI, testFederatedActorIRI4), nil)
+		mockDB.EXPECT().Update(ctx, newFollowingFn(testFederatedActorIRI4))
+		mockDB.EXPECT().Unlock(ctx, mustParse(testFederatedActorIRI2))
+		finder := NewMockOutboxFollowFinder(ctl)
+		w.db = outboxFollowFinderDatabase{mockDB, finder}
+		storedFollow := newFollow(mustParse(testFederatedActorIRI2), mustParse(testFederatedActorIRI))
+		storedId := streams.NewJSONLDIdProperty()
+		storedId.Set(mustParse(testNewActivityIRI2))
+		storedFollow.SetJSONLDId(storedId)
+		mockDB.EXPECT().Lock(ctx, mustParse(testMyOutboxIRI))
+		finder.EXPECT().FindOutboxFollow(ctx, mustParse(testMyOutboxIRI), mustParse(testFederatedActorIRI)).Return(
+			storedFollow, nil)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testMyOutboxIRI))
+		expectUndo := newUndo(mustParse(testFederatedActorIRI2), storedFollow, mustParse(testFederatedActorIRI))
+		id := streams.NewJSONLDIdProperty()
+		id.Set(mustParse(testNewActivityIRI))
+		expectUndo.SetJSONLDId(id)
+		expectFollow := newFollow(mustParse(testFederatedActorIRI2), mustParse(testFederatedActorIRI3))
+		id = streams.NewJSONLDIdProperty()
+		id.Set(mustParse(testNewActivityIRI))
+		expectFollow.SetJSONLDId(id)
+		mockDB.EXPECT().Lock(ctx, mustParse(testNewActivityIRI)).Times(2)
+		mockDB.EXPECT().Create(ctx, expectUndo)
+		mockDB.EXPECT().Create(ctx, expectFollow)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testNewActivityIRI)).Times(2)
+		var gotOutboxes []*url.URL
+		var gotActivities []Activity
+		w.deliver = func(c context.Context, outboxIRI *url.URL, activity Activity) error {
+			gotOutboxes = append(gotOutboxes, outboxIRI)
+			gotActivities = append(gotActivities, activity)
+			return nil
+		}
+		m := newMoveFn()
+		err := w.move(ctx, m)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		assertEqual(t, len(gotActivities), 2)
+		assertEqual(t, gotOutboxes[0].String(), testMyOutboxIRI)
+		assertByteEqual(t, mustSerializeToBytes(gotActivities[0]), mustSerializeToBytes(expectUndo))
+		assertEqual(t, gotOutboxes[1].String(), testMyOutboxIRI)
+		assertByteEqual(t, mustSerializeToBytes(gotActivities[1]), mustSerializeToBytes(expectFollow))
+	})
+	t.Run("UndoesNewFollowWithoutOutboxFollowFinder", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		w, mockDB, mockTp := setupFn(ctl)
+		mockTp.EXPECT().Dereference(ctx, mustParse(testFederatedActorIRI3)).Return(
+			mustSerializeToBytes(newTargetFn(testFederatedActorIRI)), nil)
+		expectActorFn(mockDB, newFollowingFn(testFederatedActorIRI))
+		mockDB.EXPECT().Lock(ctx, mustParse(testFederatedActorIRI2))
+		mockDB.EXPECT().Following(ctx, mustParse(testFederatedActorIRI2)).Return(
+			newFollowingFn(testFederatedActorIRI), nil)
+		mockDB.EXPECT().Update(ctx, gomock.Any())
+		mockDB.EXPECT().Unlock(ctx, mustParse(testFederatedActorIRI2))
+		mockDB.EXPECT().Lock(ctx, mustParse(testNewActivityIRI)).Times(2)
+		mockDB.EXPECT().Create(ctx, gomock.Any()).Times(2)
+		mockDB.EXPECT().Unlock(ctx, mustParse(testNewActivityIRI)).Times(2)
+		var gotActivities []Activity
+		w.deliver = func(c context.Context, outboxIRI *url.URL, activity Activity) error {
+			gotActivities = append(gotActivities, activity)
+			return nil
+		}
+		m := newMoveFn()
+		err := w.move(ctx, m)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		assertEqual(t, len(gotActivities), 2)
+		expectUndo := newUndo(mustParse(testFederatedActorIRI2),
+			newFollow(mustParse(testFederatedActorIRI2), mustParse(testFederatedActorIRI)),
+			mustParse(testFederatedActorIRI))
+		id := streams.NewJSONLDIdProperty()
+		id.Set(mustParse(testNewActivityIRI))
+		expectUndo.SetJSONLDId(id)
+		assertByteEqual(t, mustSerializeToBytes(gotActivities[0]), mustSerializeToBytes(expectUndo))
+	})
+	t.Run("SkipMoveLeavesFollowing", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		w, mockDB, mockTp := setupFn(ctl)
+		mockTp.EXPECT().Dereference(ctx, mustParse(testFederatedActorIRI3)).Return(
+			mustSerializeToBytes(newTargetFn(testFederatedActorIRI)), nil)
+		expectActorFn(mockDB, newFollowingFn(testFederatedActorIRI))
+		var gotActor, gotOrigin, gotTarget *url.URL
+		w.SkipMove = func(c context.Context, actorIRI, origin, target *url.URL) bool {
+			gotActor = actorIRI
+			gotOrigin = origin
+			gotTarget = target
+			return true
+		}
+		w.deliver = func(c context.Context, outboxIRI *url.URL, activity Activity) error {
+			t.Fatalf("unexpected delivery")
+			return nil
+		}
+		m := newMoveFn()
+		err := w.move(ctx, m)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		assertEqual(t, gotActor.String(), testFederatedActorIRI2)
+		assertEqual(t, gotOrigin.String(), testFederatedActorIRI)
+		assertEqual(t, gotTarget.String(), testFederatedActorIRI3)
+	})
+	t.Run("CallsCustomCallback", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		w, mockDB, mockTp := setupFn(ctl)
+		mockTp.EXPECT().Dereference(ctx, mustParse(testFederatedActorIRI3)).Return(
+			mustSerializeToBytes(newTargetFn(testFederatedActorIRI)), nil)
+		expectActorFn(mockDB, newFollowingFn(testFederatedActorIRI4))
+		var gotc context.Context
+		var got vocab.ActivityStreamsMove
+		w.Move = func(ctx context.Context, v vocab.ActivityStreamsMove) error {
+			gotc = ctx
+			got = v
+			return nil
+		}
+		m := newMoveFn()
+		err := w.move(ctx, m)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		assertEqual(t, ctx, gotc)
+		assertEqual(t, m, got)
+	})
+}
@@ -0,0 +1,115 @@
+package pub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/go-fed/activity/streams"
+	"github.com/go-fed/activity/streams/vocab"
+	"net/url"
+)
+
+// IdMismatchError is returned by FetchAndVerify when the value fetched from an
+// IRI has a different 'id', such as when a peer serves, or redirects to, a
+// value owned by someone else, or when it was served by a different host than
+// the one of its 'id'.
+type IdMismatchError struct {
+	// IRI is the IRI that was fetched.
+	IRI *url.URL
+	// Id is the 'id' of the fetched value, or nil if it had none.
+	Id *url.URL
+	// FinalIRI is the IRI the value was served from after following
+	// redirects, if it is on a different host than the 'id'.
+	FinalIRI *url.URL
+}
+
+// Error describes the mismatched ids.
+func (e *IdMismatchError) Error() string {
+	if e.Id == nil {
+		return fmt.Sprintf("value fetched from %s has no id", e.IRI)
+	} else if e.FinalIRI != nil {
+		return fmt.Sprintf("value fetched from %s with id %s was served from %s", e.IRI, e.Id, e.FinalIRI)
+	}
+	return fmt.Sprintf("value fetched from %s has id %s", e.IRI, e.Id)
+}
+
+// FetchAndVerify dereferences the IRI with the Transport and deserializes the
+// ActivityStreams value.
+//
+// The 'id' of the value must be the IRI. If the Transport is a
+// RedirectReportingTransport, such as HttpSigTransport, the value must also
+// have been served by the host of the IRI after following redirects, which
+// ensures it is not a copy served from elsewhere. Other Transports are only
+// able to be trusted this way if they refuse redirects to other hosts. An
+// *IdMismatchError is returned otherwise.
+func FetchAndVerify(c context.Context, t Transport, iri *url.URL) (vocab.Type, error) {
+	var b []byte
+	var finalIRI *url.URL
+	var err error
+	if rt, ok := t.(RedirectReportingTransport); ok {
+		b, finalIRI, err = rt.DereferenceFinalURL(c, iri)
+	} else {
+		b, err = t.Dereference(c, iri)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err = json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	v, err := streams.ToType(c, m)
+	if err != nil {
+		return nil, err
+	}
+	idProp := v.GetJSONLDId()
+	if idProp == nil || idProp.Get() == nil {
+		return nil, &IdMismatchError{IRI: iri}
+	} else if id := idProp.Get(); id.String() != iri.String() {
+		return nil, &IdMismatchError{IRI: iri, Id: id}
+	} else if finalIRI != nil && finalIRI.Host != id.Host {
+		return nil, &IdMismatchError{IRI: iri, Id: id, FinalIRI: finalIRI}
+	}
+	return v, nil
+}
+
+// RefetchForeignObjects replaces the values embedded in the 'object' property
+// of the activity that are not on the host of one of its actors with the
+// values fetched from their 'id' using FetchAndVerify.
+//
+// A peer can only vouch for the objects it hosts, so this prevents it from
+// forging the content of others' objects. Embedded values without an 'id' and
+// 'object' entries that are only IRIs are left unchanged. An error is returned
+// if any value cannot be fetched and verified.
+func RefetchForeignObjects(c context.Context, t Transport, activity Activity) error {
+	op := activity.GetActivityStreamsObject()
+	if op == nil {
+		return nil
+	}
+	actors, err := getActorIds(activity)
+	if err != nil {
+		return err
+	}
+	actorHosts := make(map[string]bool, len(actors))
+	for _, actor := range actors {
+		actorHosts[actor.Host] = true
+	}
+	for iter := op.Begin(); iter != op.End(); iter = iter.Next() {
+		embedded := iter.GetType()
+		if embedded == nil || embedded.GetJSONLDId() == nil || embedded.GetJSONLDId().Get() == nil {
+			continue
+		}
+		id := embedded.GetJSONLDId().Get()
+		if actorHosts[id.Host] {
+			continue
+		}
+		fetched, err := FetchAndVerify(c, t, id)
+		if err != nil {
+			return err
+		}
+		if err = iter.SetType(fetched); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package pub
+
+import (
+	"context"
+	"errors"
+	"github.com/go-fed/activity/streams"
+	"github.com/go-fed/activity/streams/vocab"
+	"github.com/golang/mock/gomock"
+	"net/url"
+	"testing"
+)
+
+// TestFetchAndVerify tests fetching values and verifying their ids.
+func TestFetchAndVerify(t *testing.T) {
+	ctx := context.Background()
+	setupData()
+	t.Run("ReturnsValueWithMatchingId", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		tp := NewMockTransport(ctl)
+		tp.EXPECT().Dereference(ctx, mustParse(testNoteId1)).Return(
+			mustSerializeToBytes(testFederatedNote), nil)
+		v, err := FetchAndVerify(ctx, tp, mustParse(testNoteId1))
+		assertEqual(t, err, nil)
+		assertByteEqual(t, mustSerializeToBytes(v), mustSerializeToBytes(testFederatedNote))
+	})
+	t.Run("ErrorIfIdMismatches", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		tp := NewMockTransport(ctl)
+		tp.EXPECT().Dereference(ctx, mustParse(testNoteId2)).Return(
+			mustSerializeToBytes(testFederatedNote), nil)
+		_, err := FetchAndVerify(ctx, tp, mustParse(testNoteId2))
+		var mismatch *IdMismatchError
+		if !errors.As(err, &mismatch) {
+			t.Fatalf("expected *IdMismatchError, got %v", err)
+		}
+		assertEqual(t, mismatch.IRI.String(), testNoteId2)
+		assertEqual(t, mismatch.Id.String(), testNoteId1)
+	})
+	t.Run("ErrorIfNoId", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		tp := NewMockTransport(ctl)
+		tp.EXPECT().Dereference(ctx, mustParse(testNoteId1)).Return(
+			mustSerializeToBytes(streams.NewActivityStreamsNote()), nil)
+		_, err := FetchAndVerify(ctx, tp, mustParse(testNoteId1))
+		var mismatch *IdMismatchError
+		if !errors.As(err, &mismatch) {
+			t.Fatalf("expected *IdMismatchError, got %v", err)
+		}
+		assertEqual(t, mismatch.Id, (*url.URL)(nil))
+	})
+	t.Run("ReturnsValueServedFromSameHost", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		rt := NewMockRedirectReportingTransport(ctl)
+		tp := redirectReportingTransport{NewMockTransport(ctl), rt}
+		rt.EXPECT().DereferenceFinalURL(ctx, mustParse(testNoteId1)).Return(
+			mustSerializeToBytes(testFederatedNote), mustParse(testNoteId2), nil)
+		v, err := FetchAndVerify(ctx, tp, mustParse(testNoteId1))
+		assertEqual(t, err, nil)
+		assertByteEqual(t, mustSerializeToBytes(v), mustSerializeToBytes(testFederatedNote))
+	})
+	t.Run("ErrorIfRedirectedToOtherHost", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		rt := NewMockRedirectReportingTransport(ctl)
+		tp := redirectReportingTransport{NewMockTransport(ctl), rt}
+		rt.EXPECT().DereferenceFinalURL(ctx, mustParse(testNoteId1)).Return(
+			mustSerializeToBytes(testFederatedNote), mustParse("https://elsewhere.example.com/note"), nil)
+		_, err := FetchAndVerify(ctx, tp, mustParse(testNoteId1))
+		var mismatch *IdMismatchError
+		if !errors.As(err, &mismatch) {
+			t.Fatalf("expected *IdMismatchError, got %v", err)
+		}
+		assertEqual(t, mismatch.Id.String(), testNoteId1)
+		assertEqual(t, mismatch.FinalIRI.String(), "https://elsewhere.example.com/note")
+	})
+}
+
+// redirectReportingTransport is a Transport that is also a
+// RedirectReportingTransport.
+type redirectReportingTransport struct {
+	*MockTransport
+	*MockRedirectReportingTransport
+}
+
+// TestRefetchForeignObjects tests replacing embedded objects hosted elsewhere
+// than the activity's actors.
+func TestRefetchForeignObjects(t *testing.T) {
+	ctx := context.Background()
+	setupData()
+	newNoteFn := func(id, content string) vocab.ActivityStreamsNote {
+		n := streams.NewActivityStreamsNote()
+		idProp := streams.NewJSONLDIdProperty()
+		idProp.Set(mustParse(id))
+		n.SetJSONLDId(idProp)
+		cp := streams.NewActivityStreamsContentProperty()
+		cp.AppendXMLSchemaString(content)
+		n.SetActivityStreamsContent(cp)
+		return n
+	}
+	newAnnounceFn := func(objs ...vocab.ActivityStreamsNote) vocab.ActivityStreamsAnnounce {
+		a := streams.NewActivityStreamsAnnounce()
+		actor := streams.NewActivityStreamsActorProperty()
+		actor.AppendIRI(mustParse(testFederatedActorIRI))
+		a.SetActivityStreamsActor(actor)
+		op := streams.NewActivityStreamsObjectProperty()
+		for _, o := range objs {
+			op.AppendActivityStreamsNote(o)
+		}
+		op.AppendIRI(mustParse(testNoteId2))
+		a.SetActivityStreamsObject(op)
+		return a
+	}
+	t.Run("ReplacesForeignObjects", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		tp := NewMockTransport(ctl)
+		genuine := newNoteFn(testNoteId1, "genuine")
+		tp.EXPECT().Dereference(ctx, mustParse(testNoteId1)).Return(
+			mustSerializeToBytes(genuine), nil)
+		a := newAnnounceFn(newNoteFn(testNoteId1, "forged"), newNoteFn(testFederatedActivityIRI, "own"))
+		err := RefetchForeignObjects(ctx, tp, a)
+		assertEqual(t, err, nil)
+		op := a.GetActivityStreamsObject()
+		assertEqual(t, op.Len(), 3)
+		assertByteEqual(t, mustSerializeToBytes(op.At(0).GetType()), mustSerializeToBytes(genuine))
+		assertEqual(t, op.At(1).GetActivityStreamsNote().GetActivityStreamsContent().At(0).GetXMLSchemaString(), "own")
+		assertEqual(t, op.At(2).GetIRI().String(), testNoteId2)
+	})
+	t.Run("ErrorIfForeignObjectCannotBeVerified", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		tp := NewMockTransport(ctl)
+		tp.EXPECT().Dereference(ctx, mustParse(testNoteId1)).Return(
+			mustSerializeToBytes(newNoteFn(testNoteId2, "elsewhere")), nil)
+		a := newAnnounceFn(newNoteFn(testNoteId1, "forged"))
+		err := RefetchForeignObjects(ctx, tp, a)
+		if err == nil {
+			t.Fatalf("expected error, got none")
+		}
+	})
+}
@@ -22,7 +22,8 @@ import (
 // If 'isASRequest' is true and there is no error, then the HandlerFunc
 // successfully served the request and wrote to the ResponseWriter.
 //
-// Callers are responsible for authorized access to this resource.
+// Callers are responsible for authorized access to this resource, such as by
+// wrapping the HandlerFunc with an AuthorizedFetch.
 type HandlerFunc func(c context.Context, w http.ResponseWriter, r *http.Request) (isASRequest bool, err error)
 
 // NewActivityStreamsHandler creates a HandlerFunc to serve ActivityStreams
@@ -33,6 +34,24 @@ type HandlerFunc func(c context.Context, w http.ResponseWriter, r *http.Request)
 // before responding with them. Sets the appropriate HTTP status code for
 // Tombstone Activities as well.
 func NewActivityStreamsHandler(db Database, clock Clock) HandlerFunc {
+	return newActivityStreamsHandler(db, clock, nil)
+}
+
+// NewAuthorizedActivityStreamsHandler creates a HandlerFunc like
+// NewActivityStreamsHandler, that requires ActivityStreams requests to be
+// signed and only serves the values Visible to the signer, as done by the
+// AuthorizedFetch.
+//
+// Values that are not visible are answered with 404 Not Found, except for
+// Tombstones which are served with 410 Gone.
+func NewAuthorizedActivityStreamsHandler(db Database, clock Clock, a *AuthorizedFetch) HandlerFunc {
+	return a.Wrap(newActivityStreamsHandler(db, clock, a))
+}
+
+// newActivityStreamsHandler creates a HandlerFunc serving the stored
+// ActivityStreams values, that are Visible to the signer of the request if the
+// AuthorizedFetch is not nil.
+func newActivityStreamsHandler(db Database, clock Clock, a *AuthorizedFetch) HandlerFunc {
 	return func(c context.Context, w http.ResponseWriter, r *http.Request) (isASRequest bool, err error) {
 		// Do nothing if it is not an ActivityPub GET request
 		if !isActivityPubGet(r) {
@@ -55,6 +74,18 @@ func NewActivityStreamsHandler(db Database, clock Clock) HandlerFunc {
 		// Unlock must have been called by this point and in every
 		// branch above
 		//
+		// Hide the value from those it is not addressed to.
+		if a != nil && !streams.IsOrExtendsActivityStreamsTombstone(t) {
+			signer, _ := SignerFromContext(c)
+			var visible bool
+			visible, err = a.Visible(c, signer, t)
+			if err != nil {
+				return
+			} else if !visible {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+		}
 		// Remove sensitive fields.
 		clearSensitiveFields(t)
 		// Serialize the fetched value.
@@ -0,0 +1,436 @@
+package pub
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"github.com/go-fed/activity/streams"
+	"github.com/go-fed/activity/streams/vocab"
+	"github.com/go-fed/httpsig"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultMaxDateSkew is the default maximum difference allowed between
+	// the Date header of a signed request and the current time.
+	DefaultMaxDateSkew = 12 * time.Hour
+	// DefaultPublicKeyTTL is the default duration for which a cached public
+	// key is trusted before it is fetched again.
+	DefaultPublicKeyTTL = 24 * time.Hour
+	// The Host header.
+	hostHeader = "Host"
+	// The headers parameter in the Signature or Authorization header.
+	signatureHeadersParameter = "headers"
+)
+
+// HttpSigVerifier verifies the HTTP Signature on requests from peer servers,
+// and is intended to help applications implement AuthenticatePostInbox and
+// AuthenticateGetInbox, AuthenticateGetOutbox, and similar methods.
+//
+// A request is authentic when its Date header is within the allowed skew of
+// the Clock, its Digest or Content-Digest header matches its body, and its signature verifies
+// against the public key identified by the signature's keyId. The public key
+// is fetched with the Transport, and must be owned by an actor that also
+// lists the key. The owner is either fetched along with the key from the
+// key's host, or fetched from its own id with FetchAndVerify.
+//
+// Keys may be cached in a PublicKeyStore. A cached key failing verification is
+// fetched once more, in case the peer rotated its keys.
+//
+// It is safe for concurrent use, but the Transport passed to Verify is not.
+type HttpSigVerifier struct {
+	clock       Clock
+	algos       []httpsig.Algorithm
+	maxDateSkew time.Duration
+	// keys is optional. If set, fetched keys are cached in it for keyTTL.
+	keys   PublicKeyStore
+	keyTTL time.Duration
+}
+
+// NewHttpSigVerifier returns a verifier of HTTP Signatures.
+//
+// The algos are the HTTP Signature algorithms accepted from peers, tried in
+// order. If none are provided, only RSA_SHA256 is accepted. A zero
+// maxDateSkew uses DefaultMaxDateSkew.
+func NewHttpSigVerifier(clock Clock, algos []httpsig.Algorithm, maxDateSkew time.Duration) *HttpSigVerifier {
+	if len(algos) == 0 {
+		algos = []httpsig.Algorithm{httpsig.RSA_SHA256}
+	}
+	if maxDateSkew == 0 {
+		maxDateSkew = DefaultMaxDateSkew
+	}
+	return &HttpSigVerifier{
+		clock:       clock,
+		algos:       algos,
+		maxDateSkew: maxDateSkew,
+	}
+}
+
+// NewCachingHttpSigVerifier returns a verifier of HTTP Signatures that caches
+// the public keys of peers in the PublicKeyStore.
+//
+// Cached keys are fetched again once they are older than the ttl, according
+// to the Clock. A zero ttl uses DefaultPublicKeyTTL. The other parameters are
+// the same as for NewHttpSigVerifier.
+func NewCachingHttpSigVerifier(clock Clock, algos []httpsig.Algorithm, maxDateSkew time.Duration, keys PublicKeyStore, ttl time.Duration) *HttpSigVerifier {
+	v := NewHttpSigVerifier(clock, algos, maxDateSkew)
+	if ttl == 0 {
+		ttl = DefaultPublicKeyTTL
+	}
+	v.keys = keys
+	v.keyTTL = ttl
+	return v
+}
+
+// Verify checks the HTTP Signature on the request, returning the id of the
+// public key that signed it and the IRI of the actor owning that key.
+//
+// The Transport is used to dereference the public key and its owner. It should
+// send requests on behalf of the actor receiving the request.
+//
+// The request body is read in order to check the digest headers and is replaced
+// afterwards, so it may be read again by the caller. The returned owner is only
+// the actor that signed the request; use VerifyActivity to also ensure it is
+// the actor of the activity in the body.
+//
+// Within AuthenticatePostInbox or AuthenticatePostOutbox of an Actor with
+// RequestLimits, reading the body stops at MaxBodyBytes and Verify returns
+// ErrRequestBodyTooLarge, which the Actor answers with 413 Request Entity Too
+// Large.
+func (v *HttpSigVerifier) Verify(c context.Context, r *http.Request, t Transport) (keyId, owner *url.URL, err error) {
+	signed, err := signedHeaders(r.Header)
+	if err != nil {
+		return
+	}
+	if err = v.verifyDate(r.Header, signed); err != nil {
+		return
+	}
+	if r.Body != nil {
+		var b []byte
+		b, err = ioutil.ReadAll(r.Body)
+		if err != nil {
+			return
+		}
+		r.Body.Close()
+		r.Body = ioutil.NopCloser(bytes.NewReader(b))
+		if err = verifyDigest(r.Header, signed, b); err != nil {
+			return
+		}
+	}
+	// The standard library moves the Host header out of the request
+	// headers, but peers commonly sign it.
+	if len(r.Header.Get(hostHeader)) == 0 && len(r.Host) > 0 {
+		r.Header.Set(hostHeader, r.Host)
+	}
+	verifier, err := httpsig.NewVerifier(r)
+	if err != nil {
+		return
+	}
+	keyId, err = url.Parse(verifier.KeyId())
+	if err != nil {
+		return
+	}
+	pubKey, owner, fetched, err := v.publicKey(c, t, keyId, false)
+	if err != nil {
+		return
+	}
+	if err = v.verifySignature(verifier, pubKey); err != nil && !fetched {
+		// The cached key may have been rotated, so fetch it once more.
+		pubKey, owner, _, err = v.publicKey(c, t, keyId, true)
+		if err != nil {
+			return
+		}
+		err = v.verifySignature(verifier, pubKey)
+	}
+	if err == nil {
+		return
+	}
+	owner = nil
+	err = fmt.Errorf("http signature by %s failed verification: %s", keyId, err)
+	return
+}
+
+// VerifyActivity checks the HTTP Signature on the request as Verify does, and
+// that the owner of the key is the actor of the activity in its body.
+//
+// Returns ErrSignerNotActor if the activity has any other actor.
+func (v *HttpSigVerifier) VerifyActivity(c context.Context, r *http.Request, t Transport, activity Activity) (keyId, owner *url.URL, err error) {
+	keyId, owner, err = v.Verify(c, r, t)
+	if err != nil {
+		return
+	}
+	actors, err := getActorIds(activity)
+	if err != nil {
+		return
+	}
+	for _, actor := range actors {
+		if actor.String() != owner.String() {
+			err = ErrSignerNotActor
+			return
+		}
+	}
+	return
+}
+
+// verifySignature tries each of the accepted algorithms in turn.
+func (v *HttpSigVerifier) verifySignature(verifier httpsig.Verifier, pubKey crypto.PublicKey) (err error) {
+	for _, algo := range v.algos {
+		if err = verifier.Verify(pubKey, algo); err == nil {
+			return
+		}
+	}
+	return
+}
+
+// publicKey returns the public key with the given id and its owner, and
+// whether it was just fetched from the peer.
+//
+// A cached key is returned if it has not expired, unless refetch is true.
+// Fetched keys are cached, unless their owner has been revoked.
+func (v *HttpSigVerifier) publicKey(c context.Context, t Transport, keyId *url.URL, refetch bool) (pubKey crypto.PublicKey, owner *url.URL, fetched bool, err error) {
+	if v.keys != nil && !refetch {
+		var k CachedPublicKey
+		var exists bool
+		k, exists, err = v.keys.Get(c, keyId)
+		if err != nil {
+			return
+		} else if exists && v.clock.Now().Before(k.Expires) {
+			pubKey, err = parsePublicKeyPem(k.Key)
+			owner = k.Owner
+			return
+		}
+	}
+	key, owner, err := dereferencePublicKey(c, t, keyId)
+	if err != nil {
+		return
+	}
+	fetched = true
+	if v.keys != nil {
+		var revoked bool
+		revoked, err = v.keys.IsRevoked(c, owner)
+		if err != nil {
+			return
+		} else if revoked {
+			err = fmt.Errorf("public keys of %s have been revoked", owner)
+			return
+		}
+		err = v.keys.Set(c, CachedPublicKey{
+			KeyId:   keyId,
+			Owner:   owner,
+			Key:     key,
+			Expires: v.clock.Now().Add(v.keyTTL),
+		})
+		if err != nil {
+			return
+		}
+	}
+	pubKey, err = parsePublicKeyPem(key)
+	return
+}
+
+// verifyDate ensures the Date header is signed and is within the maximum skew
+// of the current time.
+func (v *HttpSigVerifier) verifyDate(h http.Header, signed []string) error {
+	if !containsHeader(signed, dateHeader) {
+		return fmt.Errorf("http signature does not sign the %s header", dateHeader)
+	}
+	date, err := http.ParseTime(h.Get(dateHeader))
+	if err != nil {
+		return fmt.Errorf("cannot parse %s header: %s", dateHeader, err)
+	}
+	skew := v.clock.Now().Sub(date)
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > v.maxDateSkew {
+		return fmt.Errorf("%s header %s is outside the allowed skew of %s", dateHeader, h.Get(dateHeader), v.maxDateSkew)
+	}
+	return nil
+}
+
+// verifyDigest ensures that the Digest and Content-Digest headers present are
+// signed, and that they match the body.
+func verifyDigest(h http.Header, signed []string, body []byte) error {
+	for _, name := range []string{digestHeader, contentDigestHeader} {
+		if len(h.Get(name)) > 0 && !containsHeader(signed, name) {
+			return fmt.Errorf("http signature does not sign the %s header", name)
+		}
+	}
+	return verifyDigestHeaders(h, body)
+}
+
+// signedHeaders returns the lowercase names of the headers covered by the HTTP
+// Signature, which defaults to only the Date header.
+func signedHeaders(h http.Header) ([]string, error) {
+	s := h.Get(string(httpsig.Signature))
+	if len(s) == 0 {
+		s = strings.TrimPrefix(h.Get(string(httpsig.Authorization)), string(httpsig.Signature)+" ")
+	}
+	if len(s) == 0 {
+		return nil, fmt.Errorf("request has no http signature")
+	}
+	for _, p := range strings.Split(s, ",") {
+		kv := strings.SplitN(strings.TrimSpace(p), "=", 2)
+		if len(kv) == 2 && kv[0] == signatureHeadersParameter {
+			return strings.Fields(strings.ToLower(strings.Trim(kv[1], "\""))), nil
+		}
+	}
+	return []string{strings.ToLower(dateHeader)}, nil
+}
+
+// containsHeader determines if the header name is in the list of lowercase
+// header names.
+func containsHeader(headers []string, name string) bool {
+	name = strings.ToLower(name)
+	for _, h := range headers {
+		if h == name {
+			return true
+		}
+	}
+	return false
+}
+
+// dereferencePublicKey fetches the public key with the given id, returning it
+// with the IRI of its owner.
+//
+// The key id may either refer to a key embedded in its owner, such as with a
+// fragment, or to a standalone key document. Standalone keys have no
+// ActivityStreams type, so their 'owner' is fetched and verified with
+// FetchAndVerify instead. An owner embedding the key must be hosted on the same
+// host as the key id, so that a peer cannot serve a copy of another's actor
+// with its own key. In both cases, the key material is taken from the owner,
+// which must list the key.
+func dereferencePublicKey(c context.Context, t Transport, keyId *url.URL) (vocab.W3IDSecurityV1PublicKey, *url.URL, error) {
+	m, err := dereferenceMap(c, t, keyId)
+	if err != nil {
+		return nil, nil, err
+	}
+	var actorId *url.URL
+	actor, err := streams.ToType(c, m)
+	if err == nil && hasPublicKeys(actor) {
+		if actorId, err = GetId(actor); err != nil {
+			return nil, nil, err
+		} else if actorId.Host != keyId.Host {
+			return nil, nil, fmt.Errorf("public key %s is not on the host of its owner %s", keyId, actorId)
+		}
+	} else {
+		owner, ok := m["owner"].(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("public key %s has no owner", keyId)
+		}
+		ownerIRI, err := url.Parse(owner)
+		if err != nil {
+			return nil, nil, err
+		}
+		if actor, err = FetchAndVerify(c, t, ownerIRI); err != nil {
+			return nil, nil, err
+		}
+		actorId = ownerIRI
+	}
+	key, err := findPublicKey(actor, keyId)
+	if err != nil {
+		return nil, nil, err
+	}
+	owner, err := publicKeyOwner(key)
+	if err != nil {
+		return nil, nil, err
+	} else if owner.String() != actorId.String() {
+		return nil, nil, fmt.Errorf("public key %s is owned by %s, not %s", keyId, owner, actorId)
+	}
+	return key, owner, nil
+}
+
+// hasPublicKeys determines whether the type has a 'publicKey' property.
+func hasPublicKeys(t vocab.Type) bool {
+	pk, ok := t.(publicKeyer)
+	return ok && pk.GetW3IDSecurityV1PublicKey() != nil
+}
+
+// findPublicKey returns the public key with the given id from the actor's
+// 'publicKey' property.
+func findPublicKey(actor vocab.Type, keyId *url.URL) (vocab.W3IDSecurityV1PublicKey, error) {
+	pk, ok := actor.(publicKeyer)
+	if !ok {
+		return nil, fmt.Errorf("%s type has no public keys", actor.GetTypeName())
+	}
+	keys := pk.GetW3IDSecurityV1PublicKey()
+	if keys == nil {
+		return nil, fmt.Errorf("%s has no public keys", keyId)
+	}
+	for iter := keys.Begin(); iter != keys.End(); iter = iter.Next() {
+		if !iter.IsW3IDSecurityV1PublicKey() {
+			continue
+		}
+		id, err := GetId(iter.Get())
+		if err != nil {
+			return nil, err
+		}
+		if id.String() == keyId.String() {
+			return iter.Get(), nil
+		}
+	}
+	return nil, fmt.Errorf("public key %s is not listed by its owner", keyId)
+}
+
+// publicKeyOwner returns the IRI in the 'owner' property of a public key.
+func publicKeyOwner(key vocab.W3IDSecurityV1PublicKey) (*url.URL, error) {
+	o := key.GetW3IDSecurityV1Owner()
+	if o == nil || !o.IsIRI() && !o.IsXMLSchemaAnyURI() {
+		return nil, fmt.Errorf("public key has no owner")
+	}
+	if o.IsIRI() {
+		return o.GetIRI(), nil
+	}
+	return o.Get(), nil
+}
+
+// parsePublicKeyPem parses the 'publicKeyPem' property of a public key, which
+// is either a PKIX or PKCS #1 encoded key.
+func parsePublicKeyPem(key vocab.W3IDSecurityV1PublicKey) (crypto.PublicKey, error) {
+	p := key.GetW3IDSecurityV1PublicKeyPem()
+	if p == nil || !p.IsXMLSchemaString() {
+		return nil, fmt.Errorf("public key has no publicKeyPem")
+	}
+	block, _ := pem.Decode([]byte(p.Get()))
+	if block == nil {
+		return nil, fmt.Errorf("publicKeyPem is not PEM encoded")
+	}
+	if pubKey, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		return pubKey, nil
+	}
+	return x509.ParsePKCS1PublicKey(block.Bytes)
+}
+
+// dereferenceMap fetches the IRI with the Transport and decodes it as JSON.
+//
+// If the Transport is a RedirectReportingTransport, the value must have been
+// served by the host of the IRI after following redirects.
+func dereferenceMap(c context.Context, t Transport, iri *url.URL) (map[string]interface{}, error) {
+	var b []byte
+	var err error
+	if rt, ok := t.(RedirectReportingTransport); ok {
+		var finalIRI *url.URL
+		b, finalIRI, err = rt.DereferenceFinalURL(c, iri)
+		if err == nil && finalIRI != nil && finalIRI.Host != iri.Host {
+			err = fmt.Errorf("%s was served from %s", iri, finalIRI)
+		}
+	} else {
+		b, err = t.Dereference(c, iri)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err = json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
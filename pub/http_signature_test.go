@@ -0,0 +1,464 @@
+package pub
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-fed/activity/streams"
+	"github.com/go-fed/activity/streams/vocab"
+	"github.com/go-fed/httpsig"
+	"github.com/golang/mock/gomock"
+)
+
+const (
+	testFederatedKeyIRI = "https://other.example.com/dakota#main-key"
+)
+
+// newTestPublicKey creates a public key owned by the owner IRI.
+func newTestPublicKey(t *testing.T, id, owner string, pubKey *rsa.PublicKey) vocab.W3IDSecurityV1PublicKey {
+	der, err := x509.MarshalPKIXPublicKey(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := streams.NewW3IDSecurityV1PublicKey()
+	idp := streams.NewJSONLDIdProperty()
+	idp.Set(mustParse(id))
+	key.SetJSONLDId(idp)
+	op := streams.NewW3IDSecurityV1OwnerProperty()
+	op.SetIRI(mustParse(owner))
+	key.SetW3IDSecurityV1Owner(op)
+	pp := streams.NewW3IDSecurityV1PublicKeyPemProperty()
+	pp.Set(string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})))
+	key.SetW3IDSecurityV1PublicKeyPem(pp)
+	return key
+}
+
+// newTestKeyedPerson creates a federated Person listing the public key.
+func newTestKeyedPerson(key vocab.W3IDSecurityV1PublicKey) vocab.ActivityStreamsPerson {
+	p := streams.NewActivityStreamsPerson()
+	id := streams.NewJSONLDIdProperty()
+	id.Set(mustParse(testFederatedActorIRI))
+	p.SetJSONLDId(id)
+	pk := streams.NewW3IDSecurityV1PublicKeyProperty()
+	pk.AppendW3IDSecurityV1PublicKey(key)
+	p.SetW3IDSecurityV1PublicKey(pk)
+	return p
+}
+
+// newTestSignedRequest creates a POST request to the inbox, signed over the
+// given headers. The Digest header is set to the body's SHA-256 digest.
+func newTestSignedRequest(t *testing.T, privKey *rsa.PrivateKey, keyId string, body []byte, headers []string) *http.Request {
+	r := httptest.NewRequest("POST", testMyInboxIRI, bytes.NewReader(body))
+	r.Header.Set(hostHeader, r.Host)
+	r.Header.Set(dateHeader, nowDateHeader())
+	hashed := sha256.Sum256(body)
+	r.Header.Set(digestHeader, sha256Digest+digestDelimiter+base64.StdEncoding.EncodeToString(hashed[:]))
+	s, _, err := httpsig.NewSigner([]httpsig.Algorithm{httpsig.RSA_SHA256}, httpsig.DigestSha256, headers, httpsig.Signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.SignRequest(privKey, keyId, r, nil); err != nil {
+		t.Fatal(err)
+	}
+	// Servers do not see the Host header among the request headers.
+	r.Header.Del(hostHeader)
+	return r
+}
+
+// TestHttpSigVerifier tests verifying incoming HTTP Signatures.
+func TestHttpSigVerifier(t *testing.T) {
+	ctx := context.Background()
+	privKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	allHeaders := []string{httpsig.RequestTarget, "host", "date", "digest"}
+	setupFn := func(ctl *gomock.Controller) (v *HttpSigVerifier, c *MockClock, tp *MockTransport) {
+		c = NewMockClock(ctl)
+		tp = NewMockTransport(ctl)
+		v = NewHttpSigVerifier(c, nil, time.Minute)
+		return
+	}
+	t.Run("VerifiesKeyEmbeddedInActor", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		v, c, tp := setupFn(ctl)
+		person := newTestKeyedPerson(newTestPublicKey(t, testFederatedKeyIRI, testFederatedActorIRI, &privKey.PublicKey))
+		r := newTestSignedRequest(t, privKey, testFederatedKeyIRI, testRespBody, allHeaders)
+		// Mock
+		c.EXPECT().Now().Return(now())
+		tp.EXPECT().Dereference(ctx, mustParse(testFederatedKeyIRI)).Return(mustSerializeToBytes(person), nil)
+		// Run
+		keyId, owner, err := v.Verify(ctx, r, tp)
+		// Verify
+		assertEqual(t, err, nil)
+		assertEqual(t, keyId.String(), testFederatedKeyIRI)
+		assertEqual(t, owner.String(), testFederatedActorIRI)
+		b := make([]byte, len(testRespBody))
+		r.Body.Read(b)
+		assertByteEqual(t, b, testRespBody)
+	})
+	t.Run("VerifiesStandaloneKey", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		v, c, tp := setupFn(ctl)
+		key := newTestPublicKey(t, testFederatedActorIRI2, testFederatedActorIRI, &privKey.PublicKey)
+		person := newTestKeyedPerson(key)
+		r := newTestSignedRequest(t, privKey, testFederatedActorIRI2, testRespBody, allHeaders)
+		// Mock
+		c.EXPECT().Now().Return(now())
+		tp.EXPECT().Dereference(ctx, mustParse(testFederatedActorIRI2)).Return(mustSerializeToBytes(key), nil)
+		tp.EXPECT().Dereference(ctx, mustParse(testFederatedActorIRI)).Return(mustSerializeToBytes(person), nil)
+		// Run
+		keyId, owner, err := v.Verify(ctx, r, tp)
+		// Verify
+		assertEqual(t, err, nil)
+		assertEqual(t, keyId.String(), testFederatedActorIRI2)
+		assertEqual(t, owner.String(), testFederatedActorIRI)
+	})
+	t.Run("RejectsKeyNotListedByOwner", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		v, c, tp := setupFn(ctl)
+		key := newTestPublicKey(t, testFederatedActorIRI2, testFederatedActorIRI, &privKey.PublicKey)
+		person := newTestKeyedPerson(newTestPublicKey(t, testFederatedKeyIRI, testFederatedActorIRI, &privKey.PublicKey))
+		r := newTestSignedRequest(t, privKey, testFederatedActorIRI2, testRespBody, allHeaders)
+		// Mock
+		c.EXPECT().Now().Return(now())
+		tp.EXPECT().Dereference(ctx, mustParse(testFederatedActorIRI2)).Return(mustSerializeToBytes(key), nil)
+		tp.EXPECT().Dereference(ctx, mustParse(testFederatedActorIRI)).Return(mustSerializeToBytes(person), nil)
+		// Run
+		_, owner, err := v.Verify(ctx, r, tp)
+		// Verify
+		assertNotEqual(t, err, nil)
+		assertEqual(t, owner, (*url.URL)(nil))
+	})
+	t.Run("RejectsKeyOwnedByAnotherActor", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		v, c, tp := setupFn(ctl)
+		person := newTestKeyedPerson(newTestPublicKey(t, testFederatedKeyIRI, testFederatedActorIRI2, &privKey.PublicKey))
+		r := newTestSignedRequest(t, privKey, testFederatedKeyIRI, testRespBody, allHeaders)
+		// Mock
+		c.EXPECT().Now().Return(now())
+		tp.EXPECT().Dereference(ctx, mustParse(testFederatedKeyIRI)).Return(mustSerializeToBytes(person), nil)
+		// Run
+		_, _, err := v.Verify(ctx, r, tp)
+		// Verify
+		assertNotEqual(t, err, nil)
+	})
+	t.Run("RejectsKeyOnAnotherHostThanOwner", func(t *testing.T) {
+		// Setup
+		const evilKeyIRI = "https://evil.example.net/k#main-key"
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		v, c, tp := setupFn(ctl)
+		person := newTestKeyedPerson(newTestPublicKey(t, evilKeyIRI, testFederatedActorIRI, &privKey.PublicKey))
+		r := newTestSignedRequest(t, privKey, evilKeyIRI, testRespBody, allHeaders)
+		// Mock
+		c.EXPECT().Now().Return(now())
+		tp.EXPECT().Dereference(ctx, mustParse(evilKeyIRI)).Return(mustSerializeToBytes(person), nil)
+		// Run
+		_, owner, err := v.Verify(ctx, r, tp)
+		// Verify
+		assertNotEqual(t, err, nil)
+		assertEqual(t, owner, (*url.URL)(nil))
+	})
+	t.Run("RejectsStandaloneKeyWithForgedOwner", func(t *testing.T) {
+		// Setup
+		const evilKeyIRI = "https://evil.example.net/k"
+		const evilOwnerIRI = "https://evil.example.net/dakota"
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		v, c, tp := setupFn(ctl)
+		key := newTestPublicKey(t, evilKeyIRI, testFederatedActorIRI, &privKey.PublicKey)
+		key.GetW3IDSecurityV1Owner().SetIRI(mustParse(evilOwnerIRI))
+		person := newTestKeyedPerson(newTestPublicKey(t, evilKeyIRI, testFederatedActorIRI, &privKey.PublicKey))
+		r := newTestSignedRequest(t, privKey, evilKeyIRI, testRespBody, allHeaders)
+		// Mock
+		c.EXPECT().Now().Return(now())
+		tp.EXPECT().Dereference(ctx, mustParse(evilKeyIRI)).Return(mustSerializeToBytes(key), nil)
+		tp.EXPECT().Dereference(ctx, mustParse(evilOwnerIRI)).Return(mustSerializeToBytes(person), nil)
+		// Run
+		_, owner, err := v.Verify(ctx, r, tp)
+		// Verify
+		assertNotEqual(t, err, nil)
+		assertEqual(t, owner, (*url.URL)(nil))
+	})
+	t.Run("RejectsWrongKey", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		v, c, tp := setupFn(ctl)
+		person := newTestKeyedPerson(newTestPublicKey(t, testFederatedKeyIRI, testFederatedActorIRI, &otherKey.PublicKey))
+		r := newTestSignedRequest(t, privKey, testFederatedKeyIRI, testRespBody, allHeaders)
+		// Mock
+		c.EXPECT().Now().Return(now())
+		tp.EXPECT().Dereference(ctx, mustParse(testFederatedKeyIRI)).Return(mustSerializeToBytes(person), nil)
+		// Run
+		_, owner, err := v.Verify(ctx, r, tp)
+		// Verify
+		assertNotEqual(t, err, nil)
+		assertEqual(t, owner, (*url.URL)(nil))
+	})
+	t.Run("RejectsDateSkew", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		v, c, tp := setupFn(ctl)
+		r := newTestSignedRequest(t, privKey, testFederatedKeyIRI, testRespBody, allHeaders)
+		// Mock
+		c.EXPECT().Now().Return(now().Add(time.Hour))
+		// Run
+		_, _, err := v.Verify(ctx, r, tp)
+		// Verify
+		assertNotEqual(t, err, nil)
+	})
+	t.Run("RejectsUnsignedDate", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		v, _, tp := setupFn(ctl)
+		r := newTestSignedRequest(t, privKey, testFederatedKeyIRI, testRespBody, []string{httpsig.RequestTarget, "digest"})
+		// Run
+		_, _, err := v.Verify(ctx, r, tp)
+		// Verify
+		assertNotEqual(t, err, nil)
+	})
+	t.Run("RejectsDigestMismatch", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		v, c, tp := setupFn(ctl)
+		r := newTestSignedRequest(t, privKey, testFederatedKeyIRI, testRespBody, allHeaders)
+		r.Body = httptest.NewRequest("POST", testMyInboxIRI, bytes.NewReader([]byte("tampered"))).Body
+		// Mock
+		c.EXPECT().Now().Return(now())
+		// Run
+		_, _, err := v.Verify(ctx, r, tp)
+		// Verify
+		assertNotEqual(t, err, nil)
+	})
+	t.Run("ReturnsErrRequestBodyTooLargeIfBodyExceedsLimit", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		v, c, tp := setupFn(ctl)
+		r := newTestSignedRequest(t, privKey, testFederatedKeyIRI, testRespBody, allHeaders)
+		r.ContentLength = -1
+		err := RequestLimits{MaxBodyBytes: 4}.limitBody(r)
+		assertEqual(t, err, nil)
+		// Mock
+		c.EXPECT().Now().Return(now())
+		// Run
+		_, _, err = v.Verify(ctx, r, tp)
+		// Verify
+		assertEqual(t, err, ErrRequestBodyTooLarge)
+	})
+	t.Run("RejectsUnsignedDigest", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		v, c, tp := setupFn(ctl)
+		r := newTestSignedRequest(t, privKey, testFederatedKeyIRI, testRespBody, []string{httpsig.RequestTarget, "date"})
+		// Mock
+		c.EXPECT().Now().Return(now())
+		// Run
+		_, _, err := v.Verify(ctx, r, tp)
+		// Verify
+		assertNotEqual(t, err, nil)
+	})
+	newActivityFn := func(actors ...string) Activity {
+		act := streams.NewActivityStreamsCreate()
+		actor := streams.NewActivityStreamsActorProperty()
+		for _, a := range actors {
+			actor.AppendIRI(mustParse(a))
+		}
+		act.SetActivityStreamsActor(actor)
+		return act
+	}
+	t.Run("VerifiesActivityBySigner", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		v, c, tp := setupFn(ctl)
+		person := newTestKeyedPerson(newTestPublicKey(t, testFederatedKeyIRI, testFederatedActorIRI, &privKey.PublicKey))
+		r := newTestSignedRequest(t, privKey, testFederatedKeyIRI, testRespBody, allHeaders)
+		// Mock
+		c.EXPECT().Now().Return(now())
+		tp.EXPECT().Dereference(ctx, mustParse(testFederatedKeyIRI)).Return(mustSerializeToBytes(person), nil)
+		// Run
+		keyId, owner, err := v.VerifyActivity(ctx, r, tp, newActivityFn(testFederatedActorIRI))
+		// Verify
+		assertEqual(t, err, nil)
+		assertEqual(t, keyId.String(), testFederatedKeyIRI)
+		assertEqual(t, owner.String(), testFederatedActorIRI)
+	})
+	t.Run("RejectsActivityByAnotherActor", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		v, c, tp := setupFn(ctl)
+		person := newTestKeyedPerson(newTestPublicKey(t, testFederatedKeyIRI, testFederatedActorIRI, &privKey.PublicKey))
+		r := newTestSignedRequest(t, privKey, testFederatedKeyIRI, testRespBody, allHeaders)
+		// Mock
+		c.EXPECT().Now().Return(now())
+		tp.EXPECT().Dereference(ctx, mustParse(testFederatedKeyIRI)).Return(mustSerializeToBytes(person), nil)
+		// Run
+		_, _, err := v.VerifyActivity(ctx, r, tp, newActivityFn(testFederatedActorIRI, testFederatedActorIRI2))
+		// Verify
+		assertEqual(t, err, ErrSignerNotActor)
+	})
+}
+
+// TestHttpSigVerifierCachesKeys tests verifying HTTP Signatures with keys from a
+// PublicKeyStore.
+func TestHttpSigVerifierCachesKeys(t *testing.T) {
+	ctx := context.Background()
+	privKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	headers := []string{httpsig.RequestTarget, "host", "date", "digest"}
+	setupFn := func(ctl *gomock.Controller) (v *HttpSigVerifier, s *MemoryPublicKeyStore, c *MockClock, tp *MockTransport) {
+		s = NewMemoryPublicKeyStore()
+		c = NewMockClock(ctl)
+		tp = NewMockTransport(ctl)
+		v = NewCachingHttpSigVerifier(c, nil, time.Minute, s, time.Hour)
+		return
+	}
+	cacheFn := func(s *MemoryPublicKeyStore, pubKey *rsa.PublicKey, expires time.Time) {
+		s.Set(ctx, CachedPublicKey{
+			KeyId:   mustParse(testFederatedKeyIRI),
+			Owner:   mustParse(testFederatedActorIRI),
+			Key:     newTestPublicKey(t, testFederatedKeyIRI, testFederatedActorIRI, pubKey),
+			Expires: expires,
+		})
+	}
+	t.Run("CachesFetchedKey", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		v, s, c, tp := setupFn(ctl)
+		person := newTestKeyedPerson(newTestPublicKey(t, testFederatedKeyIRI, testFederatedActorIRI, &privKey.PublicKey))
+		r := newTestSignedRequest(t, privKey, testFederatedKeyIRI, testRespBody, headers)
+		// Mock
+		c.EXPECT().Now().Return(now()).Times(2)
+		tp.EXPECT().Dereference(ctx, mustParse(testFederatedKeyIRI)).Return(mustSerializeToBytes(person), nil)
+		// Run
+		_, owner, err := v.Verify(ctx, r, tp)
+		// Verify
+		assertEqual(t, err, nil)
+		assertEqual(t, owner.String(), testFederatedActorIRI)
+		k, exists, err := s.Get(ctx, mustParse(testFederatedKeyIRI))
+		assertEqual(t, err, nil)
+		assertEqual(t, exists, true)
+		assertEqual(t, k.Expires.Equal(now().Add(time.Hour)), true)
+	})
+	t.Run("UsesCachedKey", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		v, s, c, tp := setupFn(ctl)
+		cacheFn(s, &privKey.PublicKey, now().Add(time.Hour))
+		r := newTestSignedRequest(t, privKey, testFederatedKeyIRI, testRespBody, headers)
+		// Mock
+		c.EXPECT().Now().Return(now()).Times(2)
+		// Run
+		_, owner, err := v.Verify(ctx, r, tp)
+		// Verify
+		assertEqual(t, err, nil)
+		assertEqual(t, owner.String(), testFederatedActorIRI)
+	})
+	t.Run("RefetchesExpiredKey", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		v, s, c, tp := setupFn(ctl)
+		cacheFn(s, &privKey.PublicKey, now().Add(-time.Second))
+		person := newTestKeyedPerson(newTestPublicKey(t, testFederatedKeyIRI, testFederatedActorIRI, &privKey.PublicKey))
+		r := newTestSignedRequest(t, privKey, testFederatedKeyIRI, testRespBody, headers)
+		// Mock
+		c.EXPECT().Now().Return(now()).Times(3)
+		tp.EXPECT().Dereference(ctx, mustParse(testFederatedKeyIRI)).Return(mustSerializeToBytes(person), nil)
+		// Run
+		_, _, err := v.Verify(ctx, r, tp)
+		// Verify
+		assertEqual(t, err, nil)
+	})
+	t.Run("RefetchesRotatedKey", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		v, s, c, tp := setupFn(ctl)
+		cacheFn(s, &oldKey.PublicKey, now().Add(time.Hour))
+		person := newTestKeyedPerson(newTestPublicKey(t, testFederatedKeyIRI, testFederatedActorIRI, &privKey.PublicKey))
+		r := newTestSignedRequest(t, privKey, testFederatedKeyIRI, testRespBody, headers)
+		// Mock
+		c.EXPECT().Now().Return(now()).Times(3)
+		tp.EXPECT().Dereference(ctx, mustParse(testFederatedKeyIRI)).Return(mustSerializeToBytes(person), nil)
+		// Run
+		_, owner, err := v.Verify(ctx, r, tp)
+		// Verify
+		assertEqual(t, err, nil)
+		assertEqual(t, owner.String(), testFederatedActorIRI)
+	})
+	t.Run("RefetchesOnlyOnce", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		v, s, c, tp := setupFn(ctl)
+		cacheFn(s, &oldKey.PublicKey, now().Add(time.Hour))
+		person := newTestKeyedPerson(newTestPublicKey(t, testFederatedKeyIRI, testFederatedActorIRI, &oldKey.PublicKey))
+		r := newTestSignedRequest(t, privKey, testFederatedKeyIRI, testRespBody, headers)
+		// Mock
+		c.EXPECT().Now().Return(now()).Times(3)
+		tp.EXPECT().Dereference(ctx, mustParse(testFederatedKeyIRI)).Return(mustSerializeToBytes(person), nil)
+		// Run
+		_, _, err := v.Verify(ctx, r, tp)
+		// Verify
+		assertNotEqual(t, err, nil)
+	})
+	t.Run("RejectsRevokedOwner", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		v, s, c, tp := setupFn(ctl)
+		cacheFn(s, &privKey.PublicKey, now().Add(time.Hour))
+		s.RevokeOwner(ctx, mustParse(testFederatedActorIRI))
+		person := newTestKeyedPerson(newTestPublicKey(t, testFederatedKeyIRI, testFederatedActorIRI, &privKey.PublicKey))
+		r := newTestSignedRequest(t, privKey, testFederatedKeyIRI, testRespBody, headers)
+		// Mock
+		c.EXPECT().Now().Return(now())
+		tp.EXPECT().Dereference(ctx, mustParse(testFederatedKeyIRI)).Return(mustSerializeToBytes(person), nil)
+		// Run
+		_, _, err := v.Verify(ctx, r, tp)
+		// Verify
+		assertNotEqual(t, err, nil)
+		_, exists, _ := s.Get(ctx, mustParse(testFederatedKeyIRI))
+		assertEqual(t, exists, false)
+	})
+}
@@ -0,0 +1,136 @@
+package pub
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/go-fed/httpsig"
+	"net/http"
+	"net/url"
+)
+
+// InstanceActor is an Application actor representing this server as a whole,
+// with its own keypair.
+//
+// The library signs the GET requests it makes in the background with its key
+// when given with WithInstanceActor: dereferencing recipients to resolve their
+// inboxes, and dereferencing the objects of activities to check their actors.
+// Peers running authorized fetch thus see a signature from this server rather
+// than from one of its users, or none at all.
+//
+// Its document must be served to unsigned requests with the HandlerFunc
+// returned by NewInstanceActorHandler, since peers fetch it to verify its
+// signatures.
+type InstanceActor struct {
+	profile  ActorProfile
+	privKey  *rsa.PrivateKey
+	client   HttpClient
+	appAgent string
+	clock    Clock
+}
+
+// NewInstanceActor returns the InstanceActor with the id, such as
+// 'https://example.com/actor', whose requests are sent through the HttpClient
+// and signed with the private key.
+//
+// Its inbox, outbox, followers, and following collections are at the paths
+// 'inbox', 'outbox', 'followers', and 'following' below the id, its public key
+// is identified by the 'main-key' fragment of the id, and its preferred
+// username is the host of the id. The appAgent is as for
+// NewHttpSigTransport.
+func NewInstanceActor(id *url.URL, privKey *rsa.PrivateKey, client HttpClient, appAgent string, clock Clock) (*InstanceActor, error) {
+	der, err := x509.MarshalPKIXPublicKey(&privKey.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	collectionFn := func(name string) *url.URL {
+		u := *id
+		u.Path = u.Path + "/" + name
+		u.RawQuery = ""
+		u.Fragment = ""
+		return &u
+	}
+	keyId := *id
+	keyId.Fragment = "main-key"
+	return &InstanceActor{
+		profile: ActorProfile{
+			Type:              "Application",
+			Id:                id,
+			Inbox:             collectionFn("inbox"),
+			Outbox:            collectionFn("outbox"),
+			Followers:         collectionFn("followers"),
+			Following:         collectionFn("following"),
+			PreferredUsername: id.Host,
+			PublicKeyId:       &keyId,
+			PublicKeyPem:      string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+		},
+		privKey:  privKey,
+		client:   client,
+		appAgent: appAgent,
+		clock:    clock,
+	}, nil
+}
+
+// Profile returns the profile of the instance actor, from which its document
+// is built.
+func (i *InstanceActor) Profile() ActorProfile {
+	return i.profile
+}
+
+// ActorProfile returns the profile of the instance actor, which is the only
+// actor it knows about.
+func (i *InstanceActor) ActorProfile(c context.Context, actorIRI *url.URL) (p ActorProfile, exists bool, err error) {
+	if actorIRI.String() != i.profile.Id.String() {
+		return
+	}
+	return i.profile, true, nil
+}
+
+// NewTransport returns an HttpSigTransport signing its requests with the
+// instance actor's key.
+//
+// It has the signature of CommonBehavior's NewTransport, so that it may be
+// given wherever one is expected, such as to NewAuthorizedFetch. The box IRI
+// and agent are ignored.
+func (i *InstanceActor) NewTransport(c context.Context, actorBoxIRI *url.URL, gofedAgent string) (Transport, error) {
+	algos := []httpsig.Algorithm{httpsig.RSA_SHA256}
+	getSigner, _, err := httpsig.NewSigner(algos, httpsig.DigestSha256, []string{httpsig.RequestTarget, "host", "date"}, httpsig.Signature)
+	if err != nil {
+		return nil, err
+	}
+	postSigner, _, err := httpsig.NewSigner(algos, httpsig.DigestSha256, []string{httpsig.RequestTarget, "host", "date", "digest"}, httpsig.Signature)
+	if err != nil {
+		return nil, err
+	}
+	return NewHttpSigTransport(
+		i.client,
+		i.appAgent,
+		i.clock,
+		hostSigner{getSigner},
+		hostSigner{postSigner},
+		i.profile.PublicKeyId.String(),
+		i.privKey), nil
+}
+
+// hostSigner sets the Host header before signing, since the standard library
+// keeps the host of outgoing requests out of their headers.
+type hostSigner struct {
+	httpsig.Signer
+}
+
+// SignRequest sets the Host header of the request and signs it.
+func (h hostSigner) SignRequest(pKey crypto.PrivateKey, pubKeyId string, r *http.Request, body []byte) error {
+	if len(r.Header.Get(hostHeader)) == 0 {
+		r.Header.Set(hostHeader, r.URL.Host)
+	}
+	return h.Signer.SignRequest(pKey, pubKeyId, r, body)
+}
+
+// NewInstanceActorHandler creates a HandlerFunc serving the document of the
+// instance actor, as NewActorHandler does without an HTML fallback. It must
+// not be wrapped by an AuthorizedFetch.
+func NewInstanceActorHandler(i *InstanceActor) HandlerFunc {
+	return NewActorHandler(i, i.clock, nil)
+}
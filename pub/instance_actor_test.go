@@ -0,0 +1,131 @@
+package pub
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-fed/httpsig"
+	"github.com/golang/mock/gomock"
+)
+
+// TestInstanceActor tests the Application actor signing background requests.
+func TestInstanceActor(t *testing.T) {
+	ctx := context.Background()
+	const instanceIRI = "https://example.com/actor"
+	privKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setupFn := func(ctl *gomock.Controller) (i *InstanceActor, hc *MockHttpClient, clock *MockClock) {
+		hc = NewMockHttpClient(ctl)
+		clock = NewMockClock(ctl)
+		i, err := NewInstanceActor(mustParse(instanceIRI), privKey, hc, testAppAgent, clock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	t.Run("BuildsProfile", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		i, _, _ := setupFn(ctl)
+		// Run
+		p := i.Profile()
+		// Verify
+		assertEqual(t, p.Type, "Application")
+		assertEqual(t, p.Id.String(), instanceIRI)
+		assertEqual(t, p.Inbox.String(), instanceIRI+"/inbox")
+		assertEqual(t, p.Outbox.String(), instanceIRI+"/outbox")
+		assertEqual(t, p.Followers.String(), instanceIRI+"/followers")
+		assertEqual(t, p.Following.String(), instanceIRI+"/following")
+		assertEqual(t, p.PreferredUsername, "example.com")
+		assertEqual(t, p.PublicKeyId.String(), instanceIRI+"#main-key")
+		block, _ := pem.Decode([]byte(p.PublicKeyPem))
+		if block == nil {
+			t.Fatalf("expected PEM public key, got %q", p.PublicKeyPem)
+		}
+		pubKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+		assertEqual(t, err, nil)
+		assertEqual(t, pubKey.(*rsa.PublicKey).N.Cmp(privKey.PublicKey.N), 0)
+	})
+	t.Run("KnowsOnlyItself", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		i, _, _ := setupFn(ctl)
+		// Run & Verify
+		_, exists, err := i.ActorProfile(ctx, mustParse(testFederatedActorIRI))
+		assertEqual(t, exists, false)
+		assertEqual(t, err, nil)
+		p, exists, err := i.ActorProfile(ctx, mustParse(instanceIRI))
+		assertEqual(t, exists, true)
+		assertEqual(t, err, nil)
+		assertEqual(t, p.Id.String(), instanceIRI)
+	})
+	t.Run("ServesDocument", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		i, _, clock := setupFn(ctl)
+		hf := NewInstanceActorHandler(i)
+		resp := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", instanceIRI, nil)
+		req.Header.Set(acceptHeader, activityStreamsMediaTypes[0])
+		// Mock
+		clock.EXPECT().Now().Return(now())
+		// Run
+		isAPReq, err := hf(ctx, resp, req)
+		// Verify
+		assertEqual(t, isAPReq, true)
+		assertEqual(t, err, nil)
+		assertEqual(t, resp.Code, http.StatusOK)
+		b, err := ioutil.ReadAll(resp.Result().Body)
+		assertEqual(t, err, nil)
+		var m map[string]interface{}
+		err = json.Unmarshal(b, &m)
+		assertEqual(t, err, nil)
+		assertEqual(t, m["type"], "Application")
+		assertEqual(t, m["id"], instanceIRI)
+		pk, ok := m["publicKey"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("expected publicKey object, got %v", m["publicKey"])
+		}
+		assertEqual(t, pk["id"], instanceIRI+"#main-key")
+		assertEqual(t, pk["owner"], instanceIRI)
+	})
+	t.Run("SignsDereferences", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		i, hc, clock := setupFn(ctl)
+		tp, err := i.NewTransport(ctx, mustParse(testMyInboxIRI), goFedUserAgent())
+		assertEqual(t, err, nil)
+		respR := httptest.NewRecorder()
+		respR.Write(testRespBody)
+		var sent *http.Request
+		// Mock
+		clock.EXPECT().Now().Return(now())
+		hc.EXPECT().Do(gomock.Any()).DoAndReturn(func(r *http.Request) (*http.Response, error) {
+			sent = r
+			return respR.Result(), nil
+		})
+		// Run
+		b, err := tp.Dereference(ctx, mustParse(testNoteId1))
+		// Verify
+		assertByteEqual(t, b, testRespBody)
+		assertEqual(t, err, nil)
+		v, err := httpsig.NewVerifier(sent)
+		assertEqual(t, err, nil)
+		assertEqual(t, v.KeyId(), instanceIRI+"#main-key")
+		assertEqual(t, v.Verify(&privKey.PublicKey, httpsig.RSA_SHA256), nil)
+	})
+}
@@ -0,0 +1,537 @@
+package pub
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/go-fed/activity/streams"
+	"github.com/go-fed/activity/streams/vocab"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// memoryDatabasePageSize is the number of items in each page of the inboxes
+// and outboxes of a MemoryDatabase.
+const memoryDatabasePageSize = 20
+
+// MemoryDatabase must satisfy the Database interface.
+var _ Database = &MemoryDatabase{}
+
+// MemoryDatabase must satisfy the LocalFollowerLister interface.
+var _ LocalFollowerLister = &MemoryDatabase{}
+
+// MemoryDatabase must satisfy the PagedDatabase interface.
+var _ PagedDatabase = &MemoryDatabase{}
+
+// MemoryDatabase is a Database that keeps everything in memory. It is meant for
+// tests, examples, and prototypes, and not for production use: nothing is
+// persisted and no entry is ever evicted.
+//
+// Every IRI whose host matches the base IRI given to NewMemoryDatabase is
+// considered to be owned by this database, provided an entry exists for it.
+//
+// Values are stored in their serialized form, so the values returned by Get
+// and the other getters are copies. Modifying them has no effect until they
+// are passed back to Update.
+//
+// Inboxes and outboxes are kept as lists of ids rather than as entries, and
+// are paged through with cursors that are the positions of their items. Their
+// GetInbox and GetOutbox return all of their items in a single page.
+//
+// Actors must be registered with AddActor before they are able to send or
+// receive activities.
+//
+// A MemoryDatabase is safe for concurrent use.
+type MemoryDatabase struct {
+	base *url.URL
+	// locks contains one lock per id ever passed to Lock.
+	locks   map[string]*sync.Mutex
+	locksMu sync.Mutex
+	// mu guards all of the maps below.
+	mu sync.RWMutex
+	// entries contains the serialized JSON of every stored value.
+	entries map[string][]byte
+	// actorForInbox and actorForOutbox map an inbox or outbox IRI to the
+	// IRI of its actor.
+	actorForInbox  map[string]*url.URL
+	actorForOutbox map[string]*url.URL
+	// outboxForInbox maps an inbox IRI to its actor's outbox IRI.
+	outboxForInbox map[string]*url.URL
+	// boxes maps an inbox or outbox IRI to the ids of its items, oldest
+	// first.
+	boxes map[string][]*url.URL
+	// followers, following, and liked map an actor IRI to the IRI of the
+	// respective collection.
+	followers map[string]*url.URL
+	following map[string]*url.URL
+	liked     map[string]*url.URL
+}
+
+// NewMemoryDatabase returns an empty MemoryDatabase that owns the IRIs on the
+// same host as base, and generates new ids beneath it.
+func NewMemoryDatabase(base *url.URL) *MemoryDatabase {
+	return &MemoryDatabase{
+		base:           base,
+		locks:          make(map[string]*sync.Mutex),
+		entries:        make(map[string][]byte),
+		actorForInbox:  make(map[string]*url.URL),
+		actorForOutbox: make(map[string]*url.URL),
+		outboxForInbox: make(map[string]*url.URL),
+		boxes:          make(map[string][]*url.URL),
+		followers:      make(map[string]*url.URL),
+		following:      make(map[string]*url.URL),
+		liked:          make(map[string]*url.URL),
+	}
+}
+
+// AddActor registers a local actor and stores it.
+//
+// The actor must have its 'id', 'inbox', and 'outbox' properties set as IRIs.
+// An empty inbox and outbox are created, as well as empty 'followers',
+// 'following', and 'liked' Collections for each of those properties that is
+// set as an IRI.
+func (m *MemoryDatabase) AddActor(c context.Context, actor vocab.Type) error {
+	actorIRI, err := GetId(actor)
+	if err != nil {
+		return err
+	}
+	inbox, err := getInbox(actor)
+	if err != nil {
+		return err
+	}
+	ob, ok := actor.(outboxer)
+	if !ok || ob.GetActivityStreamsOutbox() == nil {
+		return fmt.Errorf("actor type %T has no outbox", actor)
+	}
+	outbox, err := ToId(ob.GetActivityStreamsOutbox())
+	if err != nil {
+		return err
+	}
+	toCreate := []vocab.Type{actor}
+	var followers, following, liked *url.URL
+	if f, ok := actor.(followerser); ok && f.GetActivityStreamsFollowers() != nil && f.GetActivityStreamsFollowers().IsIRI() {
+		followers = f.GetActivityStreamsFollowers().GetIRI()
+		toCreate = append(toCreate, newCollection(followers))
+	}
+	if f, ok := actor.(followinger); ok && f.GetActivityStreamsFollowing() != nil && f.GetActivityStreamsFollowing().IsIRI() {
+		following = f.GetActivityStreamsFollowing().GetIRI()
+		toCreate = append(toCreate, newCollection(following))
+	}
+	if l, ok := actor.(likeder); ok && l.GetActivityStreamsLiked() != nil && l.GetActivityStreamsLiked().IsIRI() {
+		liked = l.GetActivityStreamsLiked().GetIRI()
+		toCreate = append(toCreate, newCollection(liked))
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, t := range toCreate {
+		if err := m.put(t); err != nil {
+			return err
+		}
+	}
+	m.actorForInbox[inbox.String()] = actorIRI
+	m.actorForOutbox[outbox.String()] = actorIRI
+	m.outboxForInbox[inbox.String()] = outbox
+	m.boxes[inbox.String()] = nil
+	m.boxes[outbox.String()] = nil
+	if followers != nil {
+		m.followers[actorIRI.String()] = followers
+	}
+	if following != nil {
+		m.following[actorIRI.String()] = following
+	}
+	if liked != nil {
+		m.liked[actorIRI.String()] = liked
+	}
+	return nil
+}
+
+// Lock takes the lock for the id, creating it if this is the first time the
+// id has been locked.
+func (m *MemoryDatabase) Lock(c context.Context, id *url.URL) error {
+	m.locksMu.Lock()
+	mu, ok := m.locks[id.String()]
+	if !ok {
+		mu = &sync.Mutex{}
+		m.locks[id.String()] = mu
+	}
+	m.locksMu.Unlock()
+	mu.Lock()
+	return nil
+}
+
+// Unlock releases the lock for the id.
+func (m *MemoryDatabase) Unlock(c context.Context, id *url.URL) error {
+	m.locksMu.Lock()
+	mu, ok := m.locks[id.String()]
+	m.locksMu.Unlock()
+	if !ok {
+		return fmt.Errorf("unlock of %s that was never locked", id)
+	}
+	mu.Unlock()
+	return nil
+}
+
+// InboxContains returns true if the inbox contains the id.
+func (m *MemoryDatabase) InboxContains(c context.Context, inbox, id *url.URL) (contains bool, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	items, ok := m.boxes[inbox.String()]
+	if !ok {
+		return false, fmt.Errorf("no box %s", inbox)
+	}
+	for _, item := range items {
+		if item.String() == id.String() {
+			return true, nil
+		}
+	}
+	return
+}
+
+// GetInbox returns the inbox as an OrderedCollectionPage of all its items.
+func (m *MemoryDatabase) GetInbox(c context.Context, inboxIRI *url.URL) (inbox vocab.ActivityStreamsOrderedCollectionPage, err error) {
+	return m.getBox(inboxIRI)
+}
+
+// SetInbox replaces the items of the inbox with those of the
+// OrderedCollectionPage.
+func (m *MemoryDatabase) SetInbox(c context.Context, inbox vocab.ActivityStreamsOrderedCollectionPage) error {
+	return m.setBox(inbox)
+}
+
+// AppendInbox adds the id as the newest item of the inbox.
+func (m *MemoryDatabase) AppendInbox(c context.Context, inboxIRI, id *url.URL) error {
+	return m.appendBox(inboxIRI, id)
+}
+
+// GetInboxPage returns the page of the inbox identified by the cursor.
+func (m *MemoryDatabase) GetInboxPage(c context.Context, inboxIRI *url.URL, cursor CollectionCursor) (page BoxPage, err error) {
+	return m.getBoxPage(inboxIRI, cursor)
+}
+
+// Owns returns true if the id is on this database's host and has an entry.
+func (m *MemoryDatabase) Owns(c context.Context, id *url.URL) (owns bool, err error) {
+	if id.Host != m.base.Host {
+		return
+	}
+	return m.Exists(c, id)
+}
+
+// ActorForOutbox returns the IRI of the actor registered with the outbox.
+func (m *MemoryDatabase) ActorForOutbox(c context.Context, outboxIRI *url.URL) (actorIRI *url.URL, err error) {
+	return m.lookup(m.actorForOutbox, outboxIRI, "actor for outbox")
+}
+
+// ActorForInbox returns the IRI of the actor registered with the inbox.
+func (m *MemoryDatabase) ActorForInbox(c context.Context, inboxIRI *url.URL) (actorIRI *url.URL, err error) {
+	return m.lookup(m.actorForInbox, inboxIRI, "actor for inbox")
+}
+
+// OutboxForInbox returns the outbox IRI of the actor registered with the
+// inbox.
+func (m *MemoryDatabase) OutboxForInbox(c context.Context, inboxIRI *url.URL) (outboxIRI *url.URL, err error) {
+	return m.lookup(m.outboxForInbox, inboxIRI, "outbox for inbox")
+}
+
+// Exists returns true if there is an entry for the id.
+func (m *MemoryDatabase) Exists(c context.Context, id *url.URL) (exists bool, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, exists = m.entries[id.String()]
+	return
+}
+
+// Get returns a copy of the value stored for the id.
+func (m *MemoryDatabase) Get(c context.Context, id *url.URL) (value vocab.Type, err error) {
+	m.mu.RLock()
+	b, ok := m.entries[id.String()]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no entry for %s", id)
+	}
+	var raw map[string]interface{}
+	if err = json.Unmarshal(b, &raw); err != nil {
+		return
+	}
+	return streams.ToType(c, raw)
+}
+
+// Create stores the value by its id, replacing any existing entry.
+func (m *MemoryDatabase) Create(c context.Context, asType vocab.Type) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.put(asType)
+}
+
+// Update stores the value by its id, replacing any existing entry.
+func (m *MemoryDatabase) Update(c context.Context, asType vocab.Type) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.put(asType)
+}
+
+// Delete removes the entry for the id.
+func (m *MemoryDatabase) Delete(c context.Context, id *url.URL) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, id.String())
+	return nil
+}
+
+// GetOutbox returns the outbox as an OrderedCollectionPage of all its items.
+func (m *MemoryDatabase) GetOutbox(c context.Context, outboxIRI *url.URL) (inbox vocab.ActivityStreamsOrderedCollectionPage, err error) {
+	return m.getBox(outboxIRI)
+}
+
+// SetOutbox replaces the items of the outbox with those of the
+// OrderedCollectionPage.
+func (m *MemoryDatabase) SetOutbox(c context.Context, outbox vocab.ActivityStreamsOrderedCollectionPage) error {
+	return m.setBox(outbox)
+}
+
+// AppendOutbox adds the id as the newest item of the outbox.
+func (m *MemoryDatabase) AppendOutbox(c context.Context, outboxIRI, id *url.URL) error {
+	return m.appendBox(outboxIRI, id)
+}
+
+// GetOutboxPage returns the page of the outbox identified by the cursor.
+func (m *MemoryDatabase) GetOutboxPage(c context.Context, outboxIRI *url.URL, cursor CollectionCursor) (page BoxPage, err error) {
+	return m.getBoxPage(outboxIRI, cursor)
+}
+
+// NewID returns a new random IRI beneath the base IRI, namespaced by the type
+// name of the value.
+func (m *MemoryDatabase) NewID(c context.Context, t vocab.Type) (id *url.URL, err error) {
+	b := make([]byte, 16)
+	if _, err = rand.Read(b); err != nil {
+		return
+	}
+	id = &url.URL{
+		Scheme: m.base.Scheme,
+		Host:   m.base.Host,
+		Path: strings.TrimSuffix(m.base.Path, "/") +
+			"/" + strings.ToLower(t.GetTypeName()) +
+			"/" + hex.EncodeToString(b),
+	}
+	return
+}
+
+// Followers returns the actor's followers Collection.
+func (m *MemoryDatabase) Followers(c context.Context, actorIRI *url.URL) (followers vocab.ActivityStreamsCollection, err error) {
+	return m.getActorCollection(c, m.followers, actorIRI, "followers")
+}
+
+// Following returns the actor's following Collection.
+func (m *MemoryDatabase) Following(c context.Context, actorIRI *url.URL) (followers vocab.ActivityStreamsCollection, err error) {
+	return m.getActorCollection(c, m.following, actorIRI, "following")
+}
+
+// Liked returns the actor's liked Collection.
+func (m *MemoryDatabase) Liked(c context.Context, actorIRI *url.URL) (followers vocab.ActivityStreamsCollection, err error) {
+	return m.getActorCollection(c, m.liked, actorIRI, "liked")
+}
+
+// LocalFollowers returns the registered actors whose following Collection
+// contains the actor.
+func (m *MemoryDatabase) LocalFollowers(c context.Context, actorIRI *url.URL) (followers []*url.URL, err error) {
+	m.mu.RLock()
+	local := make(map[string]*url.URL, len(m.following))
+	for k, v := range m.following {
+		local[k] = v
+	}
+	m.mu.RUnlock()
+	for k, followingIRI := range local {
+		var t vocab.Type
+		t, err = m.Get(c, followingIRI)
+		if err != nil {
+			return
+		}
+		col, ok := t.(vocab.ActivityStreamsCollection)
+		if !ok || col.GetActivityStreamsItems() == nil {
+			continue
+		}
+		items := col.GetActivityStreamsItems()
+		for iter := items.Begin(); iter != items.End(); iter = iter.Next() {
+			id, err := ToId(iter)
+			if err != nil {
+				return nil, err
+			}
+			if id.String() == actorIRI.String() {
+				var follower *url.URL
+				follower, err = url.Parse(k)
+				if err != nil {
+					return nil, err
+				}
+				followers = append(followers, follower)
+				break
+			}
+		}
+	}
+	return
+}
+
+// put serializes and stores the value. Must be called with mu held.
+func (m *MemoryDatabase) put(t vocab.Type) error {
+	id, err := GetId(t)
+	if err != nil {
+		return err
+	}
+	raw, err := streams.Serialize(t)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	m.entries[id.String()] = b
+	return nil
+}
+
+// lookup returns the IRI registered for the key in one of the actor maps.
+func (m *MemoryDatabase) lookup(from map[string]*url.URL, key *url.URL, what string) (*url.URL, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	v, ok := from[key.String()]
+	if !ok {
+		return nil, fmt.Errorf("no %s %s", what, key)
+	}
+	return v, nil
+}
+
+// getBox returns an OrderedCollectionPage of all the items of the inbox or
+// outbox, newest first.
+func (m *MemoryDatabase) getBox(boxIRI *url.URL) (vocab.ActivityStreamsOrderedCollectionPage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	items, ok := m.boxes[boxIRI.String()]
+	if !ok {
+		return nil, fmt.Errorf("no box %s", boxIRI)
+	}
+	ocp := streams.NewActivityStreamsOrderedCollectionPage()
+	id := streams.NewJSONLDIdProperty()
+	id.Set(boxIRI)
+	ocp.SetJSONLDId(id)
+	oi := streams.NewActivityStreamsOrderedItemsProperty()
+	for i := len(items) - 1; i >= 0; i-- {
+		oi.AppendIRI(items[i])
+	}
+	ocp.SetActivityStreamsOrderedItems(oi)
+	return ocp, nil
+}
+
+// setBox replaces the items of the inbox or outbox identified by the id of the
+// OrderedCollectionPage with its items.
+func (m *MemoryDatabase) setBox(ocp vocab.ActivityStreamsOrderedCollectionPage) error {
+	boxIRI, err := GetId(ocp)
+	if err != nil {
+		return err
+	}
+	var items []*url.URL
+	if oi := ocp.GetActivityStreamsOrderedItems(); oi != nil {
+		for i := oi.Len() - 1; i >= 0; i-- {
+			id, err := ToId(oi.At(i))
+			if err != nil {
+				return err
+			}
+			items = append(items, id)
+		}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.boxes[boxIRI.String()]; !ok {
+		return fmt.Errorf("no box %s", boxIRI)
+	}
+	m.boxes[boxIRI.String()] = items
+	return nil
+}
+
+// appendBox adds the id as the newest item of the inbox or outbox.
+func (m *MemoryDatabase) appendBox(boxIRI, id *url.URL) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items, ok := m.boxes[boxIRI.String()]
+	if !ok {
+		return fmt.Errorf("no box %s", boxIRI)
+	}
+	m.boxes[boxIRI.String()] = append(items, id)
+	return nil
+}
+
+// getBoxPage returns the page of the inbox or outbox identified by the cursor.
+// The cursors are the positions of items, counted from the oldest, so they
+// remain valid as newer items are appended.
+func (m *MemoryDatabase) getBoxPage(boxIRI *url.URL, cursor CollectionCursor) (page BoxPage, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	items, ok := m.boxes[boxIRI.String()]
+	if !ok {
+		return page, fmt.Errorf("no box %s", boxIRI)
+	}
+	// The pages are counted from the newest items, so the last page holds
+	// the remainder of the oldest items. Cursors that are not positions
+	// are ignored.
+	n := len(items)
+	start, end := n-memoryDatabasePageSize, n
+	if cursor.Last {
+		start, end = 0, n%memoryDatabasePageSize
+		if end == 0 {
+			end = memoryDatabasePageSize
+		}
+	} else if maxId, err := strconv.Atoi(cursor.MaxID); err == nil && maxId > 0 {
+		start, end = maxId-memoryDatabasePageSize, maxId
+	} else if minId, err := strconv.Atoi(cursor.MinID); err == nil && minId >= 0 {
+		start, end = minId+1, minId+1+memoryDatabasePageSize
+	}
+	if start < 0 {
+		start = 0
+	}
+	if end > n {
+		end = n
+	}
+	if start > end {
+		start = end
+	}
+	for i := end - 1; i >= start; i-- {
+		page.Items = append(page.Items, items[i])
+	}
+	page.TotalItems = n
+	if start > 0 {
+		page.Next = strconv.Itoa(start)
+	}
+	if end < n {
+		page.Prev = strconv.Itoa(end - 1)
+	}
+	return
+}
+
+// getActorCollection fetches the Collection registered for an actor in one of
+// the followers, following, or liked maps.
+func (m *MemoryDatabase) getActorCollection(c context.Context, from map[string]*url.URL, actorIRI *url.URL, what string) (vocab.ActivityStreamsCollection, error) {
+	id, err := m.lookup(from, actorIRI, what+" of actor")
+	if err != nil {
+		return nil, err
+	}
+	t, err := m.Get(c, id)
+	if err != nil {
+		return nil, err
+	}
+	col, ok := t.(vocab.ActivityStreamsCollection)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a Collection: %T", id, t)
+	}
+	return col, nil
+}
+
+// newCollection returns an empty Collection with the id.
+func newCollection(id *url.URL) vocab.ActivityStreamsCollection {
+	col := streams.NewActivityStreamsCollection()
+	idProp := streams.NewJSONLDIdProperty()
+	idProp.Set(id)
+	col.SetJSONLDId(idProp)
+	col.SetActivityStreamsItems(streams.NewActivityStreamsItemsProperty())
+	return col
+}
@@ -0,0 +1,394 @@
+package pub
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-fed/activity/streams"
+	"github.com/go-fed/activity/streams/vocab"
+)
+
+// newMemoryTestPerson creates a Person with all of its collections beneath
+// the actor IRI.
+func newMemoryTestPerson(actorIRI string) vocab.ActivityStreamsPerson {
+	p := streams.NewActivityStreamsPerson()
+	id := streams.NewJSONLDIdProperty()
+	id.Set(mustParse(actorIRI))
+	p.SetJSONLDId(id)
+	inbox := streams.NewActivityStreamsInboxProperty()
+	inbox.SetIRI(mustParse(actorIRI + "/inbox"))
+	p.SetActivityStreamsInbox(inbox)
+	outbox := streams.NewActivityStreamsOutboxProperty()
+	outbox.SetIRI(mustParse(actorIRI + "/outbox"))
+	p.SetActivityStreamsOutbox(outbox)
+	followers := streams.NewActivityStreamsFollowersProperty()
+	followers.SetIRI(mustParse(actorIRI + "/followers"))
+	p.SetActivityStreamsFollowers(followers)
+	following := streams.NewActivityStreamsFollowingProperty()
+	following.SetIRI(mustParse(actorIRI + "/following"))
+	p.SetActivityStreamsFollowing(following)
+	liked := streams.NewActivityStreamsLikedProperty()
+	liked.SetIRI(mustParse(actorIRI + "/liked"))
+	p.SetActivityStreamsLiked(liked)
+	return p
+}
+
+// TestMemoryDatabase tests the in-memory Database.
+func TestMemoryDatabase(t *testing.T) {
+	ctx := context.Background()
+	actorIRI := "https://example.com/addison"
+	setupFn := func() *MemoryDatabase {
+		setupData()
+		db := NewMemoryDatabase(mustParse("https://example.com"))
+		if err := db.AddActor(ctx, newMemoryTestPerson(actorIRI)); err != nil {
+			t.Fatal(err)
+		}
+		return db
+	}
+	t.Run("LocksIdsThatDoNotExist", func(t *testing.T) {
+		db := setupFn()
+		assertEqual(t, db.Lock(ctx, mustParse(testNoteId1)), nil)
+		assertEqual(t, db.Unlock(ctx, mustParse(testNoteId1)), nil)
+	})
+	t.Run("RegistersActors", func(t *testing.T) {
+		db := setupFn()
+		a, err := db.ActorForInbox(ctx, mustParse(testMyInboxIRI))
+		assertEqual(t, err, nil)
+		assertEqual(t, a.String(), actorIRI)
+		a, err = db.ActorForOutbox(ctx, mustParse(testMyOutboxIRI))
+		assertEqual(t, err, nil)
+		assertEqual(t, a.String(), actorIRI)
+		o, err := db.OutboxForInbox(ctx, mustParse(testMyInboxIRI))
+		assertEqual(t, err, nil)
+		assertEqual(t, o.String(), testMyOutboxIRI)
+		f, err := db.Followers(ctx, mustParse(actorIRI))
+		assertEqual(t, err, nil)
+		assertEqual(t, f.GetJSONLDId().Get().String(), actorIRI+"/followers")
+		_, err = db.ActorForInbox(ctx, mustParse(testFederatedInboxIRI))
+		assertNotEqual(t, err, nil)
+	})
+	t.Run("GetReturnsCopies", func(t *testing.T) {
+		db := setupFn()
+		assertEqual(t, db.Create(ctx, testMyNote), nil)
+		v, err := db.Get(ctx, mustParse(testNoteId1))
+		assertEqual(t, err, nil)
+		v.(vocab.ActivityStreamsNote).SetActivityStreamsName(nil)
+		v, err = db.Get(ctx, mustParse(testNoteId1))
+		assertEqual(t, err, nil)
+		assertByteEqual(t, mustSerializeToBytes(v), mustSerializeToBytes(testMyNote))
+	})
+	t.Run("OwnsOnlyLocalEntries", func(t *testing.T) {
+		db := setupFn()
+		assertEqual(t, db.Create(ctx, testMyNote), nil)
+		assertEqual(t, db.Create(ctx, testFederatedPerson1), nil)
+		owns, err := db.Owns(ctx, mustParse(testNoteId1))
+		assertEqual(t, err, nil)
+		assertEqual(t, owns, true)
+		owns, err = db.Owns(ctx, mustParse(testFederatedActorIRI))
+		assertEqual(t, err, nil)
+		assertEqual(t, owns, false)
+		exists, err := db.Exists(ctx, mustParse(testFederatedActorIRI))
+		assertEqual(t, err, nil)
+		assertEqual(t, exists, true)
+		assertEqual(t, db.Delete(ctx, mustParse(testNoteId1)), nil)
+		owns, err = db.Owns(ctx, mustParse(testNoteId1))
+		assertEqual(t, err, nil)
+		assertEqual(t, owns, false)
+	})
+	t.Run("InboxContainsAppendedItems", func(t *testing.T) {
+		db := setupFn()
+		assertEqual(t, db.AppendInbox(ctx, mustParse(testMyInboxIRI), mustParse(testFederatedActivityIRI)), nil)
+		contains, err := db.InboxContains(ctx, mustParse(testMyInboxIRI), mustParse(testFederatedActivityIRI))
+		assertEqual(t, err, nil)
+		assertEqual(t, contains, true)
+		contains, err = db.InboxContains(ctx, mustParse(testMyInboxIRI), mustParse(testFederatedActivityIRI2))
+		assertEqual(t, err, nil)
+		assertEqual(t, contains, false)
+	})
+	t.Run("PagesThroughBoxes", func(t *testing.T) {
+		db := setupFn()
+		outboxIRI := mustParse(testMyOutboxIRI)
+		for i := 0; i < 45; i++ {
+			assertEqual(t, db.AppendOutbox(ctx, outboxIRI, mustParse(fmt.Sprintf("%s/%d", testNoteId1, i))), nil)
+		}
+		first, err := db.GetOutboxPage(ctx, outboxIRI, CollectionCursor{})
+		assertEqual(t, err, nil)
+		assertEqual(t, first.TotalItems, 45)
+		assertEqual(t, len(first.Items), 20)
+		assertEqual(t, first.Items[0].String(), testNoteId1+"/44")
+		assertEqual(t, first.Prev, "")
+		second, err := db.GetOutboxPage(ctx, outboxIRI, CollectionCursor{Page: true, MaxID: first.Next})
+		assertEqual(t, err, nil)
+		assertEqual(t, len(second.Items), 20)
+		assertEqual(t, second.Items[0].String(), testNoteId1+"/24")
+		last, err := db.GetOutboxPage(ctx, outboxIRI, CollectionCursor{Page: true, MaxID: second.Next})
+		assertEqual(t, err, nil)
+		assertEqual(t, len(last.Items), 5)
+		assertEqual(t, last.Items[4].String(), testNoteId1+"/0")
+		assertEqual(t, last.Next, "")
+		prev, err := db.GetOutboxPage(ctx, outboxIRI, CollectionCursor{Page: true, MinID: last.Prev})
+		assertEqual(t, err, nil)
+		assertEqual(t, prev.Items[len(prev.Items)-1].String(), testNoteId1+"/5")
+		oldest, err := db.GetOutboxPage(ctx, outboxIRI, CollectionCursor{Page: true, Last: true})
+		assertEqual(t, err, nil)
+		assertEqual(t, len(oldest.Items), 5)
+		assertEqual(t, oldest.Items[0].String(), testNoteId1+"/4")
+		assertEqual(t, oldest.Items[4].String(), testNoteId1+"/0")
+		assertEqual(t, oldest.Prev, last.Prev)
+		assertEqual(t, oldest.Next, "")
+	})
+	t.Run("IgnoresInvalidCursors", func(t *testing.T) {
+		db := setupFn()
+		outboxIRI := mustParse(testMyOutboxIRI)
+		for i := 0; i < 25; i++ {
+			assertEqual(t, db.AppendOutbox(ctx, outboxIRI, mustParse(fmt.Sprintf("%s/%d", testNoteId1, i))), nil)
+		}
+		page, err := db.GetOutboxPage(ctx, outboxIRI, CollectionCursor{Page: true, MaxID: "abc"})
+		assertEqual(t, err, nil)
+		assertEqual(t, len(page.Items), 20)
+		assertEqual(t, page.Items[0].String(), testNoteId1+"/24")
+		page, err = db.GetOutboxPage(ctx, outboxIRI, CollectionCursor{Page: true, MinID: "abc"})
+		assertEqual(t, err, nil)
+		assertEqual(t, len(page.Items), 20)
+		assertEqual(t, page.Items[0].String(), testNoteId1+"/24")
+	})
+	t.Run("GetsAndSetsWholeBoxes", func(t *testing.T) {
+		db := setupFn()
+		inboxIRI := mustParse(testMyInboxIRI)
+		assertEqual(t, db.AppendInbox(ctx, inboxIRI, mustParse(testFederatedActivityIRI)), nil)
+		inbox, err := db.GetInbox(ctx, inboxIRI)
+		assertEqual(t, err, nil)
+		inbox.GetActivityStreamsOrderedItems().PrependIRI(mustParse(testFederatedActivityIRI2))
+		assertEqual(t, db.SetInbox(ctx, inbox), nil)
+		page, err := db.GetInboxPage(ctx, inboxIRI, CollectionCursor{})
+		assertEqual(t, err, nil)
+		assertEqual(t, page.TotalItems, 2)
+		assertEqual(t, page.Items[0].String(), testFederatedActivityIRI2)
+		assertEqual(t, page.Items[1].String(), testFederatedActivityIRI)
+	})
+	t.Run("ListsLocalFollowers", func(t *testing.T) {
+		db := setupFn()
+		following, err := db.Following(ctx, mustParse(actorIRI))
+		assertEqual(t, err, nil)
+		following.GetActivityStreamsItems().AppendIRI(mustParse(testFederatedActorIRI))
+		assertEqual(t, db.Update(ctx, following), nil)
+		followers, err := db.LocalFollowers(ctx, mustParse(testFederatedActorIRI))
+		assertEqual(t, err, nil)
+		assertEqual(t, len(followers), 1)
+		assertEqual(t, followers[0].String(), actorIRI)
+		followers, err = db.LocalFollowers(ctx, mustParse(testFederatedActorIRI2))
+		assertEqual(t, err, nil)
+		assertEqual(t, len(followers), 0)
+	})
+	t.Run("NewIDIsBeneathBase", func(t *testing.T) {
+		db := setupFn()
+		id, err := db.NewID(ctx, testMyNote)
+		assertEqual(t, err, nil)
+		assertEqual(t, strings.HasPrefix(id.String(), "https://example.com/note/"), true)
+		id2, err := db.NewID(ctx, testMyNote)
+		assertEqual(t, err, nil)
+		assertNotEqual(t, id.String(), id2.String())
+	})
+}
+
+// memoryTestClock is a Clock that returns the real time.
+type memoryTestClock struct{}
+
+func (memoryTestClock) Now() time.Time { return time.Now() }
+
+// memoryTestTransport is a Transport that sends unsigned requests.
+type memoryTestTransport struct {
+	client *http.Client
+}
+
+func (m memoryTestTransport) Dereference(c context.Context, iri *url.URL) ([]byte, error) {
+	req, err := http.NewRequest("GET", iri.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(acceptHeader, acceptHeaderValue)
+	resp, err := m.client.Do(req.WithContext(c))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", iri, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+func (m memoryTestTransport) Deliver(c context.Context, b []byte, to *url.URL) error {
+	req, err := http.NewRequest("POST", to.String(), bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set(contentTypeHeader, contentTypeHeaderValue)
+	resp, err := m.client.Do(req.WithContext(c))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if !isSuccess(resp.StatusCode) {
+		return fmt.Errorf("POST %s: %s", to, resp.Status)
+	}
+	return nil
+}
+
+func (m memoryTestTransport) BatchDeliver(c context.Context, b []byte, recipients []*url.URL) error {
+	for _, r := range recipients {
+		if err := m.Deliver(c, b, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// memoryTestApp implements the CommonBehavior and FederatingProtocol with
+// permissive defaults on top of a MemoryDatabase.
+type memoryTestApp struct {
+	db     *MemoryDatabase
+	client *http.Client
+}
+
+func (m *memoryTestApp) AuthenticateGetInbox(c context.Context, w http.ResponseWriter, r *http.Request) (context.Context, bool, error) {
+	return c, true, nil
+}
+
+func (m *memoryTestApp) AuthenticateGetOutbox(c context.Context, w http.ResponseWriter, r *http.Request) (context.Context, bool, error) {
+	return c, true, nil
+}
+
+func (m *memoryTestApp) GetOutbox(c context.Context, r *http.Request) (vocab.ActivityStreamsOrderedCollectionPage, error) {
+	return m.db.GetOutbox(c, requestId(r))
+}
+
+func (m *memoryTestApp) GetPagedOutbox(c context.Context, r *http.Request) (vocab.Type, error) {
+	return BoxForRequest(c, r, m.db.GetOutboxPage)
+}
+
+func (m *memoryTestApp) NewTransport(c context.Context, actorBoxIRI *url.URL, gofedAgent string) (Transport, error) {
+	return memoryTestTransport{client: m.client}, nil
+}
+
+func (m *memoryTestApp) PostInboxRequestBodyHook(c context.Context, r *http.Request, activity Activity) (context.Context, error) {
+	return c, nil
+}
+
+func (m *memoryTestApp) AuthenticatePostInbox(c context.Context, w http.ResponseWriter, r *http.Request) (context.Context, bool, error) {
+	return c, true, nil
+}
+
+func (m *memoryTestApp) Blocked(c context.Context, actorIRIs []*url.URL) (bool, error) {
+	return false, nil
+}
+
+func (m *memoryTestApp) FederatingCallbacks(c context.Context) (FederatingWrappedCallbacks, []interface{}, error) {
+	return FederatingWrappedCallbacks{OnFollow: OnFollowAutomaticallyAccept}, nil, nil
+}
+
+func (m *memoryTestApp) DefaultCallback(c context.Context, activity Activity) error {
+	return nil
+}
+
+func (m *memoryTestApp) MaxInboxForwardingRecursionDepth(c context.Context) int {
+	return 1
+}
+
+func (m *memoryTestApp) MaxDeliveryRecursionDepth(c context.Context) int {
+	return 1
+}
+
+func (m *memoryTestApp) FilterForwarding(c context.Context, potentialRecipients []*url.URL, a Activity) ([]*url.URL, error) {
+	return potentialRecipients, nil
+}
+
+func (m *memoryTestApp) GetInbox(c context.Context, r *http.Request) (vocab.ActivityStreamsOrderedCollectionPage, error) {
+	return m.db.GetInbox(c, requestId(r))
+}
+
+func (m *memoryTestApp) GetPagedInbox(c context.Context, r *http.Request) (vocab.Type, error) {
+	return BoxForRequest(c, r, m.db.GetInboxPage)
+}
+
+// newMemoryTestServer starts a server hosting a single actor named 'name'.
+func newMemoryTestServer(t *testing.T, name string) (srv *httptest.Server, db *MemoryDatabase, actor FederatingActor, actorIRI *url.URL) {
+	app := &memoryTestApp{}
+	srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := r.Context()
+		if strings.HasSuffix(r.URL.Path, "/inbox") {
+			if handled, err := actor.PostInbox(c, w, r); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			} else if handled {
+				return
+			}
+		}
+		if handled, err := NewActivityStreamsHandler(db, memoryTestClock{})(c, w, r); err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if handled {
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	db = NewMemoryDatabase(mustParse(srv.URL))
+	app.db = db
+	app.client = srv.Client()
+	actor = NewFederatingActor(app, app, db, memoryTestClock{})
+	actorIRI = mustParse(srv.URL + "/" + name)
+	if err := db.AddActor(context.Background(), newMemoryTestPerson(actorIRI.String())); err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+// TestMemoryDatabaseFederation runs two actors on two servers, each with their
+// own MemoryDatabase, and has one follow the other.
+func TestMemoryDatabaseFederation(t *testing.T) {
+	ctx := context.Background()
+	srvA, dbA, actorA, iriA := newMemoryTestServer(t, "alex")
+	defer srvA.Close()
+	srvB, dbB, _, iriB := newMemoryTestServer(t, "blake")
+	defer srvB.Close()
+	// Alex follows Blake.
+	follow := streams.NewActivityStreamsFollow()
+	actorProp := streams.NewActivityStreamsActorProperty()
+	actorProp.AppendIRI(iriA)
+	follow.SetActivityStreamsActor(actorProp)
+	op := streams.NewActivityStreamsObjectProperty()
+	op.AppendIRI(iriB)
+	follow.SetActivityStreamsObject(op)
+	to := streams.NewActivityStreamsToProperty()
+	to.AppendIRI(iriB)
+	follow.SetActivityStreamsTo(to)
+	_, err := actorA.Send(ctx, mustParse(iriA.String()+"/outbox"), follow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Blake automatically accepted, so Alex follows Blake.
+	followers, err := dbB.Followers(ctx, iriB)
+	assertEqual(t, err, nil)
+	assertEqual(t, followers.GetActivityStreamsItems().Len(), 1)
+	assertEqual(t, followers.GetActivityStreamsItems().At(0).GetIRI().String(), iriA.String())
+	following, err := dbA.Following(ctx, iriA)
+	assertEqual(t, err, nil)
+	assertEqual(t, following.GetActivityStreamsItems().Len(), 1)
+	assertEqual(t, following.GetActivityStreamsItems().At(0).GetIRI().String(), iriB.String())
+	// The Follow is in Alex's outbox and Blake's inbox.
+	outbox, err := dbA.GetOutboxPage(ctx, mustParse(iriA.String()+"/outbox"), CollectionCursor{})
+	assertEqual(t, err, nil)
+	assertEqual(t, len(outbox.Items), 1)
+	followIRI := outbox.Items[0]
+	contains, err := dbB.InboxContains(ctx, mustParse(iriB.String()+"/inbox"), followIRI)
+	assertEqual(t, err, nil)
+	assertEqual(t, contains, true)
+}
@@ -0,0 +1,51 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: actor_handler.go
+
+// Package pub is a generated GoMock package.
+package pub
+
+import (
+	context "context"
+	gomock "github.com/golang/mock/gomock"
+	url "net/url"
+	reflect "reflect"
+)
+
+// MockActorProfileProvider is a mock of ActorProfileProvider interface
+type MockActorProfileProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockActorProfileProviderMockRecorder
+}
+
+// MockActorProfileProviderMockRecorder is the mock recorder for MockActorProfileProvider
+type MockActorProfileProviderMockRecorder struct {
+	mock *MockActorProfileProvider
+}
+
+// NewMockActorProfileProvider creates a new mock instance
+func NewMockActorProfileProvider(ctrl *gomock.Controller) *MockActorProfileProvider {
+	mock := &MockActorProfileProvider{ctrl: ctrl}
+	mock.recorder = &MockActorProfileProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockActorProfileProvider) EXPECT() *MockActorProfileProviderMockRecorder {
+	return m.recorder
+}
+
+// ActorProfile mocks base method
+func (m *MockActorProfileProvider) ActorProfile(c context.Context, actorIRI *url.URL) (ActorProfile, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ActorProfile", c, actorIRI)
+	ret0, _ := ret[0].(ActorProfile)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ActorProfile indicates an expected call of ActorProfile
+func (mr *MockActorProfileProviderMockRecorder) ActorProfile(c, actorIRI interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ActorProfile", reflect.TypeOf((*MockActorProfileProvider)(nil).ActorProfile), c, actorIRI)
+}
@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: pub/common_behavior.go
+// Source: common_behavior.go
 
 // Package pub is a generated GoMock package.
 package pub
@@ -97,3 +97,41 @@ func (mr *MockCommonBehaviorMockRecorder) NewTransport(c, actorBoxIRI, gofedAgen
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewTransport", reflect.TypeOf((*MockCommonBehavior)(nil).NewTransport), c, actorBoxIRI, gofedAgent)
 }
+
+// MockPagedCommonBehavior is a mock of PagedCommonBehavior interface
+type MockPagedCommonBehavior struct {
+	ctrl     *gomock.Controller
+	recorder *MockPagedCommonBehaviorMockRecorder
+}
+
+// MockPagedCommonBehaviorMockRecorder is the mock recorder for MockPagedCommonBehavior
+type MockPagedCommonBehaviorMockRecorder struct {
+	mock *MockPagedCommonBehavior
+}
+
+// NewMockPagedCommonBehavior creates a new mock instance
+func NewMockPagedCommonBehavior(ctrl *gomock.Controller) *MockPagedCommonBehavior {
+	mock := &MockPagedCommonBehavior{ctrl: ctrl}
+	mock.recorder = &MockPagedCommonBehaviorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockPagedCommonBehavior) EXPECT() *MockPagedCommonBehaviorMockRecorder {
+	return m.recorder
+}
+
+// GetPagedOutbox mocks base method
+func (m *MockPagedCommonBehavior) GetPagedOutbox(c context.Context, r *http.Request) (vocab.Type, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPagedOutbox", c, r)
+	ret0, _ := ret[0].(vocab.Type)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPagedOutbox indicates an expected call of GetPagedOutbox
+func (mr *MockPagedCommonBehaviorMockRecorder) GetPagedOutbox(c, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPagedOutbox", reflect.TypeOf((*MockPagedCommonBehavior)(nil).GetPagedOutbox), c, r)
+}
@@ -327,3 +327,122 @@ func (mr *MockDatabaseMockRecorder) Liked(c, actorIRI interface{}) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Liked", reflect.TypeOf((*MockDatabase)(nil).Liked), c, actorIRI)
 }
+
+// MockPagedDatabase is a mock of PagedDatabase interface
+type MockPagedDatabase struct {
+	ctrl     *gomock.Controller
+	recorder *MockPagedDatabaseMockRecorder
+}
+
+// MockPagedDatabaseMockRecorder is the mock recorder for MockPagedDatabase
+type MockPagedDatabaseMockRecorder struct {
+	mock *MockPagedDatabase
+}
+
+// NewMockPagedDatabase creates a new mock instance
+func NewMockPagedDatabase(ctrl *gomock.Controller) *MockPagedDatabase {
+	mock := &MockPagedDatabase{ctrl: ctrl}
+	mock.recorder = &MockPagedDatabaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockPagedDatabase) EXPECT() *MockPagedDatabaseMockRecorder {
+	return m.recorder
+}
+
+// AppendInbox mocks base method
+func (m *MockPagedDatabase) AppendInbox(c context.Context, inboxIRI, id *url.URL) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AppendInbox", c, inboxIRI, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AppendInbox indicates an expected call of AppendInbox
+func (mr *MockPagedDatabaseMockRecorder) AppendInbox(c, inboxIRI, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AppendInbox", reflect.TypeOf((*MockPagedDatabase)(nil).AppendInbox), c, inboxIRI, id)
+}
+
+// GetInboxPage mocks base method
+func (m *MockPagedDatabase) GetInboxPage(c context.Context, inboxIRI *url.URL, cursor CollectionCursor) (BoxPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInboxPage", c, inboxIRI, cursor)
+	ret0, _ := ret[0].(BoxPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInboxPage indicates an expected call of GetInboxPage
+func (mr *MockPagedDatabaseMockRecorder) GetInboxPage(c, inboxIRI, cursor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInboxPage", reflect.TypeOf((*MockPagedDatabase)(nil).GetInboxPage), c, inboxIRI, cursor)
+}
+
+// AppendOutbox mocks base method
+func (m *MockPagedDatabase) AppendOutbox(c context.Context, outboxIRI, id *url.URL) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AppendOutbox", c, outboxIRI, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AppendOutbox indicates an expected call of AppendOutbox
+func (mr *MockPagedDatabaseMockRecorder) AppendOutbox(c, outboxIRI, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AppendOutbox", reflect.TypeOf((*MockPagedDatabase)(nil).AppendOutbox), c, outboxIRI, id)
+}
+
+// GetOutboxPage mocks base method
+func (m *MockPagedDatabase) GetOutboxPage(c context.Context, outboxIRI *url.URL, cursor CollectionCursor) (BoxPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOutboxPage", c, outboxIRI, cursor)
+	ret0, _ := ret[0].(BoxPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOutboxPage indicates an expected call of GetOutboxPage
+func (mr *MockPagedDatabaseMockRecorder) GetOutboxPage(c, outboxIRI, cursor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOutboxPage", reflect.TypeOf((*MockPagedDatabase)(nil).GetOutboxPage), c, outboxIRI, cursor)
+}
+
+// MockOutboxFollowFinder is a mock of OutboxFollowFinder interface
+type MockOutboxFollowFinder struct {
+	ctrl     *gomock.Controller
+	recorder *MockOutboxFollowFinderMockRecorder
+}
+
+// MockOutboxFollowFinderMockRecorder is the mock recorder for MockOutboxFollowFinder
+type MockOutboxFollowFinderMockRecorder struct {
+	mock *MockOutboxFollowFinder
+}
+
+// NewMockOutboxFollowFinder creates a new mock instance
+func NewMockOutboxFollowFinder(ctrl *gomock.Controller) *MockOutboxFollowFinder {
+	mock := &MockOutboxFollowFinder{ctrl: ctrl}
+	mock.recorder = &MockOutboxFollowFinderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockOutboxFollowFinder) EXPECT() *MockOutboxFollowFinderMockRecorder {
+	return m.recorder
+}
+
+// FindOutboxFollow mocks base method
+func (m *MockOutboxFollowFinder) FindOutboxFollow(c context.Context, outboxIRI, object *url.URL) (vocab.ActivityStreamsFollow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindOutboxFollow", c, outboxIRI, object)
+	ret0, _ := ret[0].(vocab.ActivityStreamsFollow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindOutboxFollow indicates an expected call of FindOutboxFollow
+func (mr *MockOutboxFollowFinderMockRecorder) FindOutboxFollow(c, outboxIRI, object interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindOutboxFollow", reflect.TypeOf((*MockOutboxFollowFinder)(nil).FindOutboxFollow), c, outboxIRI, object)
+}
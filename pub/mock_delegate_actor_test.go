@@ -260,3 +260,161 @@ func (mr *MockDelegateActorMockRecorder) GetInbox(c, r interface{}) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInbox", reflect.TypeOf((*MockDelegateActor)(nil).GetInbox), c, r)
 }
+
+// MockPagedDelegateActor is a mock of PagedDelegateActor interface
+type MockPagedDelegateActor struct {
+	ctrl     *gomock.Controller
+	recorder *MockPagedDelegateActorMockRecorder
+}
+
+// MockPagedDelegateActorMockRecorder is the mock recorder for MockPagedDelegateActor
+type MockPagedDelegateActorMockRecorder struct {
+	mock *MockPagedDelegateActor
+}
+
+// NewMockPagedDelegateActor creates a new mock instance
+func NewMockPagedDelegateActor(ctrl *gomock.Controller) *MockPagedDelegateActor {
+	mock := &MockPagedDelegateActor{ctrl: ctrl}
+	mock.recorder = &MockPagedDelegateActorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockPagedDelegateActor) EXPECT() *MockPagedDelegateActorMockRecorder {
+	return m.recorder
+}
+
+// GetPagedOutbox mocks base method
+func (m *MockPagedDelegateActor) GetPagedOutbox(c context.Context, r *http.Request) (vocab.Type, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPagedOutbox", c, r)
+	ret0, _ := ret[0].(vocab.Type)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPagedOutbox indicates an expected call of GetPagedOutbox
+func (mr *MockPagedDelegateActorMockRecorder) GetPagedOutbox(c, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPagedOutbox", reflect.TypeOf((*MockPagedDelegateActor)(nil).GetPagedOutbox), c, r)
+}
+
+// GetPagedInbox mocks base method
+func (m *MockPagedDelegateActor) GetPagedInbox(c context.Context, r *http.Request) (vocab.Type, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPagedInbox", c, r)
+	ret0, _ := ret[0].(vocab.Type)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPagedInbox indicates an expected call of GetPagedInbox
+func (mr *MockPagedDelegateActorMockRecorder) GetPagedInbox(c, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPagedInbox", reflect.TypeOf((*MockPagedDelegateActor)(nil).GetPagedInbox), c, r)
+}
+
+// MockSharedInboxDelegateActor is a mock of SharedInboxDelegateActor interface
+type MockSharedInboxDelegateActor struct {
+	ctrl     *gomock.Controller
+	recorder *MockSharedInboxDelegateActorMockRecorder
+}
+
+// MockSharedInboxDelegateActorMockRecorder is the mock recorder for MockSharedInboxDelegateActor
+type MockSharedInboxDelegateActorMockRecorder struct {
+	mock *MockSharedInboxDelegateActor
+}
+
+// NewMockSharedInboxDelegateActor creates a new mock instance
+func NewMockSharedInboxDelegateActor(ctrl *gomock.Controller) *MockSharedInboxDelegateActor {
+	mock := &MockSharedInboxDelegateActor{ctrl: ctrl}
+	mock.recorder = &MockSharedInboxDelegateActorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockSharedInboxDelegateActor) EXPECT() *MockSharedInboxDelegateActorMockRecorder {
+	return m.recorder
+}
+
+// SharedInboxRecipients mocks base method
+func (m *MockSharedInboxDelegateActor) SharedInboxRecipients(c context.Context, activity Activity) ([]*url.URL, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SharedInboxRecipients", c, activity)
+	ret0, _ := ret[0].([]*url.URL)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SharedInboxRecipients indicates an expected call of SharedInboxRecipients
+func (mr *MockSharedInboxDelegateActorMockRecorder) SharedInboxRecipients(c, activity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SharedInboxRecipients", reflect.TypeOf((*MockSharedInboxDelegateActor)(nil).SharedInboxRecipients), c, activity)
+}
+
+// PostSharedInbox mocks base method
+func (m *MockSharedInboxDelegateActor) PostSharedInbox(c context.Context, inboxIRIs []*url.URL, activity Activity) ([]*url.URL, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PostSharedInbox", c, inboxIRIs, activity)
+	ret0, _ := ret[0].([]*url.URL)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PostSharedInbox indicates an expected call of PostSharedInbox
+func (mr *MockSharedInboxDelegateActorMockRecorder) PostSharedInbox(c, inboxIRIs, activity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PostSharedInbox", reflect.TypeOf((*MockSharedInboxDelegateActor)(nil).PostSharedInbox), c, inboxIRIs, activity)
+}
+
+// MockFollowApprovalDelegateActor is a mock of FollowApprovalDelegateActor interface
+type MockFollowApprovalDelegateActor struct {
+	ctrl     *gomock.Controller
+	recorder *MockFollowApprovalDelegateActorMockRecorder
+}
+
+// MockFollowApprovalDelegateActorMockRecorder is the mock recorder for MockFollowApprovalDelegateActor
+type MockFollowApprovalDelegateActorMockRecorder struct {
+	mock *MockFollowApprovalDelegateActor
+}
+
+// NewMockFollowApprovalDelegateActor creates a new mock instance
+func NewMockFollowApprovalDelegateActor(ctrl *gomock.Controller) *MockFollowApprovalDelegateActor {
+	mock := &MockFollowApprovalDelegateActor{ctrl: ctrl}
+	mock.recorder = &MockFollowApprovalDelegateActorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockFollowApprovalDelegateActor) EXPECT() *MockFollowApprovalDelegateActorMockRecorder {
+	return m.recorder
+}
+
+// RespondToPendingFollow mocks base method
+func (m *MockFollowApprovalDelegateActor) RespondToPendingFollow(c context.Context, outboxIRI, followId *url.URL, accept bool) (Activity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RespondToPendingFollow", c, outboxIRI, followId, accept)
+	ret0, _ := ret[0].(Activity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RespondToPendingFollow indicates an expected call of RespondToPendingFollow
+func (mr *MockFollowApprovalDelegateActorMockRecorder) RespondToPendingFollow(c, outboxIRI, followId, accept interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RespondToPendingFollow", reflect.TypeOf((*MockFollowApprovalDelegateActor)(nil).RespondToPendingFollow), c, outboxIRI, followId, accept)
+}
+
+// ResolvePendingFollow mocks base method
+func (m *MockFollowApprovalDelegateActor) ResolvePendingFollow(c context.Context, outboxIRI, followId *url.URL, accept bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolvePendingFollow", c, outboxIRI, followId, accept)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResolvePendingFollow indicates an expected call of ResolvePendingFollow
+func (mr *MockFollowApprovalDelegateActorMockRecorder) ResolvePendingFollow(c, outboxIRI, followId, accept interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolvePendingFollow", reflect.TypeOf((*MockFollowApprovalDelegateActor)(nil).ResolvePendingFollow), c, outboxIRI, followId, accept)
+}
@@ -0,0 +1,132 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: delivery_queue.go
+
+// Package pub is a generated GoMock package.
+package pub
+
+import (
+	context "context"
+	gomock "github.com/golang/mock/gomock"
+	url "net/url"
+	reflect "reflect"
+	time "time"
+)
+
+// MockDeliveryStore is a mock of DeliveryStore interface
+type MockDeliveryStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeliveryStoreMockRecorder
+}
+
+// MockDeliveryStoreMockRecorder is the mock recorder for MockDeliveryStore
+type MockDeliveryStoreMockRecorder struct {
+	mock *MockDeliveryStore
+}
+
+// NewMockDeliveryStore creates a new mock instance
+func NewMockDeliveryStore(ctrl *gomock.Controller) *MockDeliveryStore {
+	mock := &MockDeliveryStore{ctrl: ctrl}
+	mock.recorder = &MockDeliveryStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockDeliveryStore) EXPECT() *MockDeliveryStoreMockRecorder {
+	return m.recorder
+}
+
+// Put mocks base method
+func (m *MockDeliveryStore) Put(c context.Context, d Delivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Put", c, d)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Put indicates an expected call of Put
+func (mr *MockDeliveryStoreMockRecorder) Put(c, d interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockDeliveryStore)(nil).Put), c, d)
+}
+
+// Get mocks base method
+func (m *MockDeliveryStore) Get(c context.Context, activityIRI, recipient *url.URL) (Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", c, activityIRI, recipient)
+	ret0, _ := ret[0].(Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get
+func (mr *MockDeliveryStoreMockRecorder) Get(c, activityIRI, recipient interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockDeliveryStore)(nil).Get), c, activityIRI, recipient)
+}
+
+// List mocks base method
+func (m *MockDeliveryStore) List(c context.Context, activityIRI *url.URL) ([]Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", c, activityIRI)
+	ret0, _ := ret[0].([]Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List
+func (mr *MockDeliveryStoreMockRecorder) List(c, activityIRI interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockDeliveryStore)(nil).List), c, activityIRI)
+}
+
+// Due mocks base method
+func (m *MockDeliveryStore) Due(c context.Context, now time.Time, max int) ([]Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Due", c, now, max)
+	ret0, _ := ret[0].([]Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Due indicates an expected call of Due
+func (mr *MockDeliveryStoreMockRecorder) Due(c, now, max interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Due", reflect.TypeOf((*MockDeliveryStore)(nil).Due), c, now, max)
+}
+
+// MockDeliveryQueue is a mock of DeliveryQueue interface
+type MockDeliveryQueue struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeliveryQueueMockRecorder
+}
+
+// MockDeliveryQueueMockRecorder is the mock recorder for MockDeliveryQueue
+type MockDeliveryQueueMockRecorder struct {
+	mock *MockDeliveryQueue
+}
+
+// NewMockDeliveryQueue creates a new mock instance
+func NewMockDeliveryQueue(ctrl *gomock.Controller) *MockDeliveryQueue {
+	mock := &MockDeliveryQueue{ctrl: ctrl}
+	mock.recorder = &MockDeliveryQueueMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockDeliveryQueue) EXPECT() *MockDeliveryQueueMockRecorder {
+	return m.recorder
+}
+
+// Enqueue mocks base method
+func (m *MockDeliveryQueue) Enqueue(c context.Context, boxIRI, activityIRI *url.URL, b []byte, recipients []*url.URL) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Enqueue", c, boxIRI, activityIRI, b, recipients)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Enqueue indicates an expected call of Enqueue
+func (mr *MockDeliveryQueueMockRecorder) Enqueue(c, boxIRI, activityIRI, b, recipients interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enqueue", reflect.TypeOf((*MockDeliveryQueue)(nil).Enqueue), c, boxIRI, activityIRI, b, recipients)
+}
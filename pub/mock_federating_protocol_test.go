@@ -169,3 +169,41 @@ func (mr *MockFederatingProtocolMockRecorder) GetInbox(c, r interface{}) *gomock
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInbox", reflect.TypeOf((*MockFederatingProtocol)(nil).GetInbox), c, r)
 }
+
+// MockPagedFederatingProtocol is a mock of PagedFederatingProtocol interface
+type MockPagedFederatingProtocol struct {
+	ctrl     *gomock.Controller
+	recorder *MockPagedFederatingProtocolMockRecorder
+}
+
+// MockPagedFederatingProtocolMockRecorder is the mock recorder for MockPagedFederatingProtocol
+type MockPagedFederatingProtocolMockRecorder struct {
+	mock *MockPagedFederatingProtocol
+}
+
+// NewMockPagedFederatingProtocol creates a new mock instance
+func NewMockPagedFederatingProtocol(ctrl *gomock.Controller) *MockPagedFederatingProtocol {
+	mock := &MockPagedFederatingProtocol{ctrl: ctrl}
+	mock.recorder = &MockPagedFederatingProtocolMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockPagedFederatingProtocol) EXPECT() *MockPagedFederatingProtocolMockRecorder {
+	return m.recorder
+}
+
+// GetPagedInbox mocks base method
+func (m *MockPagedFederatingProtocol) GetPagedInbox(c context.Context, r *http.Request) (vocab.Type, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPagedInbox", c, r)
+	ret0, _ := ret[0].(vocab.Type)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPagedInbox indicates an expected call of GetPagedInbox
+func (mr *MockPagedFederatingProtocolMockRecorder) GetPagedInbox(c, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPagedInbox", reflect.TypeOf((*MockPagedFederatingProtocol)(nil).GetPagedInbox), c, r)
+}
@@ -78,6 +78,45 @@ func (mr *MockTransportMockRecorder) BatchDeliver(c, b, recipients interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchDeliver", reflect.TypeOf((*MockTransport)(nil).BatchDeliver), c, b, recipients)
 }
 
+// MockRedirectReportingTransport is a mock of RedirectReportingTransport interface
+type MockRedirectReportingTransport struct {
+	ctrl     *gomock.Controller
+	recorder *MockRedirectReportingTransportMockRecorder
+}
+
+// MockRedirectReportingTransportMockRecorder is the mock recorder for MockRedirectReportingTransport
+type MockRedirectReportingTransportMockRecorder struct {
+	mock *MockRedirectReportingTransport
+}
+
+// NewMockRedirectReportingTransport creates a new mock instance
+func NewMockRedirectReportingTransport(ctrl *gomock.Controller) *MockRedirectReportingTransport {
+	mock := &MockRedirectReportingTransport{ctrl: ctrl}
+	mock.recorder = &MockRedirectReportingTransportMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockRedirectReportingTransport) EXPECT() *MockRedirectReportingTransportMockRecorder {
+	return m.recorder
+}
+
+// DereferenceFinalURL mocks base method
+func (m *MockRedirectReportingTransport) DereferenceFinalURL(c context.Context, iri *url.URL) ([]byte, *url.URL, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DereferenceFinalURL", c, iri)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(*url.URL)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// DereferenceFinalURL indicates an expected call of DereferenceFinalURL
+func (mr *MockRedirectReportingTransportMockRecorder) DereferenceFinalURL(c, iri interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DereferenceFinalURL", reflect.TypeOf((*MockRedirectReportingTransport)(nil).DereferenceFinalURL), c, iri)
+}
+
 // MockHttpClient is a mock of HttpClient interface
 type MockHttpClient struct {
 	ctrl     *gomock.Controller
@@ -0,0 +1,144 @@
+package pub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/go-fed/activity/streams"
+	"github.com/go-fed/activity/streams/vocab"
+	"net/url"
+	"sync"
+)
+
+// PendingFollowStore records the Follow requests from peers that await
+// approval by the actors on this server, like the follow requests of a locked
+// account.
+//
+// Follows are added when received with OnFollowPendingApproval, and removed
+// when approved with AcceptFollow, refused with RejectFollow, or undone by the
+// peer.
+type PendingFollowStore interface {
+	// Add records the Follow of the actor as pending, replacing any
+	// pending Follow with the same id.
+	Add(c context.Context, actorIRI *url.URL, follow vocab.ActivityStreamsFollow) error
+	// Remove removes the pending Follow of the actor with the given id,
+	// and returns it if it existed.
+	Remove(c context.Context, actorIRI, followId *url.URL) (follow vocab.ActivityStreamsFollow, exists bool, err error)
+	// List returns the pending Follows of the actor, oldest first.
+	List(c context.Context, actorIRI *url.URL) (follows []vocab.ActivityStreamsFollow, err error)
+}
+
+// PendingFollowStore must be implemented by MemoryPendingFollowStore.
+var _ PendingFollowStore = &MemoryPendingFollowStore{}
+
+// MemoryPendingFollowStore is a PendingFollowStore that keeps serialized copies
+// of the Follows in memory.
+//
+// Pending Follows do not survive restarts of the application. It is safe for
+// concurrent use.
+type MemoryPendingFollowStore struct {
+	mu      sync.Mutex
+	pending map[string][]memoryPendingFollow
+}
+
+// memoryPendingFollow is a serialized pending Follow.
+type memoryPendingFollow struct {
+	id string
+	b  []byte
+}
+
+// NewMemoryPendingFollowStore returns an empty MemoryPendingFollowStore.
+func NewMemoryPendingFollowStore() *MemoryPendingFollowStore {
+	return &MemoryPendingFollowStore{
+		pending: make(map[string][]memoryPendingFollow),
+	}
+}
+
+// Add records a serialized copy of the Follow as pending.
+func (m *MemoryPendingFollowStore) Add(c context.Context, actorIRI *url.URL, follow vocab.ActivityStreamsFollow) error {
+	id, err := GetId(follow)
+	if err != nil {
+		return err
+	}
+	raw, err := streams.Serialize(follow)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k := actorIRI.String()
+	pending := m.pending[k]
+	for i, p := range pending {
+		if p.id == id.String() {
+			pending[i].b = b
+			return nil
+		}
+	}
+	m.pending[k] = append(pending, memoryPendingFollow{id: id.String(), b: b})
+	return nil
+}
+
+// Remove removes the pending Follow with the given id.
+func (m *MemoryPendingFollowStore) Remove(c context.Context, actorIRI, followId *url.URL) (follow vocab.ActivityStreamsFollow, exists bool, err error) {
+	m.mu.Lock()
+	k := actorIRI.String()
+	pending := m.pending[k]
+	var b []byte
+	for i, p := range pending {
+		if p.id == followId.String() {
+			b = p.b
+			m.pending[k] = append(pending[:i:i], pending[i+1:]...)
+			break
+		}
+	}
+	if len(m.pending[k]) == 0 {
+		delete(m.pending, k)
+	}
+	m.mu.Unlock()
+	if b == nil {
+		return
+	}
+	follow, err = toFollow(c, b)
+	if err != nil {
+		return
+	}
+	exists = true
+	return
+}
+
+// List returns the pending Follows of the actor, oldest first.
+func (m *MemoryPendingFollowStore) List(c context.Context, actorIRI *url.URL) (follows []vocab.ActivityStreamsFollow, err error) {
+	m.mu.Lock()
+	pending := append([]memoryPendingFollow(nil), m.pending[actorIRI.String()]...)
+	m.mu.Unlock()
+	for _, p := range pending {
+		var follow vocab.ActivityStreamsFollow
+		follow, err = toFollow(c, p.b)
+		if err != nil {
+			return
+		}
+		follows = append(follows, follow)
+	}
+	return
+}
+
+// toFollow deserializes a Follow.
+func toFollow(c context.Context, b []byte) (vocab.ActivityStreamsFollow, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+	t, err := streams.ToType(c, raw)
+	if err != nil {
+		return nil, err
+	}
+	follow, ok := t.(vocab.ActivityStreamsFollow)
+	if !ok {
+		return nil, fmt.Errorf("pending follow is not a Follow: %T", t)
+	}
+	return follow, nil
+}
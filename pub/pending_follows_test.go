@@ -0,0 +1,60 @@
+package pub
+
+import (
+	"context"
+	"github.com/go-fed/activity/streams"
+	"github.com/go-fed/activity/streams/vocab"
+	"testing"
+)
+
+// TestMemoryPendingFollowStore tests adding, listing, and removing pending
+// Follows.
+func TestMemoryPendingFollowStore(t *testing.T) {
+	ctx := context.Background()
+	myIRI := mustParse(testFederatedActorIRI2)
+	newFollowFn := func(id string) vocab.ActivityStreamsFollow {
+		f := streams.NewActivityStreamsFollow()
+		idProp := streams.NewJSONLDIdProperty()
+		idProp.Set(mustParse(id))
+		f.SetJSONLDId(idProp)
+		actor := streams.NewActivityStreamsActorProperty()
+		actor.AppendIRI(mustParse(testFederatedActorIRI))
+		f.SetActivityStreamsActor(actor)
+		op := streams.NewActivityStreamsObjectProperty()
+		op.AppendIRI(myIRI)
+		f.SetActivityStreamsObject(op)
+		return f
+	}
+	t.Run("ListsPendingFollowsInOrder", func(t *testing.T) {
+		s := NewMemoryPendingFollowStore()
+		err := s.Add(ctx, myIRI, newFollowFn(testFederatedActivityIRI))
+		assertEqual(t, err, nil)
+		err = s.Add(ctx, myIRI, newFollowFn(testFederatedActivityIRI2))
+		assertEqual(t, err, nil)
+		err = s.Add(ctx, myIRI, newFollowFn(testFederatedActivityIRI))
+		assertEqual(t, err, nil)
+		follows, err := s.List(ctx, myIRI)
+		assertEqual(t, err, nil)
+		assertEqual(t, len(follows), 2)
+		assertEqual(t, follows[0].GetJSONLDId().Get().String(), testFederatedActivityIRI)
+		assertEqual(t, follows[1].GetJSONLDId().Get().String(), testFederatedActivityIRI2)
+		follows, err = s.List(ctx, mustParse(testFederatedActorIRI3))
+		assertEqual(t, err, nil)
+		assertEqual(t, len(follows), 0)
+	})
+	t.Run("RemovesPendingFollow", func(t *testing.T) {
+		s := NewMemoryPendingFollowStore()
+		s.Add(ctx, myIRI, newFollowFn(testFederatedActivityIRI))
+		s.Add(ctx, myIRI, newFollowFn(testFederatedActivityIRI2))
+		f, exists, err := s.Remove(ctx, myIRI, mustParse(testFederatedActivityIRI))
+		assertEqual(t, err, nil)
+		assertEqual(t, exists, true)
+		assertEqual(t, f.GetJSONLDId().Get().String(), testFederatedActivityIRI)
+		_, exists, err = s.Remove(ctx, myIRI, mustParse(testFederatedActivityIRI))
+		assertEqual(t, err, nil)
+		assertEqual(t, exists, false)
+		follows, _ := s.List(ctx, myIRI)
+		assertEqual(t, len(follows), 1)
+		assertEqual(t, follows[0].GetJSONLDId().Get().String(), testFederatedActivityIRI2)
+	})
+}
@@ -87,6 +87,31 @@ type inboxer interface {
 	GetActivityStreamsInbox() vocab.ActivityStreamsInboxProperty
 }
 
+// outboxer is an ActivityStreams type with an 'outbox' property
+type outboxer interface {
+	GetActivityStreamsOutbox() vocab.ActivityStreamsOutboxProperty
+}
+
+// followerser is an ActivityStreams type with a 'followers' property
+type followerser interface {
+	GetActivityStreamsFollowers() vocab.ActivityStreamsFollowersProperty
+}
+
+// followinger is an ActivityStreams type with a 'following' property
+type followinger interface {
+	GetActivityStreamsFollowing() vocab.ActivityStreamsFollowingProperty
+}
+
+// likeder is an ActivityStreams type with a 'liked' property
+type likeder interface {
+	GetActivityStreamsLiked() vocab.ActivityStreamsLikedProperty
+}
+
+// publicKeyer is an ActivityStreams type with a 'publicKey' property
+type publicKeyer interface {
+	GetW3IDSecurityV1PublicKey() vocab.W3IDSecurityV1PublicKeyProperty
+}
+
 // attributedToer is an ActivityStreams type with an 'attributedTo' property
 type attributedToer interface {
 	GetActivityStreamsAttributedTo() vocab.ActivityStreamsAttributedToProperty
@@ -105,6 +130,23 @@ type shareser interface {
 	SetActivityStreamsShares(i vocab.ActivityStreamsSharesProperty)
 }
 
+// namer is an ActivityStreams type with a 'name' property
+type namer interface {
+	GetActivityStreamsName() vocab.ActivityStreamsNameProperty
+}
+
+// replieser is an ActivityStreams type with a 'replies' property
+type replieser interface {
+	GetActivityStreamsReplies() vocab.ActivityStreamsRepliesProperty
+	SetActivityStreamsReplies(i vocab.ActivityStreamsRepliesProperty)
+}
+
+// totalItemser is an ActivityStreams type with a 'totalItems' property
+type totalItemser interface {
+	GetActivityStreamsTotalItems() vocab.ActivityStreamsTotalItemsProperty
+	SetActivityStreamsTotalItems(i vocab.ActivityStreamsTotalItemsProperty)
+}
+
 // actorer is an ActivityStreams type with an 'actor' property
 type actorer interface {
 	GetActivityStreamsActor() vocab.ActivityStreamsActorProperty
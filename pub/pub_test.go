@@ -16,33 +16,34 @@ import (
 )
 
 const (
-	testMyInboxIRI            = "https://example.com/addison/inbox"
-	testMyOutboxIRI           = "https://example.com/addison/outbox"
-	testFederatedActivityIRI  = "https://other.example.com/activity/1"
-	testFederatedActivityIRI2 = "https://other.example.com/activity/2"
-	testFederatedActorIRI     = "https://other.example.com/dakota"
-	testFederatedActorIRI2    = "https://other.example.com/addison"
-	testFederatedActorIRI3    = "https://other.example.com/sam"
-	testFederatedActorIRI4    = "https://other.example.com/jessie"
-	testFederatedInboxIRI     = "https://other.example.com/dakota/inbox"
-	testFederatedInboxIRI2    = "https://other.example.com/addison/inbox"
-	testNoteId1               = "https://example.com/note/1"
-	testNoteId2               = "https://example.com/note/2"
-	testNewActivityIRI        = "https://example.com/new/1"
-	testNewActivityIRI2       = "https://example.com/new/2"
-	testNewActivityIRI3       = "https://example.com/new/3"
-	testToIRI                 = "https://maybe.example.com/to/1"
-	testToIRI2                = "https://maybe.example.com/to/2"
-	testCcIRI                 = "https://maybe.example.com/cc/1"
-	testCcIRI2                = "https://maybe.example.com/cc/2"
-	testAudienceIRI           = "https://maybe.example.com/audience/1"
-	testAudienceIRI2          = "https://maybe.example.com/audience/2"
-	testPersonIRI             = "https://maybe.example.com/person"
-	testServiceIRI            = "https://maybe.example.com/service"
-	testTagIRI                = "https://example.com/tag/1"
-	testTagIRI2               = "https://example.com/tag/2"
-	inReplyToIRI              = "https://example.com/inReplyTo/1"
-	inReplyToIRI2             = "https://example.com/inReplyTo/2"
+	testMyInboxIRI              = "https://example.com/addison/inbox"
+	testMyOutboxIRI             = "https://example.com/addison/outbox"
+	testFederatedActivityIRI    = "https://other.example.com/activity/1"
+	testFederatedActivityIRI2   = "https://other.example.com/activity/2"
+	testFederatedActorIRI       = "https://other.example.com/dakota"
+	testFederatedActorIRI2      = "https://other.example.com/addison"
+	testFederatedActorIRI3      = "https://other.example.com/sam"
+	testFederatedActorIRI4      = "https://other.example.com/jessie"
+	testFederatedInboxIRI       = "https://other.example.com/dakota/inbox"
+	testFederatedInboxIRI2      = "https://other.example.com/addison/inbox"
+	testFederatedSharedInboxIRI = "https://other.example.com/inbox"
+	testNoteId1                 = "https://example.com/note/1"
+	testNoteId2                 = "https://example.com/note/2"
+	testNewActivityIRI          = "https://example.com/new/1"
+	testNewActivityIRI2         = "https://example.com/new/2"
+	testNewActivityIRI3         = "https://example.com/new/3"
+	testToIRI                   = "https://maybe.example.com/to/1"
+	testToIRI2                  = "https://maybe.example.com/to/2"
+	testCcIRI                   = "https://maybe.example.com/cc/1"
+	testCcIRI2                  = "https://maybe.example.com/cc/2"
+	testAudienceIRI             = "https://maybe.example.com/audience/1"
+	testAudienceIRI2            = "https://maybe.example.com/audience/2"
+	testPersonIRI               = "https://maybe.example.com/person"
+	testServiceIRI              = "https://maybe.example.com/service"
+	testTagIRI                  = "https://example.com/tag/1"
+	testTagIRI2                 = "https://example.com/tag/2"
+	inReplyToIRI                = "https://example.com/inReplyTo/1"
+	inReplyToIRI2               = "https://example.com/inReplyTo/2"
 )
 
 // mustParse parses a URL or panics.
@@ -520,6 +521,13 @@ func now() time.Time {
 	return time.Date(2000, 2, 3, 4, 5, 6, 7, l)
 }
 
+// setTestSharedInbox sets the 'sharedInbox' in the 'endpoints' of a Person.
+func setTestSharedInbox(p vocab.ActivityStreamsPerson, iri string) {
+	p.GetUnknownProperties()[endpointsProperty] = map[string]interface{}{
+		sharedInboxProperty: iri,
+	}
+}
+
 // nowDateHeader returns the "current" time formatted in a form expected by the
 // Date header in HTTP responses.
 func nowDateHeader() string {
@@ -705,3 +713,43 @@ func newActivityWithId(id string) vocab.ActivityStreamsActivity {
 	a.SetJSONLDId(i)
 	return a
 }
+
+// pagedDelegateActor is a DelegateActor that is also a PagedDelegateActor.
+type pagedDelegateActor struct {
+	*MockDelegateActor
+	*MockPagedDelegateActor
+}
+
+// sharedInboxDelegateActor is a DelegateActor that is also a
+// SharedInboxDelegateActor.
+type sharedInboxDelegateActor struct {
+	*MockDelegateActor
+	*MockSharedInboxDelegateActor
+}
+
+// followApprovalDelegateActor is a DelegateActor that is also a
+// FollowApprovalDelegateActor.
+type followApprovalDelegateActor struct {
+	*MockDelegateActor
+	*MockFollowApprovalDelegateActor
+}
+
+// pagedFederatingProtocol is a FederatingProtocol that is also a
+// PagedFederatingProtocol.
+type pagedFederatingProtocol struct {
+	*MockFederatingProtocol
+	*MockPagedFederatingProtocol
+}
+
+// outboxFollowFinderDatabase is a Database that is also an
+// OutboxFollowFinder.
+type outboxFollowFinderDatabase struct {
+	*MockDatabase
+	*MockOutboxFollowFinder
+}
+
+// pagedDatabase is a Database that is also a PagedDatabase.
+type pagedDatabase struct {
+	*MockDatabase
+	*MockPagedDatabase
+}
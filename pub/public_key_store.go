@@ -0,0 +1,130 @@
+package pub
+
+import (
+	"context"
+	"github.com/go-fed/activity/streams/vocab"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// CachedPublicKey is a peer's public key along with when it must be fetched
+// again.
+type CachedPublicKey struct {
+	// KeyId is the id of the public key.
+	KeyId *url.URL
+	// Owner is the actor that owns, and lists, the public key.
+	Owner *url.URL
+	// Key is the public key as it was fetched from the peer.
+	Key vocab.W3IDSecurityV1PublicKey
+	// Expires is the time after which the key is no longer trusted without
+	// fetching it again.
+	Expires time.Time
+}
+
+// PublicKeyStore caches the public keys of peers so that they do not need to
+// be fetched when verifying every HTTP Signature.
+//
+// The store does not need to check whether keys have expired; that is done by
+// its callers using their Clock. A store may evict keys at any time.
+//
+// The same PublicKeyStore is meant to be given to both the HttpSigVerifier and
+// the Actor, so that federated Update and Delete activities affect which keys
+// are trusted.
+type PublicKeyStore interface {
+	// Get returns the cached public key with the given id, and whether it
+	// exists.
+	Get(c context.Context, keyId *url.URL) (k CachedPublicKey, exists bool, err error)
+	// Set caches the public key, replacing any key with the same id.
+	Set(c context.Context, k CachedPublicKey) error
+	// InvalidateOwner removes the cached keys owned by the actor, so they
+	// are fetched again when next used. It is called when the actor is
+	// updated, which is how peers rotate their keys.
+	InvalidateOwner(c context.Context, owner *url.URL) error
+	// RevokeOwner removes the cached keys owned by the actor and, if there
+	// were any, marks the actor as revoked. It is called for every object a
+	// peer deletes, most of which own no keys, so owners without cached keys
+	// must not be remembered.
+	RevokeOwner(c context.Context, owner *url.URL) error
+	// IsRevoked returns whether the actor's keys have been revoked.
+	IsRevoked(c context.Context, owner *url.URL) (revoked bool, err error)
+}
+
+// PublicKeyStore must be implemented by MemoryPublicKeyStore.
+var _ PublicKeyStore = &MemoryPublicKeyStore{}
+
+// MemoryPublicKeyStore is a PublicKeyStore that keeps keys in memory.
+//
+// Keys, and revocations, do not survive restarts of the application. It is
+// safe for concurrent use.
+type MemoryPublicKeyStore struct {
+	mu      sync.Mutex
+	keys    map[string]CachedPublicKey
+	revoked map[string]bool
+}
+
+// NewMemoryPublicKeyStore returns an empty MemoryPublicKeyStore.
+func NewMemoryPublicKeyStore() *MemoryPublicKeyStore {
+	return &MemoryPublicKeyStore{
+		keys:    make(map[string]CachedPublicKey),
+		revoked: make(map[string]bool),
+	}
+}
+
+// Get returns the cached public key with the given id.
+func (m *MemoryPublicKeyStore) Get(c context.Context, keyId *url.URL) (k CachedPublicKey, exists bool, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k, exists = m.keys[keyId.String()]
+	return
+}
+
+// Set caches the public key.
+func (m *MemoryPublicKeyStore) Set(c context.Context, k CachedPublicKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keys[k.KeyId.String()] = k
+	return nil
+}
+
+// InvalidateOwner removes the cached keys owned by the actor.
+func (m *MemoryPublicKeyStore) InvalidateOwner(c context.Context, owner *url.URL) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.invalidate(owner)
+	return nil
+}
+
+// RevokeOwner removes the cached keys owned by the actor and marks it as
+// revoked if it owned any.
+func (m *MemoryPublicKeyStore) RevokeOwner(c context.Context, owner *url.URL) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.invalidate(owner) {
+		m.revoked[owner.String()] = true
+	}
+	return nil
+}
+
+// IsRevoked returns whether the actor's keys have been revoked.
+func (m *MemoryPublicKeyStore) IsRevoked(c context.Context, owner *url.URL) (revoked bool, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	revoked = m.revoked[owner.String()]
+	return
+}
+
+// invalidate removes the keys owned by the actor, returning whether there were
+// any.
+//
+// Must be called with the mutex held.
+func (m *MemoryPublicKeyStore) invalidate(owner *url.URL) (owned bool) {
+	o := owner.String()
+	for id, k := range m.keys {
+		if k.Owner.String() == o {
+			delete(m.keys, id)
+			owned = true
+		}
+	}
+	return
+}
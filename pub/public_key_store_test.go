@@ -0,0 +1,68 @@
+package pub
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestMemoryPublicKeyStore tests caching, invalidating, and revoking keys.
+func TestMemoryPublicKeyStore(t *testing.T) {
+	ctx := context.Background()
+	newKeyFn := func(keyId, owner string) CachedPublicKey {
+		return CachedPublicKey{
+			KeyId:   mustParse(keyId),
+			Owner:   mustParse(owner),
+			Expires: now().Add(time.Hour),
+		}
+	}
+	t.Run("GetsSetKeys", func(t *testing.T) {
+		s := NewMemoryPublicKeyStore()
+		err := s.Set(ctx, newKeyFn(testFederatedKeyIRI, testFederatedActorIRI))
+		assertEqual(t, err, nil)
+		k, exists, err := s.Get(ctx, mustParse(testFederatedKeyIRI))
+		assertEqual(t, err, nil)
+		assertEqual(t, exists, true)
+		assertEqual(t, k.Owner.String(), testFederatedActorIRI)
+		_, exists, err = s.Get(ctx, mustParse(testFederatedActorIRI2))
+		assertEqual(t, err, nil)
+		assertEqual(t, exists, false)
+	})
+	t.Run("InvalidatesOnlyOwnedKeys", func(t *testing.T) {
+		s := NewMemoryPublicKeyStore()
+		s.Set(ctx, newKeyFn(testFederatedKeyIRI, testFederatedActorIRI))
+		s.Set(ctx, newKeyFn(testFederatedActorIRI3, testFederatedActorIRI2))
+		err := s.InvalidateOwner(ctx, mustParse(testFederatedActorIRI))
+		assertEqual(t, err, nil)
+		_, exists, _ := s.Get(ctx, mustParse(testFederatedKeyIRI))
+		assertEqual(t, exists, false)
+		_, exists, _ = s.Get(ctx, mustParse(testFederatedActorIRI3))
+		assertEqual(t, exists, true)
+		revoked, err := s.IsRevoked(ctx, mustParse(testFederatedActorIRI))
+		assertEqual(t, err, nil)
+		assertEqual(t, revoked, false)
+	})
+	t.Run("RevokesOwnedKeys", func(t *testing.T) {
+		s := NewMemoryPublicKeyStore()
+		s.Set(ctx, newKeyFn(testFederatedKeyIRI, testFederatedActorIRI))
+		err := s.RevokeOwner(ctx, mustParse(testFederatedActorIRI))
+		assertEqual(t, err, nil)
+		_, exists, _ := s.Get(ctx, mustParse(testFederatedKeyIRI))
+		assertEqual(t, exists, false)
+		revoked, err := s.IsRevoked(ctx, mustParse(testFederatedActorIRI))
+		assertEqual(t, err, nil)
+		assertEqual(t, revoked, true)
+	})
+	t.Run("DoesNotRevokeOwnersWithoutKeys", func(t *testing.T) {
+		s := NewMemoryPublicKeyStore()
+		s.Set(ctx, newKeyFn(testFederatedKeyIRI, testFederatedActorIRI))
+		err := s.RevokeOwner(ctx, mustParse(testFederatedActorIRI2))
+		assertEqual(t, err, nil)
+		_, exists, _ := s.Get(ctx, mustParse(testFederatedKeyIRI))
+		assertEqual(t, exists, true)
+		revoked, err := s.IsRevoked(ctx, mustParse(testFederatedActorIRI2))
+		assertEqual(t, err, nil)
+		assertEqual(t, revoked, false)
+		assertEqual(t, len(s.revoked), 0)
+	})
+}
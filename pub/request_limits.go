@@ -0,0 +1,87 @@
+package pub
+
+import (
+	"context"
+	"errors"
+	"github.com/go-fed/activity/streams"
+	"io"
+	"net/http"
+)
+
+// ErrRequestBodyTooLarge indicates the body of a POST to an inbox or outbox is
+// larger than allowed by the RequestLimits. The Actor responds to such requests
+// with 413 Request Entity Too Large.
+var ErrRequestBodyTooLarge = errors.New("request body too large")
+
+// RequestLimits bounds the POST requests to inboxes and outboxes, so that a
+// hostile peer cannot exhaust the server's resources with a large or deeply
+// nested ActivityStreams value.
+//
+// A zero field means that aspect of the requests is not limited.
+type RequestLimits struct {
+	// MaxBodyBytes is the maximum size of the request body.
+	MaxBodyBytes int64
+	// MaxDepth is the maximum nesting depth of the JSON objects and arrays
+	// in the request body. Exceeding it results in a 400 Bad Request.
+	MaxDepth int
+	// MaxItems is the maximum number of items in each JSON array in the
+	// request body. Exceeding it results in a 400 Bad Request.
+	MaxItems int
+}
+
+// limitBody bounds the body of the request to MaxBodyBytes before anything
+// reads it, including an HttpSigVerifier in the delegate's authentication. It
+// returns ErrRequestBodyTooLarge if the Content-Length already exceeds the
+// limit. Otherwise, reading past the limit fails with ErrRequestBodyTooLarge.
+func (l RequestLimits) limitBody(r *http.Request) error {
+	if l.MaxBodyBytes <= 0 || r.Body == nil {
+		return nil
+	}
+	if r.ContentLength > l.MaxBodyBytes {
+		return ErrRequestBodyTooLarge
+	}
+	r.Body = &limitedBody{ReadCloser: r.Body, remaining: l.MaxBodyBytes}
+	return nil
+}
+
+// limitedBody is a request body failing with ErrRequestBodyTooLarge once more
+// than its limit is read from it.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+// Read reads from the underlying body, reading one byte past the limit in
+// order to detect a body exceeding it.
+func (b *limitedBody) Read(p []byte) (n int, err error) {
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err = b.ReadCloser.Read(p)
+	if int64(n) <= b.remaining {
+		b.remaining -= int64(n)
+		return
+	}
+	n = int(b.remaining)
+	b.remaining = 0
+	return n, ErrRequestBodyTooLarge
+}
+
+// context returns a copy of the context carrying the depth and item limits, so
+// that streams.ToType enforces them on the request body and on any value
+// deserialized later while handling the request.
+func (l RequestLimits) context(c context.Context) context.Context {
+	if l.MaxDepth <= 0 && l.MaxItems <= 0 {
+		return c
+	}
+	return streams.WithLimits(c, streams.Limits{
+		MaxDepth: l.MaxDepth,
+		MaxItems: l.MaxItems,
+	})
+}
+
+// isLimitsErr determines whether the error is due to a value exceeding the
+// streams.Limits.
+func isLimitsErr(err error) bool {
+	return err == streams.ErrMaxDepthExceeded || err == streams.ErrMaxItemsExceeded
+}
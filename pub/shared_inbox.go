@@ -0,0 +1,227 @@
+package pub
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-fed/activity/streams/vocab"
+	"net/url"
+)
+
+const (
+	// endpointsProperty is the 'endpoints' property of actors, which is
+	// not natively supported by the streams package.
+	endpointsProperty = "endpoints"
+	// sharedInboxProperty is the 'sharedInbox' member of 'endpoints'.
+	sharedInboxProperty = "sharedInbox"
+)
+
+// LocalFollowerLister may optionally be implemented by a Database to allow
+// activities received in the shared inbox to reach the local followers of the
+// sending actor.
+//
+// Without it, the shared inbox only delivers to local actors that are
+// explicitly addressed by the activity.
+type LocalFollowerLister interface {
+	// LocalFollowers returns the ids of the actors on this server that
+	// follow the given actor, which is usually a federated peer.
+	//
+	// The library makes this call without holding any lock.
+	LocalFollowers(c context.Context, actorIRI *url.URL) (followers []*url.URL, err error)
+}
+
+// unknownPropertieser is an ActivityStreams type that is able to hold
+// properties unknown to the streams package.
+type unknownPropertieser interface {
+	GetUnknownProperties() map[string]interface{}
+}
+
+// getSharedInbox extracts the 'sharedInbox' IRI from the 'endpoints' of an
+// actor type, returning false if the actor does not have one.
+func getSharedInbox(t vocab.Type) (u *url.URL, ok bool) {
+	up, ok := t.(unknownPropertieser)
+	if !ok {
+		return nil, false
+	}
+	endpoints, ok := up.GetUnknownProperties()[endpointsProperty].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	s, ok := endpoints[sharedInboxProperty].(string)
+	if !ok {
+		return nil, false
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, false
+	}
+	return u, true
+}
+
+// getSharedInboxes extracts the 'sharedInbox' IRIs from actor types, falling
+// back to the 'inbox' IRI for actors without a shared inbox.
+func getSharedInboxes(t []vocab.Type) (u []*url.URL, err error) {
+	for _, elem := range t {
+		if iri, ok := getSharedInbox(elem); ok {
+			u = append(u, iri)
+			continue
+		}
+		var iri *url.URL
+		iri, err = getInbox(elem)
+		if err != nil {
+			return
+		}
+		u = append(u, iri)
+	}
+	return
+}
+
+// getRecipients returns the ids in the 'to', 'bto', 'cc', 'bcc', and
+// 'audience' properties of the activity.
+func getRecipients(activity Activity) (r []*url.URL, err error) {
+	appendFn := func(iter IdProperty) error {
+		val, err := ToId(iter)
+		if err != nil {
+			return err
+		}
+		r = append(r, val)
+		return nil
+	}
+	if to := activity.GetActivityStreamsTo(); to != nil {
+		for iter := to.Begin(); iter != to.End(); iter = iter.Next() {
+			if err = appendFn(iter); err != nil {
+				return
+			}
+		}
+	}
+	if bto := activity.GetActivityStreamsBto(); bto != nil {
+		for iter := bto.Begin(); iter != bto.End(); iter = iter.Next() {
+			if err = appendFn(iter); err != nil {
+				return
+			}
+		}
+	}
+	if cc := activity.GetActivityStreamsCc(); cc != nil {
+		for iter := cc.Begin(); iter != cc.End(); iter = iter.Next() {
+			if err = appendFn(iter); err != nil {
+				return
+			}
+		}
+	}
+	if bcc := activity.GetActivityStreamsBcc(); bcc != nil {
+		for iter := bcc.Begin(); iter != bcc.End(); iter = iter.Next() {
+			if err = appendFn(iter); err != nil {
+				return
+			}
+		}
+	}
+	if audience := activity.GetActivityStreamsAudience(); audience != nil {
+		for iter := audience.Begin(); iter != audience.End(); iter = iter.Next() {
+			if err = appendFn(iter); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// getHiddenOnlyRecipients returns the ids in the 'bto' and 'bcc' properties of
+// the activity that are not also in its 'to', 'cc', or 'audience' properties.
+// They are no longer known to be recipients once 'bto' and 'bcc' are stripped
+// before delivery.
+func getHiddenOnlyRecipients(activity Activity) (r []*url.URL, err error) {
+	visible := make(map[string]bool)
+	visibleFn := func(iter IdProperty) error {
+		val, err := ToId(iter)
+		if err != nil {
+			return err
+		}
+		visible[val.String()] = true
+		return nil
+	}
+	var hidden []*url.URL
+	hiddenFn := func(iter IdProperty) error {
+		val, err := ToId(iter)
+		if err != nil {
+			return err
+		}
+		hidden = append(hidden, val)
+		return nil
+	}
+	if to := activity.GetActivityStreamsTo(); to != nil {
+		for iter := to.Begin(); iter != to.End(); iter = iter.Next() {
+			if err = visibleFn(iter); err != nil {
+				return
+			}
+		}
+	}
+	if bto := activity.GetActivityStreamsBto(); bto != nil {
+		for iter := bto.Begin(); iter != bto.End(); iter = iter.Next() {
+			if err = hiddenFn(iter); err != nil {
+				return
+			}
+		}
+	}
+	if cc := activity.GetActivityStreamsCc(); cc != nil {
+		for iter := cc.Begin(); iter != cc.End(); iter = iter.Next() {
+			if err = visibleFn(iter); err != nil {
+				return
+			}
+		}
+	}
+	if bcc := activity.GetActivityStreamsBcc(); bcc != nil {
+		for iter := bcc.Begin(); iter != bcc.End(); iter = iter.Next() {
+			if err = hiddenFn(iter); err != nil {
+				return
+			}
+		}
+	}
+	if audience := activity.GetActivityStreamsAudience(); audience != nil {
+		for iter := audience.Begin(); iter != audience.End(); iter = iter.Next() {
+			if err = visibleFn(iter); err != nil {
+				return
+			}
+		}
+	}
+	for _, h := range hidden {
+		if !visible[h.String()] {
+			r = append(r, h)
+		}
+	}
+	return
+}
+
+// getActorIds returns the ids in the 'actor' property of the activity.
+func getActorIds(activity Activity) (actors []*url.URL, err error) {
+	ap := activity.GetActivityStreamsActor()
+	if ap == nil {
+		return nil, fmt.Errorf("activity has no actor")
+	}
+	for iter := ap.Begin(); iter != ap.End(); iter = iter.Next() {
+		var id *url.URL
+		id, err = ToId(iter)
+		if err != nil {
+			return
+		}
+		actors = append(actors, id)
+	}
+	return
+}
+
+// isAddressedToFollowers determines whether the actor's 'followers' collection
+// is among the recipients.
+func isAddressedToFollowers(actor vocab.Type, recipients []*url.URL) bool {
+	f, ok := actor.(followerser)
+	if !ok || f.GetActivityStreamsFollowers() == nil {
+		return false
+	}
+	followers, err := ToId(f.GetActivityStreamsFollowers())
+	if err != nil {
+		return false
+	}
+	for _, r := range recipients {
+		if r.String() == followers.String() {
+			return true
+		}
+	}
+	return false
+}
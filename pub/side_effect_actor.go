@@ -13,6 +13,15 @@ import (
 // sideEffectActor must satisfy the DelegateActor interface.
 var _ DelegateActor = &sideEffectActor{}
 
+// sideEffectActor must satisfy the PagedDelegateActor interface.
+var _ PagedDelegateActor = &sideEffectActor{}
+
+// sideEffectActor must satisfy the SharedInboxDelegateActor interface.
+var _ SharedInboxDelegateActor = &sideEffectActor{}
+
+// sideEffectActor must satisfy the FollowApprovalDelegateActor interface.
+var _ FollowApprovalDelegateActor = &sideEffectActor{}
+
 // sideEffectActor is a DelegateActor that handles the ActivityPub
 // implementation side effects, but requires a more opinionated application to
 // be written.
@@ -26,6 +35,30 @@ type sideEffectActor struct {
 	c2s    SocialProtocol
 	db     Database
 	clock  Clock
+	// queue is optional. If set, deliveries are enqueued on it instead of
+	// being sent with the Transport's BatchDeliver.
+	queue DeliveryQueue
+	// keys is optional. If set, federated Updates and Deletes of actors
+	// invalidate and revoke their cached public keys.
+	keys PublicKeyStore
+	// actorCache is optional. If set, it is consulted before dereferencing
+	// actors and collections when resolving inboxes.
+	actorCache ActorCache
+	// blocks is optional. If set, it records the Blocks posted to outboxes
+	// and is enforced when receiving and delivering activities.
+	blocks BlockList
+	// pendingFollows is optional. If set, it holds the Follows received
+	// with OnFollowPendingApproval.
+	pendingFollows PendingFollowStore
+	// votes is optional. If set, it records the votes tallied on
+	// Questions owned by this server.
+	votes VoteStore
+	// replies is optional. If set, the 'replies' collections of local
+	// objects are maintained.
+	replies bool
+	// instance is optional. If set, its Transport is used for dereferencing
+	// in the background.
+	instance *InstanceActor
 }
 
 // PostInboxRequestBodyHook defers to the delegate.
@@ -63,9 +96,130 @@ func (a *sideEffectActor) GetOutbox(c context.Context, r *http.Request) (vocab.A
 	return a.common.GetOutbox(c, r)
 }
 
+// GetPagedOutbox delegates to the CommonBehavior if it is a
+// PagedCommonBehavior, and otherwise to its GetOutbox.
+func (a *sideEffectActor) GetPagedOutbox(c context.Context, r *http.Request) (vocab.Type, error) {
+	if paged, ok := a.common.(PagedCommonBehavior); ok {
+		return paged.GetPagedOutbox(c, r)
+	}
+	outbox, err := a.common.GetOutbox(c, r)
+	if err != nil {
+		return nil, err
+	}
+	return outbox, nil
+}
+
 // GetInbox delegates to the FederatingProtocol.
+//
+// Activities from peers blocked by the inbox's actor are removed from the
+// returned page. Its 'totalItems' is left as is, so the count still includes
+// the hidden activities.
 func (a *sideEffectActor) GetInbox(c context.Context, r *http.Request) (vocab.ActivityStreamsOrderedCollectionPage, error) {
-	return a.s2s.GetInbox(c, r)
+	inbox, err := a.s2s.GetInbox(c, r)
+	if err != nil {
+		return inbox, err
+	}
+	err = a.removeBlockedInboxItems(c, r, inbox)
+	if err != nil {
+		return nil, err
+	}
+	return inbox, nil
+}
+
+// GetPagedInbox delegates to the FederatingProtocol if it is a
+// PagedFederatingProtocol, and otherwise to its GetInbox.
+//
+// Activities from peers blocked by the inbox's actor are removed from a
+// returned page, as in GetInbox.
+func (a *sideEffectActor) GetPagedInbox(c context.Context, r *http.Request) (vocab.Type, error) {
+	paged, ok := a.s2s.(PagedFederatingProtocol)
+	if !ok {
+		inbox, err := a.GetInbox(c, r)
+		if err != nil {
+			return nil, err
+		}
+		return inbox, nil
+	}
+	inbox, err := paged.GetPagedInbox(c, r)
+	if err != nil {
+		return nil, err
+	}
+	if oi, ok := inbox.(orderedItemser); ok {
+		err = a.removeBlockedInboxItems(c, r, oi)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return inbox, nil
+}
+
+// removeBlockedInboxItems removes the activities from peers blocked by the
+// actor of the requested inbox from the ordered items of the inbox page.
+func (a *sideEffectActor) removeBlockedInboxItems(c context.Context, r *http.Request, oi orderedItemser) error {
+	if a.blocks == nil || oi.GetActivityStreamsOrderedItems() == nil {
+		return nil
+	}
+	inboxIRI := CollectionCursor{}.URL(requestId(r))
+	err := a.db.Lock(c, inboxIRI)
+	if err != nil {
+		return err
+	}
+	// WARNING: Unlock is not deferred
+	actorIRI, err := a.db.ActorForInbox(c, inboxIRI)
+	a.db.Unlock(c, inboxIRI)
+	// Unlock by this point -- Still need to handle err
+	if err != nil {
+		return err
+	}
+	items := oi.GetActivityStreamsOrderedItems()
+	for i := 0; i < items.Len(); /*Conditional*/ {
+		blocked, err := a.blockedInboxItem(c, actorIRI, items.At(i))
+		if err != nil {
+			return err
+		} else if blocked {
+			items.Remove(i)
+		} else {
+			i++
+		}
+	}
+	return nil
+}
+
+// blockedInboxItem determines whether an item in an inbox is an activity from
+// a peer blocked by the actor. Items only referenced by IRI are looked up in
+// the database, and are otherwise judged by their own IRI.
+func (a *sideEffectActor) blockedInboxItem(c context.Context, actorIRI *url.URL, iter vocab.ActivityStreamsOrderedItemsPropertyIterator) (bool, error) {
+	t := iter.GetType()
+	if t == nil {
+		id, err := ToId(iter)
+		if err != nil {
+			return false, err
+		}
+		t, err = func() (vocab.Type, error) {
+			if err := a.db.Lock(c, id); err != nil {
+				return nil, err
+			}
+			defer a.db.Unlock(c, id)
+			if exists, err := a.db.Exists(c, id); err != nil || !exists {
+				return nil, err
+			}
+			return a.db.Get(c, id)
+		}()
+		if err != nil {
+			return false, err
+		} else if t == nil {
+			return a.blocks.Blocked(c, actorIRI, []*url.URL{id})
+		}
+	}
+	activity, ok := t.(Activity)
+	if !ok || activity.GetActivityStreamsActor() == nil {
+		return false, nil
+	}
+	actors, err := getActorIds(activity)
+	if err != nil {
+		return false, err
+	}
+	return a.blocks.Blocked(c, actorIRI, actors)
 }
 
 // AuthorizePostInbox defers to the federating protocol whether the peer request
@@ -83,14 +237,28 @@ func (a *sideEffectActor) AuthorizePostInbox(c context.Context, w http.ResponseW
 		if iter.IsIRI() {
 			iris = append(iris, iter.GetIRI())
 		} else if t := iter.GetType(); t != nil {
-			iris = append(iris, activity.GetJSONLDId().Get())
+			var id *url.URL
+			id, err = GetId(t)
+			if err != nil {
+				return
+			}
+			iris = append(iris, id)
 		} else {
 			err = fmt.Errorf("actor at index %d is missing an id", i)
 			return
 		}
 	}
-	// Determine if the actor(s) sending this request are blocked.
+	// Determine if the actor(s) sending this request are blocked, first
+	// by the whole server and then by the application.
 	var blocked bool
+	if a.blocks != nil {
+		if blocked, err = a.blocks.Blocked(c, nil, iris); err != nil {
+			return
+		} else if blocked {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+	}
 	if blocked, err = a.s2s.Blocked(c, iris); err != nil {
 		return
 	} else if blocked {
@@ -104,36 +272,68 @@ func (a *sideEffectActor) AuthorizePostInbox(c context.Context, w http.ResponseW
 // PostInbox handles the side effects of determining whether to block the peer's
 // request, adding the activity to the actor's inbox, and triggering side
 // effects based on the activity's type.
+//
+// Activities from peers blocked by the inbox's actor are dropped.
 func (a *sideEffectActor) PostInbox(c context.Context, inboxIRI *url.URL, activity Activity) error {
-	isNew, err := a.addToInboxIfNew(c, inboxIRI, activity)
-	if err != nil {
-		return err
-	}
-	if isNew {
-		wrapped, other, err := a.s2s.FederatingCallbacks(c)
-		if err != nil {
-			return err
-		}
-		// Populate side channels.
-		wrapped.db = a.db
-		wrapped.inboxIRI = inboxIRI
-		wrapped.newTransport = a.common.NewTransport
-		wrapped.deliver = a.Deliver
-		wrapped.addNewIds = a.AddNewIDs
-		res, err := streams.NewTypeResolver(wrapped.callbacks(other)...)
-		if err != nil {
-			return err
+	_, err := a.PostSharedInbox(c, []*url.URL{inboxIRI}, activity)
+	return err
+}
+
+// PostSharedInbox handles the side effects of PostInbox for each of the
+// inboxes an activity received in the shared inbox is for.
+//
+// The activity is added to each inbox whose actor does not block the peer,
+// which are returned. Its side effects are then triggered once, if it is new to
+// any of them, with those specific to the actor owning an inbox applied for
+// each inbox it is new to.
+func (a *sideEffectActor) PostSharedInbox(c context.Context, inboxIRIs []*url.URL, activity Activity) (posted []*url.URL, err error) {
+	var newInboxes []*url.URL
+	for _, inboxIRI := range inboxIRIs {
+		var blocked bool
+		if blocked, err = a.blockedByInboxActor(c, inboxIRI, activity); err != nil {
+			return
+		} else if blocked {
+			continue
 		}
-		if err = res.Resolve(c, activity); err != nil && !streams.IsUnmatchedErr(err) {
-			return err
-		} else if streams.IsUnmatchedErr(err) {
-			err = a.s2s.DefaultCallback(c, activity)
-			if err != nil {
-				return err
-			}
+		posted = append(posted, inboxIRI)
+		var isNew bool
+		if isNew, err = a.addToInboxIfNew(c, inboxIRI, activity); err != nil {
+			return
+		} else if isNew {
+			newInboxes = append(newInboxes, inboxIRI)
 		}
 	}
-	return nil
+	if len(newInboxes) == 0 {
+		return
+	}
+	wrapped, other, err := a.s2s.FederatingCallbacks(c)
+	if err != nil {
+		return
+	}
+	// Populate side channels.
+	wrapped.db = a.db
+	wrapped.inboxIRI = newInboxes[0]
+	wrapped.inboxIRIs = newInboxes
+	wrapped.newTransport = a.common.NewTransport
+	wrapped.newDereferenceTransport = a.newDereferenceTransport
+	wrapped.deliver = a.Deliver
+	wrapped.addNewIds = a.AddNewIDs
+	wrapped.keys = a.keys
+	wrapped.actorCache = a.actorCache
+	wrapped.pendingFollows = a.pendingFollows
+	wrapped.votes = a.votes
+	wrapped.replies = a.replies
+	wrapped.clock = a.clock
+	res, err := streams.NewTypeResolver(wrapped.callbacks(other)...)
+	if err != nil {
+		return
+	}
+	if err = res.Resolve(c, activity); err != nil && !streams.IsUnmatchedErr(err) {
+		return
+	} else if streams.IsUnmatchedErr(err) {
+		err = a.s2s.DefaultCallback(c, activity)
+	}
+	return
 }
 
 // InboxForwarding implements the 3-part inbox forwarding algorithm specified in
@@ -313,9 +513,210 @@ func (a *sideEffectActor) InboxForwarding(c context.Context, inboxIRI *url.URL,
 			}
 		}
 	}
+	// Do not forward to peers blocked by the inbox's actor.
+	if a.blocks != nil {
+		err = a.db.Lock(c, inboxIRI)
+		if err != nil {
+			return err
+		}
+		// WARNING: Unlock is not deferred
+		actorIRI, err := a.db.ActorForInbox(c, inboxIRI)
+		a.db.Unlock(c, inboxIRI)
+		// Unlock by this point -- Still need to handle err
+		if err != nil {
+			return err
+		}
+		recipients, err = filterBlocked(c, a.blocks, actorIRI, recipients)
+		if err != nil {
+			return err
+		}
+	}
 	return a.deliverToRecipients(c, inboxIRI, activity, recipients)
 }
 
+// blockedByInboxActor determines whether the actors of the activity are blocked
+// by the actor owning the inbox, or by the whole server.
+func (a *sideEffectActor) blockedByInboxActor(c context.Context, inboxIRI *url.URL, activity Activity) (bool, error) {
+	if a.blocks == nil {
+		return false, nil
+	}
+	actors, err := getActorIds(activity)
+	if err != nil {
+		return false, err
+	}
+	err = a.db.Lock(c, inboxIRI)
+	if err != nil {
+		return false, err
+	}
+	// WARNING: Unlock is not deferred
+	actorIRI, err := a.db.ActorForInbox(c, inboxIRI)
+	a.db.Unlock(c, inboxIRI)
+	// Unlock by this point -- Still need to handle err
+	if err != nil {
+		return false, err
+	}
+	return a.blocks.Blocked(c, actorIRI, actors)
+}
+
+// SharedInboxRecipients determines the inboxes of the local actors addressed by
+// an activity received in the shared inbox.
+//
+// When the activity is addressed to the Public collection, or to the followers
+// of its actor, the local followers of its actor are included if the Database
+// is a LocalFollowerLister. The followers collection of the actor is only
+// known if the actor is in the Database.
+func (a *sideEffectActor) SharedInboxRecipients(c context.Context, activity Activity) (inboxes []*url.URL, err error) {
+	r, err := getRecipients(activity)
+	if err != nil {
+		return
+	}
+	nonPublic := filterURLs(r, IsPublic)
+	toFollowers := len(nonPublic) < len(r)
+	local := nonPublic
+	if lister, ok := a.db.(LocalFollowerLister); ok {
+		var actors []*url.URL
+		actors, err = getActorIds(activity)
+		if err != nil {
+			return
+		}
+		for _, actorIRI := range actors {
+			if !toFollowers {
+				toFollowers, err = a.isAddressedToFollowersOf(c, actorIRI, nonPublic)
+				if err != nil {
+					return
+				}
+			}
+			if !toFollowers {
+				continue
+			}
+			var followers []*url.URL
+			followers, err = lister.LocalFollowers(c, actorIRI)
+			if err != nil {
+				return
+			}
+			local = append(local, followers...)
+		}
+	}
+	// Create anonymous loop function to be able to properly scope the defer
+	// for the database lock at each iteration.
+	loopFn := func(iri *url.URL) error {
+		err := a.db.Lock(c, iri)
+		if err != nil {
+			return err
+		}
+		defer a.db.Unlock(c, iri)
+		owns, err := a.db.Owns(c, iri)
+		if err != nil {
+			return err
+		} else if !owns {
+			return nil
+		}
+		t, err := a.db.Get(c, iri)
+		if err != nil {
+			return err
+		}
+		// Ignore local values that are not actors, such as
+		// collections.
+		if inbox, err := getInbox(t); err == nil {
+			inboxes = append(inboxes, inbox)
+		}
+		return nil
+	}
+	for _, iri := range local {
+		if err = loopFn(iri); err != nil {
+			return
+		}
+	}
+	inboxes = dedupeIRIs(inboxes, nil)
+	return
+}
+
+// RespondToPendingFollow builds the response to the pending Follow, without
+// changing anything.
+func (a *sideEffectActor) RespondToPendingFollow(c context.Context, outboxIRI, followId *url.URL, accept bool) (response Activity, err error) {
+	actorIRI, follow, err := a.pendingFollow(c, outboxIRI, followId)
+	if err != nil {
+		return
+	}
+	response, _, err = newFollowResponse(actorIRI, follow, accept)
+	return
+}
+
+// ResolvePendingFollow adds the actors of the pending Follow as followers if
+// accepted, and only then removes the Follow from those pending.
+func (a *sideEffectActor) ResolvePendingFollow(c context.Context, outboxIRI, followId *url.URL, accept bool) error {
+	actorIRI, follow, err := a.pendingFollow(c, outboxIRI, followId)
+	if err != nil {
+		return err
+	}
+	if accept {
+		followers, err := getActorIds(follow)
+		if err != nil {
+			return err
+		}
+		if err = addFollowers(c, a.db, actorIRI, followers); err != nil {
+			return err
+		}
+	}
+	_, _, err = a.pendingFollows.Remove(c, actorIRI, followId)
+	return err
+}
+
+// pendingFollow returns the actor of the outbox and its pending Follow with the
+// given id.
+func (a *sideEffectActor) pendingFollow(c context.Context, outboxIRI, followId *url.URL) (actorIRI *url.URL, follow vocab.ActivityStreamsFollow, err error) {
+	if a.pendingFollows == nil {
+		err = fmt.Errorf("responding to a pending Follow requires a PendingFollowStore")
+		return
+	}
+	err = a.db.Lock(c, outboxIRI)
+	if err != nil {
+		return
+	}
+	// WARNING: No deferring the Unlock
+	actorIRI, err = a.db.ActorForOutbox(c, outboxIRI)
+	a.db.Unlock(c, outboxIRI)
+	// Unlock by this point -- Still need to handle err
+	if err != nil {
+		return
+	}
+	follows, err := a.pendingFollows.List(c, actorIRI)
+	if err != nil {
+		return
+	}
+	for _, f := range follows {
+		var id *url.URL
+		id, err = GetId(f)
+		if err != nil {
+			return
+		} else if id.String() == followId.String() {
+			follow = f
+			return
+		}
+	}
+	err = ErrFollowNotPending
+	return
+}
+
+// isAddressedToFollowersOf determines whether the recipients include the
+// followers collection of the actor, if the actor is in the database.
+func (a *sideEffectActor) isAddressedToFollowersOf(c context.Context, actorIRI *url.URL, recipients []*url.URL) (bool, error) {
+	err := a.db.Lock(c, actorIRI)
+	if err != nil {
+		return false, err
+	}
+	defer a.db.Unlock(c, actorIRI)
+	exists, err := a.db.Exists(c, actorIRI)
+	if err != nil || !exists {
+		return false, err
+	}
+	actor, err := a.db.Get(c, actorIRI)
+	if err != nil {
+		return false, err
+	}
+	return isAddressedToFollowers(actor, recipients), nil
+}
+
 // PostOutbox handles the side effects of adding the activity to the actor's
 // outbox, and triggering side effects based on the activity's type.
 //
@@ -337,6 +738,9 @@ func (a *sideEffectActor) PostOutbox(c context.Context, activity Activity, outbo
 		wrapped.rawActivity = rawJSON
 		wrapped.clock = a.clock
 		wrapped.newTransport = a.common.NewTransport
+		wrapped.newDereferenceTransport = a.newDereferenceTransport
+		wrapped.blocks = a.blocks
+		wrapped.replies = a.replies
 		undeliverable := false
 		wrapped.undeliverable = &undeliverable
 		var res *streams.TypeResolver
@@ -425,6 +829,10 @@ func (a *sideEffectActor) WrapInCreate(c context.Context, obj vocab.Type, outbox
 
 // deliverToRecipients will take a prepared Activity and send it to specific
 // recipients on behalf of an actor.
+//
+// Without a DeliveryQueue, recipients deferred by the Transport are not
+// delivered to again, and only the failures of the other recipients are
+// returned.
 func (a *sideEffectActor) deliverToRecipients(c context.Context, boxIRI *url.URL, activity Activity, recipients []*url.URL) error {
 	m, err := streams.Serialize(activity)
 	if err != nil {
@@ -434,11 +842,22 @@ func (a *sideEffectActor) deliverToRecipients(c context.Context, boxIRI *url.URL
 	if err != nil {
 		return err
 	}
+	if a.queue != nil {
+		id, err := GetId(activity)
+		if err != nil {
+			return err
+		}
+		return a.queue.Enqueue(c, boxIRI, id, b, recipients)
+	}
 	tp, err := a.common.NewTransport(c, boxIRI, goFedUserAgent())
 	if err != nil {
 		return err
 	}
-	return tp.BatchDeliver(c, b, recipients)
+	err = tp.BatchDeliver(c, b, recipients)
+	if de, ok := err.(*DeliveryDeferredError); ok {
+		return de.Err
+	}
+	return err
 }
 
 // addToOutbox adds the activity to the outbox and creates the activity in the
@@ -466,6 +885,11 @@ func (a *sideEffectActor) addToOutbox(c context.Context, outboxIRI *url.URL, act
 		return err
 	}
 	defer a.db.Unlock(c, outboxIRI)
+	// Add the activity as the newest item of the outbox, without loading
+	// it if the database is able to.
+	if paged, ok := a.db.(PagedDatabase); ok {
+		return paged.AppendOutbox(c, outboxIRI, id.Get())
+	}
 	outbox, err := a.db.GetOutbox(c, outboxIRI)
 	if err != nil {
 		return err
@@ -504,8 +928,13 @@ func (a *sideEffectActor) addToInboxIfNew(c context.Context, inboxIRI *url.URL,
 	} else if contains {
 		return
 	}
-	// It is a new id, acquire the inbox.
+	// It is a new id, add it as the newest item of the inbox, without
+	// loading it if the database is able to.
 	isNew = true
+	if paged, ok := a.db.(PagedDatabase); ok {
+		err = paged.AppendInbox(c, inboxIRI, id.Get())
+		return
+	}
 	inbox, err := a.db.GetInbox(c, inboxIRI)
 	if err != nil {
 		return
@@ -617,55 +1046,9 @@ func (a *sideEffectActor) hasInboxForwardingValues(c context.Context, inboxIRI *
 // Only call if both the social and federated protocol are supported.
 func (a *sideEffectActor) prepare(c context.Context, outboxIRI *url.URL, activity Activity) (r []*url.URL, err error) {
 	// Get inboxes of recipients
-	if to := activity.GetActivityStreamsTo(); to != nil {
-		for iter := to.Begin(); iter != to.End(); iter = iter.Next() {
-			var val *url.URL
-			val, err = ToId(iter)
-			if err != nil {
-				return
-			}
-			r = append(r, val)
-		}
-	}
-	if bto := activity.GetActivityStreamsBto(); bto != nil {
-		for iter := bto.Begin(); iter != bto.End(); iter = iter.Next() {
-			var val *url.URL
-			val, err = ToId(iter)
-			if err != nil {
-				return
-			}
-			r = append(r, val)
-		}
-	}
-	if cc := activity.GetActivityStreamsCc(); cc != nil {
-		for iter := cc.Begin(); iter != cc.End(); iter = iter.Next() {
-			var val *url.URL
-			val, err = ToId(iter)
-			if err != nil {
-				return
-			}
-			r = append(r, val)
-		}
-	}
-	if bcc := activity.GetActivityStreamsBcc(); bcc != nil {
-		for iter := bcc.Begin(); iter != bcc.End(); iter = iter.Next() {
-			var val *url.URL
-			val, err = ToId(iter)
-			if err != nil {
-				return
-			}
-			r = append(r, val)
-		}
-	}
-	if audience := activity.GetActivityStreamsAudience(); audience != nil {
-		for iter := audience.Begin(); iter != audience.End(); iter = iter.Next() {
-			var val *url.URL
-			val, err = ToId(iter)
-			if err != nil {
-				return
-			}
-			r = append(r, val)
-		}
+	r, err = getRecipients(activity)
+	if err != nil {
+		return
 	}
 	// 1. When an object is being delivered to the originating actor's
 	//    followers, a server MAY reduce the number of receiving actors
@@ -675,19 +1058,44 @@ func (a *sideEffectActor) prepare(c context.Context, outboxIRI *url.URL, activit
 	// 2. If an object is addressed to the Public special collection, a
 	//    server MAY deliver that object to all known sharedInbox endpoints
 	//    on the network.
-	r = filterURLs(r, IsPublic)
-	t, err := a.common.NewTransport(c, outboxIRI, goFedUserAgent())
+	//
+	// Both are done by delivering to the sharedInbox of recipients that
+	// have one, instead of their inbox.
+	//
+	// Recipients only in 'bto' and 'bcc' are stripped before delivery, so
+	// the sharedInbox of their server cannot tell they are recipients.
+	// They are always delivered to their own inbox.
+	nonPublic := filterURLs(r, IsPublic)
+	isPublic := len(nonPublic) < len(r)
+	r = nonPublic
+	hidden, err := getHiddenOnlyRecipients(activity)
 	if err != nil {
-		return nil, err
+		return
+	}
+	hidden = filterURLs(hidden, IsPublic)
+	isHidden := make(map[string]bool, len(hidden))
+	for _, h := range hidden {
+		isHidden[h.String()] = true
 	}
-	receiverActors, err := a.resolveInboxes(c, t, r, 0, a.s2s.MaxDeliveryRecursionDepth(c))
+	visible := filterURLs(append([]*url.URL(nil), r...), func(s string) bool {
+		return isHidden[s]
+	})
+	t, err := a.newDereferenceTransport(c, outboxIRI, goFedUserAgent())
 	if err != nil {
 		return nil, err
 	}
-	targets, err := getInboxes(receiverActors)
+	maxDepth := a.s2s.MaxDeliveryRecursionDepth(c)
+	receiverActors, err := a.resolveInboxes(c, t, visible, 0, maxDepth)
 	if err != nil {
 		return nil, err
 	}
+	var hiddenActors []vocab.Type
+	if len(hidden) > 0 {
+		hiddenActors, err = a.resolveInboxes(c, t, hidden, 0, maxDepth)
+		if err != nil {
+			return nil, err
+		}
+	}
 	// Get inboxes of sender.
 	err = a.db.Lock(c, outboxIRI)
 	if err != nil {
@@ -712,7 +1120,32 @@ func (a *sideEffectActor) prepare(c context.Context, outboxIRI *url.URL, activit
 	if err != nil {
 		return nil, err
 	}
+	// Do not deliver to peers blocked by the sender.
+	if a.blocks != nil {
+		receiverActors, err = a.filterBlockedActors(c, actorIRI, receiverActors)
+		if err != nil {
+			return nil, err
+		}
+		hiddenActors, err = a.filterBlockedActors(c, actorIRI, hiddenActors)
+		if err != nil {
+			return nil, err
+		}
+	}
 	// Post-processing
+	var targets []*url.URL
+	if isPublic || isAddressedToFollowers(thisActor, r) {
+		targets, err = getSharedInboxes(receiverActors)
+	} else {
+		targets, err = getInboxes(receiverActors)
+	}
+	if err != nil {
+		return nil, err
+	}
+	hiddenTargets, err := getInboxes(hiddenActors)
+	if err != nil {
+		return nil, err
+	}
+	targets = append(targets, hiddenTargets...)
 	var ignore *url.URL
 	ignore, err = getInbox(thisActor)
 	if err != nil {
@@ -723,6 +1156,35 @@ func (a *sideEffectActor) prepare(c context.Context, outboxIRI *url.URL, activit
 	return r, nil
 }
 
+// newDereferenceTransport creates the Transport for dereferencing values in the
+// background on behalf of the box: the InstanceActor's if there is one, and
+// otherwise the one created by CommonBehavior's NewTransport.
+func (a *sideEffectActor) newDereferenceTransport(c context.Context, actorBoxIRI *url.URL, gofedAgent string) (Transport, error) {
+	if a.instance != nil {
+		return a.instance.NewTransport(c, actorBoxIRI, gofedAgent)
+	}
+	return a.common.NewTransport(c, actorBoxIRI, gofedAgent)
+}
+
+// filterBlockedActors removes the actors blocked by the given actor, or by the
+// whole server, from the resolved actors.
+func (a *sideEffectActor) filterBlockedActors(c context.Context, actorIRI *url.URL, actors []vocab.Type) ([]vocab.Type, error) {
+	filtered := make([]vocab.Type, 0, len(actors))
+	for _, actor := range actors {
+		id, err := GetId(actor)
+		if err != nil {
+			return nil, err
+		}
+		blocked, err := a.blocks.Blocked(c, actorIRI, []*url.URL{id})
+		if err != nil {
+			return nil, err
+		} else if !blocked {
+			filtered = append(filtered, actor)
+		}
+	}
+	return filtered, nil
+}
+
 // resolveInboxes takes a list of Actor id URIs and returns them as concrete
 // instances of actorObject. It attempts to apply recursively when it encounters
 // a target that is a Collection or OrderedCollection.
@@ -765,19 +1227,36 @@ func (a *sideEffectActor) resolveInboxes(c context.Context, t Transport, r []*ur
 //
 // The returned actor could be nil, if it wasn't an actor (ex: a Collection or
 // OrderedCollection).
+//
+// If an ActorCache is in use, it is consulted before dereferencing and is
+// populated afterwards.
 func (a *sideEffectActor) dereferenceForResolvingInboxes(c context.Context, t Transport, actorIRI *url.URL) (actor vocab.Type, moreActorIRIs []*url.URL, err error) {
-	var resp []byte
-	resp, err = t.Dereference(c, actorIRI)
-	if err != nil {
-		return
-	}
-	var m map[string]interface{}
-	if err = json.Unmarshal(resp, &m); err != nil {
-		return
+	cached := false
+	if a.actorCache != nil {
+		actor, cached, err = a.actorCache.Get(c, actorIRI)
+		if err != nil {
+			return
+		}
 	}
-	actor, err = streams.ToType(c, m)
-	if err != nil {
-		return
+	if !cached {
+		var resp []byte
+		resp, err = t.Dereference(c, actorIRI)
+		if err != nil {
+			return
+		}
+		var m map[string]interface{}
+		if err = json.Unmarshal(resp, &m); err != nil {
+			return
+		}
+		actor, err = streams.ToType(c, m)
+		if err != nil {
+			return
+		}
+		if a.actorCache != nil {
+			if err = a.actorCache.Set(c, actorIRI, actor); err != nil {
+				return
+			}
+		}
 	}
 	// Attempt to see if the 'actor' is really some sort of type that has
 	// an 'items' or 'orderedItems' property.
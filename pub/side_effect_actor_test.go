@@ -113,6 +113,67 @@ func TestPassThroughMethods(t *testing.T) {
 		assertEqual(t, p, testOrderedCollectionUniqueElems)
 		assertEqual(t, err, testErr)
 	})
+	t.Run("GetPagedInboxFallsBackToGetInbox", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		_, fp, _, _, _, a := setupFn(ctl)
+		req := toAPRequest(toGetInboxRequest())
+		fp.EXPECT().GetInbox(ctx, req).Return(testOrderedCollectionUniqueElems, nil)
+		// Run
+		p, err := a.(*sideEffectActor).GetPagedInbox(ctx, req)
+		// Verify
+		assertEqual(t, p, testOrderedCollectionUniqueElems)
+		assertEqual(t, err, nil)
+	})
+	t.Run("GetPagedInbox", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		_, fp, _, _, _, a := setupFn(ctl)
+		paged := NewMockPagedFederatingProtocol(ctl)
+		a.(*sideEffectActor).s2s = pagedFederatingProtocol{fp, paged}
+		req := toAPRequest(toGetInboxRequest())
+		inbox := NewBoxCollection(mustParse(testMyInboxIRI), 3)
+		paged.EXPECT().GetPagedInbox(ctx, req).Return(inbox, nil)
+		// Run
+		p, err := a.(*sideEffectActor).GetPagedInbox(ctx, req)
+		// Verify
+		assertEqual(t, p, inbox)
+		assertEqual(t, err, nil)
+	})
+	t.Run("GetInboxRemovesBlockedActivities", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		_, fp, _, db, _, a := setupFn(ctl)
+		blocks := NewMemoryBlockList()
+		blocks.Block(ctx, mustParse(testPersonIRI), mustParse(testFederatedActorIRI))
+		a.(*sideEffectActor).blocks = blocks
+		page := streams.NewActivityStreamsOrderedCollectionPage()
+		items := streams.NewActivityStreamsOrderedItemsProperty()
+		items.AppendActivityStreamsListen(testListen)
+		items.AppendIRI(mustParse(testNoteId1))
+		page.SetActivityStreamsOrderedItems(items)
+		req := toAPRequest(toGetInboxRequest())
+		inboxIRI := mustParse(testMyInboxIRI)
+		fp.EXPECT().GetInbox(ctx, req).Return(page, nil)
+		gomock.InOrder(
+			db.EXPECT().Lock(ctx, inboxIRI),
+			db.EXPECT().ActorForInbox(ctx, inboxIRI).Return(mustParse(testPersonIRI), nil),
+			db.EXPECT().Unlock(ctx, inboxIRI),
+			db.EXPECT().Lock(ctx, mustParse(testNoteId1)),
+			db.EXPECT().Exists(ctx, mustParse(testNoteId1)).Return(false, nil),
+			db.EXPECT().Unlock(ctx, mustParse(testNoteId1)),
+		)
+		// Run
+		p, err := a.GetInbox(ctx, req)
+		// Verify
+		assertEqual(t, err, nil)
+		got := p.(orderedItemser).GetActivityStreamsOrderedItems()
+		assertEqual(t, got.Len(), 1)
+		assertEqual(t, got.At(0).GetIRI().String(), testNoteId1)
+	})
 }
 
 // TestAuthorizePostInbox tests the Authorization for a federated message, which
@@ -185,6 +246,41 @@ func TestAuthorizePostInbox(t *testing.T) {
 		assertEqual(t, b, false)
 		assertEqual(t, err, nil)
 	})
+	t.Run("ActorBlockedByServer", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		_, _, _, _, _, a := setupFn(ctl)
+		blocks := NewMemoryBlockList()
+		blocks.Block(ctx, nil, mustParse(testFederatedActorIRI2))
+		a.(*sideEffectActor).blocks = blocks
+		// Run
+		b, err := a.AuthorizePostInbox(ctx, resp, testCreate2)
+		// Verify
+		assertEqual(t, b, false)
+		assertEqual(t, err, nil)
+	})
+	t.Run("EmbeddedActorBlockedByServer", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		_, _, _, _, _, a := setupFn(ctl)
+		blocks := NewMemoryBlockList()
+		blocks.Block(ctx, nil, mustParse(testFederatedActorIRI))
+		a.(*sideEffectActor).blocks = blocks
+		person := streams.NewActivityStreamsPerson()
+		id := streams.NewJSONLDIdProperty()
+		id.Set(mustParse(testFederatedActorIRI))
+		person.SetJSONLDId(id)
+		actor := streams.NewActivityStreamsActorProperty()
+		actor.AppendActivityStreamsPerson(person)
+		testCreate.SetActivityStreamsActor(actor)
+		// Run
+		b, err := a.AuthorizePostInbox(ctx, resp, testCreate)
+		// Verify
+		assertEqual(t, b, false)
+		assertEqual(t, err, nil)
+	})
 }
 
 // TestPostInbox ensures that the main application side effects of receiving a
@@ -228,6 +324,46 @@ func TestPostInbox(t *testing.T) {
 		// Verify
 		assertEqual(t, err, nil)
 	})
+	t.Run("AppendsToPagedDatabaseInbox", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		_, fp, _, db, _, a := setupFn(ctl)
+		pdb := NewMockPagedDatabase(ctl)
+		a.(*sideEffectActor).db = pagedDatabase{db, pdb}
+		inboxIRI := mustParse(testMyInboxIRI)
+		gomock.InOrder(
+			db.EXPECT().Lock(ctx, inboxIRI),
+			db.EXPECT().InboxContains(ctx, inboxIRI, mustParse(testFederatedActivityIRI)).Return(false, nil),
+			pdb.EXPECT().AppendInbox(ctx, inboxIRI, mustParse(testFederatedActivityIRI)).Return(nil),
+			db.EXPECT().Unlock(ctx, inboxIRI),
+		)
+		fp.EXPECT().FederatingCallbacks(ctx).Return(FederatingWrappedCallbacks{}, nil, nil)
+		fp.EXPECT().DefaultCallback(ctx, testListen).Return(nil)
+		// Run
+		err := a.PostInbox(ctx, inboxIRI, testListen)
+		// Verify
+		assertEqual(t, err, nil)
+	})
+	t.Run("DropsActivityFromBlockedActor", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		_, _, _, db, _, a := setupFn(ctl)
+		inboxIRI := mustParse(testMyInboxIRI)
+		blocks := NewMemoryBlockList()
+		blocks.Block(ctx, mustParse(testPersonIRI), mustParse(testFederatedActorIRI))
+		a.(*sideEffectActor).blocks = blocks
+		gomock.InOrder(
+			db.EXPECT().Lock(ctx, inboxIRI),
+			db.EXPECT().ActorForInbox(ctx, inboxIRI).Return(mustParse(testPersonIRI), nil),
+			db.EXPECT().Unlock(ctx, inboxIRI),
+		)
+		// Run
+		err := a.PostInbox(ctx, inboxIRI, testListen)
+		// Verify
+		assertEqual(t, err, nil)
+	})
 	t.Run("DoesNotAddToInboxNorDoSideEffectsIfDuplicate", func(t *testing.T) {
 		// Setup
 		ctl := gomock.NewController(t)
@@ -865,6 +1001,30 @@ func TestPostOutbox(t *testing.T) {
 		assertEqual(t, err, nil)
 		assertEqual(t, deliverable, true)
 	})
+	t.Run("AppendsToPagedDatabaseOutbox", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		_, _, sp, db, _, a := setupFn(ctl)
+		pdb := NewMockPagedDatabase(ctl)
+		a.(*sideEffectActor).db = pagedDatabase{db, pdb}
+		outboxIRI := mustParse(testMyOutboxIRI)
+		gomock.InOrder(
+			db.EXPECT().Lock(ctx, mustParse(testNewActivityIRI)),
+			db.EXPECT().Create(ctx, testMyListen),
+			db.EXPECT().Unlock(ctx, mustParse(testNewActivityIRI)),
+			db.EXPECT().Lock(ctx, outboxIRI),
+			pdb.EXPECT().AppendOutbox(ctx, outboxIRI, mustParse(testNewActivityIRI)).Return(nil),
+			db.EXPECT().Unlock(ctx, outboxIRI),
+		)
+		sp.EXPECT().SocialCallbacks(ctx).Return(SocialWrappedCallbacks{}, nil, nil)
+		sp.EXPECT().DefaultCallback(ctx, testMyListen).Return(nil)
+		// Run
+		deliverable, err := a.PostOutbox(ctx, testMyListen, outboxIRI, mustSerialize(testMyListen))
+		// Verify
+		assertEqual(t, err, nil)
+		assertEqual(t, deliverable, true)
+	})
 	t.Run("AddsToOutbox", func(t *testing.T) {
 		// Setup
 		ctl := gomock.NewController(t)
@@ -979,6 +1139,42 @@ func TestPostOutbox(t *testing.T) {
 		assertEqual(t, deliverable, true)
 		assertEqual(t, pass, true)
 	})
+	t.Run("RecordsBlockInBlockList", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		_, _, sp, db, _, a := setupFn(ctl)
+		blocks := NewMemoryBlockList()
+		a.(*sideEffectActor).blocks = blocks
+		outboxIRI := mustParse(testMyOutboxIRI)
+		block := streams.NewActivityStreamsBlock()
+		id := streams.NewJSONLDIdProperty()
+		id.Set(mustParse(testNewActivityIRI))
+		block.SetJSONLDId(id)
+		op := streams.NewActivityStreamsObjectProperty()
+		op.AppendIRI(mustParse(testFederatedActorIRI))
+		block.SetActivityStreamsObject(op)
+		gomock.InOrder(
+			db.EXPECT().Lock(ctx, outboxIRI),
+			db.EXPECT().ActorForOutbox(ctx, outboxIRI).Return(mustParse(testPersonIRI), nil),
+			db.EXPECT().Unlock(ctx, outboxIRI),
+			db.EXPECT().Lock(ctx, mustParse(testNewActivityIRI)),
+			db.EXPECT().Create(ctx, block),
+			db.EXPECT().Unlock(ctx, mustParse(testNewActivityIRI)),
+			db.EXPECT().Lock(ctx, outboxIRI),
+			db.EXPECT().GetOutbox(ctx, outboxIRI).Return(testEmptyOrderedCollection, nil),
+			db.EXPECT().SetOutbox(ctx, testOrderedCollectionWithNewId).Return(nil),
+			db.EXPECT().Unlock(ctx, outboxIRI),
+		)
+		sp.EXPECT().SocialCallbacks(ctx).Return(SocialWrappedCallbacks{}, nil, nil)
+		// Run
+		deliverable, err := a.PostOutbox(ctx, block, outboxIRI, mustSerialize(block))
+		// Verify
+		assertEqual(t, err, nil)
+		assertEqual(t, deliverable, false)
+		blocked, _ := blocks.Blocked(ctx, mustParse(testPersonIRI), []*url.URL{mustParse(testFederatedActorIRI)})
+		assertEqual(t, blocked, true)
+	})
 }
 
 // TestAddNewIDs ensures that new 'id' properties are set on an activity and all
@@ -1650,90 +1846,376 @@ func TestDeliver(t *testing.T) {
 		err := a.Deliver(ctx, mustParse(testMyOutboxIRI), act)
 		assertEqual(t, err, expectErr)
 	})
-}
-
-// TestWrapInCreate ensures an object received by the Social Protocol is
-// properly wrapped in a Create Activity.
-func TestWrapInCreate(t *testing.T) {
-	baseNoteFn := func() (vocab.ActivityStreamsNote, vocab.ActivityStreamsCreate) {
-		n := streams.NewActivityStreamsNote()
-		id := streams.NewJSONLDIdProperty()
-		id.Set(mustParse(testNoteId1))
-		n.SetJSONLDId(id)
-		cr := streams.NewActivityStreamsCreate()
-		op := streams.NewActivityStreamsObjectProperty()
-		op.AppendActivityStreamsNote(n)
-		cr.SetActivityStreamsObject(op)
-		actorProp := streams.NewActivityStreamsActorProperty()
-		actorProp.AppendIRI(mustParse(testPersonIRI))
-		cr.SetActivityStreamsActor(actorProp)
-		return n, cr
-	}
-	ctx := context.Background()
-	setupFn := func(ctl *gomock.Controller) (c *MockCommonBehavior, fp *MockFederatingProtocol, sp *MockSocialProtocol, db *MockDatabase, cl *MockClock, a DelegateActor) {
-		setupData()
-		c = NewMockCommonBehavior(ctl)
-		fp = NewMockFederatingProtocol(ctl)
-		sp = NewMockSocialProtocol(ctl)
-		db = NewMockDatabase(ctl)
-		cl = NewMockClock(ctl)
-		a = &sideEffectActor{
-			common: c,
-			s2s:    fp,
-			c2s:    sp,
-			db:     db,
-			clock:  cl,
-		}
-		return
-	}
-	t.Run("CreateHasObjectAndActor", func(t *testing.T) {
+	t.Run("EnqueuesOnDeliveryQueueIfSet", func(t *testing.T) {
 		// Setup
 		ctl := gomock.NewController(t)
 		defer ctl.Finish()
-		_, _, _, mockDb, _, a := setupFn(ctl)
-		n, expect := baseNoteFn()
+		c, mockFp, _, mockDb, _, a := setupFn(ctl)
+		mockQ := NewMockDeliveryQueue(ctl)
+		a.(*sideEffectActor).queue = mockQ
+		mockTp := NewMockTransport(ctl)
+		act := baseActivityFn()
+		to := streams.NewActivityStreamsToProperty()
+		to.AppendIRI(mustParse(testFederatedActorIRI))
+		to.AppendIRI(mustParse(testFederatedActorIRI2))
+		act.SetActivityStreamsTo(to)
+		expectRecip := []*url.URL{
+			mustParse(testFederatedInboxIRI),
+			mustParse(testFederatedInboxIRI2),
+		}
 		// Mock
+		c.EXPECT().NewTransport(ctx, mustParse(testMyOutboxIRI), goFedUserAgent()).Return(
+			mockTp, nil)
+		mockFp.EXPECT().MaxDeliveryRecursionDepth(ctx).Return(1)
+		mockTp.EXPECT().Dereference(ctx, mustParse(testFederatedActorIRI)).Return(
+			mustSerializeToBytes(testFederatedPerson1), nil)
+		mockTp.EXPECT().Dereference(ctx, mustParse(testFederatedActorIRI2)).Return(
+			mustSerializeToBytes(testFederatedPerson2), nil)
 		mockDb.EXPECT().Lock(ctx, mustParse(testMyOutboxIRI))
 		mockDb.EXPECT().ActorForOutbox(ctx, mustParse(testMyOutboxIRI)).Return(
 			mustParse(testPersonIRI), nil)
 		mockDb.EXPECT().Unlock(ctx, mustParse(testMyOutboxIRI))
+		mockDb.EXPECT().Lock(ctx, mustParse(testPersonIRI))
+		mockDb.EXPECT().Get(ctx, mustParse(testPersonIRI)).Return(
+			testMyPerson, nil)
+		mockDb.EXPECT().Unlock(ctx, mustParse(testPersonIRI))
+		mockQ.EXPECT().Enqueue(ctx, mustParse(testMyOutboxIRI), mustParse(testNewActivityIRI), mustSerializeToBytes(act), expectRecip)
 		// Run & Verify
-		got, err := a.WrapInCreate(ctx, n, mustParse(testMyOutboxIRI))
+		err := a.Deliver(ctx, mustParse(testMyOutboxIRI), act)
 		assertEqual(t, err, nil)
-		assertByteEqual(t, mustSerializeToBytes(got), mustSerializeToBytes(expect))
 	})
-	t.Run("CreateHasTo", func(t *testing.T) {
+	t.Run("SendsToSharedInboxIfPublic", func(t *testing.T) {
 		// Setup
 		ctl := gomock.NewController(t)
 		defer ctl.Finish()
-		_, _, _, mockDb, _, a := setupFn(ctl)
-		n, expect := baseNoteFn()
+		c, mockFp, _, mockDb, _, a := setupFn(ctl)
+		mockTp := NewMockTransport(ctl)
+		act := baseActivityFn()
 		to := streams.NewActivityStreamsToProperty()
+		to.AppendIRI(mustParse(PublicActivityPubIRI))
 		to.AppendIRI(mustParse(testFederatedActorIRI))
 		to.AppendIRI(mustParse(testFederatedActorIRI2))
-		n.SetActivityStreamsTo(to)
-		expect.SetActivityStreamsTo(to)
+		act.SetActivityStreamsTo(to)
+		setTestSharedInbox(testFederatedPerson1, testFederatedSharedInboxIRI)
+		setTestSharedInbox(testFederatedPerson2, testFederatedSharedInboxIRI)
+		expectRecip := []*url.URL{
+			mustParse(testFederatedSharedInboxIRI),
+		}
 		// Mock
+		c.EXPECT().NewTransport(ctx, mustParse(testMyOutboxIRI), goFedUserAgent()).Return(
+			mockTp, nil)
+		mockFp.EXPECT().MaxDeliveryRecursionDepth(ctx).Return(1)
+		mockTp.EXPECT().Dereference(ctx, mustParse(testFederatedActorIRI)).Return(
+			mustSerializeToBytes(testFederatedPerson1), nil)
+		mockTp.EXPECT().Dereference(ctx, mustParse(testFederatedActorIRI2)).Return(
+			mustSerializeToBytes(testFederatedPerson2), nil)
 		mockDb.EXPECT().Lock(ctx, mustParse(testMyOutboxIRI))
 		mockDb.EXPECT().ActorForOutbox(ctx, mustParse(testMyOutboxIRI)).Return(
 			mustParse(testPersonIRI), nil)
 		mockDb.EXPECT().Unlock(ctx, mustParse(testMyOutboxIRI))
+		mockDb.EXPECT().Lock(ctx, mustParse(testPersonIRI))
+		mockDb.EXPECT().Get(ctx, mustParse(testPersonIRI)).Return(
+			testMyPerson, nil)
+		mockDb.EXPECT().Unlock(ctx, mustParse(testPersonIRI))
+		c.EXPECT().NewTransport(ctx, mustParse(testMyOutboxIRI), goFedUserAgent()).Return(
+			mockTp, nil)
+		mockTp.EXPECT().BatchDeliver(ctx, mustSerializeToBytes(act), expectRecip)
 		// Run & Verify
-		got, err := a.WrapInCreate(ctx, n, mustParse(testMyOutboxIRI))
+		err := a.Deliver(ctx, mustParse(testMyOutboxIRI), act)
 		assertEqual(t, err, nil)
-		assertByteEqual(t, mustSerializeToBytes(got), mustSerializeToBytes(expect))
 	})
-	t.Run("CreateHasCc", func(t *testing.T) {
+	t.Run("SendsToPersonalInboxOfHiddenRecipientsIfPublic", func(t *testing.T) {
 		// Setup
 		ctl := gomock.NewController(t)
 		defer ctl.Finish()
-		_, _, _, mockDb, _, a := setupFn(ctl)
-		n, expect := baseNoteFn()
-		cc := streams.NewActivityStreamsCcProperty()
-		cc.AppendIRI(mustParse(testFederatedActorIRI))
-		cc.AppendIRI(mustParse(testFederatedActorIRI2))
-		n.SetActivityStreamsCc(cc)
-		expect.SetActivityStreamsCc(cc)
+		c, mockFp, _, mockDb, _, a := setupFn(ctl)
+		mockTp := NewMockTransport(ctl)
+		act := baseActivityFn()
+		to := streams.NewActivityStreamsToProperty()
+		to.AppendIRI(mustParse(PublicActivityPubIRI))
+		to.AppendIRI(mustParse(testFederatedActorIRI))
+		act.SetActivityStreamsTo(to)
+		bcc := streams.NewActivityStreamsBccProperty()
+		bcc.AppendIRI(mustParse(testFederatedActorIRI2))
+		act.SetActivityStreamsBcc(bcc)
+		setTestSharedInbox(testFederatedPerson1, testFederatedSharedInboxIRI)
+		setTestSharedInbox(testFederatedPerson2, testFederatedSharedInboxIRI)
+		expectRecip := []*url.URL{
+			mustParse(testFederatedSharedInboxIRI),
+			mustParse(testFederatedInboxIRI2),
+		}
+		// Mock
+		c.EXPECT().NewTransport(ctx, mustParse(testMyOutboxIRI), goFedUserAgent()).Return(
+			mockTp, nil)
+		mockFp.EXPECT().MaxDeliveryRecursionDepth(ctx).Return(1)
+		mockTp.EXPECT().Dereference(ctx, mustParse(testFederatedActorIRI)).Return(
+			mustSerializeToBytes(testFederatedPerson1), nil)
+		mockTp.EXPECT().Dereference(ctx, mustParse(testFederatedActorIRI2)).Return(
+			mustSerializeToBytes(testFederatedPerson2), nil)
+		mockDb.EXPECT().Lock(ctx, mustParse(testMyOutboxIRI))
+		mockDb.EXPECT().ActorForOutbox(ctx, mustParse(testMyOutboxIRI)).Return(
+			mustParse(testPersonIRI), nil)
+		mockDb.EXPECT().Unlock(ctx, mustParse(testMyOutboxIRI))
+		mockDb.EXPECT().Lock(ctx, mustParse(testPersonIRI))
+		mockDb.EXPECT().Get(ctx, mustParse(testPersonIRI)).Return(
+			testMyPerson, nil)
+		mockDb.EXPECT().Unlock(ctx, mustParse(testPersonIRI))
+		c.EXPECT().NewTransport(ctx, mustParse(testMyOutboxIRI), goFedUserAgent()).Return(
+			mockTp, nil)
+		mockTp.EXPECT().BatchDeliver(ctx, gomock.Any(), expectRecip)
+		// Run & Verify
+		err := a.Deliver(ctx, mustParse(testMyOutboxIRI), act)
+		assertEqual(t, err, nil)
+		assertEqual(t, act.GetActivityStreamsBcc(), nil)
+	})
+	t.Run("SendsToPersonalInboxIfNotPublic", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		c, mockFp, _, mockDb, _, a := setupFn(ctl)
+		mockTp := NewMockTransport(ctl)
+		act := baseActivityFn()
+		to := streams.NewActivityStreamsToProperty()
+		to.AppendIRI(mustParse(testFederatedActorIRI))
+		to.AppendIRI(mustParse(testFederatedActorIRI2))
+		act.SetActivityStreamsTo(to)
+		setTestSharedInbox(testFederatedPerson1, testFederatedSharedInboxIRI)
+		setTestSharedInbox(testFederatedPerson2, testFederatedSharedInboxIRI)
+		expectRecip := []*url.URL{
+			mustParse(testFederatedInboxIRI),
+			mustParse(testFederatedInboxIRI2),
+		}
+		// Mock
+		c.EXPECT().NewTransport(ctx, mustParse(testMyOutboxIRI), goFedUserAgent()).Return(
+			mockTp, nil)
+		mockFp.EXPECT().MaxDeliveryRecursionDepth(ctx).Return(1)
+		mockTp.EXPECT().Dereference(ctx, mustParse(testFederatedActorIRI)).Return(
+			mustSerializeToBytes(testFederatedPerson1), nil)
+		mockTp.EXPECT().Dereference(ctx, mustParse(testFederatedActorIRI2)).Return(
+			mustSerializeToBytes(testFederatedPerson2), nil)
+		mockDb.EXPECT().Lock(ctx, mustParse(testMyOutboxIRI))
+		mockDb.EXPECT().ActorForOutbox(ctx, mustParse(testMyOutboxIRI)).Return(
+			mustParse(testPersonIRI), nil)
+		mockDb.EXPECT().Unlock(ctx, mustParse(testMyOutboxIRI))
+		mockDb.EXPECT().Lock(ctx, mustParse(testPersonIRI))
+		mockDb.EXPECT().Get(ctx, mustParse(testPersonIRI)).Return(
+			testMyPerson, nil)
+		mockDb.EXPECT().Unlock(ctx, mustParse(testPersonIRI))
+		c.EXPECT().NewTransport(ctx, mustParse(testMyOutboxIRI), goFedUserAgent()).Return(
+			mockTp, nil)
+		mockTp.EXPECT().BatchDeliver(ctx, mustSerializeToBytes(act), expectRecip)
+		// Run & Verify
+		err := a.Deliver(ctx, mustParse(testMyOutboxIRI), act)
+		assertEqual(t, err, nil)
+	})
+	t.Run("UsesCachedActors", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		c, mockFp, _, mockDb, clock, a := setupFn(ctl)
+		mockTp := NewMockTransport(ctl)
+		a.(*sideEffectActor).actorCache = NewMemoryActorCache(clock, time.Hour, 0)
+		act := baseActivityFn()
+		to := streams.NewActivityStreamsToProperty()
+		to.AppendIRI(mustParse(testFederatedActorIRI))
+		act.SetActivityStreamsTo(to)
+		expectRecip := []*url.URL{
+			mustParse(testFederatedInboxIRI),
+		}
+		// Mock
+		clock.EXPECT().Now().Return(now()).AnyTimes()
+		c.EXPECT().NewTransport(ctx, mustParse(testMyOutboxIRI), goFedUserAgent()).Return(
+			mockTp, nil).Times(2)
+		mockFp.EXPECT().MaxDeliveryRecursionDepth(ctx).Return(1)
+		mockTp.EXPECT().Dereference(ctx, mustParse(testFederatedActorIRI)).Return(
+			mustSerializeToBytes(testFederatedPerson1), nil)
+		mockDb.EXPECT().Lock(ctx, mustParse(testMyOutboxIRI))
+		mockDb.EXPECT().ActorForOutbox(ctx, mustParse(testMyOutboxIRI)).Return(
+			mustParse(testPersonIRI), nil)
+		mockDb.EXPECT().Unlock(ctx, mustParse(testMyOutboxIRI))
+		mockDb.EXPECT().Lock(ctx, mustParse(testPersonIRI))
+		mockDb.EXPECT().Get(ctx, mustParse(testPersonIRI)).Return(
+			testMyPerson, nil)
+		mockDb.EXPECT().Unlock(ctx, mustParse(testPersonIRI))
+		mockTp.EXPECT().BatchDeliver(ctx, mustSerializeToBytes(act), expectRecip)
+		// Run
+		err := a.Deliver(ctx, mustParse(testMyOutboxIRI), act)
+		assertEqual(t, err, nil)
+		// Mock
+		c.EXPECT().NewTransport(ctx, mustParse(testMyOutboxIRI), goFedUserAgent()).Return(
+			mockTp, nil).Times(2)
+		mockFp.EXPECT().MaxDeliveryRecursionDepth(ctx).Return(1)
+		mockDb.EXPECT().Lock(ctx, mustParse(testMyOutboxIRI))
+		mockDb.EXPECT().ActorForOutbox(ctx, mustParse(testMyOutboxIRI)).Return(
+			mustParse(testPersonIRI), nil)
+		mockDb.EXPECT().Unlock(ctx, mustParse(testMyOutboxIRI))
+		mockDb.EXPECT().Lock(ctx, mustParse(testPersonIRI))
+		mockDb.EXPECT().Get(ctx, mustParse(testPersonIRI)).Return(
+			testMyPerson, nil)
+		mockDb.EXPECT().Unlock(ctx, mustParse(testPersonIRI))
+		mockTp.EXPECT().BatchDeliver(ctx, mustSerializeToBytes(act), expectRecip)
+		// Run & Verify
+		err = a.Deliver(ctx, mustParse(testMyOutboxIRI), act)
+		assertEqual(t, err, nil)
+	})
+	t.Run("DoesNotFailOnDeferredRecipients", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		c, mockFp, _, mockDb, _, a := setupFn(ctl)
+		mockTp := NewMockTransport(ctl)
+		act := baseActivityFn()
+		to := streams.NewActivityStreamsToProperty()
+		to.AppendIRI(mustParse(testFederatedActorIRI))
+		act.SetActivityStreamsTo(to)
+		expectRecip := []*url.URL{
+			mustParse(testFederatedInboxIRI),
+		}
+		deferErr := &DeliveryDeferredError{
+			Deferred: []DeferredDelivery{{Recipient: mustParse(testFederatedInboxIRI)}},
+		}
+		// Mock
+		c.EXPECT().NewTransport(ctx, mustParse(testMyOutboxIRI), goFedUserAgent()).Return(
+			mockTp, nil).Times(2)
+		mockFp.EXPECT().MaxDeliveryRecursionDepth(ctx).Return(1)
+		mockTp.EXPECT().Dereference(ctx, mustParse(testFederatedActorIRI)).Return(
+			mustSerializeToBytes(testFederatedPerson1), nil)
+		mockDb.EXPECT().Lock(ctx, mustParse(testMyOutboxIRI))
+		mockDb.EXPECT().ActorForOutbox(ctx, mustParse(testMyOutboxIRI)).Return(
+			mustParse(testPersonIRI), nil)
+		mockDb.EXPECT().Unlock(ctx, mustParse(testMyOutboxIRI))
+		mockDb.EXPECT().Lock(ctx, mustParse(testPersonIRI))
+		mockDb.EXPECT().Get(ctx, mustParse(testPersonIRI)).Return(
+			testMyPerson, nil)
+		mockDb.EXPECT().Unlock(ctx, mustParse(testPersonIRI))
+		mockTp.EXPECT().BatchDeliver(ctx, mustSerializeToBytes(act), expectRecip).Return(
+			deferErr)
+		// Run & Verify
+		err := a.Deliver(ctx, mustParse(testMyOutboxIRI), act)
+		assertEqual(t, err, nil)
+	})
+	t.Run("ReturnsFailuresBesideDeferredRecipients", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		c, mockFp, _, mockDb, _, a := setupFn(ctl)
+		mockTp := NewMockTransport(ctl)
+		act := baseActivityFn()
+		to := streams.NewActivityStreamsToProperty()
+		to.AppendIRI(mustParse(testFederatedActorIRI))
+		act.SetActivityStreamsTo(to)
+		expectRecip := []*url.URL{
+			mustParse(testFederatedInboxIRI),
+		}
+		batchErr := &BatchDeliveryError{Errors: []error{fmt.Errorf("test error")}}
+		deferErr := &DeliveryDeferredError{
+			Deferred: []DeferredDelivery{{Recipient: mustParse(testFederatedInboxIRI)}},
+			Err:      batchErr,
+		}
+		// Mock
+		c.EXPECT().NewTransport(ctx, mustParse(testMyOutboxIRI), goFedUserAgent()).Return(
+			mockTp, nil).Times(2)
+		mockFp.EXPECT().MaxDeliveryRecursionDepth(ctx).Return(1)
+		mockTp.EXPECT().Dereference(ctx, mustParse(testFederatedActorIRI)).Return(
+			mustSerializeToBytes(testFederatedPerson1), nil)
+		mockDb.EXPECT().Lock(ctx, mustParse(testMyOutboxIRI))
+		mockDb.EXPECT().ActorForOutbox(ctx, mustParse(testMyOutboxIRI)).Return(
+			mustParse(testPersonIRI), nil)
+		mockDb.EXPECT().Unlock(ctx, mustParse(testMyOutboxIRI))
+		mockDb.EXPECT().Lock(ctx, mustParse(testPersonIRI))
+		mockDb.EXPECT().Get(ctx, mustParse(testPersonIRI)).Return(
+			testMyPerson, nil)
+		mockDb.EXPECT().Unlock(ctx, mustParse(testPersonIRI))
+		mockTp.EXPECT().BatchDeliver(ctx, mustSerializeToBytes(act), expectRecip).Return(
+			deferErr)
+		// Run & Verify
+		err := a.Deliver(ctx, mustParse(testMyOutboxIRI), act)
+		assertEqual(t, err, error(batchErr))
+	})
+}
+
+// TestWrapInCreate ensures an object received by the Social Protocol is
+// properly wrapped in a Create Activity.
+func TestWrapInCreate(t *testing.T) {
+	baseNoteFn := func() (vocab.ActivityStreamsNote, vocab.ActivityStreamsCreate) {
+		n := streams.NewActivityStreamsNote()
+		id := streams.NewJSONLDIdProperty()
+		id.Set(mustParse(testNoteId1))
+		n.SetJSONLDId(id)
+		cr := streams.NewActivityStreamsCreate()
+		op := streams.NewActivityStreamsObjectProperty()
+		op.AppendActivityStreamsNote(n)
+		cr.SetActivityStreamsObject(op)
+		actorProp := streams.NewActivityStreamsActorProperty()
+		actorProp.AppendIRI(mustParse(testPersonIRI))
+		cr.SetActivityStreamsActor(actorProp)
+		return n, cr
+	}
+	ctx := context.Background()
+	setupFn := func(ctl *gomock.Controller) (c *MockCommonBehavior, fp *MockFederatingProtocol, sp *MockSocialProtocol, db *MockDatabase, cl *MockClock, a DelegateActor) {
+		setupData()
+		c = NewMockCommonBehavior(ctl)
+		fp = NewMockFederatingProtocol(ctl)
+		sp = NewMockSocialProtocol(ctl)
+		db = NewMockDatabase(ctl)
+		cl = NewMockClock(ctl)
+		a = &sideEffectActor{
+			common: c,
+			s2s:    fp,
+			c2s:    sp,
+			db:     db,
+			clock:  cl,
+		}
+		return
+	}
+	t.Run("CreateHasObjectAndActor", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		_, _, _, mockDb, _, a := setupFn(ctl)
+		n, expect := baseNoteFn()
+		// Mock
+		mockDb.EXPECT().Lock(ctx, mustParse(testMyOutboxIRI))
+		mockDb.EXPECT().ActorForOutbox(ctx, mustParse(testMyOutboxIRI)).Return(
+			mustParse(testPersonIRI), nil)
+		mockDb.EXPECT().Unlock(ctx, mustParse(testMyOutboxIRI))
+		// Run & Verify
+		got, err := a.WrapInCreate(ctx, n, mustParse(testMyOutboxIRI))
+		assertEqual(t, err, nil)
+		assertByteEqual(t, mustSerializeToBytes(got), mustSerializeToBytes(expect))
+	})
+	t.Run("CreateHasTo", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		_, _, _, mockDb, _, a := setupFn(ctl)
+		n, expect := baseNoteFn()
+		to := streams.NewActivityStreamsToProperty()
+		to.AppendIRI(mustParse(testFederatedActorIRI))
+		to.AppendIRI(mustParse(testFederatedActorIRI2))
+		n.SetActivityStreamsTo(to)
+		expect.SetActivityStreamsTo(to)
+		// Mock
+		mockDb.EXPECT().Lock(ctx, mustParse(testMyOutboxIRI))
+		mockDb.EXPECT().ActorForOutbox(ctx, mustParse(testMyOutboxIRI)).Return(
+			mustParse(testPersonIRI), nil)
+		mockDb.EXPECT().Unlock(ctx, mustParse(testMyOutboxIRI))
+		// Run & Verify
+		got, err := a.WrapInCreate(ctx, n, mustParse(testMyOutboxIRI))
+		assertEqual(t, err, nil)
+		assertByteEqual(t, mustSerializeToBytes(got), mustSerializeToBytes(expect))
+	})
+	t.Run("CreateHasCc", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		_, _, _, mockDb, _, a := setupFn(ctl)
+		n, expect := baseNoteFn()
+		cc := streams.NewActivityStreamsCcProperty()
+		cc.AppendIRI(mustParse(testFederatedActorIRI))
+		cc.AppendIRI(mustParse(testFederatedActorIRI2))
+		n.SetActivityStreamsCc(cc)
+		expect.SetActivityStreamsCc(cc)
 		// Mock
 		mockDb.EXPECT().Lock(ctx, mustParse(testMyOutboxIRI))
 		mockDb.EXPECT().ActorForOutbox(ctx, mustParse(testMyOutboxIRI)).Return(
@@ -1828,3 +2310,392 @@ func TestWrapInCreate(t *testing.T) {
 		assertByteEqual(t, mustSerializeToBytes(got), mustSerializeToBytes(expect))
 	})
 }
+
+// testLocalFollowerListerDatabase is a mock Database that also lists the
+// local followers of actors.
+type testLocalFollowerListerDatabase struct {
+	*MockDatabase
+	followers map[string][]*url.URL
+}
+
+// LocalFollowers returns the configured followers of the actor.
+func (d testLocalFollowerListerDatabase) LocalFollowers(c context.Context, actorIRI *url.URL) ([]*url.URL, error) {
+	return d.followers[actorIRI.String()], nil
+}
+
+// TestSharedInboxRecipients tests determining the local inboxes of an activity
+// received in the shared inbox.
+func TestSharedInboxRecipients(t *testing.T) {
+	baseActivityFn := func(recipients ...string) vocab.ActivityStreamsCreate {
+		act := streams.NewActivityStreamsCreate()
+		id := streams.NewJSONLDIdProperty()
+		id.Set(mustParse(testFederatedActivityIRI))
+		act.SetJSONLDId(id)
+		actor := streams.NewActivityStreamsActorProperty()
+		actor.AppendIRI(mustParse(testFederatedActorIRI))
+		act.SetActivityStreamsActor(actor)
+		to := streams.NewActivityStreamsToProperty()
+		for _, r := range recipients {
+			to.AppendIRI(mustParse(r))
+		}
+		act.SetActivityStreamsTo(to)
+		return act
+	}
+	ctx := context.Background()
+	setupFn := func(ctl *gomock.Controller, withLister bool) (db *MockDatabase, a DelegateActor) {
+		setupData()
+		db = NewMockDatabase(ctl)
+		var d Database = db
+		if withLister {
+			d = testLocalFollowerListerDatabase{
+				MockDatabase: db,
+				followers: map[string][]*url.URL{
+					testFederatedActorIRI: {mustParse(testPersonIRI)},
+				},
+			}
+		}
+		a = &sideEffectActor{
+			db: d,
+		}
+		return
+	}
+	expectLocalActorFn := func(db *MockDatabase) {
+		db.EXPECT().Lock(ctx, mustParse(testPersonIRI))
+		db.EXPECT().Owns(ctx, mustParse(testPersonIRI)).Return(true, nil)
+		db.EXPECT().Get(ctx, mustParse(testPersonIRI)).Return(testMyPerson, nil)
+		db.EXPECT().Unlock(ctx, mustParse(testPersonIRI))
+	}
+	expectPeerFn := func(db *MockDatabase, iri string) {
+		db.EXPECT().Lock(ctx, mustParse(iri))
+		db.EXPECT().Owns(ctx, mustParse(iri)).Return(false, nil)
+		db.EXPECT().Unlock(ctx, mustParse(iri))
+	}
+	t.Run("ReturnsInboxesOfAddressedLocalActors", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		db, a := setupFn(ctl, false)
+		act := baseActivityFn(testPersonIRI, testFederatedActorIRI2, PublicActivityPubIRI)
+		// Mock
+		expectLocalActorFn(db)
+		expectPeerFn(db, testFederatedActorIRI2)
+		// Run
+		inboxes, err := a.(SharedInboxDelegateActor).SharedInboxRecipients(ctx, act)
+		// Verify
+		assertEqual(t, err, nil)
+		assertEqual(t, len(inboxes), 1)
+		assertEqual(t, inboxes[0].String(), testMyInboxIRI)
+	})
+	t.Run("IncludesLocalFollowersIfPublic", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		db, a := setupFn(ctl, true)
+		act := baseActivityFn(PublicActivityPubIRI)
+		// Mock
+		expectLocalActorFn(db)
+		// Run
+		inboxes, err := a.(SharedInboxDelegateActor).SharedInboxRecipients(ctx, act)
+		// Verify
+		assertEqual(t, err, nil)
+		assertEqual(t, len(inboxes), 1)
+		assertEqual(t, inboxes[0].String(), testMyInboxIRI)
+	})
+	t.Run("IncludesLocalFollowersIfAddressedToFollowers", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		db, a := setupFn(ctl, true)
+		followersIRI := testFederatedActorIRI + "/followers"
+		followers := streams.NewActivityStreamsFollowersProperty()
+		followers.SetIRI(mustParse(followersIRI))
+		testFederatedPerson1.SetActivityStreamsFollowers(followers)
+		act := baseActivityFn(followersIRI)
+		// Mock
+		db.EXPECT().Lock(ctx, mustParse(testFederatedActorIRI))
+		db.EXPECT().Exists(ctx, mustParse(testFederatedActorIRI)).Return(true, nil)
+		db.EXPECT().Get(ctx, mustParse(testFederatedActorIRI)).Return(testFederatedPerson1, nil)
+		db.EXPECT().Unlock(ctx, mustParse(testFederatedActorIRI))
+		expectPeerFn(db, followersIRI)
+		expectLocalActorFn(db)
+		// Run
+		inboxes, err := a.(SharedInboxDelegateActor).SharedInboxRecipients(ctx, act)
+		// Verify
+		assertEqual(t, err, nil)
+		assertEqual(t, len(inboxes), 1)
+		assertEqual(t, inboxes[0].String(), testMyInboxIRI)
+	})
+	t.Run("ExcludesLocalFollowersOtherwise", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		db, a := setupFn(ctl, true)
+		act := baseActivityFn(testFederatedActorIRI2)
+		// Mock
+		db.EXPECT().Lock(ctx, mustParse(testFederatedActorIRI))
+		db.EXPECT().Exists(ctx, mustParse(testFederatedActorIRI)).Return(false, nil)
+		db.EXPECT().Unlock(ctx, mustParse(testFederatedActorIRI))
+		expectPeerFn(db, testFederatedActorIRI2)
+		// Run
+		inboxes, err := a.(SharedInboxDelegateActor).SharedInboxRecipients(ctx, act)
+		// Verify
+		assertEqual(t, err, nil)
+		assertEqual(t, len(inboxes), 0)
+	})
+}
+
+// TestPostSharedInbox tests posting an activity received in the shared inbox
+// to the inboxes of several local actors.
+func TestPostSharedInbox(t *testing.T) {
+	ctx := context.Background()
+	const (
+		actorIRI  = "https://example.com/addison"
+		actorIRI2 = "https://example.com/blake"
+	)
+	setupFn := func(ctl *gomock.Controller) (fp *MockFederatingProtocol, db *MemoryDatabase, a DelegateActor) {
+		setupData()
+		fp = NewMockFederatingProtocol(ctl)
+		db = NewMemoryDatabase(mustParse(actorIRI))
+		assertEqual(t, db.AddActor(ctx, newMemoryTestPerson(actorIRI)), nil)
+		assertEqual(t, db.AddActor(ctx, newMemoryTestPerson(actorIRI2)), nil)
+		assertEqual(t, db.Create(ctx, testMyNote), nil)
+		a = &sideEffectActor{
+			common: NewMockCommonBehavior(ctl),
+			s2s:    fp,
+			db:     db,
+		}
+		return
+	}
+	t.Run("CountsLikeOnce", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		fp, db, a := setupFn(ctl)
+		inboxes := []*url.URL{mustParse(actorIRI + "/inbox"), mustParse(actorIRI2 + "/inbox")}
+		like := streams.NewActivityStreamsLike()
+		id := streams.NewJSONLDIdProperty()
+		id.Set(mustParse(testFederatedActivityIRI))
+		like.SetJSONLDId(id)
+		actor := streams.NewActivityStreamsActorProperty()
+		actor.AppendIRI(mustParse(testFederatedActorIRI))
+		like.SetActivityStreamsActor(actor)
+		op := streams.NewActivityStreamsObjectProperty()
+		op.AppendIRI(mustParse(testNoteId1))
+		like.SetActivityStreamsObject(op)
+		// Mock
+		fp.EXPECT().FederatingCallbacks(ctx).Return(FederatingWrappedCallbacks{}, nil, nil)
+		// Run
+		posted, err := a.(SharedInboxDelegateActor).PostSharedInbox(ctx, inboxes, like)
+		// Verify
+		assertEqual(t, err, nil)
+		assertEqual(t, len(posted), 2)
+		for _, inbox := range inboxes {
+			contains, err := db.InboxContains(ctx, inbox, mustParse(testFederatedActivityIRI))
+			assertEqual(t, err, nil)
+			assertEqual(t, contains, true)
+		}
+		note, err := db.Get(ctx, mustParse(testNoteId1))
+		assertEqual(t, err, nil)
+		likes := note.(vocab.ActivityStreamsNote).GetActivityStreamsLikes()
+		items := likes.GetActivityStreamsCollection().GetActivityStreamsItems()
+		assertEqual(t, items.Len(), 1)
+		assertEqual(t, items.At(0).GetIRI().String(), testFederatedActivityIRI)
+	})
+	t.Run("DoesNothingIfNotNewToAnyInbox", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		_, db, a := setupFn(ctl)
+		inboxes := []*url.URL{mustParse(actorIRI + "/inbox"), mustParse(actorIRI2 + "/inbox")}
+		for _, inbox := range inboxes {
+			assertEqual(t, db.AppendInbox(ctx, inbox, mustParse(testFederatedActivityIRI)), nil)
+		}
+		// Run
+		posted, err := a.(SharedInboxDelegateActor).PostSharedInbox(ctx, inboxes, testListen)
+		// Verify
+		assertEqual(t, err, nil)
+		assertEqual(t, len(posted), 2)
+	})
+	t.Run("LeavesOutInboxesOfBlockingActors", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		fp, db, a := setupFn(ctl)
+		blocks := NewMemoryBlockList()
+		assertEqual(t, blocks.Block(ctx, mustParse(actorIRI), mustParse(testFederatedActorIRI)), nil)
+		a.(*sideEffectActor).blocks = blocks
+		inboxes := []*url.URL{mustParse(actorIRI + "/inbox"), mustParse(actorIRI2 + "/inbox")}
+		// Mock
+		fp.EXPECT().FederatingCallbacks(ctx).Return(FederatingWrappedCallbacks{}, nil, nil)
+		fp.EXPECT().DefaultCallback(ctx, testListen)
+		// Run
+		posted, err := a.(SharedInboxDelegateActor).PostSharedInbox(ctx, inboxes, testListen)
+		// Verify
+		assertEqual(t, err, nil)
+		assertEqual(t, len(posted), 1)
+		assertEqual(t, posted[0].String(), actorIRI2+"/inbox")
+		contains, err := db.InboxContains(ctx, inboxes[0], mustParse(testFederatedActivityIRI))
+		assertEqual(t, err, nil)
+		assertEqual(t, contains, false)
+	})
+}
+
+// TestRespondToPendingFollow ensures that pending Follows are answered and, if
+// accepted, their actors become followers.
+func TestRespondToPendingFollow(t *testing.T) {
+	ctx := context.Background()
+	setupFn := func(ctl *gomock.Controller) (db *MockDatabase, pending *MemoryPendingFollowStore, a FollowApprovalDelegateActor) {
+		setupData()
+		db = NewMockDatabase(ctl)
+		pending = NewMemoryPendingFollowStore()
+		pending.Add(ctx, mustParse(testFederatedActorIRI), testFollow)
+		a = &sideEffectActor{
+			db:             db,
+			pendingFollows: pending,
+		}
+		return
+	}
+	// Run tests
+	t.Run("BuildsAcceptWithoutChanges", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		db, pending, a := setupFn(ctl)
+		outboxIRI := mustParse(testMyOutboxIRI)
+		actorIRI := mustParse(testFederatedActorIRI)
+		gomock.InOrder(
+			db.EXPECT().Lock(ctx, outboxIRI),
+			db.EXPECT().ActorForOutbox(ctx, outboxIRI).Return(actorIRI, nil),
+			db.EXPECT().Unlock(ctx, outboxIRI),
+		)
+		// Run
+		response, err := a.RespondToPendingFollow(ctx, outboxIRI, mustParse(testFederatedActivityIRI), true)
+		// Verify
+		assertEqual(t, err, nil)
+		assertEqual(t, streams.IsOrExtendsActivityStreamsAccept(response), true)
+		assertEqual(t, response.GetActivityStreamsTo().At(0).GetIRI().String(), testFederatedActorIRI2)
+		follows, _ := pending.List(ctx, actorIRI)
+		assertEqual(t, len(follows), 1)
+	})
+	t.Run("RejectDoesNotAddFollowers", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		db, _, a := setupFn(ctl)
+		outboxIRI := mustParse(testMyOutboxIRI)
+		gomock.InOrder(
+			db.EXPECT().Lock(ctx, outboxIRI),
+			db.EXPECT().ActorForOutbox(ctx, outboxIRI).Return(mustParse(testFederatedActorIRI), nil),
+			db.EXPECT().Unlock(ctx, outboxIRI),
+		)
+		// Run
+		response, err := a.RespondToPendingFollow(ctx, outboxIRI, mustParse(testFederatedActivityIRI), false)
+		// Verify
+		assertEqual(t, err, nil)
+		assertEqual(t, streams.IsOrExtendsActivityStreamsReject(response), true)
+	})
+	t.Run("ErrorIfNotPending", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		db, _, a := setupFn(ctl)
+		outboxIRI := mustParse(testMyOutboxIRI)
+		gomock.InOrder(
+			db.EXPECT().Lock(ctx, outboxIRI),
+			db.EXPECT().ActorForOutbox(ctx, outboxIRI).Return(mustParse(testFederatedActorIRI), nil),
+			db.EXPECT().Unlock(ctx, outboxIRI),
+		)
+		// Run
+		_, err := a.RespondToPendingFollow(ctx, outboxIRI, mustParse(testFederatedActivityIRI2), true)
+		// Verify
+		assertEqual(t, err, ErrFollowNotPending)
+	})
+}
+
+// TestResolvePendingFollow ensures that pending Follows are only removed once
+// their accepted actors are followers.
+func TestResolvePendingFollow(t *testing.T) {
+	ctx := context.Background()
+	setupFn := func(ctl *gomock.Controller) (db *MockDatabase, pending *MemoryPendingFollowStore, a FollowApprovalDelegateActor) {
+		setupData()
+		db = NewMockDatabase(ctl)
+		pending = NewMemoryPendingFollowStore()
+		pending.Add(ctx, mustParse(testFederatedActorIRI), testFollow)
+		a = &sideEffectActor{
+			db:             db,
+			pendingFollows: pending,
+		}
+		return
+	}
+	// Run tests
+	t.Run("AcceptAddsFollowers", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		db, pending, a := setupFn(ctl)
+		outboxIRI := mustParse(testMyOutboxIRI)
+		actorIRI := mustParse(testFederatedActorIRI)
+		followers := streams.NewActivityStreamsCollection()
+		expectFollowers := streams.NewActivityStreamsCollection()
+		expectItems := streams.NewActivityStreamsItemsProperty()
+		expectItems.AppendIRI(mustParse(testFederatedActorIRI2))
+		expectFollowers.SetActivityStreamsItems(expectItems)
+		gomock.InOrder(
+			db.EXPECT().Lock(ctx, outboxIRI),
+			db.EXPECT().ActorForOutbox(ctx, outboxIRI).Return(actorIRI, nil),
+			db.EXPECT().Unlock(ctx, outboxIRI),
+			db.EXPECT().Lock(ctx, actorIRI),
+			db.EXPECT().Followers(ctx, actorIRI).Return(followers, nil),
+			db.EXPECT().Update(ctx, expectFollowers).Return(nil),
+			db.EXPECT().Unlock(ctx, actorIRI),
+		)
+		// Run
+		err := a.ResolvePendingFollow(ctx, outboxIRI, mustParse(testFederatedActivityIRI), true)
+		// Verify
+		assertEqual(t, err, nil)
+		follows, _ := pending.List(ctx, actorIRI)
+		assertEqual(t, len(follows), 0)
+	})
+	t.Run("RejectDoesNotAddFollowers", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		db, pending, a := setupFn(ctl)
+		outboxIRI := mustParse(testMyOutboxIRI)
+		actorIRI := mustParse(testFederatedActorIRI)
+		gomock.InOrder(
+			db.EXPECT().Lock(ctx, outboxIRI),
+			db.EXPECT().ActorForOutbox(ctx, outboxIRI).Return(actorIRI, nil),
+			db.EXPECT().Unlock(ctx, outboxIRI),
+		)
+		// Run
+		err := a.ResolvePendingFollow(ctx, outboxIRI, mustParse(testFederatedActivityIRI), false)
+		// Verify
+		assertEqual(t, err, nil)
+		follows, _ := pending.List(ctx, actorIRI)
+		assertEqual(t, len(follows), 0)
+	})
+	t.Run("KeepsPendingIfFollowersNotUpdated", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		db, pending, a := setupFn(ctl)
+		outboxIRI := mustParse(testMyOutboxIRI)
+		actorIRI := mustParse(testFederatedActorIRI)
+		gomock.InOrder(
+			db.EXPECT().Lock(ctx, outboxIRI),
+			db.EXPECT().ActorForOutbox(ctx, outboxIRI).Return(actorIRI, nil),
+			db.EXPECT().Unlock(ctx, outboxIRI),
+			db.EXPECT().Lock(ctx, actorIRI),
+			db.EXPECT().Followers(ctx, actorIRI).Return(streams.NewActivityStreamsCollection(), nil),
+			db.EXPECT().Update(ctx, gomock.Any()).Return(testErr),
+			db.EXPECT().Unlock(ctx, actorIRI),
+		)
+		// Run
+		err := a.ResolvePendingFollow(ctx, outboxIRI, mustParse(testFederatedActivityIRI), true)
+		// Verify
+		assertEqual(t, err, testErr)
+		follows, _ := pending.List(ctx, actorIRI)
+		assertEqual(t, len(follows), 1)
+	})
+}
@@ -18,7 +18,9 @@ type SocialWrappedCallbacks struct {
 	//
 	// The wrapping callback copies the actor(s) to the 'attributedTo'
 	// property and copies recipients between the Create activity and all
-	// objects. It then saves the entry in the database.
+	// objects. It then saves the entry in the database. If the Actor was
+	// created with WithRepliesCollections, the objects are also added to
+	// the 'replies' collection of the local objects they are 'inReplyTo'.
 	Create func(context.Context, vocab.ActivityStreamsCreate) error
 	// Update handles additional side effects for the Update ActivityStreams
 	// type.
@@ -31,7 +33,8 @@ type SocialWrappedCallbacks struct {
 	// type.
 	//
 	// The wrapping callback replaces the object(s) with tombstones in the
-	// database.
+	// database. Any 'replies' collection listing them is left unchanged,
+	// as it now refers to the tombstones.
 	Delete func(context.Context, vocab.ActivityStreamsDelete) error
 	// Follow handles additional side effects for the Follow ActivityStreams
 	// type.
@@ -69,20 +72,40 @@ type SocialWrappedCallbacks struct {
 	// It enforces that the actors on the Undo must correspond to all of the
 	// 'object' actors in some manner.
 	//
-	// It is expected that the application will implement the proper
-	// reversal of activities that are being undone.
+	// The wrapping function then reverses the default side effects of the
+	// activities being undone: an undone 'Follow' removes its objects from
+	// this actor's 'following' collection, and an undone 'Like' removes
+	// its objects from this actor's 'liked' collection. An undone 'Block'
+	// lifts the blocks of its objects if a BlockList is in use. An 'Undo'
+	// of only 'Block' activities is, like the 'Block', not federated. Any
+	// other reversal is left to the application.
 	Undo func(context.Context, vocab.ActivityStreamsUndo) error
+	// SkipUndo optionally determines whether the default side effects of
+	// an activity being undone should be left in place. If it returns
+	// true, the wrapping Undo function does not reverse them, but the
+	// Undo callback is still called.
+	SkipUndo func(c context.Context, undone vocab.Type) bool
 	// Block handles additional side effects for the Block ActivityStreams
 	// type.
 	//
-	// The wrapping callback only ensures the 'Block' has at least one
-	// 'object' entry, but otherwise has no default side effect. It is up
-	// to the wrapped application function to properly enforce the new
-	// blocking behavior.
+	// The wrapping callback ensures the 'Block' has at least one 'object'
+	// entry. If a BlockList is in use, the 'object' entries are recorded
+	// as blocked by this actor, and the BlockList enforces the blocks.
+	// Otherwise, it is up to the wrapped application function to properly
+	// enforce the new blocking behavior.
 	//
 	// Note that go-fed does not federate 'Block' activities received in the
 	// Social Protocol.
 	Block func(context.Context, vocab.ActivityStreamsBlock) error
+	// Move handles additional side effects for the Move ActivityStreams
+	// type.
+	//
+	// The wrapping function ensures the 'Move' has an 'object' and a
+	// 'target', and that its only 'object' and 'actor' is this actor,
+	// which is the old account being moved. It then addresses the Move to
+	// this actor's 'followers' collection, so that they are notified and
+	// may follow the 'target' instead.
+	Move func(context.Context, vocab.ActivityStreamsMove) error
 
 	// Sidechannel data -- this is set at request handling time. These must
 	// be set before the callbacks are used.
@@ -99,6 +122,14 @@ type SocialWrappedCallbacks struct {
 	clock Clock
 	// newTransport creates a new Transport.
 	newTransport func(c context.Context, actorBoxIRI *url.URL, gofedAgent string) (t Transport, err error)
+	// newDereferenceTransport creates a new Transport for dereferencing
+	// values in the background, such as with the InstanceActor.
+	newDereferenceTransport func(c context.Context, actorBoxIRI *url.URL, gofedAgent string) (t Transport, err error)
+	// blocks is the optional BlockList recording this actor's blocks.
+	blocks BlockList
+	// replies determines whether the 'replies' collections of local
+	// objects are maintained.
+	replies bool
 	// undeliverable is a sidechannel out, indicating if the handled activity
 	// should not be delivered to a peer.
 	//
@@ -122,6 +153,7 @@ func (w SocialWrappedCallbacks) callbacks(fns []interface{}) []interface{} {
 	enableLike := true
 	enableUndo := true
 	enableBlock := true
+	enableMove := true
 	for _, fn := range fns {
 		switch fn.(type) {
 		default:
@@ -144,6 +176,8 @@ func (w SocialWrappedCallbacks) callbacks(fns []interface{}) []interface{} {
 			enableUndo = false
 		case func(context.Context, vocab.ActivityStreamsBlock) error:
 			enableBlock = false
+		case func(context.Context, vocab.ActivityStreamsMove) error:
+			enableMove = false
 		}
 	}
 	if enableCreate {
@@ -173,6 +207,9 @@ func (w SocialWrappedCallbacks) callbacks(fns []interface{}) []interface{} {
 	if enableBlock {
 		fns = append(fns, w.block)
 	}
+	if enableMove {
+		fns = append(fns, w.move)
+	}
 	return fns
 }
 
@@ -275,6 +312,13 @@ func (w SocialWrappedCallbacks) create(c context.Context, a vocab.ActivityStream
 			return err
 		}
 	}
+	if w.replies {
+		for i := 0; i < op.Len(); i++ {
+			if err := addToReplies(c, op.At(i).GetType(), w.db); err != nil {
+				return err
+			}
+		}
+	}
 	if w.Create != nil {
 		return w.Create(c, a)
 	}
@@ -508,15 +552,76 @@ func (w SocialWrappedCallbacks) undo(c context.Context, a vocab.ActivityStreamsU
 		return ErrObjectRequired
 	}
 	actors := a.GetActivityStreamsActor()
-	if err := mustHaveActivityActorsMatchObjectActors(c, actors, op, w.newTransport, w.outboxIRI); err != nil {
+	undone, err := mustHaveActivityActorsMatchObjectActors(c, actors, op, w.newDereferenceTransport, w.outboxIRI)
+	if err != nil {
 		return err
 	}
+	// Undoing only Blocks is as undeliverable as the Blocks were.
+	onlyBlocks := true
+	for _, t := range undone {
+		if !streams.IsOrExtendsActivityStreamsBlock(t) {
+			onlyBlocks = false
+		}
+		if w.SkipUndo != nil && w.SkipUndo(c, t) {
+			continue
+		}
+		if err := w.undoSideEffects(c, t); err != nil {
+			return err
+		}
+	}
+	*w.undeliverable = onlyBlocks
 	if w.Undo != nil {
 		return w.Undo(c, a)
 	}
 	return nil
 }
 
+// undoSideEffects reverses the social side effects of an undone Follow, Like,
+// or Block. Other types have no side effects to reverse.
+func (w SocialWrappedCallbacks) undoSideEffects(c context.Context, t vocab.Type) error {
+	activity, ok := t.(Activity)
+	if !ok {
+		return nil
+	}
+	if streams.IsOrExtendsActivityStreamsBlock(t) {
+		if w.blocks == nil || activity.GetActivityStreamsObject() == nil {
+			return nil
+		}
+		return w.updateBlocks(c, activity.GetActivityStreamsObject(), w.blocks.Unblock)
+	}
+	var colFn func(c context.Context, actorIRI *url.URL) (vocab.ActivityStreamsCollection, error)
+	if streams.IsOrExtendsActivityStreamsFollow(t) {
+		colFn = w.db.Following
+	} else if streams.IsOrExtendsActivityStreamsLike(t) {
+		colFn = w.db.Liked
+	} else {
+		return nil
+	}
+	var ids []*url.URL
+	if op := activity.GetActivityStreamsObject(); op != nil {
+		for iter := op.Begin(); iter != op.End(); iter = iter.Next() {
+			id, err := ToId(iter)
+			if err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+	}
+	// Get this actor's IRI.
+	if err := w.db.Lock(c, w.outboxIRI); err != nil {
+		return err
+	}
+	// WARNING: Unlock not deferred.
+	actorIRI, err := w.db.ActorForOutbox(c, w.outboxIRI)
+	if err != nil {
+		w.db.Unlock(c, w.outboxIRI)
+		return err
+	}
+	w.db.Unlock(c, w.outboxIRI)
+	// Unlock must be called by now and every branch above.
+	return removeFromActorCollection(c, actorIRI, ids, colFn, w.db)
+}
+
 // block implements the social Block activity side effects.
 func (w SocialWrappedCallbacks) block(c context.Context, a vocab.ActivityStreamsBlock) error {
 	*w.undeliverable = true
@@ -524,8 +629,130 @@ func (w SocialWrappedCallbacks) block(c context.Context, a vocab.ActivityStreams
 	if op == nil || op.Len() == 0 {
 		return ErrObjectRequired
 	}
+	if w.blocks != nil {
+		if err := w.updateBlocks(c, op, w.blocks.Block); err != nil {
+			return err
+		}
+	}
 	if w.Block != nil {
 		return w.Block(c, a)
 	}
 	return nil
 }
+
+// updateBlocks applies the BlockList function to this actor and each of the
+// blocked objects.
+func (w SocialWrappedCallbacks) updateBlocks(c context.Context, op vocab.ActivityStreamsObjectProperty, fn func(c context.Context, actorIRI, blockedIRI *url.URL) error) error {
+	// Get this actor's IRI.
+	if err := w.db.Lock(c, w.outboxIRI); err != nil {
+		return err
+	}
+	// WARNING: Unlock not deferred.
+	actorIRI, err := w.db.ActorForOutbox(c, w.outboxIRI)
+	if err != nil {
+		w.db.Unlock(c, w.outboxIRI)
+		return err
+	}
+	w.db.Unlock(c, w.outboxIRI)
+	// Unlock must be called by now and every branch above.
+	for iter := op.Begin(); iter != op.End(); iter = iter.Next() {
+		id, err := ToId(iter)
+		if err != nil {
+			return err
+		}
+		if err = fn(c, actorIRI, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// move implements the social Move activity side effects.
+func (w SocialWrappedCallbacks) move(c context.Context, a vocab.ActivityStreamsMove) error {
+	*w.undeliverable = false
+	op := a.GetActivityStreamsObject()
+	if op == nil || op.Len() == 0 {
+		return ErrObjectRequired
+	}
+	target := a.GetActivityStreamsTarget()
+	if target == nil || target.Len() == 0 {
+		return ErrTargetRequired
+	}
+	// Get this actor's IRI.
+	if err := w.db.Lock(c, w.outboxIRI); err != nil {
+		return err
+	}
+	// WARNING: Unlock not deferred.
+	actorIRI, err := w.db.ActorForOutbox(c, w.outboxIRI)
+	if err != nil {
+		w.db.Unlock(c, w.outboxIRI)
+		return err
+	}
+	w.db.Unlock(c, w.outboxIRI)
+	// Unlock must be called by now and every branch above.
+	//
+	// Only this actor may be moved, and only by itself.
+	for iter := op.Begin(); iter != op.End(); iter = iter.Next() {
+		id, err := ToId(iter)
+		if err != nil {
+			return err
+		}
+		if id.String() != actorIRI.String() {
+			return fmt.Errorf("a Move in the outbox of %s cannot move %s", actorIRI, id)
+		}
+	}
+	actors := a.GetActivityStreamsActor()
+	if actors == nil {
+		actors = streams.NewActivityStreamsActorProperty()
+		actors.AppendIRI(actorIRI)
+		a.SetActivityStreamsActor(actors)
+	}
+	for iter := actors.Begin(); iter != actors.End(); iter = iter.Next() {
+		id, err := ToId(iter)
+		if err != nil {
+			return err
+		}
+		if id.String() != actorIRI.String() {
+			return fmt.Errorf("a Move in the outbox of %s cannot have actor %s", actorIRI, id)
+		}
+	}
+	// Address the followers, if they are not already.
+	if err = w.db.Lock(c, actorIRI); err != nil {
+		return err
+	}
+	// WARNING: Unlock not deferred.
+	followers, err := w.db.Followers(c, actorIRI)
+	if err != nil {
+		w.db.Unlock(c, actorIRI)
+		return err
+	}
+	w.db.Unlock(c, actorIRI)
+	// Unlock must be called by now and every branch above.
+	followersId, err := GetId(followers)
+	if err != nil {
+		return err
+	}
+	recipients, err := getRecipients(a)
+	if err != nil {
+		return err
+	}
+	addressed := false
+	for _, r := range recipients {
+		if r.String() == followersId.String() {
+			addressed = true
+			break
+		}
+	}
+	if !addressed {
+		to := a.GetActivityStreamsTo()
+		if to == nil {
+			to = streams.NewActivityStreamsToProperty()
+			a.SetActivityStreamsTo(to)
+		}
+		to.AppendIRI(followersId)
+	}
+	if w.Move != nil {
+		return w.Move(c, a)
+	}
+	return nil
+}
@@ -9,8 +9,8 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
-	"strings"
 	"sync"
+	"time"
 )
 
 const (
@@ -51,10 +51,25 @@ type Transport interface {
 // Transport must be implemented by HttpSigTransport.
 var _ Transport = &HttpSigTransport{}
 
+// RedirectReportingTransport may optionally be implemented by a Transport to
+// report the IRI a dereferenced value was finally served from, after
+// following redirects. FetchAndVerify uses it to ensure the value was served
+// by the host owning its 'id'.
+type RedirectReportingTransport interface {
+	// DereferenceFinalURL fetches the ActivityStreams object located at
+	// this IRI with a GET request, like Dereference, and also returns the
+	// IRI of the response that served it.
+	DereferenceFinalURL(c context.Context, iri *url.URL) (b []byte, finalIRI *url.URL, err error)
+}
+
+// RedirectReportingTransport must be implemented by HttpSigTransport.
+var _ RedirectReportingTransport = &HttpSigTransport{}
+
 // HttpSigTransport makes a dereference call using HTTP signatures to
 // authenticate the request on behalf of a particular actor.
 //
-// No rate limiting is applied.
+// No rate limiting is applied unless it is created with a DeliveryLimiter by
+// NewLimitedHttpSigTransport.
 //
 // Only one request is tried per call.
 type HttpSigTransport struct {
@@ -68,6 +83,7 @@ type HttpSigTransport struct {
 	postSignerMu *sync.Mutex
 	pubKeyId     string
 	privKey      crypto.PrivateKey
+	limiter      *DeliveryLimiter
 }
 
 // NewHttpSigTransport returns a new Transport.
@@ -106,12 +122,49 @@ func NewHttpSigTransport(
 	}
 }
 
+// NewLimitedHttpSigTransport returns a new Transport like NewHttpSigTransport,
+// whose deliveries are subject to the limits of the DeliveryLimiter.
+//
+// Recipients that cannot be delivered to because of a limit, or because the
+// peer responded with 429 Too Many Requests, are reported in a
+// DeliveryDeferredError instead of being attempted or failed. The same
+// DeliveryLimiter should be given to every Transport so that the limits apply
+// across all of them.
+//
+// Actors using it should be created WithDeliveryQueue, which retries the
+// deferred recipients. Without one, deferred recipients are not delivered to.
+func NewLimitedHttpSigTransport(
+	client HttpClient,
+	appAgent string,
+	clock Clock,
+	getSigner, postSigner httpsig.Signer,
+	pubKeyId string,
+	privKey crypto.PrivateKey,
+	limiter *DeliveryLimiter) *HttpSigTransport {
+	t := NewHttpSigTransport(client, appAgent, clock, getSigner, postSigner, pubKeyId, privKey)
+	t.limiter = limiter
+	return t
+}
+
 // Dereference sends a GET request signed with an HTTP Signature to obtain an
 // ActivityStreams value.
+//
+// Returns a *TransportError if the request failed.
 func (h HttpSigTransport) Dereference(c context.Context, iri *url.URL) ([]byte, error) {
+	b, _, err := h.DereferenceFinalURL(c, iri)
+	return b, err
+}
+
+// DereferenceFinalURL sends a GET request signed with an HTTP Signature to
+// obtain an ActivityStreams value, like Dereference, and also returns the IRI
+// of the request that the HttpClient finally got the response for, after
+// following redirects.
+//
+// Returns a *TransportError if the request failed.
+func (h HttpSigTransport) DereferenceFinalURL(c context.Context, iri *url.URL) ([]byte, *url.URL, error) {
 	req, err := http.NewRequest("GET", iri.String(), nil)
 	if err != nil {
-		return nil, err
+		return nil, nil, newRequestError("GET", iri, err, false)
 	}
 	req = req.WithContext(c)
 	req.Header.Add(acceptHeader, acceptHeaderValue)
@@ -122,76 +175,180 @@ func (h HttpSigTransport) Dereference(c context.Context, iri *url.URL) ([]byte,
 	err = h.getSigner.SignRequest(h.privKey, h.pubKeyId, req, nil)
 	h.getSignerMu.Unlock()
 	if err != nil {
-		return nil, err
+		return nil, nil, newRequestError("GET", iri, err, false)
 	}
 	resp, err := h.client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, nil, newRequestError("GET", iri, err, true)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("GET request to %s failed (%d): %s", iri.String(), resp.StatusCode, resp.Status)
+		return nil, nil, newResponseError("GET", iri, resp, h.clock.Now())
+	}
+	// The response's request is the last one made when redirects were
+	// followed. HttpClients that do not set it are assumed not to have
+	// followed any.
+	finalIRI := iri
+	if resp.Request != nil && resp.Request.URL != nil {
+		finalIRI = resp.Request.URL
 	}
-	return ioutil.ReadAll(resp.Body)
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b, finalIRI, nil
 }
 
 // Deliver sends a POST request with an HTTP Signature.
+//
+// The request has both the Digest and Content-Digest headers set to the
+// SHA-256 digest of the body, so the postSigner should sign at least one of
+// them. The body is not given to the postSigner, which would otherwise try to
+// set the Digest header itself.
+//
+// Returns a *DeliveryDeferredError if the delivery was deferred, or a
+// *TransportError if it failed.
 func (h HttpSigTransport) Deliver(c context.Context, b []byte, to *url.URL) error {
+	if h.limiter != nil {
+		release, err := h.limiter.acquireGlobal(c)
+		if err != nil {
+			return newRequestError("POST", to, err, true)
+		}
+		defer release()
+	}
+	return h.deliver(c, b, to)
+}
+
+// deliver sends a POST request with an HTTP Signature, applying only the
+// per-host limits of the DeliveryLimiter.
+func (h HttpSigTransport) deliver(c context.Context, b []byte, to *url.URL) error {
+	if h.limiter != nil {
+		release, retryAfter, err := h.limiter.acquireHost(c, to.Host)
+		if err != nil {
+			return newRequestError("POST", to, err, true)
+		} else if !retryAfter.IsZero() {
+			return &DeliveryDeferredError{
+				Deferred: []DeferredDelivery{{Recipient: to, RetryAfter: retryAfter}},
+			}
+		}
+		defer release()
+	}
 	req, err := http.NewRequest("POST", to.String(), bytes.NewReader(b))
 	if err != nil {
-		return err
+		return newRequestError("POST", to, err, false)
 	}
 	req = req.WithContext(c)
 	req.Header.Add(contentTypeHeader, contentTypeHeaderValue)
 	req.Header.Add("Accept-Charset", "utf-8")
 	req.Header.Add("Date", h.clock.Now().UTC().Format("Mon, 02 Jan 2006 15:04:05")+" GMT")
 	req.Header.Add("User-Agent", fmt.Sprintf("%s %s", h.appAgent, h.gofedAgent))
+	addDigestHeaders(req.Header, b)
 	h.postSignerMu.Lock()
-	err = h.postSigner.SignRequest(h.privKey, h.pubKeyId, req, b)
+	err = h.postSigner.SignRequest(h.privKey, h.pubKeyId, req, nil)
 	h.postSignerMu.Unlock()
 	if err != nil {
-		return err
+		return newRequestError("POST", to, err, false)
 	}
 	resp, err := h.client.Do(req)
 	if err != nil {
-		return err
+		return newRequestError("POST", to, err, true)
 	}
 	defer resp.Body.Close()
-	if !isSuccess(resp.StatusCode) {
-		return fmt.Errorf("POST request to %s failed (%d): %s", to.String(), resp.StatusCode, resp.Status)
+	if retryAfter, ok := h.retryAfter(resp); ok {
+		if h.limiter != nil {
+			h.limiter.deferHost(to.Host, retryAfter)
+		}
+		return &DeliveryDeferredError{
+			Deferred: []DeferredDelivery{{Recipient: to, RetryAfter: retryAfter}},
+		}
+	} else if !isSuccess(resp.StatusCode) {
+		return newResponseError("POST", to, resp, h.clock.Now())
 	}
 	return nil
 }
 
-// BatchDeliver sends concurrent POST requests. Returns an error if any of the
-// requests had an error.
+// retryAfter determines whether the response asks for the request to be
+// retried later, and when.
+//
+// A 429 Too Many Requests response always does, and a 503 Service Unavailable
+// response does if it has a Retry-After header.
+func (h HttpSigTransport) retryAfter(resp *http.Response) (t time.Time, ok bool) {
+	if resp.StatusCode != http.StatusTooManyRequests &&
+		resp.StatusCode != http.StatusServiceUnavailable {
+		return
+	}
+	now := h.clock.Now()
+	if t, ok = parseRetryAfter(resp.Header.Get(retryAfterHeader), now); ok {
+		return
+	} else if resp.StatusCode != http.StatusTooManyRequests {
+		return
+	}
+	d := DefaultRetryAfter
+	if h.limiter != nil {
+		d = h.limiter.limits.RetryAfter
+	}
+	return now.Add(d), true
+}
+
+// BatchDeliver sends concurrent POST requests. Returns a *BatchDeliveryError
+// if any of the requests had an error.
+//
+// If created with a DeliveryLimiter, no more than its MaxConcurrent requests
+// are in flight at once. If any recipients were deferred, a
+// *DeliveryDeferredError is returned that lists them, along with the
+// *BatchDeliveryError of the other recipients that failed.
 func (h HttpSigTransport) BatchDeliver(c context.Context, b []byte, recipients []*url.URL) error {
 	var wg sync.WaitGroup
 	errCh := make(chan error, len(recipients))
-	for _, recipient := range recipients {
+	deferCh := make(chan DeferredDelivery, len(recipients))
+	for i, recipient := range recipients {
+		release := func() {}
+		if h.limiter != nil {
+			var err error
+			release, err = h.limiter.acquireGlobal(c)
+			if err != nil {
+				for _, r := range recipients[i:] {
+					errCh <- newRequestError("POST", r, err, true)
+				}
+				break
+			}
+		}
 		wg.Add(1)
-		go func(r *url.URL) {
+		go func(r *url.URL, release func()) {
 			defer wg.Done()
-			if err := h.Deliver(c, b, r); err != nil {
+			defer release()
+			err := h.deliver(c, b, r)
+			if de, ok := err.(*DeliveryDeferredError); ok {
+				for _, d := range de.Deferred {
+					deferCh <- d
+				}
+			} else if err != nil {
 				errCh <- err
 			}
-		}(recipient)
+		}(recipient, release)
 	}
 	wg.Wait()
-	errs := make([]string, 0, len(recipients))
-outer:
-	for {
-		select {
-		case e := <-errCh:
-			errs = append(errs, e.Error())
-		default:
-			break outer
-		}
+	close(errCh)
+	close(deferCh)
+	var errs []error
+	for e := range errCh {
+		errs = append(errs, e)
 	}
+	var err error
 	if len(errs) > 0 {
-		return fmt.Errorf("batch deliver had at least one failure: %s", strings.Join(errs, "; "))
+		err = &BatchDeliveryError{Errors: errs}
 	}
-	return nil
+	var deferred []DeferredDelivery
+	for d := range deferCh {
+		deferred = append(deferred, d)
+	}
+	if len(deferred) > 0 {
+		return &DeliveryDeferredError{
+			Deferred: deferred,
+			Err:      err,
+		}
+	}
+	return err
 }
 
 // HttpClient sends http requests, and is an abstraction only needed by the
@@ -0,0 +1,128 @@
+package pub
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TransportError is returned by the HttpSigTransport when a request to a peer
+// fails, either because it could not be sent or because the peer responded
+// with an unsuccessful status.
+//
+// It may be obtained from the errors returned by Dereference, Deliver, and
+// BatchDeliver using errors.As.
+type TransportError struct {
+	// Method is the HTTP method of the failed request.
+	Method string
+	// IRI is the IRI the request was sent to.
+	IRI *url.URL
+	// StatusCode is the HTTP status code of the response, or zero if there
+	// was no response.
+	StatusCode int
+	// Status is the HTTP status of the response, if any.
+	Status string
+	// RetryAfter is the time given by the Retry-After header of the
+	// response. It is the zero time if there was no such header.
+	RetryAfter time.Time
+	// Retryable is whether the same request may succeed if tried again
+	// later. It is true for network errors, timeouts, 408 Request Timeout,
+	// 429 Too Many Requests, and 5xx responses.
+	Retryable bool
+	// Err is the error that prevented a response from being received, if
+	// any.
+	Err error
+}
+
+// newResponseError returns the TransportError for an unsuccessful response.
+func newResponseError(method string, iri *url.URL, resp *http.Response, now time.Time) *TransportError {
+	retryAfter, _ := parseRetryAfter(resp.Header.Get(retryAfterHeader), now)
+	return &TransportError{
+		Method:     method,
+		IRI:        iri,
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		RetryAfter: retryAfter,
+		Retryable:  isRetryableStatus(resp.StatusCode),
+	}
+}
+
+// newRequestError returns the TransportError for a request that did not
+// receive a response.
+func newRequestError(method string, iri *url.URL, err error, retryable bool) *TransportError {
+	return &TransportError{
+		Method:    method,
+		IRI:       iri,
+		Retryable: retryable,
+		Err:       err,
+	}
+}
+
+// isRetryableStatus determines whether a request that received the HTTP status
+// code may succeed if tried again later.
+func isRetryableStatus(code int) bool {
+	return code == http.StatusRequestTimeout ||
+		code == http.StatusTooManyRequests ||
+		code >= 500
+}
+
+// Error describes the failed request.
+func (e *TransportError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s request to %s failed: %s", e.Method, e.IRI, e.Err)
+	}
+	return fmt.Sprintf("%s request to %s failed (%d): %s", e.Method, e.IRI, e.StatusCode, e.Status)
+}
+
+// Unwrap returns the error that prevented a response from being received, if
+// any.
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// Gone is whether the peer responded with 410 Gone, meaning that nothing
+// should be sent to the IRI again.
+func (e *TransportError) Gone() bool {
+	return e.StatusCode == http.StatusGone
+}
+
+// BatchDeliveryError is returned by BatchDeliver when delivering to one or
+// more recipients failed.
+//
+// Each of its Errors is a *TransportError naming the recipient, which are
+// returned by Recipients. With Go 1.20 and later they may also be walked with
+// errors.As.
+type BatchDeliveryError struct {
+	// Errors has one error for each recipient that failed.
+	Errors []error
+}
+
+// Error joins the errors of all of the failed recipients.
+func (e *BatchDeliveryError) Error() string {
+	errs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err.Error())
+	}
+	return fmt.Sprintf("batch deliver had at least one failure: %s", strings.Join(errs, "; "))
+}
+
+// Recipients returns the *TransportError of each failed recipient.
+func (e *BatchDeliveryError) Recipients() []*TransportError {
+	r := make([]*TransportError, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		if te, ok := err.(*TransportError); ok {
+			r = append(r, te)
+		}
+	}
+	return r
+}
+
+// Unwrap returns the errors of all of the failed recipients.
+//
+// Only Go 1.20 and later walk these errors in errors.Is and errors.As; use
+// Recipients to obtain them on earlier versions.
+func (e *BatchDeliveryError) Unwrap() []error {
+	return e.Errors
+}
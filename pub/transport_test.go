@@ -2,11 +2,13 @@ package pub
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 )
@@ -52,7 +54,33 @@ func TestHttpSigTransportDereference(t *testing.T) {
 		// Run & Verify
 		b, err := tp.Dereference(ctx, mustParse(testNoteId1))
 		assertEqual(t, len(b), 0)
-		assertEqual(t, err, testErr)
+		assertEqual(t, errors.Is(err, testErr), true)
+		var te *TransportError
+		assertEqual(t, errors.As(err, &te), true)
+		assertEqual(t, te.Retryable, true)
+	})
+	t.Run("ReturnsTransportErrorWithStatus", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		tp, c, hc, gs, _ := httpSigSetupFn(ctl)
+		respR := httptest.NewRecorder()
+		respR.WriteHeader(http.StatusGone)
+		resp := respR.Result()
+		// Mock
+		c.EXPECT().Now().Return(now()).Times(2)
+		gs.EXPECT().SignRequest(testPrivKey, testPubKeyId, gomock.Any(), nil)
+		hc.EXPECT().Do(gomock.Any()).Return(resp, nil)
+		// Run
+		_, err := tp.Dereference(ctx, mustParse(testNoteId1))
+		// Verify
+		var te *TransportError
+		assertEqual(t, errors.As(err, &te), true)
+		assertEqual(t, te.Method, "GET")
+		assertEqual(t, te.IRI.String(), testNoteId1)
+		assertEqual(t, te.StatusCode, http.StatusGone)
+		assertEqual(t, te.Gone(), true)
+		assertEqual(t, te.Retryable, false)
 	})
 	t.Run("Dereferences", func(t *testing.T) {
 		// Setup
@@ -78,6 +106,25 @@ func TestHttpSigTransportDereference(t *testing.T) {
 		assertByteEqual(t, b, testRespBody)
 		assertEqual(t, err, nil)
 	})
+	t.Run("ReportsFinalURLAfterRedirects", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		tp, c, hc, gs, _ := httpSigSetupFn(ctl)
+		respR := httptest.NewRecorder()
+		respR.Write(testRespBody)
+		resp := respR.Result()
+		resp.Request, _ = http.NewRequest("GET", testNoteId2, nil)
+		// Mock
+		c.EXPECT().Now().Return(now())
+		gs.EXPECT().SignRequest(testPrivKey, testPubKeyId, gomock.Any(), nil)
+		hc.EXPECT().Do(gomock.Any()).Return(resp, nil)
+		// Run & Verify
+		b, finalIRI, err := tp.DereferenceFinalURL(ctx, mustParse(testNoteId1))
+		assertByteEqual(t, b, testRespBody)
+		assertEqual(t, finalIRI.String(), testNoteId2)
+		assertEqual(t, err, nil)
+	})
 }
 
 func TestHttpSigTransportDeliver(t *testing.T) {
@@ -95,7 +142,30 @@ func TestHttpSigTransportDeliver(t *testing.T) {
 		hc.EXPECT().Do(gomock.Any()).Return(resp, testErr)
 		// Run & Verify
 		err := tp.Deliver(ctx, testRespBody, mustParse(testNoteId1))
-		assertEqual(t, err, testErr)
+		assertEqual(t, errors.Is(err, testErr), true)
+	})
+	t.Run("ReturnsRetryableTransportErrorOnServerError", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		tp, c, hc, _, ps := httpSigSetupFn(ctl)
+		respR := httptest.NewRecorder()
+		respR.Header().Set(retryAfterHeader, "60")
+		respR.WriteHeader(http.StatusBadGateway)
+		resp := respR.Result()
+		// Mock
+		c.EXPECT().Now().Return(now()).Times(2)
+		ps.EXPECT().SignRequest(testPrivKey, testPubKeyId, gomock.Any(), nil)
+		hc.EXPECT().Do(gomock.Any()).Return(resp, nil)
+		// Run
+		err := tp.Deliver(ctx, testRespBody, mustParse(testFederatedActorIRI))
+		// Verify
+		var te *TransportError
+		assertEqual(t, errors.As(err, &te), true)
+		assertEqual(t, te.Method, "POST")
+		assertEqual(t, te.StatusCode, http.StatusBadGateway)
+		assertEqual(t, te.Retryable, true)
+		assertEqual(t, te.RetryAfter.Equal(now().Add(time.Minute)), true)
 	})
 	t.Run("Delivers", func(t *testing.T) {
 		// Setup
@@ -108,12 +178,80 @@ func TestHttpSigTransportDeliver(t *testing.T) {
 		resp := respR.Result()
 		// Mock
 		c.EXPECT().Now().Return(now())
-		ps.EXPECT().SignRequest(testPrivKey, testPubKeyId, gomock.Any(), testRespBody)
+		ps.EXPECT().SignRequest(testPrivKey, testPubKeyId, gomock.Any(), nil)
+		hc.EXPECT().Do(gomock.Any()).Return(resp, nil)
+		// Run & Verify
+		err := tp.Deliver(ctx, testRespBody, mustParse(testFederatedActorIRI))
+		assertEqual(t, err, nil)
+	})
+	t.Run("SetsDigestHeadersBeforeSigning", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		tp, c, hc, _, ps := httpSigSetupFn(ctl)
+		respR := httptest.NewRecorder()
+		respR.WriteHeader(http.StatusOK)
+		resp := respR.Result()
+		// Mock
+		c.EXPECT().Now().Return(now())
+		ps.EXPECT().SignRequest(testPrivKey, testPubKeyId, gomock.Any(), nil).Do(
+			func(_ interface{}, _ string, r *http.Request, _ []byte) {
+				assertEqual(t, r.Header.Get(digestHeader), "SHA-256=eqvilq+YXa19cTFDbgTozpAcUU4Y40zUXuC6DH8hRZo=")
+				assertEqual(t, r.Header.Get(contentDigestHeader), "sha-256=:eqvilq+YXa19cTFDbgTozpAcUU4Y40zUXuC6DH8hRZo=:")
+			})
 		hc.EXPECT().Do(gomock.Any()).Return(resp, nil)
 		// Run & Verify
 		err := tp.Deliver(ctx, testRespBody, mustParse(testFederatedActorIRI))
 		assertEqual(t, err, nil)
 	})
+	t.Run("DefersOnTooManyRequests", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		tp, c, hc, _, ps := httpSigSetupFn(ctl)
+		respR := httptest.NewRecorder()
+		respR.Header().Set(retryAfterHeader, "30")
+		respR.WriteHeader(http.StatusTooManyRequests)
+		resp := respR.Result()
+		// Mock
+		c.EXPECT().Now().Return(now()).Times(2)
+		ps.EXPECT().SignRequest(testPrivKey, testPubKeyId, gomock.Any(), nil)
+		hc.EXPECT().Do(gomock.Any()).Return(resp, nil)
+		// Run
+		err := tp.Deliver(ctx, testRespBody, mustParse(testFederatedActorIRI))
+		// Verify
+		de, ok := err.(*DeliveryDeferredError)
+		assertEqual(t, ok, true)
+		assertEqual(t, len(de.Deferred), 1)
+		assertEqual(t, de.Deferred[0].Recipient.String(), testFederatedActorIRI)
+		assertEqual(t, de.Deferred[0].RetryAfter.Equal(now().Add(30*time.Second)), true)
+	})
+	t.Run("DefersHostAfterTooManyRequests", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		_, c, hc, gs, ps := httpSigSetupFn(ctl)
+		lc := NewMockClock(ctl)
+		tp := NewLimitedHttpSigTransport(hc, testAppAgent, c, gs, ps, testPubKeyId, testPrivKey, NewDeliveryLimiter(lc, DeliveryLimits{}))
+		respR := httptest.NewRecorder()
+		respR.WriteHeader(http.StatusTooManyRequests)
+		resp := respR.Result()
+		// Mock
+		lc.EXPECT().Now().Return(now()).AnyTimes()
+		c.EXPECT().Now().Return(now()).Times(2)
+		ps.EXPECT().SignRequest(testPrivKey, testPubKeyId, gomock.Any(), nil)
+		hc.EXPECT().Do(gomock.Any()).Return(resp, nil)
+		// Run
+		err := tp.Deliver(ctx, testRespBody, mustParse(testFederatedActorIRI))
+		_, ok := err.(*DeliveryDeferredError)
+		assertEqual(t, ok, true)
+		err = tp.Deliver(ctx, testRespBody, mustParse(testFederatedActorIRI2))
+		// Verify
+		de, ok := err.(*DeliveryDeferredError)
+		assertEqual(t, ok, true)
+		assertEqual(t, de.Deferred[0].Recipient.String(), testFederatedActorIRI2)
+		assertEqual(t, de.Deferred[0].RetryAfter.Equal(now().Add(DefaultRetryAfter)), true)
+	})
 }
 
 func TestHttpSigTransportBatchDeliver(t *testing.T) {
@@ -129,7 +267,7 @@ func TestHttpSigTransportBatchDeliver(t *testing.T) {
 		resp := respR.Result()
 		// Mock
 		c.EXPECT().Now().Return(now()).Times(2)
-		ps.EXPECT().SignRequest(testPrivKey, testPubKeyId, gomock.Any(), testRespBody).Times(2)
+		ps.EXPECT().SignRequest(testPrivKey, testPubKeyId, gomock.Any(), nil).Times(2)
 		hc.EXPECT().Do(gomock.Any()).Return(resp, nil).Times(2)
 		// Run & Verify
 		err := tp.BatchDeliver(ctx, testRespBody, []*url.URL{mustParse(testFederatedActorIRI), mustParse(testFederatedActorIRI2)})
@@ -148,12 +286,46 @@ func TestHttpSigTransportBatchDeliver(t *testing.T) {
 		testErr := fmt.Errorf("test error")
 		// Mock
 		c.EXPECT().Now().Return(now()).Times(2)
-		ps.EXPECT().SignRequest(testPrivKey, testPubKeyId, gomock.Any(), testRespBody).Times(2)
+		ps.EXPECT().SignRequest(testPrivKey, testPubKeyId, gomock.Any(), nil).Times(2)
 		first := hc.EXPECT().Do(gomock.Any()).Return(resp, nil)
 		hc.EXPECT().Do(gomock.Any()).Return(errResp, testErr).After(first)
 		// Run & Verify
 		err := tp.BatchDeliver(ctx, testRespBody, []*url.URL{mustParse(testFederatedActorIRI), mustParse(testFederatedActorIRI2)})
-		assertNotEqual(t, err, nil)
-
+		var be *BatchDeliveryError
+		assertEqual(t, errors.As(err, &be), true)
+		assertEqual(t, len(be.Errors), 1)
+		var te *TransportError
+		assertEqual(t, errors.As(be.Errors[0], &te), true)
+		assertEqual(t, errors.Is(te, testErr), true)
+		recipients := be.Recipients()
+		assertEqual(t, len(recipients), 1)
+		assertEqual(t, recipients[0], te)
+	})
+	t.Run("ReportsDeferredRecipients", func(t *testing.T) {
+		// Setup
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+		_, c, hc, gs, ps := httpSigSetupFn(ctl)
+		lc := NewMockClock(ctl)
+		tp := NewLimitedHttpSigTransport(hc, testAppAgent, c, gs, ps, testPubKeyId, testPrivKey, NewDeliveryLimiter(lc, DeliveryLimits{
+			MaxConcurrent: 1,
+			HostRate:      1,
+		}))
+		respR := httptest.NewRecorder()
+		respR.WriteHeader(http.StatusOK)
+		resp := respR.Result()
+		// Mock
+		lc.EXPECT().Now().Return(now()).AnyTimes()
+		c.EXPECT().Now().Return(now())
+		ps.EXPECT().SignRequest(testPrivKey, testPubKeyId, gomock.Any(), nil)
+		hc.EXPECT().Do(gomock.Any()).Return(resp, nil)
+		// Run
+		err := tp.BatchDeliver(ctx, testRespBody, []*url.URL{mustParse(testFederatedActorIRI), mustParse(testFederatedActorIRI2)})
+		// Verify
+		de, ok := err.(*DeliveryDeferredError)
+		assertEqual(t, ok, true)
+		assertEqual(t, de.Err, nil)
+		assertEqual(t, len(de.Deferred), 1)
+		assertEqual(t, de.Deferred[0].RetryAfter.Equal(now().Add(time.Second)), true)
 	})
 }
@@ -1,10 +1,7 @@
 package pub
 
 import (
-	"bytes"
 	"context"
-	"crypto/sha256"
-	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -25,6 +22,12 @@ var (
 	// set. Can be returned by DelegateActor's PostInbox or PostOutbox so a
 	// Bad Request response is set.
 	ErrTargetRequired = errors.New("target property required on the provided activity")
+	// ErrFollowNotPending indicates the Follow given to AcceptFollow or
+	// RejectFollow is not awaiting approval.
+	ErrFollowNotPending = errors.New("follow is not pending approval")
+	// ErrSignerNotActor indicates the HTTP Signature on a request was made
+	// with the key of an actor other than the actor of its activity.
+	ErrSignerNotActor = errors.New("http signature is not by the actor of the activity")
 )
 
 // activityStreamsMediaTypes contains all of the accepted ActivityStreams media
@@ -132,18 +135,12 @@ const (
 )
 
 // addResponseHeaders sets headers needed in the HTTP response, such but not
-// limited to the Content-Type, Date, and Digest headers.
+// limited to the Content-Type, Date, Digest, and Content-Digest headers.
 func addResponseHeaders(h http.Header, c Clock, responseContent []byte) {
 	h.Set(contentTypeHeader, contentTypeHeaderValue)
 	// RFC 7231 §7.1.1.2
 	h.Set(dateHeader, c.Now().UTC().Format("Mon, 02 Jan 2006 15:04:05")+" GMT")
-	// RFC 3230 and RFC 5843
-	var b bytes.Buffer
-	b.WriteString(sha256Digest)
-	b.WriteString(digestDelimiter)
-	hashed := sha256.Sum256(responseContent)
-	b.WriteString(base64.StdEncoding.EncodeToString(hashed[:]))
-	h.Set(digestHeader, b.String())
+	addDigestHeaders(h, responseContent)
 }
 
 // IdProperty is a property that can readily have its id obtained
@@ -738,58 +735,62 @@ func toTombstone(obj vocab.Type, id *url.URL, now time.Time) vocab.ActivityStrea
 
 // mustHaveActivityActorsMatchObjectActors ensures that the actors on types in
 // the 'object' property are all listed in the 'actor' property.
+//
+// The dereferenced 'object' values are returned in order.
 func mustHaveActivityActorsMatchObjectActors(c context.Context,
 	actors vocab.ActivityStreamsActorProperty,
 	op vocab.ActivityStreamsObjectProperty,
 	newTransport func(c context.Context, actorBoxIRI *url.URL, gofedAgent string) (t Transport, err error),
-	boxIRI *url.URL) error {
+	boxIRI *url.URL) ([]vocab.Type, error) {
 	activityActorMap := make(map[string]bool, actors.Len())
 	for iter := actors.Begin(); iter != actors.End(); iter = iter.Next() {
 		id, err := ToId(iter)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		activityActorMap[id.String()] = true
 	}
+	objs := make([]vocab.Type, 0, op.Len())
 	for iter := op.Begin(); iter != op.End(); iter = iter.Next() {
 		iri, err := ToId(iter)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		// Attempt to dereference the IRI, regardless whether it is a
 		// type or IRI
 		tport, err := newTransport(c, boxIRI, goFedUserAgent())
 		if err != nil {
-			return err
+			return nil, err
 		}
 		b, err := tport.Dereference(c, iri)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		var m map[string]interface{}
 		if err = json.Unmarshal(b, &m); err != nil {
-			return err
+			return nil, err
 		}
 		t, err := streams.ToType(c, m)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		ac, ok := t.(actorer)
 		if !ok {
-			return fmt.Errorf("cannot verify actors: object value has no 'actor' property")
+			return nil, fmt.Errorf("cannot verify actors: object value has no 'actor' property")
 		}
 		objActors := ac.GetActivityStreamsActor()
 		for iter := objActors.Begin(); iter != objActors.End(); iter = iter.Next() {
 			id, err := ToId(iter)
 			if err != nil {
-				return err
+				return nil, err
 			}
 			if !activityActorMap[id.String()] {
-				return fmt.Errorf("activity does not have all actors from its object's actors")
+				return nil, fmt.Errorf("activity does not have all actors from its object's actors")
 			}
 		}
+		objs = append(objs, t)
 	}
-	return nil
+	return objs, nil
 }
 
 // add implements the logic of adding object ids to a target Collection or
@@ -911,61 +912,433 @@ func remove(c context.Context,
 		if err != nil {
 			return err
 		}
-		if streams.IsOrExtendsActivityStreamsOrderedCollection(tp) {
-			oi, ok := tp.(orderedItemser)
-			if !ok {
-				return fmt.Errorf("type extending from OrderedCollection cannot convert to orderedItemser interface")
+		if err = removeCollectionItems(tp, opIds); err != nil {
+			return err
+		}
+		err = db.Update(c, tp)
+		if err != nil {
+			return err
+		}
+		return nil
+	}
+	for _, t := range targetIds {
+		if err := loopFn(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeCollectionItems removes the ids in the given set from the items of a
+// Collection or OrderedCollection.
+func removeCollectionItems(tp vocab.Type, ids map[string]bool) error {
+	if streams.IsOrExtendsActivityStreamsOrderedCollection(tp) {
+		oi, ok := tp.(orderedItemser)
+		if !ok {
+			return fmt.Errorf("type extending from OrderedCollection cannot convert to orderedItemser interface")
+		}
+		oiProp := oi.GetActivityStreamsOrderedItems()
+		if oiProp != nil {
+			for i := 0; i < oiProp.Len(); /*Conditional*/ {
+				id, err := ToId(oiProp.At(i))
+				if err != nil {
+					return err
+				}
+				if ids[id.String()] {
+					oiProp.Remove(i)
+				} else {
+					i++
+				}
 			}
-			oiProp := oi.GetActivityStreamsOrderedItems()
-			if oiProp != nil {
-				for i := 0; i < oiProp.Len(); /*Conditional*/ {
-					id, err := ToId(oiProp.At(i))
-					if err != nil {
-						return err
-					}
-					if opIds[id.String()] {
-						oiProp.Remove(i)
-					} else {
-						i++
-					}
+		}
+	} else if streams.IsOrExtendsActivityStreamsCollection(tp) {
+		i, ok := tp.(itemser)
+		if !ok {
+			return fmt.Errorf("type extending from Collection cannot convert to itemser interface")
+		}
+		iProp := i.GetActivityStreamsItems()
+		if iProp != nil {
+			for i := 0; i < iProp.Len(); /*Conditional*/ {
+				id, err := ToId(iProp.At(i))
+				if err != nil {
+					return err
+				}
+				if ids[id.String()] {
+					iProp.Remove(i)
+				} else {
+					i++
 				}
 			}
-		} else if streams.IsOrExtendsActivityStreamsCollection(tp) {
-			i, ok := tp.(itemser)
-			if !ok {
-				return fmt.Errorf("type extending from Collection cannot convert to itemser interface")
+		}
+	} else {
+		return fmt.Errorf("type %T is neither a Collection nor an OrderedCollection", tp)
+	}
+	return nil
+}
+
+// removeFromOwnedObjects removes the activity's id from a collection embedded
+// on each 'object' owned by this server. The collection is obtained by colFn,
+// which returns nil if the object has no such collection. This reverses the
+// side effects of adding to the 'likes' or 'shares' collections.
+func removeFromOwnedObjects(c context.Context,
+	op vocab.ActivityStreamsObjectProperty,
+	activityId *url.URL,
+	colFn func(t vocab.Type) vocab.Type,
+	db Database) error {
+	if op == nil {
+		return nil
+	}
+	ids := map[string]bool{activityId.String(): true}
+	// Create anonymous loop function to be able to properly scope the defer
+	// for the database lock at each iteration.
+	loopFn := func(iter vocab.ActivityStreamsObjectPropertyIterator) error {
+		objId, err := ToId(iter)
+		if err != nil {
+			return err
+		}
+		if err := db.Lock(c, objId); err != nil {
+			return err
+		}
+		defer db.Unlock(c, objId)
+		if owns, err := db.Owns(c, objId); err != nil {
+			return err
+		} else if !owns {
+			return nil
+		}
+		t, err := db.Get(c, objId)
+		if err != nil {
+			return err
+		}
+		col := colFn(t)
+		if col == nil {
+			return nil
+		}
+		if err = removeCollectionItems(col, ids); err != nil {
+			return err
+		}
+		return db.Update(c, t)
+	}
+	for iter := op.Begin(); iter != op.End(); iter = iter.Next() {
+		if err := loopFn(iter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// likesCollection returns the value of the 'likes' property, if any.
+func likesCollection(t vocab.Type) vocab.Type {
+	l, ok := t.(likeser)
+	if !ok || l.GetActivityStreamsLikes() == nil {
+		return nil
+	}
+	return l.GetActivityStreamsLikes().GetType()
+}
+
+// sharesCollection returns the value of the 'shares' property, if any.
+func sharesCollection(t vocab.Type) vocab.Type {
+	s, ok := t.(shareser)
+	if !ok || s.GetActivityStreamsShares() == nil {
+		return nil
+	}
+	return s.GetActivityStreamsShares().GetType()
+}
+
+// repliesCollection returns the value of the 'replies' property, if any.
+func repliesCollection(t vocab.Type) vocab.Type {
+	r, ok := t.(replieser)
+	if !ok || r.GetActivityStreamsReplies() == nil {
+		return nil
+	}
+	return r.GetActivityStreamsReplies().GetType()
+}
+
+// getInReplyToIds returns the ids in the 'inReplyTo' property of the value.
+func getInReplyToIds(t vocab.Type) (ids []*url.URL, err error) {
+	irt, ok := t.(inReplyToer)
+	if !ok || irt.GetActivityStreamsInReplyTo() == nil {
+		return
+	}
+	p := irt.GetActivityStreamsInReplyTo()
+	for iter := p.Begin(); iter != p.End(); iter = iter.Next() {
+		var id *url.URL
+		id, err = ToId(iter)
+		if err != nil {
+			return
+		}
+		ids = append(ids, id)
+	}
+	return
+}
+
+// addToReplies prepends the id of the reply to the 'replies' collection of
+// each object owned by this server that it is 'inReplyTo'. Votes on Questions
+// are not added.
+func addToReplies(c context.Context, reply vocab.Type, db Database) error {
+	replyId, err := GetId(reply)
+	if err != nil {
+		return err
+	}
+	parentIds, err := getInReplyToIds(reply)
+	if err != nil {
+		return err
+	}
+	_, _, isVote := getVote(reply)
+	// Create anonymous loop function to be able to properly scope the defer
+	// for the database lock at each iteration.
+	loopFn := func(parentId *url.URL) error {
+		if err := db.Lock(c, parentId); err != nil {
+			return err
+		}
+		defer db.Unlock(c, parentId)
+		if owns, err := db.Owns(c, parentId); err != nil {
+			return err
+		} else if !owns {
+			return nil
+		}
+		t, err := db.Get(c, parentId)
+		if err != nil {
+			return err
+		}
+		if isVote && streams.IsOrExtendsActivityStreamsQuestion(t) {
+			return nil
+		}
+		r, ok := t.(replieser)
+		if !ok {
+			return fmt.Errorf("cannot add reply to replies collection for type %T", t)
+		}
+		// Get 'replies' property on the object, creating default if
+		// necessary.
+		replies := r.GetActivityStreamsReplies()
+		if replies == nil {
+			replies = streams.NewActivityStreamsRepliesProperty()
+			r.SetActivityStreamsReplies(replies)
+		}
+		// Get 'replies' value, defaulting to a collection.
+		repliesT := replies.GetType()
+		if repliesT == nil {
+			col := streams.NewActivityStreamsCollection()
+			repliesT = col
+			replies.SetActivityStreamsCollection(col)
+		}
+		// Prepend the reply's 'id' on the 'replies' Collection or
+		// OrderedCollection.
+		if col, ok := repliesT.(itemser); ok {
+			items := col.GetActivityStreamsItems()
+			if items == nil {
+				items = streams.NewActivityStreamsItemsProperty()
+				col.SetActivityStreamsItems(items)
 			}
-			iProp := i.GetActivityStreamsItems()
-			if iProp != nil {
-				for i := 0; i < iProp.Len(); /*Conditional*/ {
-					id, err := ToId(iProp.At(i))
-					if err != nil {
-						return err
-					}
-					if opIds[id.String()] {
-						iProp.Remove(i)
-					} else {
-						i++
-					}
-				}
+			items.PrependIRI(replyId)
+		} else if oCol, ok := repliesT.(orderedItemser); ok {
+			oItems := oCol.GetActivityStreamsOrderedItems()
+			if oItems == nil {
+				oItems = streams.NewActivityStreamsOrderedItemsProperty()
+				oCol.SetActivityStreamsOrderedItems(oItems)
 			}
+			oItems.PrependIRI(replyId)
 		} else {
-			return fmt.Errorf("target in Remove is neither a Collection nor an OrderedCollection")
+			return fmt.Errorf("replies type is neither a Collection nor an OrderedCollection: %T", repliesT)
 		}
-		err = db.Update(c, tp)
+		return db.Update(c, t)
+	}
+	for _, parentId := range parentIds {
+		if err := loopFn(parentId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeFromReplies removes the id of the reply from the 'replies' collection
+// of each object owned by this server that it is 'inReplyTo'.
+func removeFromReplies(c context.Context, reply vocab.Type, db Database) error {
+	replyId, err := GetId(reply)
+	if err != nil {
+		return err
+	}
+	parentIds, err := getInReplyToIds(reply)
+	if err != nil {
+		return err
+	}
+	ids := map[string]bool{replyId.String(): true}
+	// Create anonymous loop function to be able to properly scope the defer
+	// for the database lock at each iteration.
+	loopFn := func(parentId *url.URL) error {
+		if err := db.Lock(c, parentId); err != nil {
+			return err
+		}
+		defer db.Unlock(c, parentId)
+		if owns, err := db.Owns(c, parentId); err != nil {
+			return err
+		} else if !owns {
+			return nil
+		}
+		t, err := db.Get(c, parentId)
 		if err != nil {
 			return err
 		}
-		return nil
+		col := repliesCollection(t)
+		if col == nil {
+			return nil
+		}
+		if err = removeCollectionItems(col, ids); err != nil {
+			return err
+		}
+		return db.Update(c, t)
 	}
-	for _, t := range targetIds {
-		if err := loopFn(t); err != nil {
+	for _, parentId := range parentIds {
+		if err := loopFn(parentId); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// removeFromActorCollection removes the ids from one of this server's actor's
+// collections, such as 'followers', 'following', or 'liked'. The colFn obtains
+// the collection and is called while holding the lock on the actor.
+func removeFromActorCollection(c context.Context,
+	actorIRI *url.URL,
+	ids []*url.URL,
+	colFn func(c context.Context, actorIRI *url.URL) (vocab.ActivityStreamsCollection, error),
+	db Database) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	idSet := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		idSet[id.String()] = true
+	}
+	if err := db.Lock(c, actorIRI); err != nil {
+		return err
+	}
+	defer db.Unlock(c, actorIRI)
+	col, err := colFn(c, actorIRI)
+	if err != nil {
+		return err
+	}
+	if err = removeCollectionItems(col, idSet); err != nil {
+		return err
+	}
+	return db.Update(c, col)
+}
+
+// newFollow builds a Follow of the target by the actor, addressed to the
+// target.
+func newFollow(actorIRI, target *url.URL) vocab.ActivityStreamsFollow {
+	follow := streams.NewActivityStreamsFollow()
+	me := streams.NewActivityStreamsActorProperty()
+	follow.SetActivityStreamsActor(me)
+	me.AppendIRI(actorIRI)
+	op := streams.NewActivityStreamsObjectProperty()
+	follow.SetActivityStreamsObject(op)
+	op.AppendIRI(target)
+	to := streams.NewActivityStreamsToProperty()
+	follow.SetActivityStreamsTo(to)
+	to.AppendIRI(target)
+	return follow
+}
+
+// newUndo builds an Undo of the Follow by the actor, addressed to the
+// recipient.
+func newUndo(actorIRI *url.URL, follow vocab.ActivityStreamsFollow, to *url.URL) vocab.ActivityStreamsUndo {
+	undo := streams.NewActivityStreamsUndo()
+	me := streams.NewActivityStreamsActorProperty()
+	undo.SetActivityStreamsActor(me)
+	me.AppendIRI(actorIRI)
+	op := streams.NewActivityStreamsObjectProperty()
+	undo.SetActivityStreamsObject(op)
+	op.AppendActivityStreamsFollow(follow)
+	toProp := streams.NewActivityStreamsToProperty()
+	undo.SetActivityStreamsTo(toProp)
+	toProp.AppendIRI(to)
+	return undo
+}
+
+// findOutboxFollow finds the newest Follow of the object in the outbox, which
+// the actor sent when it began following the object, if the Database is an
+// OutboxFollowFinder.
+//
+// If no Follow is found, a new one without an id is returned, which peers
+// are still able to match by its actor and object.
+func findOutboxFollow(c context.Context, db Database, outboxIRI, actorIRI, object *url.URL) (vocab.ActivityStreamsFollow, error) {
+	finder, ok := db.(OutboxFollowFinder)
+	if !ok {
+		return newFollow(actorIRI, object), nil
+	}
+	if err := db.Lock(c, outboxIRI); err != nil {
+		return nil, err
+	}
+	// WARNING: Unlock not deferred.
+	follow, err := finder.FindOutboxFollow(c, outboxIRI, object)
+	db.Unlock(c, outboxIRI)
+	// Unlock must be called by now and every branch above.
+	if err != nil {
+		return nil, err
+	} else if follow == nil {
+		return newFollow(actorIRI, object), nil
+	}
+	return follow, nil
+}
+
+// newFollowResponse builds the Accept, or Reject, of the Follow by the actor.
+// It is addressed to the actors of the Follow, which are also returned.
+func newFollowResponse(actorIRI *url.URL, follow vocab.ActivityStreamsFollow, accept bool) (response Activity, followers []*url.URL, err error) {
+	if accept {
+		response = streams.NewActivityStreamsAccept()
+	} else {
+		response = streams.NewActivityStreamsReject()
+	}
+	// Set us as the 'actor'.
+	me := streams.NewActivityStreamsActorProperty()
+	response.SetActivityStreamsActor(me)
+	me.AppendIRI(actorIRI)
+	// Set the Follow as the 'object' property.
+	op := streams.NewActivityStreamsObjectProperty()
+	response.SetActivityStreamsObject(op)
+	op.AppendActivityStreamsFollow(follow)
+	// Add all actors on the original Follow to the 'to' property.
+	to := streams.NewActivityStreamsToProperty()
+	response.SetActivityStreamsTo(to)
+	followActors := follow.GetActivityStreamsActor()
+	if followActors == nil {
+		return nil, nil, fmt.Errorf("a Follow has no actors")
+	}
+	for iter := followActors.Begin(); iter != followActors.End(); iter = iter.Next() {
+		var id *url.URL
+		id, err = ToId(iter)
+		if err != nil {
+			return
+		}
+		to.AppendIRI(id)
+		followers = append(followers, id)
+	}
+	return
+}
+
+// addFollowers prepends the new followers to the actor's 'followers'
+// collection.
+func addFollowers(c context.Context, db Database, actorIRI *url.URL, followers []*url.URL) error {
+	if err := db.Lock(c, actorIRI); err != nil {
+		return err
+	}
+	defer db.Unlock(c, actorIRI)
+	col, err := db.Followers(c, actorIRI)
+	if err != nil {
+		return err
+	}
+	items := col.GetActivityStreamsItems()
+	if items == nil {
+		items = streams.NewActivityStreamsItemsProperty()
+		col.SetActivityStreamsItems(items)
+	}
+	for _, elem := range followers {
+		items.PrependIRI(elem)
+	}
+	return db.Update(c, col)
+}
+
 // clearSensitiveFields removes the 'bto' and 'bcc' entries on the given value
 // and recursively on every 'object' property value.
 func clearSensitiveFields(obj vocab.Type) {
@@ -993,3 +1366,168 @@ func requestId(r *http.Request) *url.URL {
 	id.Scheme = "https"
 	return id
 }
+
+// alsoKnownAsProperty is the 'alsoKnownAs' property of actors, which lists the
+// other ids of a migrated account. It is not natively supported by the streams
+// package.
+const alsoKnownAsProperty = "alsoKnownAs"
+
+// getAlsoKnownAs extracts the 'alsoKnownAs' IRIs of an actor type. Entries
+// that are not IRIs are ignored.
+func getAlsoKnownAs(t vocab.Type) (u []*url.URL) {
+	up, ok := t.(unknownPropertieser)
+	if !ok {
+		return nil
+	}
+	var values []interface{}
+	switch v := up.GetUnknownProperties()[alsoKnownAsProperty].(type) {
+	case string:
+		values = []interface{}{v}
+	case []interface{}:
+		values = v
+	}
+	for _, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		iri, err := url.Parse(s)
+		if err != nil {
+			continue
+		}
+		u = append(u, iri)
+	}
+	return
+}
+
+// getVote determines whether the object is a vote on a Question: a Note with a
+// 'name', naming the option voted for, in reply to the Question.
+func getVote(t vocab.Type) (questionIRI *url.URL, name string, ok bool) {
+	note, isNote := t.(vocab.ActivityStreamsNote)
+	if !isNote {
+		return
+	}
+	name, ok = getName(note)
+	if !ok {
+		return
+	}
+	irt := note.GetActivityStreamsInReplyTo()
+	if irt == nil || irt.Len() != 1 {
+		return nil, "", false
+	}
+	questionIRI, err := ToId(irt.At(0))
+	if err != nil {
+		return nil, "", false
+	}
+	return questionIRI, name, true
+}
+
+// getName returns the first plain string 'name' of the value, if any.
+func getName(t vocab.Type) (string, bool) {
+	n, ok := t.(namer)
+	if !ok || n.GetActivityStreamsName() == nil {
+		return "", false
+	}
+	name := n.GetActivityStreamsName()
+	for iter := name.Begin(); iter != name.End(); iter = iter.Next() {
+		if iter.IsXMLSchemaString() {
+			return iter.GetXMLSchemaString(), true
+		}
+	}
+	return "", false
+}
+
+// isQuestionClosed determines whether the Question no longer accepts votes,
+// because it is 'closed' or its 'endTime' has passed.
+//
+// A 'closed' value of false, or a dateTime still in the future, leaves the
+// Question open. Any other value, such as an object or IRI, closes it.
+func isQuestionClosed(q vocab.ActivityStreamsQuestion, now time.Time) bool {
+	if closed := q.GetActivityStreamsClosed(); closed != nil {
+		for iter := closed.Begin(); iter != closed.End(); iter = iter.Next() {
+			if iter.IsXMLSchemaBoolean() {
+				if iter.GetXMLSchemaBoolean() {
+					return true
+				}
+			} else if iter.IsXMLSchemaDateTime() {
+				if !now.Before(iter.GetXMLSchemaDateTime()) {
+					return true
+				}
+			} else {
+				return true
+			}
+		}
+	}
+	endTime := q.GetActivityStreamsEndTime()
+	return endTime != nil && endTime.IsXMLSchemaDateTime() && !now.Before(endTime.Get())
+}
+
+// getQuestionOption returns the embedded 'oneOf' or 'anyOf' option of the
+// Question with the name, and whether the Question allows a single choice.
+func getQuestionOption(q vocab.ActivityStreamsQuestion, name string) (option vocab.Type, oneOf bool) {
+	if p := q.GetActivityStreamsOneOf(); p != nil {
+		for iter := p.Begin(); iter != p.End(); iter = iter.Next() {
+			if n, ok := getName(iter.GetType()); ok && n == name {
+				return iter.GetType(), true
+			}
+		}
+	}
+	if p := q.GetActivityStreamsAnyOf(); p != nil {
+		for iter := p.Begin(); iter != p.End(); iter = iter.Next() {
+			if n, ok := getName(iter.GetType()); ok && n == name {
+				return iter.GetType(), false
+			}
+		}
+	}
+	return nil, false
+}
+
+// addQuestionVote increments the 'totalItems' of the 'replies' collection of
+// the option, creating it if needed. If the vote is the voter's first, the
+// 'votersCount' of the Question is incremented too.
+func addQuestionVote(q vocab.ActivityStreamsQuestion, option vocab.Type, firstVote bool) error {
+	r, ok := option.(replieser)
+	if !ok {
+		return fmt.Errorf("cannot tally vote: option type %T has no replies", option)
+	}
+	replies := r.GetActivityStreamsReplies()
+	if replies == nil {
+		replies = streams.NewActivityStreamsRepliesProperty()
+		r.SetActivityStreamsReplies(replies)
+	}
+	if replies.GetType() == nil {
+		replies.SetActivityStreamsCollection(streams.NewActivityStreamsCollection())
+	}
+	col, ok := replies.GetType().(totalItemser)
+	if !ok {
+		return fmt.Errorf("cannot tally vote: replies type %T has no totalItems", replies.GetType())
+	}
+	incrementTotalItems(col)
+	if firstVote {
+		votersCount := q.GetTootVotersCount()
+		if votersCount == nil {
+			votersCount = streams.NewTootVotersCountProperty()
+			q.SetTootVotersCount(votersCount)
+		}
+		n := 0
+		if votersCount.IsXMLSchemaNonNegativeInteger() {
+			n = votersCount.Get()
+		}
+		votersCount.Set(n + 1)
+	}
+	return nil
+}
+
+// incrementTotalItems adds one to the 'totalItems' of the collection.
+func incrementTotalItems(col totalItemser) {
+	totalItems := col.GetActivityStreamsTotalItems()
+	if totalItems == nil {
+		totalItems = streams.NewActivityStreamsTotalItemsProperty()
+		col.SetActivityStreamsTotalItems(totalItems)
+	}
+	n := 0
+	if totalItems.IsXMLSchemaNonNegativeInteger() {
+		n = totalItems.Get()
+	}
+	totalItems.Set(n + 1)
+}
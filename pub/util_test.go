@@ -2,6 +2,10 @@ package pub
 
 import (
 	"testing"
+	"time"
+
+	"github.com/go-fed/activity/streams"
+	"github.com/go-fed/activity/streams/vocab"
 )
 
 func TestHeaderIsActivityPubMediaType(t *testing.T) {
@@ -74,3 +78,55 @@ func TestHeaderIsActivityPubMediaType(t *testing.T) {
 		})
 	}
 }
+
+func TestIsQuestionClosed(t *testing.T) {
+	tests := []struct {
+		name     string
+		closed   func(p vocab.ActivityStreamsClosedProperty)
+		expected bool
+	}{
+		{
+			"No Closed",
+			nil,
+			false,
+		},
+		{
+			"Closed True",
+			func(p vocab.ActivityStreamsClosedProperty) { p.AppendXMLSchemaBoolean(true) },
+			true,
+		},
+		{
+			"Closed False",
+			func(p vocab.ActivityStreamsClosedProperty) { p.AppendXMLSchemaBoolean(false) },
+			false,
+		},
+		{
+			"Closed In The Past",
+			func(p vocab.ActivityStreamsClosedProperty) { p.AppendXMLSchemaDateTime(now().Add(-time.Hour)) },
+			true,
+		},
+		{
+			"Closed In The Future",
+			func(p vocab.ActivityStreamsClosedProperty) { p.AppendXMLSchemaDateTime(now().Add(time.Hour)) },
+			false,
+		},
+		{
+			"Closed By IRI",
+			func(p vocab.ActivityStreamsClosedProperty) { p.AppendIRI(mustParse(testNoteId1)) },
+			true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q := streams.NewActivityStreamsQuestion()
+			if test.closed != nil {
+				p := streams.NewActivityStreamsClosedProperty()
+				test.closed(p)
+				q.SetActivityStreamsClosed(p)
+			}
+			if actual := isQuestionClosed(q, now()); actual != test.expected {
+				t.Fatalf("expected %v, got %v", test.expected, actual)
+			}
+		})
+	}
+}
@@ -13,3 +13,8 @@ const (
 func goFedUserAgent() string {
 	return fmt.Sprintf("(go-fed/activity %s)", version)
 }
+
+// Version returns the version of the go-fed library, such as "v1.0.0".
+func Version() string {
+	return version
+}
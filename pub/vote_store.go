@@ -0,0 +1,63 @@
+package pub
+
+import (
+	"context"
+	"net/url"
+	"sync"
+)
+
+// VoteStore records the votes of peers on the Questions owned by this server,
+// so that each actor's vote is only tallied once.
+//
+// The library calls it while holding the lock on the Question.
+type VoteStore interface {
+	// AddVote records the voter's vote for the named option of the
+	// Question.
+	AddVote(c context.Context, questionIRI, voterIRI *url.URL, name string) error
+	// Votes returns the names of the options of the Question the voter
+	// voted for, in the order they were voted for.
+	Votes(c context.Context, questionIRI, voterIRI *url.URL) (names []string, err error)
+}
+
+// VoteStore must be implemented by MemoryVoteStore.
+var _ VoteStore = &MemoryVoteStore{}
+
+// MemoryVoteStore is a VoteStore that keeps votes in memory.
+//
+// Votes do not survive restarts of the application. It is safe for concurrent
+// use.
+type MemoryVoteStore struct {
+	mu sync.Mutex
+	// votes maps Question ids to voter ids to the names of the options
+	// voted for.
+	votes map[string]map[string][]string
+}
+
+// NewMemoryVoteStore returns an empty MemoryVoteStore.
+func NewMemoryVoteStore() *MemoryVoteStore {
+	return &MemoryVoteStore{
+		votes: make(map[string]map[string][]string),
+	}
+}
+
+// AddVote records the voter's vote for the named option.
+func (m *MemoryVoteStore) AddVote(c context.Context, questionIRI, voterIRI *url.URL, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k := questionIRI.String()
+	voters, ok := m.votes[k]
+	if !ok {
+		voters = make(map[string][]string)
+		m.votes[k] = voters
+	}
+	voters[voterIRI.String()] = append(voters[voterIRI.String()], name)
+	return nil
+}
+
+// Votes returns the names of the options the voter voted for.
+func (m *MemoryVoteStore) Votes(c context.Context, questionIRI, voterIRI *url.URL) (names []string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names = append(names, m.votes[questionIRI.String()][voterIRI.String()]...)
+	return
+}
@@ -0,0 +1,34 @@
+package pub
+
+import (
+	"context"
+	"testing"
+)
+
+// TestMemoryVoteStore tests recording and listing votes.
+func TestMemoryVoteStore(t *testing.T) {
+	ctx := context.Background()
+	questionIRI := mustParse(testNoteId1)
+	t.Run("ListsVotesInOrder", func(t *testing.T) {
+		s := NewMemoryVoteStore()
+		err := s.AddVote(ctx, questionIRI, mustParse(testFederatedActorIRI), "yes")
+		assertEqual(t, err, nil)
+		err = s.AddVote(ctx, questionIRI, mustParse(testFederatedActorIRI), "maybe")
+		assertEqual(t, err, nil)
+		names, err := s.Votes(ctx, questionIRI, mustParse(testFederatedActorIRI))
+		assertEqual(t, err, nil)
+		assertEqual(t, len(names), 2)
+		assertEqual(t, names[0], "yes")
+		assertEqual(t, names[1], "maybe")
+	})
+	t.Run("SeparatesVotersAndQuestions", func(t *testing.T) {
+		s := NewMemoryVoteStore()
+		s.AddVote(ctx, questionIRI, mustParse(testFederatedActorIRI), "yes")
+		names, err := s.Votes(ctx, questionIRI, mustParse(testFederatedActorIRI2))
+		assertEqual(t, err, nil)
+		assertEqual(t, len(names), 0)
+		names, err = s.Votes(ctx, mustParse(testNoteId2), mustParse(testFederatedActorIRI))
+		assertEqual(t, err, nil)
+		assertEqual(t, len(names), 0)
+	})
+}
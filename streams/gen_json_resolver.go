@@ -218,6 +218,9 @@ func toAliasMap(i interface{}) (m map[string]string) {
 // each one in order and apply only the first one. It returns an unhandled
 // error for a multi-typed object if none of the types were able to be handled.
 func (this JSONResolver) Resolve(ctx context.Context, m map[string]interface{}) error {
+	if err := checkLimits(ctx, m); err != nil {
+		return err
+	}
 	typeValue, ok := m["type"]
 	if !ok {
 		return fmt.Errorf("cannot determine ActivityStreams type: 'type' property is missing")
@@ -0,0 +1,90 @@
+package streams
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrMaxDepthExceeded indicates a JSON-deserialized value nests
+	// objects and arrays deeper than allowed by the Limits.
+	ErrMaxDepthExceeded = errors.New("maximum JSON nesting depth exceeded")
+	// ErrMaxItemsExceeded indicates a JSON-deserialized value has an array
+	// with more items than allowed by the Limits.
+	ErrMaxItemsExceeded = errors.New("maximum number of JSON array items exceeded")
+)
+
+// Limits bounds the JSON-deserialized values resolved by a JSONResolver, so
+// that a hostile peer cannot send values that are expensive to deserialize.
+//
+// A zero field means that aspect of the value is not limited.
+type Limits struct {
+	// MaxDepth is the maximum nesting depth of the JSON objects and arrays
+	// in the value, which is itself at depth one.
+	MaxDepth int
+	// MaxItems is the maximum number of items in each JSON array of the
+	// value, such as the values of a non-functional property.
+	MaxItems int
+}
+
+// limitsKey is the context key of the Limits.
+type limitsKey struct{}
+
+// WithLimits returns a copy of the context carrying the Limits. They are
+// enforced by the Resolve method of the JSONResolver, and so by ToType, when
+// given the context.
+func WithLimits(ctx context.Context, l Limits) context.Context {
+	return context.WithValue(ctx, limitsKey{}, l)
+}
+
+// LimitsFromContext returns the Limits carried by the context, if any.
+func LimitsFromContext(ctx context.Context) (l Limits, ok bool) {
+	l, ok = ctx.Value(limitsKey{}).(Limits)
+	return
+}
+
+// Check returns ErrMaxDepthExceeded or ErrMaxItemsExceeded if the
+// JSON-deserialized value exceeds the Limits.
+func (l Limits) Check(m map[string]interface{}) error {
+	if l.MaxDepth <= 0 && l.MaxItems <= 0 {
+		return nil
+	}
+	return l.check(m, 1)
+}
+
+// check recursively checks a value at the given depth.
+func (l Limits) check(v interface{}, depth int) error {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if l.MaxDepth > 0 && depth > l.MaxDepth {
+			return ErrMaxDepthExceeded
+		}
+		for _, elem := range t {
+			if err := l.check(elem, depth+1); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if l.MaxDepth > 0 && depth > l.MaxDepth {
+			return ErrMaxDepthExceeded
+		}
+		if l.MaxItems > 0 && len(t) > l.MaxItems {
+			return ErrMaxItemsExceeded
+		}
+		for _, elem := range t {
+			if err := l.check(elem, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkLimits checks the JSON-deserialized value against the Limits carried
+// by the context, if any. It is called by the generated JSONResolver.
+func checkLimits(ctx context.Context, m map[string]interface{}) error {
+	if l, ok := LimitsFromContext(ctx); ok {
+		return l.Check(m)
+	}
+	return nil
+}
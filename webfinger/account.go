@@ -0,0 +1,52 @@
+package webfinger
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	// acctScheme is the URI scheme of accounts.
+	acctScheme = "acct:"
+)
+
+// ErrInvalidAccount indicates a resource is not an account of the form
+// 'acct:user@host', 'user@host', or '@user@host'.
+var ErrInvalidAccount = errors.New("invalid webfinger account")
+
+// Account is a 'user@host' account identifying an actor, as used in the
+// 'acct:' URIs of WebFinger.
+type Account struct {
+	// User is the name of the account on the host.
+	User string
+	// Host is the host of the account, which may include a port.
+	Host string
+}
+
+// ParseAccount parses an account from an 'acct:' URI. The 'user@host' and
+// '@user@host' forms entered by people are also accepted.
+//
+// Returns ErrInvalidAccount if the resource is not an account.
+func ParseAccount(resource string) (Account, error) {
+	s := strings.TrimPrefix(resource, acctScheme)
+	if s == resource {
+		s = strings.TrimPrefix(s, "@")
+	}
+	i := strings.LastIndex(s, "@")
+	if i <= 0 || i == len(s)-1 {
+		return Account{}, ErrInvalidAccount
+	}
+	a := Account{
+		User: s[:i],
+		Host: s[i+1:],
+	}
+	if strings.ContainsAny(a.Host, "/?#@") || strings.ContainsAny(a.User, "/?#@") {
+		return Account{}, ErrInvalidAccount
+	}
+	return a, nil
+}
+
+// String returns the 'acct:' URI of the account.
+func (a Account) String() string {
+	return acctScheme + a.User + "@" + a.Host
+}
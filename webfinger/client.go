@@ -0,0 +1,105 @@
+package webfinger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-fed/activity/pub"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+const (
+	// maxJRDBytes bounds the size of the JRD documents read by a Client.
+	maxJRDBytes = 1 << 20
+)
+
+// ErrNoActor indicates the JRD of an account has no 'self' link of one of the
+// ActivityStreams media types.
+var ErrNoActor = errors.New("webfinger account has no actor")
+
+// Client fetches the JRD documents of accounts on other servers.
+//
+// It may be used concurrently if its HttpClient may.
+type Client struct {
+	client   pub.HttpClient
+	appAgent string
+}
+
+// NewClient returns a Client sending WebFinger requests over HTTPS through the
+// HttpClient. An httptest.Server started with TLS, and its Client, may be used
+// in tests.
+//
+// The appAgent is sent in the User-Agent header, so peers may aid debugging the
+// requests incoming from this server.
+func NewClient(client pub.HttpClient, appAgent string) *Client {
+	return &Client{
+		client:   client,
+		appAgent: appAgent,
+	}
+}
+
+// Fetch requests the JRD of the account from its host.
+//
+// Returns a *pub.TransportError if the request failed.
+func (w *Client) Fetch(c context.Context, a Account) (*JRD, error) {
+	u := &url.URL{
+		Scheme:   "https",
+		Host:     a.Host,
+		Path:     Path,
+		RawQuery: url.Values{"resource": []string{a.String()}}.Encode(),
+	}
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, &pub.TransportError{Method: "GET", IRI: u, Err: err}
+	}
+	req = req.WithContext(c)
+	req.Header.Set("Accept", JRDMediaType+", application/json")
+	req.Header.Set("User-Agent", w.appAgent)
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return nil, &pub.TransportError{Method: "GET", IRI: u, Retryable: true, Err: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &pub.TransportError{
+			Method:     "GET",
+			IRI:        u,
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Retryable:  resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500,
+		}
+	}
+	var jrd JRD
+	if err = json.NewDecoder(io.LimitReader(resp.Body, maxJRDBytes)).Decode(&jrd); err != nil {
+		return nil, err
+	}
+	return &jrd, nil
+}
+
+// ResolveActor returns the IRI of the actor of an account such as
+// 'user@host', from the 'self' link of its JRD.
+//
+// Returns ErrInvalidAccount if the account cannot be parsed, and ErrNoActor if
+// the JRD has no such link.
+func (w *Client) ResolveActor(c context.Context, account string) (*url.URL, error) {
+	a, err := ParseAccount(account)
+	if err != nil {
+		return nil, err
+	}
+	jrd, err := w.Fetch(c, a)
+	if err != nil {
+		return nil, err
+	}
+	href, ok := jrd.ActorIRI()
+	if !ok {
+		return nil, ErrNoActor
+	}
+	iri, err := url.Parse(href)
+	if err != nil {
+		return nil, fmt.Errorf("webfinger actor of %s: %s", a, err)
+	}
+	return iri, nil
+}
@@ -0,0 +1,8 @@
+// Package webfinger implements the subset of WebFinger (RFC 7033) used by
+// ActivityPub servers to discover actors by their 'user@host' accounts.
+//
+// A server serves the JRD documents of its accounts at
+// /.well-known/webfinger with the handler returned by NewHandler, which
+// links each account to its actor with a 'self' link of the ActivityPub media
+// type. A Client resolves the accounts of peers to the IRIs of their actors.
+package webfinger
@@ -0,0 +1,112 @@
+package webfinger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const (
+	// Path is the path at which WebFinger requests are served.
+	Path = "/.well-known/webfinger"
+)
+
+// ErrNotFound is returned by a Lookup when there is no such account on this
+// server.
+var ErrNotFound = errors.New("webfinger account not found")
+
+// Lookup finds the actors of the accounts on this server.
+type Lookup interface {
+	// ActorIRI returns the IRI of the actor of the account.
+	//
+	// Returns ErrNotFound if there is no such account, including when the
+	// host of the account is not one served by this server.
+	ActorIRI(c context.Context, a Account) (*url.URL, error)
+}
+
+// HandlerFunc serves WebFinger requests.
+//
+// If an error is returned, then the calling function is responsible for writing
+// to the ResponseWriter as part of error handling. Otherwise the HandlerFunc
+// has written the response.
+type HandlerFunc func(c context.Context, w http.ResponseWriter, r *http.Request) error
+
+// NewHandler creates a HandlerFunc serving the JRD documents of the accounts
+// found by the Lookup. It is meant to be served at Path.
+//
+// The 'resource' query parameter must be an account, otherwise the handler
+// responds with 400 Bad Request. It responds with 404 Not Found if the Lookup
+// returns ErrNotFound. The JRD of an account has the IRI of its actor as an
+// alias and as a 'self' link of the ActivityPub media type. Its links are
+// filtered by the 'rel' query parameters, if any.
+func NewHandler(l Lookup) HandlerFunc {
+	return func(c context.Context, w http.ResponseWriter, r *http.Request) error {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return nil
+		}
+		q := r.URL.Query()
+		a, err := ParseAccount(q.Get("resource"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return nil
+		}
+		actorIRI, err := l.ActorIRI(c, a)
+		if err == ErrNotFound {
+			w.WriteHeader(http.StatusNotFound)
+			return nil
+		} else if err != nil {
+			return err
+		}
+		jrd := &JRD{
+			Subject: a.String(),
+			Aliases: []string{actorIRI.String()},
+		}
+		for _, link := range []Link{
+			{
+				Rel:  SelfRel,
+				Type: ActivityPubMediaType,
+				Href: actorIRI.String(),
+			},
+		} {
+			if hasRel(q["rel"], link.Rel) {
+				jrd.Links = append(jrd.Links, link)
+			}
+		}
+		raw, err := json.Marshal(jrd)
+		if err != nil {
+			return err
+		}
+		w.Header().Set("Content-Type", JRDMediaType)
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodHead {
+			return nil
+		}
+		n, err := w.Write(raw)
+		if err != nil {
+			return err
+		} else if n != len(raw) {
+			return fmt.Errorf("only wrote %d of %d bytes", n, len(raw))
+		}
+		return nil
+	}
+}
+
+// hasRel determines whether a link with the relation is requested by the
+// 'rel' query parameters. All relations are requested if there are none.
+func hasRel(rels []string, rel string) bool {
+	if len(rels) == 0 {
+		return true
+	}
+	for _, r := range rels {
+		if r == rel {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package webfinger
+
+const (
+	// JRDMediaType is the media type of JRD documents.
+	JRDMediaType = "application/jrd+json"
+	// ActivityPubMediaType is the media type of the 'self' links to actors.
+	ActivityPubMediaType = "application/activity+json"
+	// activityStreamsLDMediaType is the JSON-LD media type of
+	// ActivityStreams, which peers may also use for 'self' links.
+	activityStreamsLDMediaType = "application/ld+json; profile=\"https://www.w3.org/ns/activitystreams\""
+	// SelfRel is the relation of the links to actors.
+	SelfRel = "self"
+	// ProfilePageRel is the relation of the links to the profile pages of
+	// accounts.
+	ProfilePageRel = "http://webfinger.net/rel/profile-page"
+)
+
+// JRD is a JSON Resource Descriptor, the document describing an account.
+type JRD struct {
+	// Subject is the 'acct:' URI of the account.
+	Subject string `json:"subject"`
+	// Aliases are other URIs identifying the account, such as the IRI of
+	// its actor.
+	Aliases []string `json:"aliases,omitempty"`
+	// Properties are additional information about the account.
+	Properties map[string]*string `json:"properties,omitempty"`
+	// Links relate the account to other resources.
+	Links []Link `json:"links,omitempty"`
+}
+
+// Link relates an account to another resource.
+type Link struct {
+	// Rel is the relation of the resource to the account.
+	Rel string `json:"rel"`
+	// Type is the media type of the resource.
+	Type string `json:"type,omitempty"`
+	// Href is the URI of the resource.
+	Href string `json:"href,omitempty"`
+	// Template is the URI template of the resource, used instead of Href
+	// by some relations such as remote follows.
+	Template string `json:"template,omitempty"`
+}
+
+// isActivityPubMediaType determines whether the media type of a link is one of
+// the ActivityStreams media types.
+func isActivityPubMediaType(t string) bool {
+	return t == ActivityPubMediaType ||
+		t == activityStreamsLDMediaType ||
+		t == "application/ld+json; profile=https://www.w3.org/ns/activitystreams"
+}
+
+// ActorIRI returns the 'href' of the first 'self' link with one of the
+// ActivityStreams media types, which is the IRI of the account's actor.
+func (j *JRD) ActorIRI() (string, bool) {
+	for _, l := range j.Links {
+		if l.Rel == SelfRel && isActivityPubMediaType(l.Type) && l.Href != "" {
+			return l.Href, true
+		}
+	}
+	return "", false
+}
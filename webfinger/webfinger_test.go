@@ -0,0 +1,178 @@
+package webfinger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-fed/activity/pub"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const (
+	testActorIRI = "https://example.com/users/alice"
+)
+
+// testLookup finds only alice's actor.
+type testLookup struct {
+	host string
+	err  error
+}
+
+func (l testLookup) ActorIRI(c context.Context, a Account) (*url.URL, error) {
+	if l.err != nil {
+		return nil, l.err
+	} else if a.User != "alice" || a.Host != l.host {
+		return nil, ErrNotFound
+	}
+	return url.Parse(testActorIRI)
+}
+
+func TestParseAccount(t *testing.T) {
+	for _, tc := range []struct {
+		resource string
+		want     Account
+		err      error
+	}{
+		{"acct:alice@example.com", Account{"alice", "example.com"}, nil},
+		{"alice@example.com", Account{"alice", "example.com"}, nil},
+		{"@alice@example.com", Account{"alice", "example.com"}, nil},
+		{"acct:alice@example.com:8443", Account{"alice", "example.com:8443"}, nil},
+		{"acct:@alice@example.com", Account{}, ErrInvalidAccount},
+		{"https://example.com/users/alice", Account{}, ErrInvalidAccount},
+		{"alice", Account{}, ErrInvalidAccount},
+		{"alice@", Account{}, ErrInvalidAccount},
+		{"", Account{}, ErrInvalidAccount},
+	} {
+		t.Run(tc.resource, func(t *testing.T) {
+			a, err := ParseAccount(tc.resource)
+			if err != tc.err {
+				t.Fatalf("expected error %v, got %v", tc.err, err)
+			} else if a != tc.want {
+				t.Fatalf("expected %v, got %v", tc.want, a)
+			}
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	ctx := context.Background()
+	h := NewHandler(testLookup{host: "example.com"})
+	serveFn := func(t *testing.T, target string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", target, nil)
+		if err := h(ctx, resp, req); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		return resp
+	}
+	t.Run("ServesJRDWithSelfLink", func(t *testing.T) {
+		resp := serveFn(t, Path+"?resource=acct:alice@example.com")
+		if resp.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", resp.Code)
+		} else if ct := resp.Header().Get("Content-Type"); ct != JRDMediaType {
+			t.Fatalf("expected content type %s, got %s", JRDMediaType, ct)
+		}
+		var jrd JRD
+		if err := json.Unmarshal(resp.Body.Bytes(), &jrd); err != nil {
+			t.Fatal(err)
+		}
+		if jrd.Subject != "acct:alice@example.com" {
+			t.Fatalf("unexpected subject %s", jrd.Subject)
+		} else if len(jrd.Links) != 1 || jrd.Links[0] != (Link{Rel: SelfRel, Type: ActivityPubMediaType, Href: testActorIRI}) {
+			t.Fatalf("unexpected links %v", jrd.Links)
+		}
+	})
+	t.Run("FiltersLinksByRel", func(t *testing.T) {
+		resp := serveFn(t, Path+"?resource=acct:alice@example.com&rel="+url.QueryEscape(ProfilePageRel))
+		var jrd JRD
+		if err := json.Unmarshal(resp.Body.Bytes(), &jrd); err != nil {
+			t.Fatal(err)
+		} else if len(jrd.Links) != 0 {
+			t.Fatalf("expected no links, got %v", jrd.Links)
+		}
+	})
+	t.Run("BadRequestIfResourceNotAccount", func(t *testing.T) {
+		resp := serveFn(t, Path+"?resource="+url.QueryEscape(testActorIRI))
+		if resp.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400, got %d", resp.Code)
+		}
+	})
+	t.Run("NotFoundIfNoAccount", func(t *testing.T) {
+		resp := serveFn(t, Path+"?resource=acct:bob@example.com")
+		if resp.Code != http.StatusNotFound {
+			t.Fatalf("expected 404, got %d", resp.Code)
+		}
+	})
+	t.Run("ReturnsLookupError", func(t *testing.T) {
+		testErr := errors.New("test error")
+		h := NewHandler(testLookup{err: testErr})
+		resp := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", Path+"?resource=acct:alice@example.com", nil)
+		if err := h(ctx, resp, req); err != testErr {
+			t.Fatalf("expected test error, got %v", err)
+		}
+	})
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	setupFn := func(h http.HandlerFunc) (*httptest.Server, *Client, string) {
+		s := httptest.NewTLSServer(h)
+		return s, NewClient(s.Client(), "testApp"), strings.TrimPrefix(s.URL, "https://")
+	}
+	t.Run("ResolvesActorFromHandler", func(t *testing.T) {
+		var host string
+		s, c, host := setupFn(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != Path {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			NewHandler(testLookup{host: host})(r.Context(), w, r)
+		})
+		defer s.Close()
+		iri, err := c.ResolveActor(ctx, "alice@"+host)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		} else if iri.String() != testActorIRI {
+			t.Fatalf("expected %s, got %s", testActorIRI, iri)
+		}
+	})
+	t.Run("ErrorIfNoActorLink", func(t *testing.T) {
+		s, c, host := setupFn(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", JRDMediaType)
+			w.Write([]byte(`{"subject":"acct:alice@example.com","links":[{"rel":"self","type":"text/html","href":"https://example.com/@alice"}]}`))
+		})
+		defer s.Close()
+		_, err := c.ResolveActor(ctx, "acct:alice@"+host)
+		if err != ErrNoActor {
+			t.Fatalf("expected ErrNoActor, got %v", err)
+		}
+	})
+	t.Run("TransportErrorIfNotFound", func(t *testing.T) {
+		s, c, host := setupFn(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+		defer s.Close()
+		_, err := c.ResolveActor(ctx, "alice@"+host)
+		var te *pub.TransportError
+		if !errors.As(err, &te) {
+			t.Fatalf("expected *pub.TransportError, got %v", err)
+		} else if te.StatusCode != http.StatusNotFound {
+			t.Fatalf("expected 404, got %d", te.StatusCode)
+		}
+	})
+	t.Run("ErrorIfAccountInvalid", func(t *testing.T) {
+		s, c, _ := setupFn(func(w http.ResponseWriter, r *http.Request) {
+			t.Fatalf("unexpected request")
+		})
+		defer s.Close()
+		_, err := c.ResolveActor(ctx, "alice")
+		if err != ErrInvalidAccount {
+			t.Fatalf("expected ErrInvalidAccount, got %v", err)
+		}
+	})
+}